@@ -1,15 +1,20 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	_ "time/tzdata" // ICS imports need zone data even on hosts without it
 
 	"github.com/gin-gonic/gin"
 	"smart-task-planner/config"
 	"smart-task-planner/internal/database"
+	"smart-task-planner/internal/events"
+	"smart-task-planner/internal/mcp"
+	"smart-task-planner/internal/middleware"
 
 	authRoutes "smart-task-planner/internal/modules/auth/routes"
 	authService "smart-task-planner/internal/modules/auth/service"
@@ -22,6 +27,76 @@ import (
 	commandHandlers "smart-task-planner/internal/modules/command/handlers"
 	commandRoutes "smart-task-planner/internal/modules/command/routes"
 	commandService "smart-task-planner/internal/modules/command/service"
+
+	statsHandlers "smart-task-planner/internal/modules/stats/handlers"
+	statsRepository "smart-task-planner/internal/modules/stats/repository"
+	statsRoutes "smart-task-planner/internal/modules/stats/routes"
+	statsService "smart-task-planner/internal/modules/stats/service"
+
+	agendaHandlers "smart-task-planner/internal/modules/agenda/handlers"
+	agendaRoutes "smart-task-planner/internal/modules/agenda/routes"
+	agendaService "smart-task-planner/internal/modules/agenda/service"
+
+	timeHandlers "smart-task-planner/internal/modules/timetracking/handlers"
+	timeRepository "smart-task-planner/internal/modules/timetracking/repository"
+	timeRoutes "smart-task-planner/internal/modules/timetracking/routes"
+	timeService "smart-task-planner/internal/modules/timetracking/service"
+
+	estimateHandlers "smart-task-planner/internal/modules/estimates/handlers"
+	estimateRoutes "smart-task-planner/internal/modules/estimates/routes"
+	estimateService "smart-task-planner/internal/modules/estimates/service"
+
+	fieldHandlers "smart-task-planner/internal/modules/fields/handlers"
+	fieldRepository "smart-task-planner/internal/modules/fields/repository"
+	fieldRoutes "smart-task-planner/internal/modules/fields/routes"
+	fieldService "smart-task-planner/internal/modules/fields/service"
+
+	searchHandlers "smart-task-planner/internal/modules/search/handlers"
+	searchRoutes "smart-task-planner/internal/modules/search/routes"
+	searchService "smart-task-planner/internal/modules/search/service"
+
+	calendarHandlers "smart-task-planner/internal/modules/calendar/handlers"
+	calendarRepository "smart-task-planner/internal/modules/calendar/repository"
+	calendarRoutes "smart-task-planner/internal/modules/calendar/routes"
+	calendarService "smart-task-planner/internal/modules/calendar/service"
+
+	templateHandlers "smart-task-planner/internal/modules/templates/handlers"
+	templateRepository "smart-task-planner/internal/modules/templates/repository"
+	templateRoutes "smart-task-planner/internal/modules/templates/routes"
+	templateService "smart-task-planner/internal/modules/templates/service"
+
+	importHandlers "smart-task-planner/internal/modules/imports/handlers"
+	importRoutes "smart-task-planner/internal/modules/imports/routes"
+	importService "smart-task-planner/internal/modules/imports/service"
+
+	webhookHandlers "smart-task-planner/internal/modules/webhooks/handlers"
+	webhookRepository "smart-task-planner/internal/modules/webhooks/repository"
+	webhookRoutes "smart-task-planner/internal/modules/webhooks/routes"
+	webhookService "smart-task-planner/internal/modules/webhooks/service"
+
+	notificationChannels "smart-task-planner/internal/modules/notifications/channels"
+	notificationHandlers "smart-task-planner/internal/modules/notifications/handlers"
+	notificationRepository "smart-task-planner/internal/modules/notifications/repository"
+	notificationRoutes "smart-task-planner/internal/modules/notifications/routes"
+	notificationService "smart-task-planner/internal/modules/notifications/service"
+
+	realtimeHandlers "smart-task-planner/internal/modules/realtime/handlers"
+	realtimeRoutes "smart-task-planner/internal/modules/realtime/routes"
+	realtimeService "smart-task-planner/internal/modules/realtime/service"
+
+	workspaceHandlers "smart-task-planner/internal/modules/workspaces/handlers"
+	workspaceRepository "smart-task-planner/internal/modules/workspaces/repository"
+	workspaceRoutes "smart-task-planner/internal/modules/workspaces/routes"
+	workspaceService "smart-task-planner/internal/modules/workspaces/service"
+
+	commentHandlers "smart-task-planner/internal/modules/comments/handlers"
+	commentRepository "smart-task-planner/internal/modules/comments/repository"
+	commentRoutes "smart-task-planner/internal/modules/comments/routes"
+	commentService "smart-task-planner/internal/modules/comments/service"
+
+	syncHandlers "smart-task-planner/internal/modules/sync/handlers"
+	syncRoutes "smart-task-planner/internal/modules/sync/routes"
+	syncService "smart-task-planner/internal/modules/sync/service"
 )
 
 func main() {
@@ -37,8 +112,14 @@ func main() {
 	}
 	defer database.Disconnect()
 
+	// background jobs stop when this context is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	
-	router := gin.Default()
+	// gin.Default's logger would write ?token= JWTs to the access log
+	router := gin.New()
+	router.Use(middleware.AccessLog(), gin.Recovery())
 
 	
 	router.GET("/health", func(c *gin.Context) {
@@ -63,7 +144,33 @@ func main() {
 	
 	db := database.DB                                  // *mongo.Database
 	planRepo := planRepository.NewPlanRepository(db)  // repository
-	planSvc := planService.NewPlanService(planRepo)   // service
+	if err := planRepo.EnsureIndexes(); err != nil {
+		log.Println("⚠️  Failed to create plan indexes:", err)
+	}
+	go func() {
+		if err := planRepo.BackfillSummaries(); err != nil {
+			log.Println("⚠️  Failed to backfill plan summaries:", err)
+		}
+	}()
+	fieldRepo := fieldRepository.NewFieldRepository(db)
+	if err := fieldRepo.EnsureIndexes(); err != nil {
+		log.Println("⚠️  Failed to create custom field indexes:", err)
+	}
+	commentRepo := commentRepository.NewCommentRepository(db)
+	if err := commentRepo.EnsureIndexes(); err != nil {
+		log.Println("⚠️  Failed to create comment indexes:", err)
+	}
+	timeRepo := timeRepository.NewTimeEntryRepository(db)
+	if err := timeRepo.EnsureIndexes(); err != nil {
+		log.Println("⚠️  Failed to create time entry indexes:", err)
+	}
+	workspaceRepo := workspaceRepository.NewWorkspaceRepository(db)
+	if err := workspaceRepo.EnsureIndexes(); err != nil {
+		log.Println("⚠️  Failed to create workspace indexes:", err)
+	}
+	mcp.Configure(mcp.Deps{TimeRepo: timeRepo, CommentRepo: commentRepo, WorkspaceRepo: workspaceRepo}) // repositories for the AI tools
+	planSvc := planService.NewPlanService(planRepo, fieldRepo, commentRepo) // service
+	planSvc.StartRecurrence(ctx)                      // next occurrences of recurring tasks
 	planHandler := planHandlers.NewPlanHandler(planSvc) // handler
 	planRoutes.RegisterPlanRoutes(router, planHandler) // plan routes
 
@@ -73,6 +180,114 @@ func main() {
 	commandRoutes.RegisterCommandRoutes(router, cmdHandler)  // register /api/command
 
 	
+	snapshotRepo := statsRepository.NewSnapshotRepository(db)
+	if err := snapshotRepo.EnsureIndexes(); err != nil {
+		log.Println("⚠️  Failed to create snapshot indexes:", err)
+	}
+	statsSvc := statsService.NewStatsService(snapshotRepo, planRepo)
+	statsSvc.StartDailySnapshots(ctx)                         // daily progress snapshots
+	statsRoutes.RegisterStatsRoutes(router, statsHandlers.NewStatsHandler(statsSvc)) // burndown & history
+
+	
+	searchSvc := searchService.NewSearchService(planRepo)
+	searchRoutes.RegisterSearchRoutes(router, searchHandlers.NewSearchHandler(searchSvc)) // /api/search
+
+	
+	agendaSvc := agendaService.NewAgendaService(planRepo)
+	agendaRoutes.RegisterAgendaRoutes(router, agendaHandlers.NewAgendaHandler(agendaSvc)) // today, week, overdue, no deadline
+
+	
+	timeSvc := timeService.NewTimeService(timeRepo, planRepo)
+	timeRoutes.RegisterTimeRoutes(router, timeHandlers.NewTimeHandler(timeSvc)) // timers, time entries & reports
+
+	
+	estimateSvc := estimateService.NewEstimateService(planRepo, timeRepo)
+	estimateRoutes.RegisterEstimateRoutes(router, estimateHandlers.NewEstimateHandler(estimateSvc)) // learned effort estimates
+
+	
+	fieldSvc := fieldService.NewFieldService(fieldRepo)
+	fieldRoutes.RegisterFieldRoutes(router, fieldHandlers.NewFieldHandler(fieldSvc)) // per-user custom field definitions
+
+	
+	feedTokenRepo := calendarRepository.NewFeedTokenRepository(db)
+	if err := feedTokenRepo.EnsureIndexes(); err != nil {
+		log.Println("⚠️  Failed to create calendar token indexes:", err)
+	}
+	busyRepo := calendarRepository.NewBusyRepository(db)
+	if err := busyRepo.EnsureIndexes(); err != nil {
+		log.Println("⚠️  Failed to create busy time indexes:", err)
+	}
+	calendarSvc := calendarService.NewCalendarService(feedTokenRepo, planRepo)
+	busySvc := calendarService.NewBusyService(busyRepo)
+	busySvc.StartPeriodicSync(ctx) // hourly re-import of file & URL calendars
+	calendarRoutes.RegisterCalendarRoutes(router,
+		calendarHandlers.NewCalendarHandler(calendarSvc),
+		calendarHandlers.NewBusyHandler(busySvc)) // ICS export, feeds & busy time
+
+	
+	templateRepo := templateRepository.NewTemplateRepository(db)
+	if err := templateRepo.EnsureIndexes(); err != nil {
+		log.Println("⚠️  Failed to create template indexes:", err)
+	}
+	templateSvc := templateService.NewTemplateService(templateRepo, planRepo, busyRepo, fieldRepo)
+	templateRoutes.RegisterTemplateRoutes(router, templateHandlers.NewTemplateHandler(templateSvc)) // plan templates, no LLM
+
+	
+	importSvc := importService.NewImportService(planRepo)
+	importRoutes.RegisterImportRoutes(router, importHandlers.NewImportHandler(importSvc)) // Todoist / Trello / Taskwarrior
+
+	
+	webhookRepo := webhookRepository.NewWebhookRepository(db)
+	if err := webhookRepo.EnsureIndexes(); err != nil {
+		log.Println("⚠️  Failed to create webhook indexes:", err)
+	}
+	webhookSvc := webhookService.NewWebhookService(webhookRepo, planRepo)
+	webhookSvc.Start(ctx) // event subscription, outbox worker & hourly risk scan
+	webhookRoutes.RegisterWebhookRoutes(router, webhookHandlers.NewWebhookHandler(webhookSvc)) // /api/webhooks
+
+	
+	notificationRepo := notificationRepository.NewNotificationRepository(db)
+	if err := notificationRepo.EnsureIndexes(); err != nil {
+		log.Println("⚠️  Failed to create notification indexes:", err)
+	}
+	channels := []notificationChannels.Channel{notificationChannels.NewInApp(notificationRepo)}
+	if smtpCfg := notificationChannels.SMTPConfigFromEnv(); smtpCfg != nil {
+		channels = append(channels, notificationChannels.NewEmail(smtpCfg))
+	} else {
+		log.Println("⚠️  SMTP_HOST not set, email notifications disabled")
+	}
+	notificationSvc := notificationService.NewNotificationService(notificationRepo, planRepo, channels...)
+	notificationSvc.Start(ctx) // reminders, overdue alerts & daily digests
+	notificationRoutes.RegisterNotificationRoutes(router, notificationHandlers.NewNotificationHandler(notificationSvc)) // /api/notifications
+
+	
+	var mailer workspaceService.Mailer
+	if smtpCfg := notificationChannels.SMTPConfigFromEnv(); smtpCfg != nil {
+		mailer = notificationChannels.NewEmail(smtpCfg) // invitation emails
+	}
+	workspaceSvc := workspaceService.NewWorkspaceService(workspaceRepo, planRepo, mailer)
+	workspaceRoutes.RegisterWorkspaceRoutes(router, workspaceHandlers.NewWorkspaceHandler(workspaceSvc)) // teams, invitations & plan sharing
+
+	
+	commentSvc := commentService.NewCommentService(commentRepo, planRepo, notificationRepo)
+	commentRoutes.RegisterCommentRoutes(router, commentHandlers.NewCommentHandler(commentSvc)) // threaded comments & @mentions
+
+	
+	if os.Getenv("EVENTS_CHANGE_STREAM") == "true" {
+		if err := events.StartChangeStream(ctx, db); err != nil {
+			log.Println("⚠️  Event change stream unavailable, live updates stay on this instance:", err)
+		}
+	}
+	hub := realtimeService.NewHub()
+	hub.Audience = workspaceSvc.Audience // everyone who can see the plan
+	hub.Start()
+	realtimeRoutes.RegisterRealtimeRoutes(router, realtimeHandlers.NewLiveHandler(hub)) // WebSocket /api/live
+
+	
+	syncSvc := syncService.NewSyncService(planRepo)
+	syncRoutes.RegisterSyncRoutes(router, syncHandlers.NewSyncHandler(syncSvc)) // offline clients: cursors, tombstones, push
+
+	
 	log.Println("🚀 Starting server...")
 	log.Printf("🌐 Server running on http://localhost:%s", config.AppConfig.Port)
 	log.Printf("📝 Health check: http://localhost:%s/health", config.AppConfig.Port)
@@ -89,5 +304,6 @@ func main() {
 	<-quit
 
 	log.Println("🛑 Shutting down...")
+	cancel()
 	log.Println("✅ Server stopped")
 }
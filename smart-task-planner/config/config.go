@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -11,6 +12,7 @@ type Config struct {
 	Port     string
 	MongoURI string
 	MongoDB  string
+	BaseURL  string // public URL used in links that leave the API (calendar feeds, emails)
 }
 
 var AppConfig *Config
@@ -26,6 +28,7 @@ func Load() {
 		MongoURI: getEnv("MONGODB_URI", "mongodb://localhost:27017"),
 		MongoDB:  getEnv("MONGODB_DATABASE", "task_planner"),
 	}
+	AppConfig.BaseURL = strings.TrimSuffix(getEnv("APP_BASE_URL", "http://localhost:"+AppConfig.Port), "/")
 
 	log.Println("✅ Configuration loaded")
 	log.Printf("   Port: %s", AppConfig.Port)
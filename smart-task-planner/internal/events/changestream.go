@@ -0,0 +1,159 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// shared events are only needed while they travel between instances
+	recordTTL     = 24 * time.Hour
+	resumeBackoff = 5 * time.Second
+)
+
+// instanceID tells this process's own events apart in the shared collection
+var instanceID = primitive.NewObjectID().Hex()
+
+type record struct {
+	ID         string    `bson:"_id"`
+	Type       string    `bson:"type"`
+	UserID     string    `bson:"user_id"`
+	PlanID     string    `bson:"plan_id,omitempty"`
+	Key        string    `bson:"key,omitempty"`
+	Audience   []string  `bson:"audience,omitempty"`
+	OccurredAt time.Time `bson:"occurred_at"`
+	Data       bson.M    `bson:"data"`
+	Origin     string    `bson:"origin"`
+}
+
+// StartChangeStream shares events between instances through the "events"
+// collection: local events are written to it, and a change stream hands
+// events inserted by other instances to SubscribeAll handlers. Change
+// streams need a replica set; an error is returned when they aren't
+// available and the bus stays process-local.
+func StartChangeStream(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection("events")
+
+	idxCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if _, err := coll.Indexes().CreateOne(idxCtx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "occurred_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(recordTTL.Seconds())),
+	}); err != nil {
+		return err
+	}
+
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: bson.M{
+		"operationType":       "insert",
+		"fullDocument.origin": bson.M{"$ne": instanceID},
+	}}}}
+	stream, err := coll.Watch(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+
+	Subscribe(func(e Event) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := coll.InsertOne(ctx, toRecord(e)); err != nil {
+			log.Printf("⚠️  Failed to share event %s: %v", e.ID, err)
+		}
+	})
+
+	go func() {
+		for {
+			consume(ctx, stream)
+			if ctx.Err() != nil {
+				return
+			}
+
+			// resume where the broken stream stopped so no event is missed
+			token := stream.ResumeToken()
+			stream.Close(context.Background())
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(resumeBackoff):
+				}
+				opts := options.ChangeStream()
+				if token != nil {
+					opts.SetResumeAfter(token)
+				}
+				if stream, err = coll.Watch(ctx, pipeline, opts); err == nil {
+					break
+				}
+				log.Println("⚠️  Event change stream unavailable, retrying:", err)
+			}
+		}
+	}()
+	return nil
+}
+
+func consume(ctx context.Context, stream *mongo.ChangeStream) {
+	for stream.Next(ctx) {
+		var change struct {
+			FullDocument record `bson:"fullDocument"`
+		}
+		if err := stream.Decode(&change); err != nil {
+			log.Println("⚠️  Skipping undecodable shared event:", err)
+			continue
+		}
+
+		mu.RLock()
+		dispatch(fromRecord(change.FullDocument), remote)
+		mu.RUnlock()
+	}
+	if err := stream.Err(); err != nil && ctx.Err() == nil {
+		log.Println("⚠️  Event change stream interrupted:", err)
+	}
+}
+
+func toRecord(e Event) record {
+	return record{
+		ID:         e.ID,
+		Type:       e.Type,
+		UserID:     e.UserID,
+		PlanID:     e.PlanID,
+		Key:        e.Key,
+		Audience:   e.Audience,
+		OccurredAt: e.OccurredAt,
+		Data:       jsonData(e.Data),
+		Origin:     instanceID,
+	}
+}
+
+// jsonData stores Data the way WebSocket clients see it. Encoding structs
+// straight to BSON would re-key them by their bson tags (comment._id instead
+// of comment.id) on the instances that receive them.
+func jsonData(data map[string]interface{}) bson.M {
+	out := bson.M{}
+	raw, err := json.Marshal(data)
+	if err == nil {
+		err = json.Unmarshal(raw, &out)
+	}
+	if err != nil {
+		log.Printf("⚠️  Failed to encode event data: %v", err)
+	}
+	return out
+}
+
+func fromRecord(r record) Event {
+	return Event{
+		ID:         r.ID,
+		Type:       r.Type,
+		UserID:     r.UserID,
+		PlanID:     r.PlanID,
+		Key:        r.Key,
+		Audience:   r.Audience,
+		OccurredAt: r.OccurredAt,
+		Data:       map[string]interface{}(r.Data),
+	}
+}
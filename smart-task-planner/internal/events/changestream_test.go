@@ -0,0 +1,55 @@
+package events
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type comment struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	Body      string             `bson:"body" json:"body"`
+	Mentions  []string           `bson:"mentions,omitempty" json:"mentions"`
+	DeletedAt *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+}
+
+func TestRecordKeepsJSONShape(t *testing.T) {
+	e := Event{
+		ID:         primitive.NewObjectID().Hex(),
+		Type:       CommentCreated,
+		UserID:     "ann",
+		PlanID:     "plan",
+		OccurredAt: time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC),
+		Data: map[string]interface{}{
+			"plan_id": "plan",
+			"count":   3,
+			"comment": &comment{ID: primitive.NewObjectID(), Body: "@bob can you look?", Mentions: []string{"bob"}},
+		},
+	}
+
+	// what another instance reads back from the events collection
+	raw, err := bson.Marshal(toRecord(e))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r record
+	if err := bson.Unmarshal(raw, &r); err != nil {
+		t.Fatal(err)
+	}
+
+	// compared decoded, since the relayed data is a map with sorted keys
+	var local, relayed interface{}
+	asJSON := func(e Event, v *interface{}) {
+		raw, _ := json.Marshal(e)
+		json.Unmarshal(raw, v)
+	}
+	asJSON(e, &local)
+	asJSON(fromRecord(r), &relayed)
+	if !reflect.DeepEqual(local, relayed) {
+		t.Errorf("relayed event\n%v\ndiffers from the local one\n%v", relayed, local)
+	}
+}
@@ -0,0 +1,100 @@
+package events
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Event types published by the planner.
+const (
+	PlanCreated       = "plan.created"
+	PlanUpdated       = "plan.updated"
+	PlanDeleted       = "plan.deleted"
+	TaskStatusChanged = "task.status_changed"
+	PlanRescheduled   = "plan.rescheduled"
+	RiskDetected      = "risk.detected"
+	WorkloadExceeded  = "workload.exceeded"
+	CommentCreated    = "comment.created"
+	CommentUpdated    = "comment.updated"
+	CommentDeleted    = "comment.deleted"
+)
+
+// Types lists every event type a subscriber can ask for.
+var Types = []string{PlanCreated, PlanUpdated, PlanDeleted, TaskStatusChanged, PlanRescheduled, RiskDetected, WorkloadExceeded,
+	CommentCreated, CommentUpdated, CommentDeleted}
+
+// Event is something that happened to a user's plans. Key, when set,
+// identifies repeats of the same fact (the same risk detected twice) so
+// consumers can drop duplicates.
+type Event struct {
+	ID         string                 `json:"id"`
+	Type       string                 `json:"type"`
+	UserID     string                 `json:"user_id"`
+	PlanID     string                 `json:"plan_id,omitempty"`
+	Key        string                 `json:"-"`
+	Audience   []string               `json:"-"` // who to tell live, when it can't be looked up afterwards
+	OccurredAt time.Time              `json:"created_at"`
+	Data       map[string]interface{} `json:"data"`
+}
+
+// Handler receives published events. Handlers run on their own goroutine,
+// so a slow consumer never blocks the request that caused the event.
+type Handler func(Event)
+
+var (
+	mu       sync.RWMutex
+	handlers []Handler
+	remote   []Handler
+)
+
+// Subscribe registers a handler for every event published by this process
+// from now on. Side effects that must happen once per event (webhooks)
+// belong here.
+func Subscribe(h Handler) {
+	mu.Lock()
+	defer mu.Unlock()
+	handlers = append(handlers, h)
+}
+
+// SubscribeAll also receives events published by other instances when the
+// change stream source is running. Use it for per-connection fan-out such
+// as WebSocket pushes.
+func SubscribeAll(h Handler) {
+	mu.Lock()
+	defer mu.Unlock()
+	handlers = append(handlers, h)
+	remote = append(remote, h)
+}
+
+// Publish fills in the ID and timestamp and fans the event out.
+func Publish(e Event) {
+	if e.ID == "" {
+		e.ID = "evt_" + primitive.NewObjectID().Hex()
+	}
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = time.Now().UTC()
+	}
+	if e.Data == nil {
+		e.Data = map[string]interface{}{}
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+	dispatch(e, handlers)
+}
+
+func dispatch(e Event, hs []Handler) {
+	for _, h := range hs {
+		go func(h Handler) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("❌ Event handler panicked on %s: %v", e.Type, r)
+				}
+			}()
+			h(e)
+		}(h)
+	}
+}
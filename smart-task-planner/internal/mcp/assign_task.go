@@ -0,0 +1,182 @@
+package mcp
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"smart-task-planner/internal/modules/plan/models"
+	"smart-task-planner/internal/modules/plan/people"
+	"smart-task-planner/internal/modules/plan/repository"
+)
+
+var (
+	assignRe   = regexp.MustCompile(`(?i)^\s*(?:please\s+)?(?:re)?assign\s+(.+?)\s+to\s+(.+?)[\s.!?]*$`)
+	unassignRe = regexp.MustCompile(`(?i)^\s*(?:please\s+)?unassign\s+(.+?)(?:\s+from\s+.+?)?[\s.!?]*$`)
+	taskFiller = regexp.MustCompile(`(?i)^(the|task|my)\s+|\s+task$`)
+)
+
+// isAssignCommand spots chat messages like "assign the budget review to Priya"
+func isAssignCommand(message string) bool {
+	return assignRe.MatchString(message) || unassignRe.MatchString(message)
+}
+
+// parseAssignCommand splits a chat message into the text naming the task and
+// the one naming the assignee, "" when the task is being unassigned
+func parseAssignCommand(message string) (task, assignee string) {
+	if m := unassignRe.FindStringSubmatch(message); m != nil {
+		return cleanTaskText(m[1]), ""
+	}
+	if m := assignRe.FindStringSubmatch(message); m != nil {
+		return cleanTaskText(m[1]), strings.TrimSpace(m[2])
+	}
+	return "", ""
+}
+
+func cleanTaskText(text string) string {
+	if m := quotedTitleRe.FindStringSubmatch(text); m != nil {
+		return m[1] + m[2]
+	}
+	for {
+		cleaned := strings.TrimSpace(taskFiller.ReplaceAllString(strings.TrimSpace(text), ""))
+		if cleaned == text {
+			return cleaned
+		}
+		text = cleaned
+	}
+}
+
+// assign_task sets who does a task. The task comes from task_id or the chat
+// message; the assignee from assignee_id, or a name, email or "me" in
+// assignee or the message. An empty assignee_id unassigns. Only people who
+// can edit the plan can be assigned, so they can update their tasks.
+func assign_task(params map[string]interface{}, repo *repository.PlanRepository) (map[string]interface{}, error) {
+	userID, ok := params["user_id"].(string)
+	if !ok || userID == "" {
+		return nil, fmt.Errorf("user_id required")
+	}
+	planID, _ := params["plan_id"].(string)
+	taskID, _ := params["task_id"].(string)
+	message, _ := params["message"].(string)
+	assigneeID, hasID := params["assignee_id"].(string)
+	who, _ := params["assignee"].(string)
+
+	needle := ""
+	if message != "" {
+		var parsed string
+		needle, parsed = parseAssignCommand(message)
+		if !hasID && who == "" {
+			who = parsed
+			hasID = parsed == ""
+		}
+	}
+	if taskID == "" && needle == "" {
+		return nil, fmt.Errorf("task_id or message required")
+	}
+
+	var plans []models.Plan
+	if planID != "" {
+		plan, err := repo.GetByIDForRole(planID, userID, models.RoleEditor)
+		if err != nil {
+			return nil, err
+		}
+		plans = []models.Plan{*plan}
+	} else {
+		all, err := repo.GetAllByUser(userID)
+		if err != nil {
+			return nil, err
+		}
+		// only plans the user may change are candidates
+		for _, plan := range all {
+			if models.RoleAtLeast(plan.Role, models.RoleEditor) {
+				plans = append(plans, plan)
+			}
+		}
+	}
+
+	if taskID == "" {
+		candidates := resolveTask(plans, needle)
+		switch len(candidates) {
+		case 0:
+			return nil, fmt.Errorf("no task matches %q", needle)
+		case 1:
+			planID, taskID = candidates[0]["plan_id"], candidates[0]["task_id"]
+		default:
+			return map[string]interface{}{
+				"needs_disambiguation": true,
+				"message":              "Which task did you mean? Put its title in quotes or pass its task_id.",
+				"candidates":           candidates,
+			}, nil
+		}
+	}
+
+	var plan *models.Plan
+	var task *models.Task
+	for i := range plans {
+		if planID != "" && plans[i].ID.Hex() != planID {
+			continue
+		}
+		if ft, ok := repository.Flatten(plans[i].Tasks)[taskID]; ok {
+			plan, task = &plans[i], ft.Task
+			break
+		}
+	}
+	if task == nil {
+		return nil, fmt.Errorf("task not found")
+	}
+
+	audience, err := people.Assignable(plan, repo)
+	if err != nil {
+		return nil, err
+	}
+	var assignee *people.Person
+	switch {
+	case who != "":
+		matches := people.Match(audience, who, userID)
+		switch len(matches) {
+		case 0:
+			return nil, fmt.Errorf("no one called %q can edit this plan", who)
+		case 1:
+			assignee = &matches[0]
+		default:
+			return map[string]interface{}{
+				"needs_disambiguation": true,
+				"message":              fmt.Sprintf("Which %s did you mean? Pass their assignee_id.", who),
+				"candidates":           matches,
+			}, nil
+		}
+	case hasID && assigneeID != "":
+		for i := range audience {
+			if audience[i].UserID == assigneeID {
+				assignee = &audience[i]
+			}
+		}
+		if assignee == nil {
+			return nil, fmt.Errorf("the assignee must be able to edit the plan")
+		}
+	case !hasID:
+		return nil, fmt.Errorf("assignee_id required (\"\" to unassign)")
+	}
+
+	task.AssigneeID = ""
+	msg := fmt.Sprintf("Unassigned %q", task.Title)
+	if assignee != nil {
+		task.AssigneeID = assignee.UserID
+		msg = fmt.Sprintf("Assigned %q to %s", task.Title, assignee.DisplayName())
+	}
+
+	taskID = task.ID.Hex()
+	updated, err := repo.UpdatePlan(plan)
+	if err != nil {
+		return nil, err
+	}
+	ft := repository.Flatten(updated.Tasks)[taskID]
+	return map[string]interface{}{
+		"plan_id":     updated.ID.Hex(),
+		"goal":        updated.Goal,
+		"task":        ft.Task,
+		"assignee_id": ft.Task.AssigneeID,
+		"assignee":    assignee,
+		"message":     msg,
+	}, nil
+}
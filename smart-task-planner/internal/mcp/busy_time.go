@@ -0,0 +1,21 @@
+package mcp
+
+import (
+	"time"
+
+	"smart-task-planner/internal/modules/calendar/busytime"
+	calendarRepository "smart-task-planner/internal/modules/calendar/repository"
+	"smart-task-planner/internal/modules/plan/repository"
+)
+
+// busyCalendar loads the user's imported busy time for [from, to]. A user
+// without calendar sources (or a failed lookup) gets an empty calendar, so
+// callers never have to special-case it.
+func busyCalendar(userID string, from, to time.Time, repo *repository.PlanRepository) *busytime.Calendar {
+	busyRepo := calendarRepository.NewBusyRepository(repo.Collection.Database())
+	blocks, err := busyRepo.FindBetween(userID, from, to)
+	if err != nil {
+		blocks = nil
+	}
+	return busytime.New(blocks, time.Local)
+}
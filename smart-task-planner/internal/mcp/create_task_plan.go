@@ -3,18 +3,33 @@ package mcp
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
+	fieldModels "smart-task-planner/internal/modules/fields/models"
+	fieldsRepository "smart-task-planner/internal/modules/fields/repository"
+	"smart-task-planner/internal/modules/plan/ai"
+	"smart-task-planner/internal/modules/plan/attributes"
+	"smart-task-planner/internal/modules/plan/estimate"
 	"smart-task-planner/internal/modules/plan/models"
+	"smart-task-planner/internal/modules/plan/recurrence"
 	"smart-task-planner/internal/modules/plan/repository"
 )
 
 type AITask struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	DeadlineStr string `json:"deadline"`
+	Title          string                 `json:"title"`
+	Description    string                 `json:"description"`
+	DeadlineStr    string                 `json:"deadline"`
+	EstimatedHours float64                `json:"estimated_hours"`
+	ExpectedHours  float64                `json:"expected_hours,omitempty"` // for this user, given their history
+	Recurrence     string                 `json:"recurrence,omitempty"` // RRULE for repeating tasks
+	Priority       string                 `json:"priority,omitempty"`
+	Tags           []string               `json:"tags,omitempty"`
+	Contexts       []string               `json:"contexts,omitempty"`
+	Fields         map[string]interface{} `json:"fields,omitempty"`
 }
 
 type TaskPlan struct {
@@ -52,7 +67,33 @@ func CreateTaskPlan(params map[string]interface{}, repo *repository.PlanReposito
 			riskyDates[r.Deadline] = true
 		}
 	}
-	
+
+	// Days blocked by meetings, trips or vacations from imported calendars
+	busy := busyCalendar(userID, now, now.AddDate(1, 0, 0), repo)
+	busyDates := busy.BusyDates(now, now.AddDate(0, 6, 0))
+
+	// How long this user's tasks really take, learned from tracked time. The
+	// AI sees it and gives expected_hours next to its usual estimate.
+	profile, err := learnEstimates(userID, repo)
+	if err != nil {
+		fmt.Println("Error learning estimates:", err)
+	}
+	var calibration, expectedHint string
+	var examples []estimate.Match
+	if profile != nil {
+		calibration, examples = profile.PromptHint(goal)
+	}
+	if calibration != "" {
+		calibration = "How long this user's tasks really take:\n" + calibration
+		expectedHint = "- expected_hours (how long THIS user will need, judging by their history below)\n"
+	}
+
+	// The user's own custom fields, for the AI to fill in where they apply
+	defs, err := fieldsRepository.NewFieldRepository(repo.Collection.Database()).ByKey(userID)
+	if err != nil {
+		fmt.Println("Error loading custom fields:", err)
+	}
+
 	// 3️⃣ Build AI prompt
 	prompt := fmt.Sprintf(`You are an expert AI task planner.
 Generate at least 10 actionable, detailed tasks for this goal:
@@ -61,12 +102,19 @@ Each task must have:
 - title
 - description
 - deadline (YYYY-MM-DD, evenly distributed across goal duration)
+- estimated_hours (hours of focused work for a typical person)
+%s- priority (P0 urgent, P1 high, P2 medium, P3 low)
+- tags (1-3 short lowercase topic tags)
+- contexts (GTD contexts where the task can be done, e.g. "@computer", "@home", "@phone", "@errands")
+%s- recurrence (only for habits and routines like "run 3x per week": an RRULE with FREQ=DAILY or WEEKLY and optional INTERVAL, BYDAY, COUNT or UNTIL, e.g. "FREQ=WEEKLY;BYDAY=MO,WE,FR;UNTIL=20260301"; the deadline is then the first occurrence. Omit it for one-off tasks)
 Avoid scheduling tasks on dates that are already risky for the user:
 %v
-Return ONLY valid JSON:
+The user is busy (meetings, travel, vacation) on these dates, do not put deadlines on them:
+%v
+%sReturn ONLY valid JSON:
 [
-  {"title": "...", "description": "...", "deadline": "..."}
-]`, goal, riskyDates)
+  {"title": "...", "description": "...", "deadline": "...", "estimated_hours": 2, "priority": "P2", "tags": ["..."], "contexts": ["@computer"]}
+]`, goal, expectedHint, fieldPromptHint(defs), riskyDates, busyDates, calibration)
 
 	// 4️⃣ Call OpenAI
 	aiResp, err := CallOpenAIAPI(prompt)
@@ -85,8 +133,55 @@ Return ONLY valid JSON:
 		return TaskPlan{}, fmt.Errorf("failed to parse AI JSON: %v\nRaw: %s", err, raw)
 	}
 
-	// 6️⃣ Convert AI tasks → models.Task, adjust deadlines
+	// 6️⃣ Convert AI tasks → models.Task, calibrate estimates, adjust deadlines
 	var tasks []models.Task
+	for _, t := range aiTasks {
+		tasks = append(tasks, models.Task{
+			Title:          t.Title,
+			Description:    t.Description,
+			Status:         "Pending",
+			EstimatedHours: t.EstimatedHours,
+		})
+		task := &tasks[len(tasks)-1]
+		if t.Recurrence != "" {
+			if rec, err := recurrence.Parse(t.Recurrence); err == nil {
+				task.Recurrence = rec
+			}
+		}
+		// labels the AI got wrong are dropped rather than failing the plan
+		task.Priority, _ = attributes.NormalizePriority(t.Priority)
+		task.Tags = attributes.NormalizeTags(t.Tags)
+		task.Contexts = attributes.NormalizeContexts(t.Contexts)
+		for key, value := range t.Fields {
+			_ = attributes.SetFields(task, map[string]interface{}{key: value}, defs)
+		}
+	}
+	if profile != nil {
+		// tasks the AI gave no expected_hours for are calibrated here instead
+		var rest []int
+		var unexpected []models.Task
+		for i, t := range aiTasks {
+			if calibration == "" || t.ExpectedHours <= 0 {
+				rest = append(rest, i)
+				unexpected = append(unexpected, tasks[i])
+				continue
+			}
+			hours, info := profile.FromPrompt(t.EstimatedHours, t.ExpectedHours, examples)
+			tasks[i].EstimatedHours = hours
+			tasks[i].Estimate = &info
+		}
+		hours, infos := profile.EstimateTasks(unexpected)
+		for j, i := range rest {
+			if infos[j].Method == estimate.MethodNone {
+				continue
+			}
+			tasks[i].EstimatedHours = hours[j]
+			tasks[i].Estimate = &infos[j]
+		}
+	}
+
+	// The calibrated work has to fit before each deadline
+	var effort float64
 	for i, t := range aiTasks {
 		deadline, err := time.Parse("2006-01-02", t.DeadlineStr)
 		if err != nil {
@@ -96,18 +191,17 @@ Return ONLY valid JSON:
 			// Fix year if AI gave old year
 			deadline = time.Date(now.Year(), deadline.Month(), deadline.Day(), 0, 0, 0, 0, time.Local)
 		}
+		effort += tasks[i].EstimatedHours
+		if earliest := now.AddDate(0, 0, int(math.Ceil(effort/estimate.WorkHoursPerDay))); deadline.Before(earliest) {
+			deadline = time.Date(earliest.Year(), earliest.Month(), earliest.Day(), 0, 0, 0, 0, time.Local)
+		}
 
-		// Push task to next safe date if it conflicts with risky date
-		for riskyDates[deadline.Format("2006-01-02")] {
+		// Push task to next safe date if it conflicts with a risky or busy date
+		for i := 0; i < 60 && (riskyDates[deadline.Format("2006-01-02")] || busy.IsBusy(deadline)); i++ {
 			deadline = deadline.AddDate(0, 0, 1)
 		}
 
-		tasks = append(tasks, models.Task{
-			Title:       t.Title,
-			Description: t.Description,
-			Status:      "Pending",
-			Deadline:    deadline,
-		})
+		tasks[i].Deadline = deadline
 	}
 
 	// 7️⃣ Save plan to DB
@@ -124,3 +218,38 @@ Return ONLY valid JSON:
 
 	return TaskPlan{Tasks: tasks}, nil
 }
+
+// fieldPromptHint lists the user's custom fields as one more line of the
+// task format. Empty without any.
+func fieldPromptHint(defs map[string]fieldModels.FieldDefinition) string {
+	if len(defs) == 0 {
+		return ""
+	}
+	var fields []string
+	for key, def := range defs {
+		desc := fmt.Sprintf("%s (%s, %s", key, def.Name, def.Type)
+		if def.Type == fieldModels.TypeSelect {
+			desc += ": one of " + strings.Join(def.Options, ", ")
+		}
+		fields = append(fields, desc+")")
+	}
+	sort.Strings(fields)
+	return "- fields (an object with whichever of these custom fields apply: " + strings.Join(fields, "; ") + ")\n"
+}
+
+// learnEstimates builds the user's estimate profile from their own tracked
+// time. It is nil when time tracking isn't configured.
+func learnEstimates(userID string, repo *repository.PlanRepository) (*estimate.Profile, error) {
+	if deps.TimeRepo == nil {
+		return nil, nil
+	}
+	history, err := repo.GetAllByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	hours, err := deps.TimeRepo.HoursByTask(userID)
+	if err != nil {
+		return nil, err
+	}
+	return estimate.Learn(history, userID, hours, ai.DefaultEmbedder())
+}
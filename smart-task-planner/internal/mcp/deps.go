@@ -0,0 +1,23 @@
+package mcp
+
+import (
+	commentRepository "smart-task-planner/internal/modules/comments/repository"
+	timeRepository "smart-task-planner/internal/modules/timetracking/repository"
+	workspaceRepository "smart-task-planner/internal/modules/workspaces/repository"
+)
+
+// Deps are the repositories tools use besides the plan repository that
+// RunTool is given
+type Deps struct {
+	TimeRepo      *timeRepository.TimeEntryRepository
+	CommentRepo   *commentRepository.CommentRepository
+	WorkspaceRepo *workspaceRepository.WorkspaceRepository
+}
+
+var deps Deps
+
+// Configure hands the tools their repositories. main calls it once at
+// startup, before serving requests.
+func Configure(d Deps) {
+	deps = d
+}
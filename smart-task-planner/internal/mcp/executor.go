@@ -23,18 +23,26 @@ func RunTool(tool string, params map[string]interface{}, repo *repository.PlanRe
 		}
 		return RefineTask(task)
 	case "update_task_status":
+		userID, ok := params["user_id"].(string)
+		if !ok || userID == "" {
+			return nil, fmt.Errorf("user_id parameter required")
+		}
 		taskID, ok1 := params["task_id"].(string)
 		status, ok2 := params["status"].(string)
 		if !ok1 || !ok2 {
 			return nil, fmt.Errorf("task_id and status required")
 		}
-		return UpdateTaskStatus(taskID, status, repo)
+		return UpdateTaskStatus(userID, taskID, status, repo)
 	case "get_task_details":
+		userID, ok := params["user_id"].(string)
+		if !ok || userID == "" {
+			return nil, fmt.Errorf("user_id parameter required")
+		}
 		taskID, ok := params["task_id"].(string)
 		if !ok {
 			return nil, fmt.Errorf("task_id required")
 		}
-		return GetTaskDetails(taskID, repo)
+		return GetTaskDetails(userID, taskID, repo)
 
 	case "interpret_user_message":
 		return interpret_user_message(params)
@@ -50,6 +58,20 @@ func RunTool(tool string, params map[string]interface{}, repo *repository.PlanRe
 		return generate_alternative_plans(params)
 	case "handle_general_query":
     	return handle_general_query(params, repo)
+	case "forecast_completion":
+		return forecast_completion(params, repo)
+	case "search_tasks":
+		return search_tasks(params, repo)
+	case "get_agenda":
+		return get_agenda(params, repo)
+	case "suggest_next_task":
+		return suggest_next_task(params, repo)
+	case "update_task_attributes":
+		return update_task_attributes(params, repo)
+	case "assign_task":
+		return assign_task(params, repo)
+	case "get_workload":
+		return get_workload(params, repo)
 
 	default:
 		return nil, fmt.Errorf("unknown MCP tool: %s", tool)
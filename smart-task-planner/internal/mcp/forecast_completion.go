@@ -0,0 +1,57 @@
+package mcp
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"smart-task-planner/internal/modules/plan/forecast"
+	"smart-task-planner/internal/modules/plan/repository"
+)
+
+// forecastRe matches questions about finish dates ("will I finish on time?",
+// "when will we be done?"), but not reports like "I finished the slides"
+var forecastRe = regexp.MustCompile(`(?i)\bforecast|\bon time\b|\b(?:will|can|could|when)\b.*\b(?:finish|be done)\b|\bfinish(?:ed)? by\b`)
+
+// isForecastQuestion spots chat messages asking when plans will be finished
+func isForecastQuestion(message string) bool {
+	return forecastRe.MatchString(message)
+}
+
+// forecast_completion simulates finish dates for every plan of the user (or
+// just plan_id when given) from the user's historical completion velocity.
+func forecast_completion(params map[string]interface{}, repo *repository.PlanRepository) (map[string]interface{}, error) {
+	userID, ok := params["user_id"].(string)
+	if !ok || userID == "" {
+		return nil, fmt.Errorf("user_id required")
+	}
+	planID, _ := params["plan_id"].(string)
+
+	plans, err := repo.GetAllByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user plans: %v", err)
+	}
+
+	// Velocity is a property of the user: it counts what they completed on
+	// every plan, not what their teammates did on shared ones
+	completions := forecast.CompletionTimes(plans, userID)
+	now := time.Now()
+
+	var forecasts []forecast.Forecast
+	for _, plan := range plans {
+		if planID != "" && plan.ID.Hex() != planID {
+			continue
+		}
+		forecasts = append(forecasts, forecast.Simulate(plan, completions, now, forecast.Options{}))
+	}
+
+	if planID != "" && len(forecasts) == 0 {
+		return nil, fmt.Errorf("plan not found")
+	}
+
+	return map[string]interface{}{
+		"user_id":   userID,
+		"forecasts": forecasts,
+		"count":     len(forecasts),
+	}, nil
+}
@@ -0,0 +1,68 @@
+package mcp
+
+import (
+	"fmt"
+	"time"
+
+	notificationRepository "smart-task-planner/internal/modules/notifications/repository"
+	"smart-task-planner/internal/modules/plan/agenda"
+	"smart-task-planner/internal/modules/plan/repository"
+)
+
+// get_agenda lists the user's open tasks across all plans for one view
+// (today, week, overdue, no_deadline, assigned), grouped by plan. Days are
+// counted in time_zone, or the zone from the user's notification preferences.
+func get_agenda(params map[string]interface{}, repo *repository.PlanRepository) (*agenda.Agenda, error) {
+	userID, ok := params["user_id"].(string)
+	if !ok || userID == "" {
+		return nil, fmt.Errorf("user_id required")
+	}
+
+	viewParam, _ := params["view"].(string)
+	view, err := agenda.NormalizeView(viewParam)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := userLocation(userID, repo)
+	if zone, _ := params["time_zone"].(string); zone != "" {
+		if loc, err = time.LoadLocation(zone); err != nil {
+			return nil, fmt.Errorf("unknown time zone %q", zone)
+		}
+	}
+
+	plans, err := repo.GetAllByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user plans: %v", err)
+	}
+	return agenda.Build(plans, view, userID, time.Now().In(loc)), nil
+}
+
+// userLocation is the time zone saved with the user's notification
+// preferences, UTC when there is none
+func userLocation(userID string, repo *repository.PlanRepository) *time.Location {
+	prefs, err := notificationRepository.NewNotificationRepository(repo.Collection.Database()).GetPreferences(userID)
+	if err != nil || prefs.TimeZone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(prefs.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// agendaViewFromMessage picks the agenda view a chat message asks for
+func agendaViewFromMessage(message string) string {
+	switch {
+	case contains(message, "assigned to me") || contains(message, "my assigned"):
+		return agenda.Assigned
+	case contains(message, "overdue"):
+		return agenda.Overdue
+	case contains(message, "week"):
+		return agenda.Week
+	case contains(message, "no deadline") || contains(message, "someday") || contains(message, "undated"):
+		return agenda.NoDeadline
+	}
+	return agenda.Today
+}
@@ -7,11 +7,12 @@ import (
 	"smart-task-planner/internal/modules/plan/repository"
 	"time"
 
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func GetTaskDetails(taskID string, repo *repository.PlanRepository) (*models.Task, error) {
+func GetTaskDetails(userID, taskID string, repo *repository.PlanRepository) (*models.Task, error) {
 	if taskID == "" {
 		return nil, fmt.Errorf("taskID required")
 	}
@@ -21,20 +22,46 @@ func GetTaskDetails(taskID string, repo *repository.PlanRepository) (*models.Tas
 		return nil, fmt.Errorf("invalid task ID: %v", err)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	var plan models.Plan
-	err = repo.Collection.FindOne(ctx, bson.M{"tasks._id": objID}).Decode(&plan)
+	plan, err := findTaskPlan(userID, objID, repo)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, t := range plan.Tasks {
-		if t.ID == objID {
-			return &t, nil
-		}
+	if ft, ok := repository.Flatten(plan.Tasks)[objID.Hex()]; ok {
+		t := *ft.Task
+		return &t, nil
 	}
 
 	return nil, fmt.Errorf("task not found")
 }
+
+// taskLookupDepth is how many levels of subtasks findTaskPlan searches
+const taskLookupDepth = 6
+
+// findTaskPlan loads the plan holding a task or subtask, if the user can see it
+func findTaskPlan(userID string, taskID primitive.ObjectID, repo *repository.PlanRepository) (*models.Plan, error) {
+	access, err := repo.Access(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var nested bson.A
+	path := "tasks"
+	for i := 0; i < taskLookupDepth; i++ {
+		nested = append(nested, bson.M{path + "._id": taskID})
+		path += ".sub_tasks"
+	}
+	filter := bson.M{"$and": bson.A{access.Filter(models.RoleViewer), bson.M{"$or": nested}}}
+	var plan models.Plan
+	if err := repo.Collection.FindOne(ctx, filter).Decode(&plan); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("task not found")
+		}
+		return nil, err
+	}
+	plan.Role = access.Role(&plan)
+	return &plan, nil
+}
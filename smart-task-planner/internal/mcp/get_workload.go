@@ -0,0 +1,135 @@
+package mcp
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"smart-task-planner/internal/events"
+	"smart-task-planner/internal/modules/plan/repository"
+	"smart-task-planner/internal/modules/plan/workload"
+	workspaceModels "smart-task-planner/internal/modules/workspaces/models"
+)
+
+// get_workload shows each member of a workspace their planned hours per day
+// against their capacity, for the next days (default 14), and flags the
+// over-allocated days like analyze_risks flags deadlines. Without a
+// workspace_id the workspace is the user's only one, or the one the message
+// names.
+func get_workload(params map[string]interface{}, repo *repository.PlanRepository) (interface{}, error) {
+	userID, ok := params["user_id"].(string)
+	if !ok || userID == "" {
+		return nil, fmt.Errorf("user_id required")
+	}
+	workspaceID, _ := params["workspace_id"].(string)
+	message, _ := params["message"].(string)
+
+	w, candidates, err := pickWorkspace(userID, workspaceID, message)
+	if err != nil {
+		return nil, err
+	}
+	if w == nil {
+		return map[string]interface{}{
+			"needs_disambiguation": true,
+			"message":              "Which workspace? Name it in your message or pass its workspace_id.",
+			"candidates":           candidates,
+		}, nil
+	}
+
+	days := intParam(params["days"])
+	if days <= 0 {
+		days = workload.DefaultDays
+	}
+	days = min(days, workload.MaxDays)
+
+	all, err := repo.GetAllByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user plans: %v", err)
+	}
+	plans := all[:0]
+	for _, plan := range all {
+		if plan.WorkspaceID == w.ID.Hex() {
+			plans = append(plans, plan)
+		}
+	}
+
+	now := time.Now().In(userLocation(userID, repo))
+	var members []workload.Member
+	for _, m := range w.Members {
+		name := m.Name
+		if name == "" {
+			name = m.Email
+		}
+		members = append(members, workload.Member{
+			UserID:      m.UserID,
+			Name:        name,
+			HoursPerDay: m.HoursPerDay,
+			Busy:        busyCalendar(m.UserID, now, now.AddDate(0, 0, days), repo),
+		})
+	}
+
+	report := workload.Build(plans, members, days, now)
+	report.WorkspaceID, report.Workspace = w.ID.Hex(), w.Name
+
+	// the overloaded member hears about it, not whoever happened to look
+	for _, o := range report.Overloaded {
+		events.Publish(events.Event{
+			Type:     events.WorkloadExceeded,
+			UserID:   o.UserID,
+			Audience: []string{o.UserID},
+			// one member on one day is one overload, however often it is detected
+			Key: "overload:" + w.ID.Hex() + ":" + o.UserID + ":" + o.Date,
+			Data: map[string]interface{}{
+				"workspace_id": w.ID.Hex(),
+				"workspace":    w.Name,
+				"member_id":    o.UserID,
+				"member_name":  o.Name,
+				"date":         o.Date,
+				"hours":        o.Hours,
+				"capacity":     o.Capacity,
+				"excess":       o.Excess,
+				"reason":       o.Reason,
+			},
+		})
+	}
+	return report, nil
+}
+
+// pickWorkspace returns the workspace a request means, or the candidates to
+// choose from when that's unclear
+func pickWorkspace(userID, workspaceID, message string) (*workspaceModels.Workspace, []map[string]string, error) {
+	workspaces := deps.WorkspaceRepo
+	if workspaces == nil {
+		return nil, nil, fmt.Errorf("workspaces are not available")
+	}
+	if workspaceID != "" {
+		w, err := workspaces.Get(userID, workspaceID)
+		return w, nil, err
+	}
+
+	list, err := workspaces.ListForUser(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(list) == 0 {
+		return nil, nil, fmt.Errorf("you aren't a member of any workspace")
+	}
+	if len(list) == 1 {
+		return &list[0], nil, nil
+	}
+
+	var named []int
+	for i := range list {
+		if message != "" && strings.Contains(strings.ToLower(message), strings.ToLower(list[i].Name)) {
+			named = append(named, i)
+		}
+	}
+	if len(named) == 1 {
+		return &list[named[0]], nil, nil
+	}
+	var candidates []map[string]string
+	for _, w := range list {
+		candidates = append(candidates, map[string]string{"workspace_id": w.ID.Hex(), "name": w.Name})
+	}
+	return nil, candidates, nil
+}
@@ -0,0 +1,138 @@
+package mcp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"smart-task-planner/internal/modules/plan/attributes"
+	"smart-task-planner/internal/modules/plan/repository"
+	"smart-task-planner/internal/modules/plan/search"
+)
+
+// search_tasks runs full-text + structured search over all of the user's
+// tasks and subtasks.
+func search_tasks(params map[string]interface{}, repo *repository.PlanRepository) (*search.Result, error) {
+	userID, ok := params["user_id"].(string)
+	if !ok || userID == "" {
+		return nil, fmt.Errorf("user_id required")
+	}
+
+	q, err := searchQueryFromParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return search.Search(repo, userID, q, time.Now())
+}
+
+func searchQueryFromParams(params map[string]interface{}) (search.Query, error) {
+	q := search.Query{
+		Statuses: stringList(params["status"]),
+		Tags:     stringList(params["tags"]),
+		Contexts: stringList(params["contexts"]),
+		PlanIDs:  stringList(params["plan_id"]),
+	}
+	q.Text, _ = params["query"].(string)
+
+	// P1, #tag and @context written in the query filter rather than match text
+	inline := attributes.ExtractFilters(q.Text)
+	q.Text = inline.Text
+	q.Tags = attributes.NormalizeTags(append(q.Tags, inline.Tags...))
+	q.Contexts = attributes.NormalizeContexts(append(q.Contexts, inline.Contexts...))
+	for _, p := range append(stringList(params["priority"]), inline.Priorities...) {
+		priority, err := attributes.NormalizePriority(p)
+		if err != nil {
+			return q, err
+		}
+		if priority != "" {
+			q.Priorities = append(q.Priorities, priority)
+		}
+	}
+	switch v := params["fields"].(type) {
+	case map[string]string:
+		q.Fields = v
+	case map[string]interface{}:
+		q.Fields = map[string]string{}
+		for key, value := range v {
+			q.Fields[key] = fmt.Sprint(value)
+		}
+	}
+
+	var err error
+	if q.DeadlineFrom, err = dateParam(params["deadline_from"], false); err != nil {
+		return q, fmt.Errorf("invalid deadline_from: %v", err)
+	}
+	if q.DeadlineTo, err = dateParam(params["deadline_to"], true); err != nil {
+		return q, fmt.Errorf("invalid deadline_to: %v", err)
+	}
+
+	switch v := params["overdue"].(type) {
+	case bool:
+		q.Overdue = &v
+	case string:
+		if b, err := strconv.ParseBool(v); err == nil {
+			q.Overdue = &b
+		}
+	}
+
+	switch v := params["limit"].(type) {
+	case int:
+		q.Limit = v
+	case float64:
+		q.Limit = int(v)
+	case string:
+		q.Limit, _ = strconv.Atoi(v)
+	}
+	return q, nil
+}
+
+// stringList accepts []string, []interface{} or a comma separated string
+func stringList(v interface{}) []string {
+	var out []string
+	switch t := v.(type) {
+	case []string:
+		out = t
+	case []interface{}:
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+	case string:
+		for _, s := range strings.Split(t, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+// dateParam accepts a time.Time or YYYY-MM-DD / RFC3339 string. Plain dates
+// used as an upper bound cover the whole day.
+func dateParam(v interface{}, endOfDay bool) (*time.Time, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return &t, nil
+	case *time.Time:
+		return t, nil
+	case string:
+		if t == "" {
+			return nil, nil
+		}
+		if d, err := time.Parse("2006-01-02", t); err == nil {
+			if endOfDay {
+				d = d.Add(24*time.Hour - time.Nanosecond)
+			}
+			return &d, nil
+		}
+		d, err := time.Parse(time.RFC3339, t)
+		if err != nil {
+			return nil, err
+		}
+		return &d, nil
+	}
+	return nil, nil
+}
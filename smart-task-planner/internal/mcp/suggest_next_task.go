@@ -0,0 +1,109 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"smart-task-planner/internal/modules/plan/repository"
+	"smart-task-planner/internal/modules/plan/suggest"
+)
+
+var (
+	availableTimeRe = regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)\s*(minutes?|mins?|hours?|hrs?|h)\b`)
+	contextTagRe    = regexp.MustCompile(`@[\w-]+`)
+)
+
+// suggest_next_task ranks the user's actionable tasks across all plans.
+// minutes and contexts can be given directly or are read from message ("I
+// have 30 minutes @home"). The ranking is deterministic; with polish=true
+// and an OpenAI key the LLM only rewrites the summary message.
+func suggest_next_task(params map[string]interface{}, repo *repository.PlanRepository) (*suggest.Result, error) {
+	userID, ok := params["user_id"].(string)
+	if !ok || userID == "" {
+		return nil, fmt.Errorf("user_id required")
+	}
+	message, _ := params["message"].(string)
+
+	opts := suggest.Options{
+		UserID:           userID,
+		AvailableMinutes: intParam(params["minutes"]),
+		Contexts:         stringList(params["contexts"]),
+		Limit:            intParam(params["limit"]),
+	}
+	if opts.AvailableMinutes == 0 {
+		opts.AvailableMinutes = extractAvailableMinutes(message)
+	}
+	if len(opts.Contexts) == 0 {
+		opts.Contexts = contextTagRe.FindAllString(message, -1)
+	}
+	if opts.AvailableMinutes < 0 {
+		return nil, fmt.Errorf("minutes must be positive")
+	}
+
+	plans, err := repo.GetAllByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user plans: %v", err)
+	}
+	res := suggest.Rank(plans, opts, time.Now())
+
+	if polish, _ := params["polish"].(bool); polish && len(res.Suggestions) > 0 && os.Getenv("OPENAI_API_KEY") != "" {
+		if text, err := polishSuggestions(res); err == nil && text != "" {
+			res.Message = text
+		}
+	}
+	return res, nil
+}
+
+// polishSuggestions asks the LLM for a friendlier summary of the ranking. It
+// gets the picks in order and must not change them.
+func polishSuggestions(res *suggest.Result) (string, error) {
+	picks, err := json.Marshal(res.Suggestions)
+	if err != nil {
+		return "", err
+	}
+	prompt := fmt.Sprintf(`You are a supportive productivity coach. These tasks were ranked by a scheduler, best first:
+%s
+
+In 2 short sentences, tell the user to start with the first task and why, using only the reasons given. Mention the runner-up if there is one. Do not reorder, add or drop tasks.`, picks)
+
+	text, err := CallOpenAIAPI(prompt)
+	return strings.TrimSpace(text), err
+}
+
+// extractAvailableMinutes reads "30 minutes", "1.5 hours", "2h", "half an
+// hour" or "an hour" from a message; 0 when there is none
+func extractAvailableMinutes(message string) int {
+	if m := availableTimeRe.FindStringSubmatch(message); m != nil {
+		n, _ := strconv.ParseFloat(m[1], 64)
+		if strings.HasPrefix(strings.ToLower(m[2]), "h") {
+			n *= 60
+		}
+		return int(n)
+	}
+	switch {
+	case contains(message, "half an hour"):
+		return 30
+	case contains(message, "an hour"):
+		return 60
+	}
+	return 0
+}
+
+// intParam accepts an int, a JSON number or a numeric string
+func intParam(v interface{}) int {
+	switch t := v.(type) {
+	case int:
+		return t
+	case float64:
+		return int(t)
+	case string:
+		n, _ := strconv.Atoi(strings.TrimSpace(t))
+		return n
+	}
+	return 0
+}
@@ -1,6 +1,7 @@
 package mcp
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"regexp"
@@ -9,22 +10,52 @@ import (
 	"sort"
 	"time"
 
+	"smart-task-planner/internal/events"
+	"smart-task-planner/internal/modules/calendar/busytime"
+	"smart-task-planner/internal/modules/plan/agenda"
+	"smart-task-planner/internal/modules/plan/forecast"
+	"smart-task-planner/internal/modules/plan/progress"
+	"smart-task-planner/internal/modules/plan/recurrence"
 	"smart-task-planner/internal/modules/plan/repository"
 	"smart-task-planner/internal/modules/plan/models"
 )
 
 type RiskTask struct {
-	Goal     string `json:"goal"`
-	TaskName string `json:"task_name"`
-	Deadline string `json:"deadline"`
-	DaysLeft int    `json:"days_left"`
+	PlanID        string `json:"plan_id"`
+	TaskID        string `json:"task_id"`
+	Goal          string `json:"goal"`
+	TaskName      string `json:"task_name"`
+	Deadline      string `json:"deadline"`
+	DaysLeft      int    `json:"days_left"`
+	BusyDays      int    `json:"busy_days,omitempty"` // busy calendar days before the deadline
+	AvailableDays int    `json:"available_days"`      // DaysLeft minus BusyDays
+	Reason        string `json:"reason,omitempty"`
 }
 
 func interpret_user_message(params map[string]interface{}) (map[string]interface{}, error) {
 	message := params["message"].(string)
 	userID := params["user_id"].(string)
+	planID, _ := params["plan_id"].(string)
 
 	switch {
+	case isAssignCommand(message):
+		return map[string]interface{}{
+			"tool": "assign_task",
+			"params": map[string]interface{}{
+				"user_id": userID,
+				"plan_id": planID,
+				"message": message,
+			},
+		}, nil
+	case isAttributeCommand(message):
+		return map[string]interface{}{
+			"tool": "update_task_attributes",
+			"params": map[string]interface{}{
+				"user_id": userID,
+				"plan_id": planID,
+				"message": message,
+			},
+		}, nil
 	case contains(message, "behind"):
 		return map[string]interface{}{
 			"tool": "reschedule_plan",
@@ -33,6 +64,14 @@ func interpret_user_message(params map[string]interface{}) (map[string]interface
 				"message": message,
 			},
 		}, nil
+	case contains(message, "workload") || contains(message, "capacity") || contains(message, "over-allocated") || contains(message, "overallocated"):
+		return map[string]interface{}{
+			"tool": "get_workload",
+			"params": map[string]interface{}{
+				"user_id": userID,
+				"message": message,
+			},
+		}, nil
 	case contains(message, "risk"):
 		return map[string]interface{}{
 			"tool": "analyze_risks",
@@ -47,15 +86,52 @@ func interpret_user_message(params map[string]interface{}) (map[string]interface
 				"user_id": userID,
 			},
 		}, nil
+	case contains(message, "find") || contains(message, "search"):
+		return map[string]interface{}{
+			"tool": "search_tasks",
+			"params": map[string]interface{}{
+				"user_id": userID,
+				"query":   extractSearchText(message),
+			},
+		}, nil
+	case isForecastQuestion(message):
+		return map[string]interface{}{
+			"tool": "forecast_completion",
+			"params": map[string]interface{}{
+				"user_id": userID,
+				"plan_id": planID,
+			},
+		}, nil
 	case contains(message, "progress") || contains(message, "feedback"):
 		// ✅ Fixed: Set needs_chaining flag for progress/feedback queries
 		return map[string]interface{}{
 			"tool": "get_user_progress",
+			"params": map[string]interface{}{
+				"user_id":   userID,
+				"message":   message,
+				"plan_id":   planID,
+				"weighting": weightingFromMessage(message),
+			},
+			"needs_chaining": true, // Signal that feedback should follow
+		}, nil
+	case contains(message, "what should i") || contains(message, "next task") || contains(message, "work on next") ||
+		contains(message, "suggest") || extractAvailableMinutes(message) > 0:
+		return map[string]interface{}{
+			"tool": "suggest_next_task",
 			"params": map[string]interface{}{
 				"user_id": userID,
 				"message": message,
+				"polish":  true,
+			},
+		}, nil
+	case contains(message, "agenda") || contains(message, "today") || contains(message, "this week") || contains(message, "overdue") ||
+		contains(message, "assigned to me") || contains(message, "my assigned"):
+		return map[string]interface{}{
+			"tool": "get_agenda",
+			"params": map[string]interface{}{
+				"user_id": userID,
+				"view":    agendaViewFromMessage(message),
 			},
-			"needs_chaining": true, // Signal that feedback should follow
 		}, nil
 	default:
 		// ✅ NEW: Fallback to AI-powered general query handler
@@ -85,14 +161,26 @@ func reschedule_plan(params map[string]interface{}, repo *repository.PlanReposit
 		return nil, fmt.Errorf("no delay days found in message")
 	}
 
-	plan, err := repo.FindGoalByAI(userID, message)
+	// only plans the user may change are candidates
+	plan, err := repo.FindGoalForRole(userID, message, models.RoleEditor)
 	if err != nil {
+		if ambiguous, ok := disambiguation(err); ok {
+			return ambiguous, nil
+		}
 		return nil, fmt.Errorf("goal not found: %v", err)
 	}
 
+	now := time.Now()
+	busy := busyCalendar(userID, now, now.AddDate(1, 0, 0), repo)
+	moved := 0
 	for i, task := range plan.Tasks {
 		if !task.Deadline.IsZero() {
-			plan.Tasks[i].Deadline = task.Deadline.AddDate(0, 0, delay)
+			shifted := task.Deadline.AddDate(0, 0, delay)
+			// don't land a deadline on a day the user is away or booked
+			plan.Tasks[i].Deadline = busy.NextFreeDay(shifted)
+			if !plan.Tasks[i].Deadline.Equal(shifted) {
+				moved++
+			}
 		}
 	}
 
@@ -101,10 +189,28 @@ func reschedule_plan(params map[string]interface{}, repo *repository.PlanReposit
 		return nil, fmt.Errorf("failed to update plan: %v", err)
 	}
 
+	msg := fmt.Sprintf("All tasks for goal '%s' shifted by %d days", updatedPlan.Goal, delay)
+	if moved > 0 {
+		msg += fmt.Sprintf(" (%d moved further to avoid busy days)", moved)
+	}
+
+	events.Publish(events.Event{
+		Type:   events.PlanRescheduled,
+		UserID: userID,
+		PlanID: updatedPlan.ID.Hex(),
+		Data: map[string]interface{}{
+			"plan_id":         updatedPlan.ID.Hex(),
+			"goal":            updatedPlan.Goal,
+			"delay_days":      delay,
+			"busy_day_shifts": moved,
+		},
+	})
+
 	return map[string]interface{}{
-		"message": fmt.Sprintf("All tasks for goal '%s' shifted by %d days", updatedPlan.Goal, delay),
-		"goal_id": updatedPlan.ID.Hex(),
-		"tasks":   updatedPlan.Tasks,
+		"message":         msg,
+		"goal_id":         updatedPlan.ID.Hex(),
+		"tasks":           updatedPlan.Tasks,
+		"busy_day_shifts": moved,
 	}, nil
 }
 
@@ -135,28 +241,36 @@ func analyze_risks(params map[string]interface{}, repo *repository.PlanRepositor
 
 	var risks []RiskTask
 	now := time.Now()
+	busy := busyCalendar(userID, now, now.AddDate(1, 0, 0), repo)
 
 	for _, plan := range plans {
-		for _, task := range plan.Tasks {
-			if !task.Deadline.IsZero() {
-				daysLeft := int(task.Deadline.Sub(now).Hours() / 24)
-				if daysLeft <= threshold {
-					risks = append(risks, RiskTask{
-						Goal:     plan.Goal,
-						TaskName: task.Title,
-						Deadline: task.Deadline.Format("2006-01-02"),
-						DaysLeft: daysLeft,
-					})
-				}
-			}
-			checkSubTasks(plan.Goal, task.SubTasks, &risks, now, threshold)
-		}
+		checkSubTasks(plan, plan.Tasks, &risks, now, threshold, busy)
 	}
 
 	sort.SliceStable(risks, func(i, j int) bool {
-		return risks[i].DaysLeft < risks[j].DaysLeft
+		return risks[i].AvailableDays < risks[j].AvailableDays
 	})
 
+	for _, r := range risks {
+		events.Publish(events.Event{
+			Type:   events.RiskDetected,
+			UserID: userID,
+			PlanID: r.PlanID,
+			// the same task at the same deadline is one risk, however often it is detected
+			Key: "risk:" + r.TaskID + ":" + r.Deadline,
+			Data: map[string]interface{}{
+				"plan_id":        r.PlanID,
+				"task_id":        r.TaskID,
+				"goal":           r.Goal,
+				"task_name":      r.TaskName,
+				"deadline":       r.Deadline,
+				"days_left":      r.DaysLeft,
+				"available_days": r.AvailableDays,
+				"reason":         r.Reason,
+			},
+		})
+	}
+
 	return map[string]interface{}{
 		"user_id":        userID,
 		"risks":          risks,
@@ -165,20 +279,37 @@ func analyze_risks(params map[string]interface{}, repo *repository.PlanRepositor
 	}, nil
 }
 
-func checkSubTasks(goal string, tasks []models.Task, risks *[]RiskTask, now time.Time, threshold int) {
+// checkSubTasks flags tasks whose working days left (calendar days minus
+// busy days from imported calendars) fall within the threshold.
+func checkSubTasks(plan models.Plan, tasks []models.Task, risks *[]RiskTask, now time.Time, threshold int, busy *busytime.Calendar) {
 	for _, t := range tasks {
 		if !t.Deadline.IsZero() {
 			daysLeft := int(t.Deadline.Sub(now).Hours() / 24)
-			if daysLeft <= threshold {
-				*risks = append(*risks, RiskTask{
-					Goal:     goal,
-					TaskName: t.Title,
-					Deadline: t.Deadline.Format("2006-01-02"),
-					DaysLeft: daysLeft,
-				})
+			busyDays := 0
+			if daysLeft > 0 {
+				busyDays = busy.BusyDaysBetween(now, t.Deadline)
+			}
+			available := daysLeft - busyDays
+			if available <= threshold {
+				risk := RiskTask{
+					PlanID:        plan.ID.Hex(),
+					TaskID:        t.ID.Hex(),
+					Goal:          plan.Goal,
+					TaskName:      t.Title,
+					Deadline:      t.Deadline.Format("2006-01-02"),
+					DaysLeft:      daysLeft,
+					BusyDays:      busyDays,
+					AvailableDays: available,
+				}
+				if daysLeft > threshold {
+					risk.Reason = fmt.Sprintf("%d of the %d days left are blocked in your calendar", busyDays, daysLeft)
+				} else if busy.IsBusy(t.Deadline) {
+					risk.Reason = "deadline falls on a busy day"
+				}
+				*risks = append(*risks, risk)
 			}
 		}
-		checkSubTasks(goal, t.SubTasks, risks, now, threshold)
+		checkSubTasks(plan, t.SubTasks, risks, now, threshold, busy)
 	}
 }
 
@@ -189,6 +320,15 @@ func get_user_progress(params map[string]interface{}, repo *repository.PlanRepos
 	}
 
 	message, _ := params["message"].(string)
+	planID, _ := params["plan_id"].(string)
+
+	weighting, _ := params["weighting"].(string)
+	if weighting == "" {
+		weighting = progress.WeightCount
+	}
+	if !progress.ValidMode(weighting) {
+		return nil, fmt.Errorf("weighting must be one of: count, effort, leaf")
+	}
 
 	plans, err := repo.GetAllByUser(userID)
 	if err != nil {
@@ -198,43 +338,89 @@ func get_user_progress(params map[string]interface{}, repo *repository.PlanRepos
 		return nil, fmt.Errorf("no plans found for this user")
 	}
 
+	// Explicit plan_id wins; goal matching is only a fallback for free text
 	var plan *models.Plan
-	if message != "" {
-		plan, err = repo.FindGoalByAI(userID, message)
+	switch {
+	case planID != "":
+		for i := range plans {
+			if plans[i].ID.Hex() == planID {
+				plan = &plans[i]
+				break
+			}
+		}
+		if plan == nil {
+			return nil, fmt.Errorf("plan not found")
+		}
+	case message != "":
+		plan, err = repo.FindGoal(userID, message)
+		var ambiguous *repository.AmbiguousGoalError
+		if errors.As(err, &ambiguous) && ambiguous.NoneReferenced() {
+			// "what's my progress?" names no goal; behave as if no message was given
+			plan, err = &plans[0], nil
+		}
 		if err != nil {
+			if result, ok := disambiguation(err); ok {
+				return result, nil
+			}
 			return nil, fmt.Errorf("failed to find matching plan: %v", err)
 		}
-	} else {
+	default:
 		plan = &plans[0]
 	}
 
-	totalTasks := 0
-	completedTasks := 0
+	now := time.Now()
+	summary := progress.Calculate(plan.Tasks, now)
+	weighted := progress.CalculateWeighted(plan.Tasks, weighting)
 
-	var countTasks func(tasks []models.Task)
-	countTasks = func(tasks []models.Task) {
-		for _, t := range tasks {
-			totalTasks++
-			if strings.EqualFold(t.Status, "Completed") {
-				completedTasks++
-			}
-			countTasks(t.SubTasks)
-		}
+	return map[string]interface{}{
+		"user_id":               userID,
+		"plan_id":               plan.ID.Hex(),
+		"goal":                  plan.Goal,
+		"weighting":             weighting,
+		"completion_percentage": weighted.Percentage,
+		"total_tasks":           summary.Total,
+		"completed_tasks":       summary.Completed,
+		"overdue_tasks":         summary.Overdue,
+		"remaining_hours":       summary.RemainingHours,
+		"weighted":              weighted,
+		"milestones":            progress.Milestones(plan.Tasks, weighting, now),
+		"habits":                recurrence.Habits(plan.Tasks),
+		"portfolio":             portfolioSummary(plans, weighting, now),
+	}, nil
+}
+
+// portfolioSummary reports every plan's progress plus an overall figure
+// where each plan contributes in proportion to its weight.
+func portfolioSummary(plans []models.Plan, weighting string, now time.Time) map[string]interface{} {
+	var totalWeight, completedWeight float64
+	var items []map[string]interface{}
+
+	for _, p := range plans {
+		summary := progress.Calculate(p.Tasks, now)
+		weighted := progress.CalculateWeighted(p.Tasks, weighting)
+		totalWeight += weighted.TotalWeight
+		completedWeight += weighted.CompletedWeight
+
+		items = append(items, map[string]interface{}{
+			"plan_id":               p.ID.Hex(),
+			"goal":                  p.Goal,
+			"completion_percentage": weighted.Percentage,
+			"total_tasks":           summary.Total,
+			"completed_tasks":       summary.Completed,
+			"overdue_tasks":         summary.Overdue,
+		})
 	}
-	countTasks(plan.Tasks)
 
-	progress := 0
-	if totalTasks > 0 {
-		progress = (completedTasks * 100) / totalTasks
+	overall := 0
+	if totalWeight > 0 {
+		overall = int(completedWeight * 100 / totalWeight)
 	}
 
 	return map[string]interface{}{
-		"user_id":               userID,
-		"goal":                  plan.Goal,
-		"completion_percentage": progress,
-		"total_tasks":           totalTasks,
-		"completed_tasks":       completedTasks,
-	}, nil
+		"plan_count":            len(plans),
+		"completion_percentage": overall,
+		"plans":                 items,
+	}
 }
 
 func provide_feedback(params map[string]interface{}) (map[string]interface{}, error) {
@@ -263,6 +449,7 @@ func provide_feedback(params map[string]interface{}) (map[string]interface{}, er
 	remainingTasks := totalTasks - completedTasks
 
 	var message, suggestion, tone string
+	forecastData, _ := params["forecast_data"].(forecast.Forecast)
 
 	if pct == 0 {
 		tone = "Let's get started! 🚀"
@@ -299,22 +486,82 @@ func provide_feedback(params map[string]interface{}) (map[string]interface{}, er
 		suggestion = "Amazing work! Time to set a new goal and keep the momentum going!"
 	}
 
-	return map[string]interface{}{
-		"feedback": map[string]interface{}{
-			"tone":               tone,
-			"message":            message,
-			"suggestion":         suggestion,
-			"progress_summary": map[string]interface{}{
-				"goal":              goal,
-				"completion_percentage": pct,
-				"completed_tasks":   completedTasks,
-				"remaining_tasks":   remainingTasks,
-				"total_tasks":       totalTasks,
-			},
+	feedback := map[string]interface{}{
+		"tone":               tone,
+		"message":            message,
+		"suggestion":         suggestion,
+		"progress_summary": map[string]interface{}{
+			"goal":              goal,
+			"completion_percentage": pct,
+			"completed_tasks":   completedTasks,
+			"remaining_tasks":   remainingTasks,
+			"total_tasks":       totalTasks,
 		},
+	}
+
+	// Fold the Monte Carlo forecast in when the caller supplied one
+	if forecastData.Status == forecast.StatusOK {
+		feedback["forecast"] = forecastData
+		feedback["suggestion"] = suggestion + " " + forecastSuggestion(forecastData)
+	}
+
+	return map[string]interface{}{
+		"feedback": feedback,
 	}, nil
 }
 
+func forecastSuggestion(fc forecast.Forecast) string {
+	likely := fc.P85.Format("2006-01-02")
+	if fc.OnTimeProbability == nil {
+		return fmt.Sprintf("At your current pace you'll most likely be done by %s.", likely)
+	}
+
+	chance := int(*fc.OnTimeProbability * 100)
+	switch {
+	case chance >= 85:
+		return fmt.Sprintf("At your current pace you have a %d%% chance of finishing by the last deadline. You're on track!", chance)
+	case chance >= 50:
+		return fmt.Sprintf("At your current pace you have a %d%% chance of finishing on time. A little extra push will make it safe.", chance)
+	default:
+		return fmt.Sprintf("At your current pace there's only a %d%% chance of finishing by %s; you'll likely finish around %s. Consider rescheduling or picking up the pace.",
+			chance, fc.LastDeadline.Format("2006-01-02"), likely)
+	}
+}
+
+// disambiguation turns an ambiguous goal match into a result asking the user
+// to pick one of the candidate plans.
+func disambiguation(err error) (map[string]interface{}, bool) {
+	var ambiguous *repository.AmbiguousGoalError
+	if !errors.As(err, &ambiguous) {
+		return nil, false
+	}
+	return map[string]interface{}{
+		"needs_disambiguation": true,
+		"message":              "Which goal did you mean? Reply with the goal name or pass its plan_id.",
+		"candidates":           ambiguous.Candidates,
+	}, true
+}
+
+// weightingFromMessage picks a progress weighting mode from phrases like "by effort"
+func weightingFromMessage(message string) string {
+	switch {
+	case contains(message, "effort") || contains(message, "hours"):
+		return progress.WeightEffort
+	case contains(message, "leaf") || contains(message, "subtask"):
+		return progress.WeightLeaf
+	default:
+		return progress.WeightCount
+	}
+}
+
+var searchPrefix = regexp.MustCompile(`(?i)^\s*(please\s+)?(can you\s+)?(find|search(\s+for)?|look\s+for)\s+(me\s+)?(my\s+|all\s+)?(the\s+)?(tasks?\s+)?((about|for|with|on|mentioning)\s+)?`)
+
+// extractSearchText strips the command phrase from "find tasks about budget"
+func extractSearchText(message string) string {
+	text := searchPrefix.ReplaceAllString(message, "")
+	return strings.Trim(text, " ?.!\"'")
+}
+
 func contains(s, substr string) bool {
 	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
 }
@@ -372,46 +619,36 @@ func handle_general_query(params map[string]interface{}, repo *repository.PlanRe
 	var contextBuilder strings.Builder
 	contextBuilder.WriteString("User's current plans:\n")
 	
-	for i, plan := range plans {
-		totalTasks := 0
-		completedTasks := 0
-		var countTasks func([]models.Task)
-		countTasks = func(tasks []models.Task) {
-			for _, t := range tasks {
-				totalTasks++
-				if strings.EqualFold(t.Status, "Completed") {
-					completedTasks++
-				}
-				countTasks(t.SubTasks)
-			}
-		}
-		countTasks(plan.Tasks)
+	now := time.Now().In(userLocation(userID, repo))
+	upcoming := map[string][]agenda.Item{}
+	for _, g := range agenda.Upcoming(plans, userID, now, 7).Groups {
+		upcoming[g.PlanID] = g.Items
+	}
 
-		progress := 0
-		if totalTasks > 0 {
-			progress = (completedTasks * 100) / totalTasks
-		}
+	for i, plan := range plans {
+		summary := progress.Calculate(plan.Tasks, now)
 
 		contextBuilder.WriteString(fmt.Sprintf("\n%d. Goal: %s\n", i+1, plan.Goal))
-		contextBuilder.WriteString(fmt.Sprintf("   Progress: %d%% (%d/%d tasks completed)\n", progress, completedTasks, totalTasks))
+		if plan.WorkspaceID != "" || plan.Role != models.RoleOwner {
+			contextBuilder.WriteString(fmt.Sprintf("   Team plan, the user's role: %s\n", plan.Role))
+		}
+		contextBuilder.WriteString(fmt.Sprintf("   Progress: %d%% (%d/%d tasks completed)\n", summary.Percentage, summary.Completed, summary.Total))
+		if summary.Overdue > 0 {
+			contextBuilder.WriteString(fmt.Sprintf("   Overdue: %d tasks\n", summary.Overdue))
+		}
 		
-		// Add upcoming deadlines
-		now := time.Now()
-		upcomingCount := 0
-		for _, task := range plan.Tasks {
-			if !task.Deadline.IsZero() && task.Deadline.After(now) && strings.EqualFold(task.Status, "Pending") {
-				daysLeft := int(task.Deadline.Sub(now).Hours() / 24)
-				if daysLeft <= 7 {
-					contextBuilder.WriteString(fmt.Sprintf("   - %s (due in %d days)\n", task.Title, daysLeft))
-					upcomingCount++
-					if upcomingCount >= 3 {
-						break
-					}
-				}
+		// Add the plan's next open deadlines
+		for i, item := range upcoming[plan.ID.Hex()] {
+			if i == 3 {
+				break
 			}
+			daysLeft := int(item.Deadline.Sub(now).Hours() / 24)
+			contextBuilder.WriteString(fmt.Sprintf("   - %s (due in %d days)\n", item.Title, daysLeft))
 		}
 	}
 
+	writeRecentComments(&contextBuilder, plans)
+
 	// Build AI prompt
 	prompt := fmt.Sprintf(`You are a helpful task planning assistant. A user asked: "%s"
 
@@ -445,4 +682,43 @@ Response:`, message, contextBuilder.String())
 		"response": strings.TrimSpace(aiResponse),
 		"context_used": true,
 	}, nil
+}
+
+// writeRecentComments adds the newest comments across plans to an LLM
+// context, so questions like "what did Priya say about the venue?" work
+func writeRecentComments(b *strings.Builder, plans []models.Plan) {
+	if deps.CommentRepo == nil {
+		return
+	}
+	ids := make([]string, len(plans))
+	goals := map[string]string{}
+	titles := map[string]string{}
+	for i, plan := range plans {
+		ids[i] = plan.ID.Hex()
+		goals[ids[i]] = plan.Goal
+		for id, ft := range repository.Flatten(plan.Tasks) {
+			titles[id] = ft.Task.Title
+		}
+	}
+
+	comments, err := deps.CommentRepo.Recent(ids, 10)
+	if err != nil || len(comments) == 0 {
+		return
+	}
+	b.WriteString("\nRecent comments, newest first:\n")
+	for _, c := range comments {
+		on := goals[c.PlanID]
+		if title, ok := titles[c.TaskID]; ok {
+			on = fmt.Sprintf("%s (%s)", title, on)
+		}
+		author := c.AuthorName
+		if author == "" {
+			author = "someone"
+		}
+		body := strings.Join(strings.Fields(c.Body), " ")
+		if len([]rune(body)) > 300 {
+			body = string([]rune(body)[:300]) + "…"
+		}
+		fmt.Fprintf(b, "- %s on %s, %s: %s\n", author, on, c.CreatedAt.Format("Jan 2"), body)
+	}
 }
\ No newline at end of file
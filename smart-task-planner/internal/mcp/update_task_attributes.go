@@ -0,0 +1,311 @@
+package mcp
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	fieldsRepository "smart-task-planner/internal/modules/fields/repository"
+	"smart-task-planner/internal/modules/plan/attributes"
+	"smart-task-planner/internal/modules/plan/models"
+	"smart-task-planner/internal/modules/plan/repository"
+	"smart-task-planner/internal/modules/plan/search"
+)
+
+var (
+	attributeVerbRe = regexp.MustCompile(`(?i)^\s*(please\s+)?(set|make|mark|change|tag|untag|label|add|remove|prioriti[sz]e)\b`)
+	removeVerbRe    = regexp.MustCompile(`(?i)^\s*(please\s+)?(untag|remove)\b`)
+	fieldAssignRe   = regexp.MustCompile(`\b([a-z][a-z0-9_]*)\s*=\s*("[^"]*"|\S+)`)
+	quotedTitleRe   = regexp.MustCompile(`"([^"]+)"|“([^”]+)”`)
+	priorityWordRe  = regexp.MustCompile(`(?i)\b(urgent|high|medium|low)\s+priority\b|\bpriority\s+(?:to\s+|of\s+)?(urgent|high|medium|low|none|p[0-3]|[0-3])\b`)
+	attributeFiller = regexp.MustCompile(`(?i)\b(please|set|make|mark|change|tag|untag|label|add|remove|prioriti[sz]e|to|as|the|task|priority|on|from|with|of|for|a|an|and|it|its|my|tags?|contexts?)\b`)
+)
+
+// attributeChanges are the edits to one task's priority, tags, contexts and
+// custom fields. A nil priority leaves it alone; "" clears it.
+type attributeChanges struct {
+	priority       *string
+	tags           []string // replaces all tags when setTags
+	setTags        bool
+	addTags        []string
+	removeTags     []string
+	contexts       []string // replaces all contexts when setContexts
+	setContexts    bool
+	addContexts    []string
+	removeContexts []string
+	fields         map[string]interface{} // nil values remove a field
+}
+
+func (c attributeChanges) empty() bool {
+	return c.priority == nil && !c.setTags && !c.setContexts && len(c.fields) == 0 &&
+		len(c.addTags)+len(c.removeTags)+len(c.addContexts)+len(c.removeContexts) == 0
+}
+
+// isAttributeCommand spots chat edits like `set "Draft post" to P1 #writing`
+func isAttributeCommand(message string) bool {
+	if !attributeVerbRe.MatchString(message) {
+		return false
+	}
+	f := attributes.ExtractFilters(message)
+	return len(f.Priorities)+len(f.Tags)+len(f.Contexts) > 0 ||
+		fieldAssignRe.MatchString(message) || priorityWordRe.MatchString(message)
+}
+
+// parseAttributeCommand splits a chat edit into the changes and the text that
+// names the task: a quoted title if there is one, otherwise what's left.
+func parseAttributeCommand(message string) (attributeChanges, string) {
+	var c attributeChanges
+	remove := removeVerbRe.MatchString(message)
+	text := message
+
+	title := ""
+	if m := quotedTitleRe.FindStringSubmatch(text); m != nil {
+		title = m[1] + m[2]
+		text = strings.Replace(text, m[0], " ", 1)
+	}
+
+	for _, m := range fieldAssignRe.FindAllStringSubmatch(text, -1) {
+		if c.fields == nil {
+			c.fields = map[string]interface{}{}
+		}
+		value := strings.Trim(m[2], `"`)
+		if remove {
+			c.fields[m[1]] = nil
+		} else {
+			c.fields[m[1]] = strings.TrimRight(value, ",.;")
+		}
+	}
+	text = fieldAssignRe.ReplaceAllString(text, " ")
+
+	if m := priorityWordRe.FindStringSubmatch(text); m != nil {
+		if p, err := attributes.NormalizePriority(m[1] + m[2]); err == nil {
+			c.priority = &p
+		}
+		text = strings.Replace(text, m[0], " ", 1)
+	}
+
+	inline := attributes.ExtractFilters(text)
+	if len(inline.Priorities) > 0 {
+		p := inline.Priorities[0]
+		c.priority = &p
+	}
+	if remove {
+		c.removeTags, c.removeContexts = inline.Tags, inline.Contexts
+	} else {
+		c.addTags, c.addContexts = inline.Tags, inline.Contexts
+	}
+
+	if title == "" {
+		title = attributeFiller.ReplaceAllString(inline.Text, " ")
+		title = strings.Trim(strings.Join(strings.Fields(title), " "), " ?.!,:;'")
+	}
+	return c, title
+}
+
+func attributeChangesFromParams(params map[string]interface{}) (attributeChanges, error) {
+	var c attributeChanges
+	if p, ok := params["priority"].(string); ok && p != "" {
+		priority, err := attributes.NormalizePriority(p)
+		if err != nil {
+			return c, err
+		}
+		c.priority = &priority
+	}
+	if _, ok := params["tags"]; ok {
+		c.setTags = true
+		c.tags = stringList(params["tags"])
+	}
+	if _, ok := params["contexts"]; ok {
+		c.setContexts = true
+		c.contexts = stringList(params["contexts"])
+	}
+	c.addTags = stringList(params["add_tags"])
+	c.removeTags = stringList(params["remove_tags"])
+	c.addContexts = stringList(params["add_contexts"])
+	c.removeContexts = stringList(params["remove_contexts"])
+	if fields, ok := params["fields"].(map[string]interface{}); ok {
+		c.fields = fields
+	}
+	return c, nil
+}
+
+// update_task_attributes sets a task's priority, tags, contexts and custom
+// fields. The task comes from task_id, or is looked up from the chat message
+// (`set "Draft post" to P1 #writing @computer effort=3`).
+func update_task_attributes(params map[string]interface{}, repo *repository.PlanRepository) (map[string]interface{}, error) {
+	userID, ok := params["user_id"].(string)
+	if !ok || userID == "" {
+		return nil, fmt.Errorf("user_id required")
+	}
+	planID, _ := params["plan_id"].(string)
+	taskID, _ := params["task_id"].(string)
+	message, _ := params["message"].(string)
+
+	changes, err := attributeChangesFromParams(params)
+	if err != nil {
+		return nil, err
+	}
+	if taskID == "" && message == "" {
+		return nil, fmt.Errorf("task_id or message required")
+	}
+	needle := ""
+	if message != "" {
+		var parsed attributeChanges
+		parsed, needle = parseAttributeCommand(message)
+		if changes.empty() {
+			changes = parsed
+		}
+	}
+	if changes.empty() {
+		return nil, fmt.Errorf("nothing to change: give a priority, tags, contexts or fields")
+	}
+
+	var plans []models.Plan
+	if planID != "" {
+		plan, err := repo.GetByIDForUser(planID, userID)
+		if err != nil {
+			return nil, err
+		}
+		plans = []models.Plan{*plan}
+	} else if plans, err = repo.GetAllByUser(userID); err != nil {
+		return nil, err
+	}
+
+	if taskID == "" {
+		candidates := resolveTask(plans, needle)
+		switch len(candidates) {
+		case 0:
+			return nil, fmt.Errorf("no task matches %q", needle)
+		case 1:
+			planID, taskID = candidates[0]["plan_id"], candidates[0]["task_id"]
+		default:
+			return map[string]interface{}{
+				"needs_disambiguation": true,
+				"message":              "Which task did you mean? Put its title in quotes or pass its task_id.",
+				"candidates":           candidates,
+			}, nil
+		}
+	}
+
+	var plan *models.Plan
+	var task *models.Task
+	for i := range plans {
+		if planID != "" && plans[i].ID.Hex() != planID {
+			continue
+		}
+		if ft, ok := repository.Flatten(plans[i].Tasks)[taskID]; ok {
+			plan, task = &plans[i], ft.Task
+			break
+		}
+	}
+	if task == nil {
+		return nil, fmt.Errorf("task not found")
+	}
+
+	if changes.priority != nil {
+		task.Priority = *changes.priority
+	}
+	if changes.setTags {
+		task.Tags = changes.tags
+	}
+	task.Tags = attributes.NormalizeTags(append(task.Tags, changes.addTags...))
+	task.Tags = without(task.Tags, attributes.NormalizeTags(changes.removeTags))
+	// contexts still written as @tags move to their own field
+	task.Contexts = attributes.Contexts(*task)
+	var tags []string
+	for _, tag := range task.Tags {
+		if !strings.HasPrefix(tag, "@") {
+			tags = append(tags, tag)
+		}
+	}
+	task.Tags = tags
+	if changes.setContexts {
+		task.Contexts = changes.contexts
+	}
+	task.Contexts = attributes.NormalizeContexts(append(task.Contexts, changes.addContexts...))
+	task.Contexts = without(task.Contexts, attributes.NormalizeContexts(changes.removeContexts))
+	if len(changes.fields) > 0 {
+		defs, err := fieldsRepository.NewFieldRepository(repo.Collection.Database()).ByKey(userID)
+		if err != nil {
+			return nil, err
+		}
+		if err := attributes.SetFields(task, changes.fields, defs); err != nil {
+			return nil, err
+		}
+	}
+
+	taskID = task.ID.Hex()
+	updated, err := repo.UpdatePlan(plan)
+	if err != nil {
+		return nil, err
+	}
+	ft := repository.Flatten(updated.Tasks)[taskID]
+	return map[string]interface{}{
+		"plan_id":  updated.ID.Hex(),
+		"goal":     updated.Goal,
+		"task":     ft.Task,
+		"message":  fmt.Sprintf("Updated %q", ft.Task.Title),
+		"priority": ft.Task.Priority,
+		"tags":     ft.Task.Tags,
+		"contexts": ft.Task.Contexts,
+		"fields":   ft.Task.Fields,
+	}, nil
+}
+
+// resolveTask finds the tasks a chat message most likely means: every exact
+// title match, or else the search hits that score as well as the best one.
+func resolveTask(plans []models.Plan, needle string) []map[string]string {
+	if strings.TrimSpace(needle) == "" {
+		return nil
+	}
+	var exact []map[string]string
+	for _, plan := range plans {
+		for id, ft := range repository.Flatten(plan.Tasks) {
+			if strings.EqualFold(strings.TrimSpace(ft.Task.Title), strings.TrimSpace(needle)) {
+				exact = append(exact, taskCandidate(plan.ID.Hex(), plan.Goal, id, ft.Task.Title))
+			}
+		}
+	}
+	if len(exact) > 0 {
+		sort.Slice(exact, func(i, j int) bool {
+			return exact[i]["goal"]+exact[i]["task_id"] < exact[j]["goal"]+exact[j]["task_id"]
+		})
+		return exact
+	}
+
+	res := search.Run(plans, search.Query{Text: needle, Limit: 5}, time.Now())
+	var out []map[string]string
+	for _, h := range res.Hits {
+		if h.Score < res.Hits[0].Score {
+			break
+		}
+		out = append(out, taskCandidate(h.PlanID, h.Goal, h.Task.ID, h.Task.Title))
+	}
+	return out
+}
+
+func taskCandidate(planID, goal, taskID, title string) map[string]string {
+	return map[string]string{"plan_id": planID, "goal": goal, "task_id": taskID, "title": title}
+}
+
+// without drops the values of remove from list, ignoring case
+func without(list, remove []string) []string {
+	var out []string
+	for _, v := range list {
+		if !containsFold(remove, v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func containsFold(list []string, v string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,17 +2,18 @@
 package mcp
 
 import (
-	"context"
 	"fmt"
+	"smart-task-planner/internal/events"
 	"smart-task-planner/internal/modules/plan/models"
 	"smart-task-planner/internal/modules/plan/repository"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-func UpdateTaskStatus(taskID string, status string, repo *repository.PlanRepository) (*models.Task, error) {
+func UpdateTaskStatus(userID, taskID string, status string, repo *repository.PlanRepository) (*models.Task, error) {
 	if taskID == "" || status == "" {
 		return nil, fmt.Errorf("taskID and status required")
 	}
@@ -22,26 +23,56 @@ func UpdateTaskStatus(taskID string, status string, repo *repository.PlanReposit
 		return nil, fmt.Errorf("invalid task ID: %v", err)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	filter := bson.M{"tasks._id": objID}
-	update := bson.M{"$set": bson.M{"tasks.$.status": status}}
-
-	res := repo.Collection.FindOneAndUpdate(ctx, filter, update)
-	if res.Err() != nil {
-		return nil, res.Err()
+	current, err := findTaskPlan(userID, objID, repo)
+	if err != nil {
+		return nil, err
+	}
+	if !models.RoleAtLeast(current.Role, models.RoleEditor) {
+		return nil, repository.ErrReadOnly
 	}
 
-	var plan models.Plan
-	if err := repo.Collection.FindOne(ctx, filter).Decode(&plan); err != nil {
+	oldStatus := ""
+	plan, err := repo.Mutate(bson.M{"_id": current.ID}, func(plan *models.Plan) error {
+		ft, ok := repository.Flatten(plan.Tasks)[objID.Hex()]
+		if !ok {
+			return fmt.Errorf("task not found")
+		}
+		t := ft.Task
+		oldStatus = t.Status
+		t.Status = status
+		switch {
+		case !strings.EqualFold(status, "Completed"):
+			t.CompletedAt = nil
+		case !strings.EqualFold(oldStatus, "Completed") || t.CompletedAt == nil:
+			// completion timestamps feed velocity-based forecasting, so
+			// only a task that has just been finished gets one
+			now := time.Now()
+			t.CompletedAt = &now
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	for _, t := range plan.Tasks {
-		if t.ID == objID {
-			return &t, nil
+	if ft, ok := repository.Flatten(plan.Tasks)[objID.Hex()]; ok {
+		t := *ft.Task
+		if oldStatus != t.Status {
+			events.Publish(events.Event{
+				Type:   events.TaskStatusChanged,
+				UserID: plan.UserID,
+				PlanID: plan.ID.Hex(),
+				Data: map[string]interface{}{
+					"plan_id":    plan.ID.Hex(),
+					"goal":       plan.Goal,
+					"task_id":    t.ID.Hex(),
+					"title":      t.Title,
+					"old_status": oldStatus,
+					"new_status": t.Status,
+				},
+			})
 		}
+		return &t, nil
 	}
 
 	return nil, fmt.Errorf("task not found after update")
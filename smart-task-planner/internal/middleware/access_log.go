@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// secretParamRe finds query parameters that carry credentials, such as the
+// JWT WebSocketAuth accepts as ?token=
+var secretParamRe = regexp.MustCompile(`([?&]token=)[^&]*`)
+
+// AccessLog is gin's request logger with credentials in the query string
+// replaced by [redacted]
+func AccessLog() gin.HandlerFunc {
+	return gin.LoggerWithFormatter(func(p gin.LogFormatterParams) string {
+		var statusColor, methodColor, resetColor string
+		if p.IsOutputColor() {
+			statusColor, methodColor, resetColor = p.StatusCodeColor(), p.MethodColor(), p.ResetColor()
+		}
+		if p.Latency > time.Minute {
+			p.Latency = p.Latency.Truncate(time.Second)
+		}
+		return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+			p.TimeStamp.Format("2006/01/02 - 15:04:05"),
+			statusColor, p.StatusCode, resetColor,
+			p.Latency,
+			p.ClientIP,
+			methodColor, p.Method, resetColor,
+			RedactQuery(p.Path),
+			p.ErrorMessage,
+		)
+	})
+}
+
+// RedactQuery hides the values of credential parameters in a request URI
+func RedactQuery(uri string) string {
+	return secretParamRe.ReplaceAllString(uri, "${1}[redacted]")
+}
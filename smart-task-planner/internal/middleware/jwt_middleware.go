@@ -36,3 +36,16 @@ func JWTAuth() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// WebSocketAuth is JWTAuth for WebSocket upgrades. Browsers can't set
+// headers on a WebSocket handshake, so the token may also come from the
+// "token" query parameter.
+func WebSocketAuth() gin.HandlerFunc {
+	auth := JWTAuth()
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" && c.Query("token") != "" {
+			c.Request.Header.Set("Authorization", "Bearer "+c.Query("token"))
+		}
+		auth(c)
+	}
+}
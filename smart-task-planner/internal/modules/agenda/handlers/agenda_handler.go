@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"smart-task-planner/internal/modules/agenda/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AgendaHandler struct {
+	service *service.AgendaService
+}
+
+func NewAgendaHandler(svc *service.AgendaService) *AgendaHandler {
+	return &AgendaHandler{service: svc}
+}
+
+// GetAgenda handles GET /api/agenda/:view?tz=Europe/Berlin. Without a view
+// it shows today.
+func (h *AgendaHandler) GetAgenda(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	a, err := h.service.Agenda(userID, c.Param("view"), c.Query("tz"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, a)
+}
+
+// Next handles GET /api/agenda/next?minutes=30&context=@home,@phone&limit=3&polish=true
+func (h *AgendaHandler) Next(c *gin.Context) {
+	userID := c.GetString("user_id")
+	minutes, _ := strconv.Atoi(c.Query("minutes"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	var contexts []string
+	for _, ctx := range strings.Split(c.Query("context"), ",") {
+		if ctx = strings.TrimSpace(ctx); ctx != "" {
+			contexts = append(contexts, ctx)
+		}
+	}
+
+	res, err := h.service.Next(userID, minutes, contexts, limit, c.Query("polish") == "true")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
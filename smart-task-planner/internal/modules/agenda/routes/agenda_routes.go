@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"smart-task-planner/internal/middleware"
+	"smart-task-planner/internal/modules/agenda/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAgendaRoutes(router *gin.Engine, handler *handlers.AgendaHandler) {
+	api := router.Group("/api/agenda")
+	api.Use(middleware.JWTAuth())
+	{
+		// ranked "what should I work on next" picks
+		api.GET("/next", handler.Next)
+
+		// today, week, overdue or no_deadline
+		api.GET("/", handler.GetAgenda)
+		api.GET("/:view", handler.GetAgenda)
+	}
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"fmt"
+
+	"smart-task-planner/internal/mcp"
+	"smart-task-planner/internal/modules/plan/agenda"
+	"smart-task-planner/internal/modules/plan/repository"
+	"smart-task-planner/internal/modules/plan/suggest"
+)
+
+type AgendaService struct {
+	Repo *repository.PlanRepository
+}
+
+func NewAgendaService(repo *repository.PlanRepository) *AgendaService {
+	return &AgendaService{Repo: repo}
+}
+
+// Agenda runs the get_agenda MCP tool. An empty timeZone uses the one from
+// the user's notification preferences.
+func (s *AgendaService) Agenda(userID, view, timeZone string) (*agenda.Agenda, error) {
+	result, err := mcp.RunTool("get_agenda", map[string]interface{}{
+		"user_id":   userID,
+		"view":      view,
+		"time_zone": timeZone,
+	}, s.Repo)
+	if err != nil {
+		return nil, err
+	}
+
+	a, ok := result.(*agenda.Agenda)
+	if !ok {
+		return nil, fmt.Errorf("invalid data from MCP get_agenda")
+	}
+	return a, nil
+}
+
+// Next runs the suggest_next_task MCP tool. minutes and contexts describe
+// the user's situation and may be empty.
+func (s *AgendaService) Next(userID string, minutes int, contexts []string, limit int, polish bool) (*suggest.Result, error) {
+	result, err := mcp.RunTool("suggest_next_task", map[string]interface{}{
+		"user_id":  userID,
+		"minutes":  minutes,
+		"contexts": contexts,
+		"limit":    limit,
+		"polish":   polish,
+	}, s.Repo)
+	if err != nil {
+		return nil, err
+	}
+
+	res, ok := result.(*suggest.Result)
+	if !ok {
+		return nil, fmt.Errorf("invalid data from MCP suggest_next_task")
+	}
+	return res, nil
+}
@@ -0,0 +1,210 @@
+package busytime
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"smart-task-planner/internal/modules/calendar/models"
+)
+
+const (
+	dayLayout = "2006-01-02"
+
+	// DefaultBusyHours is how many blocked hours make a whole day unavailable
+	DefaultBusyHours = 4.0
+
+	// maxShift stops NextFreeDay from walking forever through a fully booked calendar
+	maxShift = 60
+)
+
+// Calendar answers "how busy is this day" from imported busy blocks.
+// Overlapping blocks are merged so double-booked meetings count once.
+type Calendar struct {
+	hours     map[string]float64
+	titles    map[string][]string
+	loc       *time.Location
+	Threshold float64
+}
+
+// New builds a Calendar with days keyed in loc. The busy-day threshold
+// comes from BUSY_DAY_HOURS when set.
+func New(blocks []models.BusyBlock, loc *time.Location) *Calendar {
+	if loc == nil {
+		loc = time.Local
+	}
+	c := &Calendar{
+		hours:     map[string]float64{},
+		titles:    map[string][]string{},
+		loc:       loc,
+		Threshold: DefaultBusyHours,
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("BUSY_DAY_HOURS"), 64); err == nil && v > 0 {
+		c.Threshold = v
+	}
+
+	sorted := append([]models.BusyBlock{}, blocks...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start.Before(sorted[j].Start) })
+
+	var curStart, curEnd time.Time
+	for _, b := range sorted {
+		start, end := b.Start, b.End
+		if b.AllDay {
+			// all-day blocks cover the calendar day, whatever zone they were stored in
+			y, m, d := b.Start.Date()
+			start = time.Date(y, m, d, 0, 0, 0, 0, loc)
+			days := int(b.End.Sub(b.Start).Hours()/24 + 0.5)
+			if days < 1 {
+				days = 1
+			}
+			end = start.AddDate(0, 0, days)
+		}
+		for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+			key := day.In(loc).Format(dayLayout)
+			c.titles[key] = appendUnique(c.titles[key], b.Summary)
+		}
+
+		if !curEnd.IsZero() && !start.After(curEnd) {
+			if end.After(curEnd) {
+				curEnd = end
+			}
+			continue
+		}
+		c.addInterval(curStart, curEnd)
+		curStart, curEnd = start, end
+	}
+	c.addInterval(curStart, curEnd)
+	return c
+}
+
+// addInterval spreads a merged interval over the days it touches
+func (c *Calendar) addInterval(start, end time.Time) {
+	if !end.After(start) {
+		return
+	}
+	start, end = start.In(c.loc), end.In(c.loc)
+	for cur := start; cur.Before(end); {
+		y, m, d := cur.Date()
+		next := time.Date(y, m, d, 0, 0, 0, 0, c.loc).AddDate(0, 0, 1)
+		if next.After(end) {
+			next = end
+		}
+		c.hours[cur.Format(dayLayout)] += next.Sub(cur).Hours()
+		cur = next
+	}
+}
+
+// Hours is the number of busy hours on the given day
+func (c *Calendar) Hours(day time.Time) float64 {
+	return c.hours[day.In(c.loc).Format(dayLayout)]
+}
+
+// IsBusy reports whether the day is blocked for task work
+func (c *Calendar) IsBusy(day time.Time) bool {
+	return c.Hours(day) >= c.Threshold
+}
+
+// BusyDaysBetween counts busy days from the day of from up to and including
+// the day of to.
+func (c *Calendar) BusyDaysBetween(from, to time.Time) int {
+	count := 0
+	for _, day := range c.days(from, to) {
+		if c.IsBusy(day) {
+			count++
+		}
+	}
+	return count
+}
+
+// BusyDates lists busy days in [from, to] as YYYY-MM-DD
+func (c *Calendar) BusyDates(from, to time.Time) []string {
+	var out []string
+	for _, day := range c.days(from, to) {
+		if c.IsBusy(day) {
+			out = append(out, day.Format(dayLayout))
+		}
+	}
+	return out
+}
+
+// Titles returns the summaries of the events blocking a day
+func (c *Calendar) Titles(day time.Time) []string {
+	return c.titles[day.In(c.loc).Format(dayLayout)]
+}
+
+// NextFreeDay moves t forward one day at a time until it lands on a day
+// that isn't busy, keeping the time of day.
+func (c *Calendar) NextFreeDay(t time.Time) time.Time {
+	for i := 0; i < maxShift && c.IsBusy(t); i++ {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t
+}
+
+// IsWorkday reports whether the day is a weekday the user isn't busy on
+func (c *Calendar) IsWorkday(day time.Time) bool {
+	switch day.In(c.loc).Weekday() {
+	case time.Saturday, time.Sunday:
+		return false
+	}
+	return !c.IsBusy(day)
+}
+
+// AddWorkdays returns the day n working days after the first working day on
+// or after from. n = 0 is that first working day.
+func (c *Calendar) AddWorkdays(from time.Time, n int) time.Time {
+	y, m, d := from.In(c.loc).Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, c.loc)
+	// a calendar booked solid still has to return eventually
+	limit := n*2 + 7 + maxShift
+	for i := 0; i < limit && !c.IsWorkday(day); i++ {
+		day = day.AddDate(0, 0, 1)
+	}
+	for i := 0; n > 0 && i < limit; i++ {
+		day = day.AddDate(0, 0, 1)
+		if c.IsWorkday(day) {
+			n--
+		}
+	}
+	return day
+}
+
+// WorkdaysBetween counts working days after the day of from up to and
+// including the day of to. It is negative when to comes first.
+func (c *Calendar) WorkdaysBetween(from, to time.Time) int {
+	if to.Before(from) {
+		return -c.WorkdaysBetween(to, from)
+	}
+	count := 0
+	days := c.days(from, to)
+	for _, day := range days[min(1, len(days)):] {
+		if c.IsWorkday(day) {
+			count++
+		}
+	}
+	return count
+}
+
+func (c *Calendar) days(from, to time.Time) []time.Time {
+	y, m, d := from.In(c.loc).Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, c.loc)
+	var out []time.Time
+	for !day.After(to) {
+		out = append(out, day)
+		day = day.AddDate(0, 0, 1)
+	}
+	return out
+}
+
+func appendUnique(list []string, s string) []string {
+	if s == "" {
+		return list
+	}
+	for _, item := range list {
+		if item == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"smart-task-planner/internal/modules/calendar/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxUploadBytes = 10 << 20
+
+type BusyHandler struct {
+	service *service.BusyService
+}
+
+func NewBusyHandler(svc *service.BusyService) *BusyHandler {
+	return &BusyHandler{service: svc}
+}
+
+// Upload handles POST /api/calendar/busy/upload with either a multipart
+// "file" field or a raw text/calendar body. Optional name and tz come from
+// the form or the query string.
+func (h *BusyHandler) Upload(c *gin.Context) {
+	userID := c.GetString("user_id")
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadBytes)
+
+	var data []byte
+	var err error
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fileHeader, ferr := c.FormFile("file")
+		if ferr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file field required"})
+			return
+		}
+		f, ferr := fileHeader.Open()
+		if ferr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": ferr.Error()})
+			return
+		}
+		defer f.Close()
+		data, err = io.ReadAll(f)
+	} else {
+		data, err = io.ReadAll(c.Request.Body)
+	}
+	if err != nil || len(data) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Calendar file required (max 10 MB)"})
+		return
+	}
+
+	name := c.DefaultPostForm("name", c.Query("name"))
+	tz := c.DefaultPostForm("tz", c.Query("tz"))
+
+	src, err := h.service.Upload(userID, name, tz, data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, src)
+}
+
+func (h *BusyHandler) AddSource(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req struct {
+		Name     string `json:"name"`
+		Location string `json:"location" binding:"required"`
+		TimeZone string `json:"time_zone"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	src, err := h.service.AddSource(userID, req.Name, req.Location, req.TimeZone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, src)
+}
+
+func (h *BusyHandler) ListSources(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	sources, err := h.service.ListSources(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch calendar sources"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sources)
+}
+
+func (h *BusyHandler) SyncSource(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	src, err := h.service.SyncSource(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, src)
+}
+
+func (h *BusyHandler) DeleteSource(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	if err := h.service.DeleteSource(userID, c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Calendar source deleted"})
+}
+
+// Busy handles GET /api/calendar/busy?from=YYYY-MM-DD&to=YYYY-MM-DD
+// (defaults to the next 30 days)
+func (h *BusyHandler) Busy(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	to := from.AddDate(0, 0, 30)
+	if v := c.Query("from"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be YYYY-MM-DD"})
+			return
+		}
+		from = t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be YYYY-MM-DD"})
+			return
+		}
+		to = t.AddDate(0, 0, 1)
+	}
+
+	blocks, err := h.service.Busy(userID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch busy time"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"from": from, "to": to, "blocks": blocks, "count": len(blocks)})
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"smart-task-planner/internal/modules/calendar/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const icsContentType = "text/calendar; charset=utf-8"
+
+type CalendarHandler struct {
+	service *service.CalendarService
+}
+
+func NewCalendarHandler(svc *service.CalendarService) *CalendarHandler {
+	return &CalendarHandler{service: svc}
+}
+
+// ExportPlan handles GET /api/plan/:id/calendar.ics?type=todo|event|both
+func (h *CalendarHandler) ExportPlan(c *gin.Context) {
+	userID := c.GetString("user_id")
+	planID := c.Param("id")
+
+	data, err := h.service.PlanCalendar(userID, planID, c.Query("type"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="plan-%s.ics"`, planID))
+	c.Data(http.StatusOK, icsContentType, data)
+}
+
+// Feed handles the public GET /calendar/:token.ics subscription URL
+func (h *CalendarHandler) Feed(c *gin.Context) {
+	secret := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	data, err := h.service.Feed(secret, c.Query("type"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Feed not found"})
+		return
+	}
+
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, icsContentType, data)
+}
+
+func (h *CalendarHandler) CreateFeedToken(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	_ = c.ShouldBindJSON(&req) // body is optional
+
+	created, err := h.service.CreateFeedToken(userID, req.Name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create feed token"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *CalendarHandler) ListFeedTokens(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	tokens, err := h.service.ListFeedTokens(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch feed tokens"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (h *CalendarHandler) RevokeFeedToken(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	if err := h.service.RevokeFeedToken(userID, c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Feed token revoked"})
+}
@@ -0,0 +1,182 @@
+package ical
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// BusyEvent is one concrete occurrence of a VEVENT that blocks time.
+type BusyEvent struct {
+	UID     string
+	Summary string
+	Start   time.Time
+	End     time.Time
+	AllDay  bool
+}
+
+// ReadBusy parses an ICS stream and expands its events into occurrences
+// overlapping [from, to]. Floating times are read in def. Events that can't
+// be read are left out.
+func ReadBusy(r io.Reader, from, to time.Time, def *time.Location) ([]BusyEvent, error) {
+	roots, err := Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events, timezones []*ParsedComponent
+	for _, root := range roots {
+		for _, c := range append([]*ParsedComponent{root}, root.Children...) {
+			switch c.Kind {
+			case "VEVENT":
+				events = append(events, c)
+			case "VTIMEZONE":
+				timezones = append(timezones, c)
+			}
+		}
+	}
+	return ExpandBusy(events, timezones, from, to, def), nil
+}
+
+// ExpandBusy turns VEVENTs into busy occurrences. Recurring events are
+// expanded via RRULE/RDATE minus EXDATE; instances overridden by a
+// RECURRENCE-ID component are replaced by that component. Transparent
+// (free) and cancelled events are skipped, and so are events with a broken
+// or unsupported start, end or RRULE. TZIDs Go doesn't know are resolved
+// through timezones, the calendar's VTIMEZONE components.
+func ExpandBusy(events, timezones []*ParsedComponent, from, to time.Time, def *time.Location) []BusyEvent {
+	tz := newZones(timezones, def)
+
+	// overrides[uid][original start] -> modified instance
+	overrides := map[string]map[int64]*ParsedComponent{}
+	var masters []*ParsedComponent
+	for _, ev := range events {
+		uid := propValue(ev, "UID")
+		rid, ok := ev.Get("RECURRENCE-ID")
+		if !ok {
+			masters = append(masters, ev)
+			continue
+		}
+		t, _, err := tz.parse(rid)
+		if err != nil {
+			continue
+		}
+		if overrides[uid] == nil {
+			overrides[uid] = map[int64]*ParsedComponent{}
+		}
+		overrides[uid][t.Unix()] = ev
+	}
+
+	var out []BusyEvent
+	for _, ev := range masters {
+		uid := propValue(ev, "UID")
+		start, end, allDay, err := eventSpan(ev, tz)
+		if err != nil {
+			continue
+		}
+		length := end.Sub(start)
+
+		starts := []time.Time{start}
+		if rr, ok := ev.Get("RRULE"); ok {
+			dtstart, _ := ev.Get("DTSTART")
+			loc, conv := tz.frame(dtstart)
+			wall, _, _ := ParseTime(dtstart.Value, dtstart.Params, loc)
+			rule, err := ParseRRule(rr.Value, loc)
+			if err != nil {
+				continue
+			}
+			// wall clocks can be up to a day off the instants they name
+			starts = starts[:0]
+			for _, w := range rule.Occurrences(wall, from.Add(-length-24*time.Hour), to.Add(24*time.Hour)) {
+				starts = append(starts, conv(w))
+			}
+		}
+		for _, rd := range ev.All("RDATE") {
+			for _, v := range strings.Split(rd.Value, ",") {
+				if t, _, err := tz.parse(ParsedProperty{Name: rd.Name, Params: rd.Params, Value: v}); err == nil {
+					starts = append(starts, t)
+				}
+			}
+		}
+
+		excluded := map[int64]bool{}
+		for _, ex := range ev.All("EXDATE") {
+			for _, v := range strings.Split(ex.Value, ",") {
+				if t, _, err := tz.parse(ParsedProperty{Name: ex.Name, Params: ex.Params, Value: v}); err == nil {
+					excluded[t.Unix()] = true
+				}
+			}
+		}
+
+		for _, s := range starts {
+			if excluded[s.Unix()] {
+				continue
+			}
+			inst, instStart, instEnd, instAllDay := ev, s, s.Add(length), allDay
+			if o, ok := overrides[uid][s.Unix()]; ok {
+				oStart, oEnd, oAllDay, err := eventSpan(o, tz)
+				if err != nil {
+					continue
+				}
+				inst, instStart, instEnd, instAllDay = o, oStart, oEnd, oAllDay
+			}
+			if !isBusy(inst) || !instEnd.After(from) || instStart.After(to) {
+				continue
+			}
+			out = append(out, BusyEvent{
+				UID:     uid,
+				Summary: UnescapeText(propValue(inst, "SUMMARY")),
+				Start:   instStart,
+				End:     instEnd,
+				AllDay:  instAllDay,
+			})
+		}
+	}
+	return out
+}
+
+// eventSpan reads DTSTART and DTEND/DURATION. An all-day event without an
+// end lasts one day, a timed one is instantaneous.
+func eventSpan(ev *ParsedComponent, tz *zones) (time.Time, time.Time, bool, error) {
+	dtstart, ok := ev.Get("DTSTART")
+	if !ok {
+		return time.Time{}, time.Time{}, false, fmt.Errorf("missing DTSTART")
+	}
+	start, allDay, err := tz.parse(dtstart)
+	if err != nil {
+		return time.Time{}, time.Time{}, false, fmt.Errorf("invalid DTSTART")
+	}
+
+	end := start
+	if allDay {
+		end = start.AddDate(0, 0, 1)
+	}
+	if dtend, ok := ev.Get("DTEND"); ok {
+		if end, _, err = tz.parse(dtend); err != nil {
+			return time.Time{}, time.Time{}, false, fmt.Errorf("invalid DTEND")
+		}
+	} else if dur, ok := ev.Get("DURATION"); ok {
+		d, err := ParseDuration(dur.Value)
+		if err != nil {
+			return time.Time{}, time.Time{}, false, err
+		}
+		end = start.Add(d)
+	}
+	if end.Before(start) {
+		end = start
+	}
+	return start, end, allDay, nil
+}
+
+func isBusy(ev *ParsedComponent) bool {
+	if strings.EqualFold(propValue(ev, "TRANSP"), "TRANSPARENT") {
+		return false
+	}
+	return !strings.EqualFold(propValue(ev, "STATUS"), "CANCELLED")
+}
+
+func propValue(c *ParsedComponent, name string) string {
+	p, _ := c.Get(name)
+	return p.Value
+}
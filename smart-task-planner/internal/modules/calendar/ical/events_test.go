@@ -0,0 +1,154 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func calendar(body string) string {
+	return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" + strings.ReplaceAll(strings.TrimSpace(body), "\n", "\r\n") + "\r\nEND:VCALENDAR\r\n"
+}
+
+// customZone is an Outlook-style VTIMEZONE under a name Go doesn't know,
+// with EU daylight saving rules
+const customZone = `
+BEGIN:VTIMEZONE
+TZID:Custom Berlin
+BEGIN:STANDARD
+DTSTART:16010101T030000
+TZOFFSETFROM:+0200
+TZOFFSETTO:+0100
+RRULE:FREQ=YEARLY;BYDAY=-1SU;BYMONTH=10
+END:STANDARD
+BEGIN:DAYLIGHT
+DTSTART:16010101T020000
+TZOFFSETFROM:+0100
+TZOFFSETTO:+0200
+RRULE:FREQ=YEARLY;BYDAY=-1SU;BYMONTH=3
+END:DAYLIGHT
+END:VTIMEZONE`
+
+func TestReadBusy(t *testing.T) {
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 4, 30, 0, 0, 0, 0, time.UTC)
+	utc := func(m time.Month, d, h int) time.Time { return time.Date(2025, m, d, h, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name  string
+		ics   string
+		start []time.Time
+	}{
+		{
+			name: "single timed event",
+			ics: `
+BEGIN:VEVENT
+UID:a
+DTSTART:20250310T090000Z
+DTEND:20250310T100000Z
+END:VEVENT`,
+			start: []time.Time{utc(3, 10, 9)},
+		},
+		{
+			name: "transparent and cancelled are free",
+			ics: `
+BEGIN:VEVENT
+UID:a
+DTSTART:20250310T090000Z
+TRANSP:TRANSPARENT
+END:VEVENT
+BEGIN:VEVENT
+UID:b
+DTSTART:20250311T090000Z
+STATUS:CANCELLED
+END:VEVENT`,
+		},
+		{
+			name: "weekly with exdate and moved instance",
+			ics: `
+BEGIN:VEVENT
+UID:w
+DTSTART:20250303T090000Z
+DURATION:PT1H
+RRULE:FREQ=WEEKLY;COUNT=3
+EXDATE:20250310T090000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:w
+RECURRENCE-ID:20250317T090000Z
+DTSTART:20250318T140000Z
+DURATION:PT1H
+END:VEVENT`,
+			start: []time.Time{utc(3, 3, 9), utc(3, 18, 14)},
+		},
+		{
+			name: "unsupported rule skips only that event",
+			ics: `
+BEGIN:VEVENT
+UID:bad
+DTSTART:20250303T090000Z
+RRULE:FREQ=HOURLY
+END:VEVENT
+BEGIN:VEVENT
+UID:good
+DTSTART:20250305T090000Z
+END:VEVENT`,
+			start: []time.Time{utc(3, 5, 9)},
+		},
+		{
+			name: "custom VTIMEZONE follows daylight saving",
+			ics: customZone + `
+BEGIN:VEVENT
+UID:tz
+DTSTART;TZID=Custom Berlin:20250327T090000
+DURATION:PT1H
+RRULE:FREQ=DAILY;COUNT=5
+END:VEVENT`,
+			// DST starts on 30 March; 9:00 is 8:00 UTC before and 7:00 UTC after
+			start: []time.Time{utc(3, 27, 8), utc(3, 28, 8), utc(3, 29, 8), utc(3, 30, 7), utc(3, 31, 7)},
+		},
+		{
+			name: "custom VTIMEZONE after the switch",
+			ics: customZone + `
+BEGIN:VEVENT
+UID:tz
+DTSTART;TZID=Custom Berlin:20250331T090000
+END:VEVENT`,
+			start: []time.Time{utc(3, 31, 7)},
+		},
+	}
+	for _, tt := range tests {
+		got, err := ReadBusy(strings.NewReader(calendar(tt.ics)), from, to, time.UTC)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if len(got) != len(tt.start) {
+			t.Errorf("%s: got %d events, want %d", tt.name, len(got), len(tt.start))
+			continue
+		}
+		for i, ev := range got {
+			if !ev.Start.Equal(tt.start[i]) {
+				t.Errorf("%s: [%d] starts %v, want %v", tt.name, i, ev.Start.UTC(), tt.start[i])
+			}
+		}
+	}
+}
+
+func TestReadBusyOldDailySeries(t *testing.T) {
+	ics := calendar(`
+BEGIN:VEVENT
+UID:old
+DTSTART:19900101T090000Z
+DURATION:PT30M
+RRULE:FREQ=DAILY
+END:VEVENT`)
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	got, err := ReadBusy(strings.NewReader(ics), from, from.AddDate(0, 0, 7), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 7 {
+		t.Errorf("got %d occurrences in the week, want 7", len(got))
+	}
+}
@@ -0,0 +1,278 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ParsedProperty is a content line split into name, parameters and value.
+type ParsedProperty struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// ParsedComponent is a parsed BEGIN/END block. Nested components (VALARM
+// inside VEVENT, VEVENTs inside VCALENDAR) are kept as children.
+type ParsedComponent struct {
+	Kind       string
+	Properties []ParsedProperty
+	Children   []*ParsedComponent
+}
+
+// Get returns the first property with the given name.
+func (c *ParsedComponent) Get(name string) (ParsedProperty, bool) {
+	for _, p := range c.Properties {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return ParsedProperty{}, false
+}
+
+// All returns every property with the given name.
+func (c *ParsedComponent) All(name string) []ParsedProperty {
+	var out []ParsedProperty
+	for _, p := range c.Properties {
+		if p.Name == name {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Parse reads an iCalendar stream and returns its top-level components.
+func Parse(r io.Reader) ([]*ParsedComponent, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var roots []*ParsedComponent
+	var stack []*ParsedComponent
+	for n, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		prop, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n+1, err)
+		}
+
+		switch prop.Name {
+		case "BEGIN":
+			comp := &ParsedComponent{Kind: strings.ToUpper(prop.Value)}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, comp)
+			} else {
+				roots = append(roots, comp)
+			}
+			stack = append(stack, comp)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Kind != strings.ToUpper(prop.Value) {
+				return nil, fmt.Errorf("line %d: unexpected END", n+1)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: property outside of a component", n+1)
+			}
+			cur := stack[len(stack)-1]
+			cur.Properties = append(cur.Properties, prop)
+		}
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("unterminated component")
+	}
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("no calendar data found")
+	}
+	return roots, nil
+}
+
+// unfold joins continuation lines (those starting with a space or tab).
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// parseLine splits NAME;PARAM=VALUE;PARAM="QUOTED":VALUE
+func parseLine(line string) (ParsedProperty, error) {
+	prop := ParsedProperty{Params: map[string]string{}}
+
+	inQuote := false
+	nameEnd, valueStart := -1, -1
+	for i, r := range line {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+		case r == ';' && !inQuote && nameEnd == -1:
+			nameEnd = i
+		case r == ':' && !inQuote:
+			valueStart = i
+		}
+		if valueStart != -1 {
+			break
+		}
+	}
+	if valueStart == -1 {
+		return prop, fmt.Errorf("missing ':'")
+	}
+	if nameEnd == -1 || nameEnd > valueStart {
+		nameEnd = valueStart
+	}
+
+	prop.Name = strings.ToUpper(line[:nameEnd])
+	prop.Value = line[valueStart+1:]
+
+	if nameEnd < valueStart {
+		for _, param := range splitParams(line[nameEnd+1 : valueStart]) {
+			kv := strings.SplitN(param, "=", 2)
+			if len(kv) == 2 {
+				prop.Params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+			}
+		}
+	}
+	return prop, nil
+}
+
+func splitParams(s string) []string {
+	var out []string
+	inQuote := false
+	start := 0
+	for i, r := range s {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+		case r == ';' && !inQuote:
+			out = append(out, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(out, s[start:])
+}
+
+// UnescapeText reverses EscapeText.
+func UnescapeText(s string) string {
+	r := strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+	return r.Replace(s)
+}
+
+// windowsZones maps the Windows zone names Outlook exports to IANA names.
+var windowsZones = map[string]string{
+	"Pacific Standard Time":        "America/Los_Angeles",
+	"Mountain Standard Time":       "America/Denver",
+	"Central Standard Time":        "America/Chicago",
+	"Eastern Standard Time":        "America/New_York",
+	"GMT Standard Time":            "Europe/London",
+	"W. Europe Standard Time":      "Europe/Berlin",
+	"Romance Standard Time":        "Europe/Paris",
+	"Central Europe Standard Time": "Europe/Budapest",
+	"India Standard Time":          "Asia/Kolkata",
+	"China Standard Time":          "Asia/Shanghai",
+	"Tokyo Standard Time":          "Asia/Tokyo",
+	"AUS Eastern Standard Time":    "Australia/Sydney",
+	"UTC":                          "UTC",
+}
+
+// ResolveLocation turns a TZID into a *time.Location, falling back to the
+// given default when the zone is unknown.
+func ResolveLocation(tzid string, def *time.Location) *time.Location {
+	if tzid == "" {
+		return def
+	}
+	tzid = strings.TrimPrefix(tzid, "/") // some producers prefix a slash
+	if iana, ok := windowsZones[tzid]; ok {
+		tzid = iana
+	}
+	if loc, err := time.LoadLocation(tzid); err == nil {
+		return loc
+	}
+	// Mozilla-style IDs such as mozilla.org/20050126_1/Europe/Berlin end in
+	// the IANA name
+	parts := strings.Split(tzid, "/")
+	for i := 1; i < len(parts)-1; i++ {
+		if loc, err := time.LoadLocation(strings.Join(parts[i:], "/")); err == nil {
+			return loc
+		}
+	}
+	return def
+}
+
+// ParseTime parses a DATE or DATE-TIME value. Values ending in Z are UTC,
+// values with a TZID use that zone, floating times use def. The bool result
+// reports whether the value was an all-day DATE.
+func ParseTime(value string, params map[string]string, def *time.Location) (time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+	if params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.ParseInLocation(dateLayout, value, def)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(dateTimeLayout, value)
+		return t, false, err
+	}
+	loc := ResolveLocation(params["TZID"], def)
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+// ParseDuration parses an RFC 5545 DURATION such as PT1H30M or P1D.
+func ParseDuration(value string) (time.Duration, error) {
+	v := strings.ToUpper(strings.TrimSpace(value))
+	sign := time.Duration(1)
+	if strings.HasPrefix(v, "-") {
+		sign = -1
+		v = v[1:]
+	}
+	v = strings.TrimPrefix(v, "+")
+	if !strings.HasPrefix(v, "P") {
+		return 0, fmt.Errorf("invalid duration")
+	}
+	v = v[1:]
+
+	var d time.Duration
+	inTime := false
+	num := 0
+	for _, r := range v {
+		switch {
+		case r >= '0' && r <= '9':
+			num = num*10 + int(r-'0')
+		case r == 'T':
+			inTime = true
+		case r == 'W':
+			d += time.Duration(num) * 7 * 24 * time.Hour
+			num = 0
+		case r == 'D':
+			d += time.Duration(num) * 24 * time.Hour
+			num = 0
+		case r == 'H' && inTime:
+			d += time.Duration(num) * time.Hour
+			num = 0
+		case r == 'M' && inTime:
+			d += time.Duration(num) * time.Minute
+			num = 0
+		case r == 'S' && inTime:
+			d += time.Duration(num) * time.Second
+			num = 0
+		default:
+			return 0, fmt.Errorf("invalid duration")
+		}
+	}
+	return sign * d, nil
+}
@@ -0,0 +1,121 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		line   string
+		name   string
+		params map[string]string
+		value  string
+	}{
+		{"SUMMARY:Standup", "SUMMARY", map[string]string{}, "Standup"},
+		{"dtstart;TZID=Europe/Berlin:20250101T090000", "DTSTART", map[string]string{"TZID": "Europe/Berlin"}, "20250101T090000"},
+		{`ATTENDEE;CN="Doe; Jane";ROLE=CHAIR:mailto:jane@example.com`, "ATTENDEE", map[string]string{"CN": "Doe; Jane", "ROLE": "CHAIR"}, "mailto:jane@example.com"},
+		{`X-NOTE;LABEL="a:b":c:d`, "X-NOTE", map[string]string{"LABEL": "a:b"}, "c:d"},
+	}
+	for _, tt := range tests {
+		prop, err := parseLine(tt.line)
+		if err != nil {
+			t.Errorf("parseLine(%q): %v", tt.line, err)
+			continue
+		}
+		if prop.Name != tt.name || prop.Value != tt.value {
+			t.Errorf("parseLine(%q) = %s %q, want %s %q", tt.line, prop.Name, prop.Value, tt.name, tt.value)
+		}
+		for k, v := range tt.params {
+			if prop.Params[k] != v {
+				t.Errorf("parseLine(%q) param %s = %q, want %q", tt.line, k, prop.Params[k], v)
+			}
+		}
+	}
+}
+
+func TestParseErrorsDontQuoteContent(t *testing.T) {
+	tests := []string{
+		"BEGIN:VCALENDAR\r\nsecret-token-in-body\r\nEND:VCALENDAR\r\n",
+		"BEGIN:VCALENDAR\r\nEND:secret-token-in-body\r\n",
+		"BEGIN:secret-token-in-body\r\n",
+	}
+	for _, in := range tests {
+		_, err := Parse(strings.NewReader(in))
+		if err == nil {
+			t.Errorf("Parse(%q) succeeded", in)
+			continue
+		}
+		if strings.Contains(err.Error(), "secret") {
+			t.Errorf("Parse error %q quotes the input", err)
+		}
+	}
+}
+
+func TestParseNestsAndUnfolds(t *testing.T) {
+	in := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nSUMMARY:Long\r\n  title\r\nBEGIN:VALARM\r\nACTION:DISPLAY\r\nEND:VALARM\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+	roots, err := Parse(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roots) != 1 || len(roots[0].Children) != 1 {
+		t.Fatalf("got %d roots", len(roots))
+	}
+	ev := roots[0].Children[0]
+	if got := propValue(ev, "SUMMARY"); got != "Long title" {
+		t.Errorf("SUMMARY = %q", got)
+	}
+	if len(ev.Children) != 1 || ev.Children[0].Kind != "VALARM" {
+		t.Errorf("VALARM not nested under VEVENT")
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	tests := []struct {
+		value  string
+		params map[string]string
+		want   time.Time
+		allDay bool
+	}{
+		{"20250301", nil, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), true},
+		{"20250301T100000Z", nil, time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC), false},
+		{"20250301T100000", map[string]string{"TZID": "Europe/Berlin"}, time.Date(2025, 3, 1, 10, 0, 0, 0, berlin), false},
+		{"20250301T100000", map[string]string{"TZID": "W. Europe Standard Time"}, time.Date(2025, 3, 1, 10, 0, 0, 0, berlin), false},
+		{"20250301T100000", map[string]string{"TZID": "/mozilla.org/20050126_1/Europe/Berlin"}, time.Date(2025, 3, 1, 10, 0, 0, 0, berlin), false},
+		{"20250301T100000", map[string]string{"TZID": "Nowhere"}, time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		got, allDay, err := ParseTime(tt.value, tt.params, time.UTC)
+		if err != nil {
+			t.Errorf("ParseTime(%q, %v): %v", tt.value, tt.params, err)
+			continue
+		}
+		if !got.Equal(tt.want) || allDay != tt.allDay {
+			t.Errorf("ParseTime(%q, %v) = %v %v, want %v %v", tt.value, tt.params, got, allDay, tt.want, tt.allDay)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"PT1H30M", 90 * time.Minute, true},
+		{"P1D", 24 * time.Hour, true},
+		{"P1W", 7 * 24 * time.Hour, true},
+		{"-PT15M", -15 * time.Minute, true},
+		{"P1DT2H", 26 * time.Hour, true},
+		{"1H", 0, false},
+		{"PT1X", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, %v", tt.in, got, err)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package ical
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPeriods bounds RRULE expansion so a rule without COUNT/UNTIL can't loop
+// forever. Expansion skips ahead to the requested window, so the bound is
+// counted from there rather than from DTSTART.
+const maxPeriods = 5000
+
+// WeekdayNum is a BYDAY entry such as MO, 2TU or -1FR.
+type WeekdayNum struct {
+	Ordinal int // 0 means every such weekday in the period
+	Day     time.Weekday
+}
+
+// RRule is the supported subset of RFC 5545 recurrence rules:
+// FREQ=DAILY|WEEKLY|MONTHLY|YEARLY with INTERVAL, COUNT, UNTIL, BYDAY,
+// BYMONTHDAY and BYMONTH.
+type RRule struct {
+	Freq       string
+	Interval   int
+	Count      int
+	Until      *time.Time
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []int
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// ParseRRule parses an RRULE value. loc is used for date-only UNTIL values.
+func ParseRRule(value string, loc *time.Location) (*RRule, error) {
+	rule := &RRule{Interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key, val := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+		switch key {
+		case "FREQ":
+			rule.Freq = val
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid INTERVAL")
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid COUNT")
+			}
+			rule.Count = n
+		case "UNTIL":
+			t, _, err := ParseTime(val, map[string]string{}, loc)
+			if err != nil {
+				return nil, fmt.Errorf("invalid UNTIL")
+			}
+			if len(val) == 8 {
+				// a date-only UNTIL includes that whole day
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			rule.Until = &t
+		case "BYDAY":
+			for _, d := range strings.Split(val, ",") {
+				wd, err := parseWeekdayNum(d)
+				if err != nil {
+					return nil, err
+				}
+				rule.ByDay = append(rule.ByDay, wd)
+			}
+		case "BYMONTHDAY":
+			ints, err := parseInts(val)
+			if err != nil {
+				return nil, fmt.Errorf("invalid BYMONTHDAY")
+			}
+			rule.ByMonthDay = ints
+		case "BYMONTH":
+			ints, err := parseInts(val)
+			if err != nil {
+				return nil, fmt.Errorf("invalid BYMONTH")
+			}
+			rule.ByMonth = ints
+		}
+	}
+
+	switch rule.Freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	default:
+		return nil, fmt.Errorf("unsupported FREQ")
+	}
+	return rule, nil
+}
+
+// Occurrences returns the start times of the rule up to and including end.
+// DTSTART itself is always the first one. Periods that end before from are
+// skipped, except under COUNT, which has to count them; either way the
+// result can still hold a few starts before from.
+func (r *RRule) Occurrences(start, from, end time.Time) []time.Time {
+	out := []time.Time{start}
+	emitted := 1
+	if r.Count > 0 && emitted >= r.Count {
+		return out
+	}
+
+	first := r.periodsBefore(start, from)
+	last := first + maxPeriods
+	if r.Count > 0 {
+		first = 0
+	}
+	for period := first; period < last; period++ {
+		candidates := r.periodCandidates(start, period)
+		for _, c := range candidates {
+			if !c.After(start) {
+				continue
+			}
+			if r.Until != nil && c.After(*r.Until) {
+				return out
+			}
+			if c.After(end) {
+				return out
+			}
+			out = append(out, c)
+			emitted++
+			if r.Count > 0 && emitted >= r.Count {
+				return out
+			}
+		}
+	}
+	return out
+}
+
+// periodsBefore is how many whole periods of the rule lie between start and
+// from, less one to allow for DST and partial weeks
+func (r *RRule) periodsBefore(start, from time.Time) int {
+	if !from.After(start) {
+		return 0
+	}
+	var n int
+	switch r.Freq {
+	case "DAILY":
+		n = int(from.Sub(start).Hours() / 24)
+	case "WEEKLY":
+		n = int(from.Sub(start).Hours() / (24 * 7))
+	case "MONTHLY":
+		n = (from.Year()-start.Year())*12 + int(from.Month()) - int(start.Month())
+	case "YEARLY":
+		n = from.Year() - start.Year()
+	}
+	return max(n/r.Interval-1, 0)
+}
+
+// periodCandidates expands the n-th period (day, week, month or year) after
+// the one containing start into concrete, sorted start times.
+func (r *RRule) periodCandidates(start time.Time, n int) []time.Time {
+	loc := start.Location()
+	h, mi, s := start.Clock()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, h, mi, s, 0, loc)
+	}
+
+	var out []time.Time
+	step := n * r.Interval
+
+	switch r.Freq {
+	case "DAILY":
+		d := start.AddDate(0, 0, step)
+		if r.matchesMonth(d.Month()) && r.matchesMonthDay(d) && r.matchesWeekday(d.Weekday()) {
+			out = append(out, at(d.Year(), d.Month(), d.Day()))
+		}
+
+	case "WEEKLY":
+		// weeks start on Monday (WKST=MO default)
+		offset := (int(start.Weekday()) + 6) % 7
+		weekStart := start.AddDate(0, 0, -offset+7*step)
+		days := r.ByDay
+		if len(days) == 0 {
+			days = []WeekdayNum{{Day: start.Weekday()}}
+		}
+		for _, wd := range days {
+			d := weekStart.AddDate(0, 0, (int(wd.Day)+6)%7)
+			if r.matchesMonth(d.Month()) {
+				out = append(out, at(d.Year(), d.Month(), d.Day()))
+			}
+		}
+
+	case "MONTHLY":
+		first := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, loc).AddDate(0, step, 0)
+		if r.matchesMonth(first.Month()) {
+			out = r.daysInMonth(first, start.Day(), at)
+		}
+
+	case "YEARLY":
+		year := start.Year() + step
+		months := r.ByMonth
+		if len(months) == 0 {
+			months = []int{int(start.Month())}
+		}
+		for _, m := range months {
+			first := time.Date(year, time.Month(m), 1, 0, 0, 0, 0, loc)
+			out = append(out, r.daysInMonth(first, start.Day(), at)...)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+	return out
+}
+
+// daysInMonth applies BYMONTHDAY / BYDAY within one month, defaulting to the
+// start's day of month (skipped in months that are too short).
+func (r *RRule) daysInMonth(first time.Time, defaultDay int, at func(int, time.Month, int) time.Time) []time.Time {
+	y, m := first.Year(), first.Month()
+	last := first.AddDate(0, 1, -1).Day()
+
+	var out []time.Time
+	switch {
+	case len(r.ByMonthDay) > 0:
+		for _, md := range r.ByMonthDay {
+			day := md
+			if md < 0 {
+				day = last + md + 1
+			}
+			if day >= 1 && day <= last {
+				out = append(out, at(y, m, day))
+			}
+		}
+	case len(r.ByDay) > 0:
+		for _, wd := range r.ByDay {
+			var matches []int
+			for day := 1; day <= last; day++ {
+				if time.Date(y, m, day, 0, 0, 0, 0, first.Location()).Weekday() == wd.Day {
+					matches = append(matches, day)
+				}
+			}
+			switch {
+			case wd.Ordinal == 0:
+				for _, day := range matches {
+					out = append(out, at(y, m, day))
+				}
+			case wd.Ordinal > 0 && wd.Ordinal <= len(matches):
+				out = append(out, at(y, m, matches[wd.Ordinal-1]))
+			case wd.Ordinal < 0 && -wd.Ordinal <= len(matches):
+				out = append(out, at(y, m, matches[len(matches)+wd.Ordinal]))
+			}
+		}
+	default:
+		if defaultDay <= last {
+			out = append(out, at(y, m, defaultDay))
+		}
+	}
+	return out
+}
+
+func (r *RRule) matchesMonth(m time.Month) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, bm := range r.ByMonth {
+		if bm == int(m) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RRule) matchesMonthDay(d time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	last := time.Date(d.Year(), d.Month()+1, 0, 0, 0, 0, 0, d.Location()).Day()
+	for _, md := range r.ByMonthDay {
+		if md == d.Day() || (md < 0 && last+md+1 == d.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RRule) matchesWeekday(wd time.Weekday) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, bd := range r.ByDay {
+		if bd.Day == wd {
+			return true
+		}
+	}
+	return false
+}
+
+func parseWeekdayNum(s string) (WeekdayNum, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY")
+	}
+	day, ok := weekdays[s[len(s)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY")
+	}
+	wd := WeekdayNum{Day: day}
+	if prefix := s[:len(s)-2]; prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil {
+			return WeekdayNum{}, fmt.Errorf("invalid BYDAY")
+		}
+		wd.Ordinal = n
+	}
+	return wd, nil
+}
+
+func parseInts(s string) ([]int, error) {
+	var out []int
+	for _, part := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
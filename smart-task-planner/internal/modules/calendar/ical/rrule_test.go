@@ -0,0 +1,100 @@
+package ical
+
+import (
+	"testing"
+	"time"
+)
+
+func day(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 9, 0, 0, 0, time.UTC)
+}
+
+func TestOccurrences(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     string
+		start    time.Time
+		from, to time.Time
+		want     []time.Time
+	}{
+		{
+			name: "daily count", rule: "FREQ=DAILY;COUNT=3",
+			start: day(2025, 1, 1), from: day(2025, 1, 1), to: day(2025, 12, 31),
+			want: []time.Time{day(2025, 1, 1), day(2025, 1, 2), day(2025, 1, 3)},
+		},
+		{
+			name: "weekly by day until", rule: "FREQ=WEEKLY;BYDAY=MO,WE;UNTIL=20250115",
+			start: day(2025, 1, 6), from: day(2025, 1, 1), to: day(2025, 12, 31),
+			want: []time.Time{day(2025, 1, 6), day(2025, 1, 8), day(2025, 1, 13), day(2025, 1, 15)},
+		},
+		{
+			name: "monthly last friday", rule: "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3",
+			start: day(2025, 1, 31), from: day(2025, 1, 1), to: day(2025, 12, 31),
+			want: []time.Time{day(2025, 1, 31), day(2025, 2, 28), day(2025, 3, 28)},
+		},
+		{
+			name: "monthly 31st skips short months", rule: "FREQ=MONTHLY;COUNT=3",
+			start: day(2025, 1, 31), from: day(2025, 1, 1), to: day(2025, 12, 31),
+			want: []time.Time{day(2025, 1, 31), day(2025, 3, 31), day(2025, 5, 31)},
+		},
+		{
+			name: "yearly interval", rule: "FREQ=YEARLY;INTERVAL=2",
+			start: day(2020, 6, 1), from: day(2020, 1, 1), to: day(2025, 1, 1),
+			want: []time.Time{day(2020, 6, 1), day(2022, 6, 1), day(2024, 6, 1)},
+		},
+	}
+	for _, tt := range tests {
+		rule, err := ParseRRule(tt.rule, time.UTC)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got := rule.Occurrences(tt.start, tt.from, tt.to)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if !got[i].Equal(tt.want[i]) {
+				t.Errorf("%s: [%d] = %v, want %v", tt.name, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+// A daily series started decades ago must still reach today, well past
+// maxPeriods days after DTSTART.
+func TestOccurrencesSkipsAheadToWindow(t *testing.T) {
+	rule, err := ParseRRule("FREQ=DAILY", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	from, to := day(2025, 3, 1), day(2025, 3, 3)
+	got := rule.Occurrences(day(1990, 1, 1), from, to)
+
+	var inWindow int
+	for _, o := range got {
+		if !o.Before(from) && !o.After(to) {
+			inWindow++
+		}
+	}
+	if inWindow != 3 {
+		t.Errorf("got %d occurrences in the window, want 3", inWindow)
+	}
+	if len(got) > 10 {
+		t.Errorf("expanded %d occurrences, expected the years before the window to be skipped", len(got))
+	}
+}
+
+func TestParseRRuleErrors(t *testing.T) {
+	for _, in := range []string{
+		"FREQ=SECONDLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=x",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"INTERVAL=2",
+	} {
+		if _, err := ParseRRule(in, time.UTC); err == nil {
+			t.Errorf("ParseRRule(%q) succeeded", in)
+		}
+	}
+}
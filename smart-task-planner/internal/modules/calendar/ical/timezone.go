@@ -0,0 +1,198 @@
+package ical
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// vtimezone is a VTIMEZONE definition for a TZID that Go's zone database
+// doesn't know, such as the custom names some Outlook and Exchange exports
+// use.
+type vtimezone struct {
+	id          string
+	observances []observance
+}
+
+// observance is a STANDARD or DAYLIGHT block: from its onsets on, wall
+// clocks are offsetTo seconds east of UTC.
+type observance struct {
+	name       string
+	offsetFrom int
+	offsetTo   int
+	start      time.Time // wall clock, in UTC
+	rule       *RRule
+	rdates     []time.Time
+}
+
+// zones resolves the TZIDs of a calendar. Names Go knows win; others use
+// the calendar's VTIMEZONE definitions; anything else is read in def.
+type zones struct {
+	def     *time.Location
+	aliases map[string]*time.Location // TZIDs whose X-LIC-LOCATION Go knows
+	defs    map[string]*vtimezone
+}
+
+func newZones(components []*ParsedComponent, def *time.Location) *zones {
+	z := &zones{def: def, aliases: map[string]*time.Location{}, defs: map[string]*vtimezone{}}
+	for _, c := range components {
+		if c.Kind != "VTIMEZONE" {
+			continue
+		}
+		id := propValue(c, "TZID")
+		if id == "" || ResolveLocation(id, nil) != nil {
+			continue
+		}
+		if loc := ResolveLocation(propValue(c, "X-LIC-LOCATION"), nil); loc != nil {
+			// known under another name; Go's rules are more complete
+			z.aliases[id] = loc
+			continue
+		}
+		if vt := parseVTimezone(id, c); vt != nil {
+			z.defs[id] = vt
+		}
+	}
+	return z
+}
+
+// frame returns the location a property's wall-clock value is read in and
+// how to turn a value read there into the actual instant. Times in a
+// VTIMEZONE are read as UTC wall clocks, so recurrences can be expanded on
+// them before converting each one.
+func (z *zones) frame(p ParsedProperty) (*time.Location, func(time.Time) time.Time) {
+	v := strings.TrimSpace(p.Value)
+	tzid := p.Params["TZID"]
+	if tzid == "" || strings.HasSuffix(v, "Z") || p.Params["VALUE"] == "DATE" || len(v) == 8 {
+		return z.def, identity
+	}
+	if loc := ResolveLocation(tzid, nil); loc != nil {
+		return loc, identity
+	}
+	if loc, ok := z.aliases[tzid]; ok {
+		return loc, identity
+	}
+	if vt, ok := z.defs[tzid]; ok {
+		return time.UTC, vt.instant
+	}
+	return z.def, identity
+}
+
+// parse reads a DATE or DATE-TIME property like ParseTime, resolving its
+// TZID through the calendar's VTIMEZONEs too
+func (z *zones) parse(p ParsedProperty) (time.Time, bool, error) {
+	loc, conv := z.frame(p)
+	t, allDay, err := ParseTime(p.Value, p.Params, loc)
+	if err != nil {
+		return t, allDay, err
+	}
+	return conv(t), allDay, nil
+}
+
+func identity(t time.Time) time.Time { return t }
+
+// instant turns a wall clock of the zone, given in UTC, into the moment it
+// names. The observance with the latest onset before it sets the offset;
+// before any onset the earliest observance's TZOFFSETFROM applies.
+func (vt *vtimezone) instant(wall time.Time) time.Time {
+	var current *observance
+	var currentOnset time.Time
+	earliest := &vt.observances[0]
+	for i := range vt.observances {
+		obs := &vt.observances[i]
+		if obs.start.Before(earliest.start) {
+			earliest = obs
+		}
+		if onset, ok := obs.lastOnset(wall); ok && (current == nil || onset.After(currentOnset)) {
+			current, currentOnset = obs, onset
+		}
+	}
+
+	offset, name := earliest.offsetFrom, vt.id
+	if current != nil {
+		offset = current.offsetTo
+		if current.name != "" {
+			name = current.name
+		}
+	}
+	y, m, d := wall.Date()
+	h, mi, s := wall.Clock()
+	return time.Date(y, m, d, h, mi, s, wall.Nanosecond(), time.FixedZone(name, offset))
+}
+
+// lastOnset is the latest time at or before wall that obs took effect
+func (obs *observance) lastOnset(wall time.Time) (time.Time, bool) {
+	if obs.start.After(wall) {
+		return time.Time{}, false
+	}
+	last := obs.start
+	if obs.rule != nil {
+		for _, t := range obs.rule.Occurrences(obs.start, wall.AddDate(-1, 0, 0), wall) {
+			if t.After(last) {
+				last = t
+			}
+		}
+	}
+	for _, t := range obs.rdates {
+		if t.After(last) && !t.After(wall) {
+			last = t
+		}
+	}
+	return last, true
+}
+
+func parseVTimezone(id string, c *ParsedComponent) *vtimezone {
+	vt := &vtimezone{id: id}
+	for _, child := range c.Children {
+		if child.Kind != "STANDARD" && child.Kind != "DAYLIGHT" {
+			continue
+		}
+		from, okFrom := parseOffset(propValue(child, "TZOFFSETFROM"))
+		to, okTo := parseOffset(propValue(child, "TZOFFSETTO"))
+		dtstart, ok := child.Get("DTSTART")
+		if !okFrom || !okTo || !ok {
+			continue
+		}
+		start, _, err := ParseTime(dtstart.Value, nil, time.UTC)
+		if err != nil {
+			continue
+		}
+		obs := observance{name: propValue(child, "TZNAME"), offsetFrom: from, offsetTo: to, start: start}
+		if rr, ok := child.Get("RRULE"); ok {
+			if obs.rule, err = ParseRRule(rr.Value, time.UTC); err != nil {
+				continue
+			}
+		}
+		for _, rd := range child.All("RDATE") {
+			for _, v := range strings.Split(rd.Value, ",") {
+				if t, _, err := ParseTime(v, nil, time.UTC); err == nil {
+					obs.rdates = append(obs.rdates, t)
+				}
+			}
+		}
+		vt.observances = append(vt.observances, obs)
+	}
+	if len(vt.observances) == 0 {
+		return nil
+	}
+	return vt
+}
+
+// parseOffset reads a UTC offset such as +0100, -0500 or +053000 as seconds
+func parseOffset(s string) (int, bool) {
+	s = strings.TrimSpace(s)
+	if len(s) != 5 && len(s) != 7 || (s[0] != '+' && s[0] != '-') {
+		return 0, false
+	}
+	digits := s[1:] + "00"
+	h, errH := strconv.Atoi(digits[0:2])
+	m, errM := strconv.Atoi(digits[2:4])
+	sec, errS := strconv.Atoi(digits[4:6])
+	if errH != nil || errM != nil || errS != nil {
+		return 0, false
+	}
+	n := h*3600 + m*60 + sec
+	if s[0] == '-' {
+		n = -n
+	}
+	return n, true
+}
@@ -0,0 +1,121 @@
+package ical
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405Z"
+	maxLineOctets  = 75
+)
+
+// Property is a single content line, e.g. DUE;VALUE=DATE:20251020.
+type Property struct {
+	Name   string
+	Params []string // already formatted "KEY=VALUE" pairs
+	Value  string   // raw value, escaped by the writer when Text is set
+	Text   bool
+}
+
+// Component is a VTODO, VEVENT or any other block of properties.
+type Component struct {
+	Kind       string
+	Properties []Property
+}
+
+// Add appends a property whose value is used verbatim.
+func (c *Component) Add(name, value string, params ...string) {
+	c.Properties = append(c.Properties, Property{Name: name, Params: params, Value: value})
+}
+
+// AddText appends a TEXT property; commas, semicolons, backslashes and
+// newlines are escaped per RFC 5545 section 3.3.11.
+func (c *Component) AddText(name, value string, params ...string) {
+	c.Properties = append(c.Properties, Property{Name: name, Params: params, Value: value, Text: true})
+}
+
+// AddDate appends a DATE value (all-day).
+func (c *Component) AddDate(name string, t time.Time) {
+	c.Add(name, t.Format(dateLayout), "VALUE=DATE")
+}
+
+// AddDateTime appends a UTC DATE-TIME value.
+func (c *Component) AddDateTime(name string, t time.Time) {
+	c.Add(name, t.UTC().Format(dateTimeLayout))
+}
+
+// Calendar is a VCALENDAR object.
+type Calendar struct {
+	ProdID     string
+	Name       string
+	Components []Component
+}
+
+// Encode renders the calendar as an RFC 5545 document with CRLF line
+// endings and lines folded at 75 octets.
+func (cal *Calendar) Encode() []byte {
+	var buf bytes.Buffer
+	writeLine(&buf, "BEGIN:VCALENDAR")
+	writeLine(&buf, "VERSION:2.0")
+	writeLine(&buf, "PRODID:"+cal.ProdID)
+	writeLine(&buf, "CALSCALE:GREGORIAN")
+	writeLine(&buf, "METHOD:PUBLISH")
+	if cal.Name != "" {
+		writeLine(&buf, "X-WR-CALNAME:"+EscapeText(cal.Name))
+	}
+
+	for _, comp := range cal.Components {
+		writeLine(&buf, "BEGIN:"+comp.Kind)
+		for _, p := range comp.Properties {
+			name := p.Name
+			if len(p.Params) > 0 {
+				name += ";" + strings.Join(p.Params, ";")
+			}
+			value := p.Value
+			if p.Text {
+				value = EscapeText(value)
+			}
+			writeLine(&buf, fmt.Sprintf("%s:%s", name, value))
+		}
+		writeLine(&buf, "END:"+comp.Kind)
+	}
+
+	writeLine(&buf, "END:VCALENDAR")
+	return buf.Bytes()
+}
+
+// EscapeText escapes a TEXT value.
+func EscapeText(s string) string {
+	r := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	)
+	return r.Replace(s)
+}
+
+// writeLine folds long lines without splitting multi-byte characters.
+func writeLine(buf *bytes.Buffer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		// continuation lines lose one octet to the leading space
+		limit = maxLineOctets - 1
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
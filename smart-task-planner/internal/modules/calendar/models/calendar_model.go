@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// FeedToken grants read-only access to a user's ICS feed. Only the SHA-256
+// hash of the secret is stored; the secret itself is shown once on creation.
+type FeedToken struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     string             `bson:"user_id" json:"user_id"`
+	Name       string             `bson:"name" json:"name"`
+	TokenHash  string             `bson:"token_hash" json:"-"`
+	Hint       string             `bson:"hint" json:"hint"` // last characters, to tell tokens apart
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	LastUsedAt *time.Time         `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+}
+
+const (
+	SourceUpload = "upload"
+	SourceFile   = "file"
+	SourceURL    = "url"
+)
+
+// CalendarSource is an external calendar whose events block the user's time.
+// Upload sources are imported once; file and URL sources are re-synced.
+type CalendarSource struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID       string             `bson:"user_id" json:"user_id"`
+	Name         string             `bson:"name" json:"name"`
+	Kind         string             `bson:"kind" json:"kind"`
+	Location     string             `bson:"location,omitempty" json:"location,omitempty"` // file path or URL
+	TimeZone     string             `bson:"time_zone,omitempty" json:"time_zone,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	LastSyncedAt *time.Time         `bson:"last_synced_at,omitempty" json:"last_synced_at,omitempty"`
+	LastError    string             `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	BlockCount   int                `bson:"block_count" json:"block_count"`
+}
+
+// BusyBlock is one expanded occurrence of a busy event.
+type BusyBlock struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID   string             `bson:"user_id" json:"user_id"`
+	SourceID primitive.ObjectID `bson:"source_id" json:"source_id"`
+	UID      string             `bson:"uid" json:"uid"`
+	Summary  string             `bson:"summary" json:"summary"`
+	Start    time.Time          `bson:"start" json:"start"`
+	End      time.Time          `bson:"end" json:"end"`
+	AllDay   bool               `bson:"all_day" json:"all_day"`
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"smart-task-planner/internal/modules/calendar/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BusyRepository stores calendar sources and the busy blocks imported from them
+type BusyRepository struct {
+	Sources *mongo.Collection
+	Blocks  *mongo.Collection
+}
+
+func NewBusyRepository(db *mongo.Database) *BusyRepository {
+	return &BusyRepository{
+		Sources: db.Collection("calendar_sources"),
+		Blocks:  db.Collection("busy_blocks"),
+	}
+}
+
+func (r *BusyRepository) EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := r.Sources.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}},
+	}); err != nil {
+		return err
+	}
+	_, err := r.Blocks.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "start", Value: 1}}},
+		{Keys: bson.D{{Key: "source_id", Value: 1}}},
+	})
+	return err
+}
+
+func (r *BusyRepository) CreateSource(src *models.CalendarSource) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	src.ID = primitive.NewObjectID()
+	_, err := r.Sources.InsertOne(ctx, src)
+	return err
+}
+
+func (r *BusyRepository) ListSources(userID string) ([]models.CalendarSource, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := r.Sources.Find(ctx, bson.M{"user_id": userID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	sources := []models.CalendarSource{}
+	if err := cursor.All(ctx, &sources); err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+// ListSyncable returns every file and URL source across all users
+func (r *BusyRepository) ListSyncable() ([]models.CalendarSource, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.Sources.Find(ctx, bson.M{"kind": bson.M{"$in": []string{models.SourceFile, models.SourceURL}}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sources []models.CalendarSource
+	if err := cursor.All(ctx, &sources); err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+func (r *BusyRepository) GetSource(userID, sourceID string) (*models.CalendarSource, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source ID: %v", err)
+	}
+
+	var src models.CalendarSource
+	if err := r.Sources.FindOne(ctx, bson.M{"_id": objID, "user_id": userID}).Decode(&src); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("source not found")
+		}
+		return nil, err
+	}
+	return &src, nil
+}
+
+// RecordSync stores the outcome of the last import of a source
+func (r *BusyRepository) RecordSync(id primitive.ObjectID, at time.Time, blocks int, syncErr error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"last_synced_at": at, "block_count": blocks, "last_error": ""}}
+	if syncErr != nil {
+		update = bson.M{"$set": bson.M{"last_synced_at": at, "last_error": syncErr.Error()}}
+	}
+	_, err := r.Sources.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+// DeleteSource removes a source together with its busy blocks
+func (r *BusyRepository) DeleteSource(userID, sourceID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(sourceID)
+	if err != nil {
+		return fmt.Errorf("invalid source ID: %v", err)
+	}
+
+	res, err := r.Sources.DeleteOne(ctx, bson.M{"_id": objID, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return fmt.Errorf("source not found")
+	}
+	_, err = r.Blocks.DeleteMany(ctx, bson.M{"source_id": objID})
+	return err
+}
+
+// ReplaceBlocks swaps all blocks of a source for a freshly imported set
+func (r *BusyRepository) ReplaceBlocks(sourceID primitive.ObjectID, blocks []models.BusyBlock) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if _, err := r.Blocks.DeleteMany(ctx, bson.M{"source_id": sourceID}); err != nil {
+		return err
+	}
+	if len(blocks) == 0 {
+		return nil
+	}
+
+	docs := make([]interface{}, len(blocks))
+	for i := range blocks {
+		blocks[i].ID = primitive.NewObjectID()
+		blocks[i].SourceID = sourceID
+		docs[i] = blocks[i]
+	}
+	_, err := r.Blocks.InsertMany(ctx, docs)
+	return err
+}
+
+// FindBetween returns the user's busy blocks overlapping [from, to], ordered by start
+func (r *BusyRepository) FindBetween(userID string, from, to time.Time) ([]models.BusyBlock, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.Blocks.Find(ctx, bson.M{
+		"user_id": userID,
+		"start":   bson.M{"$lte": to},
+		"end":     bson.M{"$gt": from},
+	}, options.Find().SetSort(bson.D{{Key: "start", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	blocks := []models.BusyBlock{}
+	if err := cursor.All(ctx, &blocks); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"smart-task-planner/internal/modules/calendar/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type FeedTokenRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewFeedTokenRepository(db *mongo.Database) *FeedTokenRepository {
+	return &FeedTokenRepository{
+		Collection: db.Collection("calendar_feed_tokens"),
+	}
+}
+
+// EnsureIndexes makes token hashes unique so lookups hit exactly one token
+func (r *FeedTokenRepository) EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.Collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+	})
+	return err
+}
+
+func (r *FeedTokenRepository) Create(token *models.FeedToken) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	token.ID = primitive.NewObjectID()
+	_, err := r.Collection.InsertOne(ctx, token)
+	return err
+}
+
+// ListByUser returns all tokens of a user, newest first
+func (r *FeedTokenRepository) ListByUser(userID string) ([]models.FeedToken, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := r.Collection.Find(ctx, bson.M{"user_id": userID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	tokens := []models.FeedToken{}
+	if err := cursor.All(ctx, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// FindActiveByHash returns the non-revoked token with the given hash
+func (r *FeedTokenRepository) FindActiveByHash(hash string) (*models.FeedToken, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var token models.FeedToken
+	err := r.Collection.FindOne(ctx, bson.M{
+		"token_hash": hash,
+		"revoked_at": bson.M{"$exists": false},
+	}).Decode(&token)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("feed not found")
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// TouchLastUsed records when a calendar client last fetched the feed
+func (r *FeedTokenRepository) TouchLastUsed(id primitive.ObjectID, at time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, _ = r.Collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"last_used_at": at}})
+}
+
+// Revoke marks a user's token as revoked so its feed URL stops working
+func (r *FeedTokenRepository) Revoke(userID, tokenID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(tokenID)
+	if err != nil {
+		return fmt.Errorf("invalid token ID: %v", err)
+	}
+
+	res, err := r.Collection.UpdateOne(ctx,
+		bson.M{"_id": objID, "user_id": userID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("token not found")
+	}
+	return nil
+}
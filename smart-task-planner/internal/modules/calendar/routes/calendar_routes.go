@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"smart-task-planner/internal/middleware"
+	"smart-task-planner/internal/modules/calendar/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterCalendarRoutes(router *gin.Engine, handler *handlers.CalendarHandler, busy *handlers.BusyHandler) {
+	api := router.Group("/api")
+	api.Use(middleware.JWTAuth())
+	{
+		// One plan as an .ics download
+		api.GET("/plan/:id/calendar.ics", handler.ExportPlan)
+
+		// Manage secret feed tokens
+		api.POST("/calendar/tokens", handler.CreateFeedToken)
+		api.GET("/calendar/tokens", handler.ListFeedTokens)
+		api.DELETE("/calendar/tokens/:id", handler.RevokeFeedToken)
+
+		// Busy time imported from external calendars
+		api.POST("/calendar/busy/upload", busy.Upload)
+		api.GET("/calendar/busy", busy.Busy)
+		api.POST("/calendar/sources", busy.AddSource)
+		api.GET("/calendar/sources", busy.ListSources)
+		api.POST("/calendar/sources/:id/sync", busy.SyncSource)
+		api.DELETE("/calendar/sources/:id", busy.DeleteSource)
+	}
+
+	// Subscribable feed; the secret token in the URL replaces the JWT
+	router.GET("/calendar/:token", handler.Feed)
+}
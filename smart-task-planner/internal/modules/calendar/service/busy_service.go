@@ -0,0 +1,284 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"smart-task-planner/internal/modules/calendar/ical"
+	"smart-task-planner/internal/modules/calendar/models"
+	"smart-task-planner/internal/modules/calendar/repository"
+	"smart-task-planner/internal/utils"
+)
+
+const (
+	maxICSBytes  = 10 << 20
+	fetchTimeout = 30 * time.Second
+	syncInterval = time.Hour
+
+	// busy blocks are expanded from a week back to a year ahead
+	pastWindow   = 7 * 24 * time.Hour
+	futureWindow = 365 * 24 * time.Hour
+)
+
+type BusyService struct {
+	Repo   *repository.BusyRepository
+	client *http.Client
+}
+
+func NewBusyService(repo *repository.BusyRepository) *BusyService {
+	return &BusyService{Repo: repo, client: utils.NewOutboundClient(fetchTimeout)}
+}
+
+// Upload imports an ICS document once as a new upload source
+func (s *BusyService) Upload(userID, name, timeZone string, data []byte) (*models.CalendarSource, error) {
+	if name == "" {
+		name = "Uploaded calendar"
+	}
+	src := &models.CalendarSource{
+		UserID:    userID,
+		Name:      name,
+		Kind:      models.SourceUpload,
+		TimeZone:  timeZone,
+		CreatedAt: time.Now(),
+	}
+	// parse before saving so a broken file doesn't leave an empty source behind
+	blocks, err := s.expand(src, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Repo.CreateSource(src); err != nil {
+		return nil, err
+	}
+	return s.store(src, blocks, nil)
+}
+
+// AddSource registers a local file or URL calendar and syncs it right away.
+// Sync failures are recorded on the source instead of failing the request.
+func (s *BusyService) AddSource(userID, name, location, timeZone string) (*models.CalendarSource, error) {
+	kind, location, err := classifyLocation(location)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = filepath.Base(location)
+	}
+
+	src := &models.CalendarSource{
+		UserID:    userID,
+		Name:      name,
+		Kind:      kind,
+		Location:  location,
+		TimeZone:  timeZone,
+		CreatedAt: time.Now(),
+	}
+	if err := s.Repo.CreateSource(src); err != nil {
+		return nil, err
+	}
+	return s.sync(src)
+}
+
+func (s *BusyService) ListSources(userID string) ([]models.CalendarSource, error) {
+	return s.Repo.ListSources(userID)
+}
+
+func (s *BusyService) DeleteSource(userID, sourceID string) error {
+	return s.Repo.DeleteSource(userID, sourceID)
+}
+
+// SyncSource re-imports a file or URL source on demand
+func (s *BusyService) SyncSource(userID, sourceID string) (*models.CalendarSource, error) {
+	src, err := s.Repo.GetSource(userID, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	if src.Kind == models.SourceUpload {
+		return nil, fmt.Errorf("uploaded calendars can't be synced; upload the file again")
+	}
+	return s.sync(src)
+}
+
+// Busy returns the user's busy blocks in [from, to]
+func (s *BusyService) Busy(userID string, from, to time.Time) ([]models.BusyBlock, error) {
+	return s.Repo.FindBetween(userID, from, to)
+}
+
+// StartPeriodicSync re-imports every file and URL source each hour until ctx is cancelled.
+func (s *BusyService) StartPeriodicSync(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(syncInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.syncAll()
+			}
+		}
+	}()
+}
+
+func (s *BusyService) syncAll() {
+	sources, err := s.Repo.ListSyncable()
+	if err != nil {
+		log.Println("❌ Calendar sync failed:", err)
+		return
+	}
+	for i := range sources {
+		if _, err := s.sync(&sources[i]); err != nil {
+			log.Printf("⚠️  Calendar source %s failed to sync: %v", sources[i].ID.Hex(), err)
+		}
+	}
+}
+
+func (s *BusyService) sync(src *models.CalendarSource) (*models.CalendarSource, error) {
+	data, err := s.read(src)
+	if err != nil {
+		return s.store(src, nil, err)
+	}
+	defer data.Close()
+
+	blocks, err := s.expand(src, data)
+	return s.store(src, blocks, err)
+}
+
+// store saves the imported blocks (or the error) and returns the updated source
+func (s *BusyService) store(src *models.CalendarSource, blocks []models.BusyBlock, syncErr error) (*models.CalendarSource, error) {
+	if syncErr == nil {
+		if err := s.Repo.ReplaceBlocks(src.ID, blocks); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	if err := s.Repo.RecordSync(src.ID, now, len(blocks), syncErr); err != nil {
+		return nil, err
+	}
+	src.LastSyncedAt = &now
+	if syncErr != nil {
+		src.LastError = syncErr.Error()
+	} else {
+		src.LastError = ""
+		src.BlockCount = len(blocks)
+	}
+	return src, nil
+}
+
+func (s *BusyService) expand(src *models.CalendarSource, r io.Reader) ([]models.BusyBlock, error) {
+	now := time.Now()
+	loc := ical.ResolveLocation(src.TimeZone, time.Local)
+
+	events, err := ical.ReadBusy(r, now.Add(-pastWindow), now.Add(futureWindow), loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid calendar: %v", err)
+	}
+
+	blocks := make([]models.BusyBlock, 0, len(events))
+	for _, ev := range events {
+		blocks = append(blocks, models.BusyBlock{
+			UserID:  src.UserID,
+			UID:     ev.UID,
+			Summary: ev.Summary,
+			Start:   ev.Start,
+			End:     ev.End,
+			AllDay:  ev.AllDay,
+		})
+	}
+	return blocks, nil
+}
+
+func (s *BusyService) read(src *models.CalendarSource) (io.ReadCloser, error) {
+	switch src.Kind {
+	case models.SourceFile:
+		path, err := importPath(src.Location)
+		if err != nil {
+			return nil, err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open calendar file: %v", err)
+		}
+		return readCloser{io.LimitReader(f, maxICSBytes), f}, nil
+
+	case models.SourceURL:
+		resp, err := s.client.Get(src.Location)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch calendar: %v", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("failed to fetch calendar: HTTP %d", resp.StatusCode)
+		}
+		return readCloser{io.LimitReader(resp.Body, maxICSBytes), resp.Body}, nil
+	}
+	return nil, fmt.Errorf("source kind %q can't be read", src.Kind)
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// classifyLocation decides between a URL and a local file source.
+// webcal:// links are fetched over https.
+func classifyLocation(location string) (string, string, error) {
+	location = strings.TrimSpace(location)
+	if location == "" {
+		return "", "", fmt.Errorf("location required")
+	}
+
+	if u, err := url.Parse(location); err == nil && u.Scheme != "" && u.Host != "" {
+		switch strings.ToLower(u.Scheme) {
+		case "webcal", "webcals":
+			u.Scheme = "https"
+		case "http", "https":
+			u.Scheme = strings.ToLower(u.Scheme)
+		default:
+			return "", "", fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := utils.CheckOutboundURL(ctx, u); err != nil {
+			return "", "", err
+		}
+		return models.SourceURL, u.String(), nil
+	}
+
+	if _, err := importPath(location); err != nil {
+		return "", "", err
+	}
+	return models.SourceFile, location, nil
+}
+
+// importPath resolves a file source inside CALENDAR_IMPORT_DIR. File sources
+// are disabled when the directory isn't configured, so API users can't read
+// arbitrary files from the server.
+func importPath(location string) (string, error) {
+	dir := os.Getenv("CALENDAR_IMPORT_DIR")
+	if dir == "" {
+		return "", fmt.Errorf("file sources are disabled (CALENDAR_IMPORT_DIR not set)")
+	}
+	base, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	path := location
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(base, path)
+	}
+	path = filepath.Clean(path)
+	if path != base && !strings.HasPrefix(path, base+string(filepath.Separator)) {
+		return "", fmt.Errorf("calendar files must be inside CALENDAR_IMPORT_DIR")
+	}
+	return path, nil
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"smart-task-planner/config"
+	"smart-task-planner/internal/modules/calendar/models"
+	"smart-task-planner/internal/modules/calendar/repository"
+	planModels "smart-task-planner/internal/modules/plan/models"
+	planRepository "smart-task-planner/internal/modules/plan/repository"
+)
+
+type CalendarService struct {
+	Repo     *repository.FeedTokenRepository
+	PlanRepo *planRepository.PlanRepository
+}
+
+func NewCalendarService(repo *repository.FeedTokenRepository, planRepo *planRepository.PlanRepository) *CalendarService {
+	return &CalendarService{Repo: repo, PlanRepo: planRepo}
+}
+
+// CreatedFeed is returned once when a feed token is created; the secret
+// cannot be recovered later.
+type CreatedFeed struct {
+	Token   string            `json:"token"`
+	FeedURL string            `json:"feed_url"`
+	Feed    *models.FeedToken `json:"feed"`
+}
+
+// PlanCalendar renders one plan as an ICS document
+func (s *CalendarService) PlanCalendar(userID, planID, kind string) ([]byte, error) {
+	plan, err := s.PlanRepo.GetByIDForUser(planID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return RenderPlans([]planModels.Plan{*plan}, plan.Goal, normalizeKind(kind), time.Now()), nil
+}
+
+// Feed renders all plans of the token's owner. Calendar clients can't send
+// JWTs, so the secret in the URL is the credential.
+func (s *CalendarService) Feed(secret, kind string) ([]byte, error) {
+	token, err := s.Repo.FindActiveByHash(hashToken(secret))
+	if err != nil {
+		return nil, err
+	}
+
+	plans, err := s.PlanRepo.GetAllByUser(token.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	s.Repo.TouchLastUsed(token.ID, now)
+	return RenderPlans(plans, "Smart Task Planner", normalizeKind(kind), now), nil
+}
+
+// CreateFeedToken issues a new feed secret for the user
+func (s *CalendarService) CreateFeedToken(userID, name string) (*CreatedFeed, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = "Calendar feed"
+	}
+
+	token := &models.FeedToken{
+		UserID:    userID,
+		Name:      name,
+		TokenHash: hashToken(secret),
+		Hint:      secret[len(secret)-4:],
+		CreatedAt: time.Now(),
+	}
+	if err := s.Repo.Create(token); err != nil {
+		return nil, err
+	}
+
+	return &CreatedFeed{
+		Token:   secret,
+		FeedURL: fmt.Sprintf("%s/calendar/%s.ics", config.AppConfig.BaseURL, secret),
+		Feed:    token,
+	}, nil
+}
+
+func (s *CalendarService) ListFeedTokens(userID string) ([]models.FeedToken, error) {
+	return s.Repo.ListByUser(userID)
+}
+
+func (s *CalendarService) RevokeFeedToken(userID, tokenID string) error {
+	return s.Repo.Revoke(userID, tokenID)
+}
+
+func normalizeKind(kind string) string {
+	switch strings.ToLower(kind) {
+	case KindTodo, KindEvent:
+		return strings.ToLower(kind)
+	default:
+		return KindBoth
+	}
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"smart-task-planner/config"
+	"smart-task-planner/internal/modules/calendar/ical"
+	planModels "smart-task-planner/internal/modules/plan/models"
+	"smart-task-planner/internal/modules/plan/progress"
+)
+
+const (
+	prodID    = "-//Smart Task Planner//Calendar Export//EN"
+	uidDomain = "smart-task-planner"
+)
+
+// Entry kinds emitted per task deadline
+const (
+	KindTodo  = "todo"
+	KindEvent = "event"
+	KindBoth  = "both"
+)
+
+// RenderPlans renders every task and subtask deadline of the plans. Tasks
+// without a deadline are skipped. UIDs derive from task IDs, so re-importing
+// the feed updates entries in place instead of duplicating them.
+func RenderPlans(plans []planModels.Plan, name, kind string, now time.Time) []byte {
+	cal := &ical.Calendar{ProdID: prodID, Name: name}
+
+	for _, plan := range plans {
+		var walk func(tasks []planModels.Task, parent *planModels.Task)
+		walk = func(tasks []planModels.Task, parent *planModels.Task) {
+			for i := range tasks {
+				t := &tasks[i]
+				if !t.Deadline.IsZero() {
+					if kind != KindEvent {
+						cal.Components = append(cal.Components, todoFor(plan, t, parent, now))
+					}
+					if kind != KindTodo {
+						cal.Components = append(cal.Components, eventFor(plan, t, now))
+					}
+				}
+				walk(t.SubTasks, t)
+			}
+		}
+		walk(plan.Tasks, nil)
+	}
+
+	return cal.Encode()
+}
+
+func todoFor(plan planModels.Plan, t *planModels.Task, parent *planModels.Task, now time.Time) ical.Component {
+	c := ical.Component{Kind: "VTODO"}
+	c.Add("UID", uid(t, KindTodo))
+	c.AddDateTime("DTSTAMP", now)
+	c.AddText("SUMMARY", t.Title)
+	c.AddText("DESCRIPTION", description(plan, t))
+	c.Add("URL", planURL(plan))
+	c.AddDate("DUE", t.Deadline)
+	c.Add("STATUS", todoStatus(t.Status))
+	if progress.IsCompleted(*t) {
+		c.Add("PERCENT-COMPLETE", "100")
+		if t.CompletedAt != nil {
+			c.AddDateTime("COMPLETED", *t.CompletedAt)
+		}
+	}
+	if parent != nil {
+		c.Add("RELATED-TO", uid(parent, KindTodo), "RELTYPE=PARENT")
+	}
+	addCategories(&c, plan, t)
+	return c
+}
+
+func eventFor(plan planModels.Plan, t *planModels.Task, now time.Time) ical.Component {
+	c := ical.Component{Kind: "VEVENT"}
+	c.Add("UID", uid(t, KindEvent))
+	c.AddDateTime("DTSTAMP", now)
+	c.AddText("SUMMARY", "Due: "+t.Title)
+	c.AddText("DESCRIPTION", description(plan, t))
+	c.Add("URL", planURL(plan))
+	c.AddDate("DTSTART", t.Deadline)
+	c.AddDate("DTEND", t.Deadline.AddDate(0, 0, 1))
+	c.Add("TRANSP", "TRANSPARENT") // deadlines shouldn't block free/busy time
+	c.Add("STATUS", eventStatus(t.Status))
+	addCategories(&c, plan, t)
+	return c
+}
+
+func addCategories(c *ical.Component, plan planModels.Plan, t *planModels.Task) {
+	cats := []string{ical.EscapeText(plan.Goal)}
+	for _, tag := range t.Tags {
+		cats = append(cats, ical.EscapeText(tag))
+	}
+	c.Add("CATEGORIES", strings.Join(cats, ","))
+}
+
+// todoStatus maps Task.Status onto VTODO STATUS values
+func todoStatus(status string) string {
+	switch strings.ToLower(status) {
+	case "completed":
+		return "COMPLETED"
+	case "in progress":
+		return "IN-PROCESS"
+	case "cancelled", "canceled":
+		return "CANCELLED"
+	default:
+		return "NEEDS-ACTION"
+	}
+}
+
+// eventStatus maps Task.Status onto VEVENT STATUS values
+func eventStatus(status string) string {
+	switch strings.ToLower(status) {
+	case "cancelled", "canceled":
+		return "CANCELLED"
+	case "pending":
+		return "TENTATIVE"
+	default:
+		return "CONFIRMED"
+	}
+}
+
+func uid(t *planModels.Task, kind string) string {
+	return fmt.Sprintf("%s-%s@%s", t.ID.Hex(), kind, uidDomain)
+}
+
+func description(plan planModels.Plan, t *planModels.Task) string {
+	var b strings.Builder
+	if t.Description != "" {
+		b.WriteString(t.Description)
+		b.WriteString("\n\n")
+	}
+	fmt.Fprintf(&b, "Status: %s\n", t.Status)
+	fmt.Fprintf(&b, "Plan: %s\n", plan.Goal)
+	b.WriteString(planURL(plan))
+	return b.String()
+}
+
+func planURL(plan planModels.Plan) string {
+	return fmt.Sprintf("%s/api/plan/%s", config.AppConfig.BaseURL, plan.ID.Hex())
+}
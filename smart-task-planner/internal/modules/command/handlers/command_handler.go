@@ -27,6 +27,7 @@ func (h *CommandHandler) ProcessCommand(c *gin.Context) {
 	// Bind message from request body
 	var req struct {
 		Message string `json:"message" binding:"required"`
+		PlanID  string `json:"plan_id"` // optional, skips AI goal matching
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -35,7 +36,7 @@ func (h *CommandHandler) ProcessCommand(c *gin.Context) {
 	}
 
 	// Call the command service (now with automatic chaining support)
-	response, err := h.Service.HandleCommand(userID, req.Message)
+	response, err := h.Service.HandleCommand(userID, req.Message, req.PlanID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -3,6 +3,7 @@ package services
 import (
 	"fmt"
 	"smart-task-planner/internal/mcp"
+	"smart-task-planner/internal/modules/plan/forecast"
 	"smart-task-planner/internal/modules/plan/repository"
 )
 
@@ -14,11 +15,13 @@ func NewCommandService(repo *repository.PlanRepository) *CommandService {
 	return &CommandService{Repo: repo}
 }
 
-// HandleCommand interprets natural language and triggers MCP tools with smart chaining
-func (s *CommandService) HandleCommand(userID, message string) (map[string]interface{}, error) {
+// HandleCommand interprets natural language and triggers MCP tools with smart chaining.
+// planID is optional and pins the command to a specific plan instead of AI goal matching.
+func (s *CommandService) HandleCommand(userID, message, planID string) (map[string]interface{}, error) {
 	intent, err := mcp.RunTool("interpret_user_message", map[string]interface{}{
 		"user_id": userID,
 		"message": message,
+		"plan_id": planID,
 	}, s.Repo)
 	if err != nil {
 		return nil, fmt.Errorf("interpretation failed: %v", err)
@@ -56,10 +59,35 @@ func (s *CommandService) HandleCommand(userID, message string) (map[string]inter
 			return nil, fmt.Errorf("invalid progress data format")
 		}
 
-		// Chain with provide_feedback
-		feedback, err := mcp.RunTool("provide_feedback", map[string]interface{}{
+		// Goal matching wasn't confident; let the user pick before giving feedback
+		if ambiguous, _ := progressData["needs_disambiguation"].(bool); ambiguous {
+			return map[string]interface{}{
+				"interpreted_action": toolName,
+				"result":             progressData,
+			}, nil
+		}
+
+		feedbackParams := map[string]interface{}{
 			"progress_data": progressData,
-		}, s.Repo)
+		}
+
+		// Attach the completion forecast for the same plan when available
+		if planID, _ := progressData["plan_id"].(string); planID != "" {
+			fcResult, err := mcp.RunTool("forecast_completion", map[string]interface{}{
+				"user_id": userID,
+				"plan_id": planID,
+			}, s.Repo)
+			if err == nil {
+				if fcMap, ok := fcResult.(map[string]interface{}); ok {
+					if fcs, ok := fcMap["forecasts"].([]forecast.Forecast); ok && len(fcs) > 0 {
+						feedbackParams["forecast_data"] = fcs[0]
+					}
+				}
+			}
+		}
+
+		// Chain with provide_feedback
+		feedback, err := mcp.RunTool("provide_feedback", feedbackParams, s.Repo)
 
 		if err != nil {
 			return nil, fmt.Errorf("feedback generation failed: %v", err)
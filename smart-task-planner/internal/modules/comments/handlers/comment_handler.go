@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+
+	"smart-task-planner/internal/modules/comments/service"
+	planRepository "smart-task-planner/internal/modules/plan/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CommentHandler struct {
+	service *service.CommentService
+}
+
+func NewCommentHandler(svc *service.CommentService) *CommentHandler {
+	return &CommentHandler{service: svc}
+}
+
+// GetComments handles GET /api/plan/:id/comments?task_id=...; an empty
+// task_id narrows to the comments on the plan itself
+func (h *CommentHandler) GetComments(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var taskID *string
+	if v, ok := c.GetQuery("task_id"); ok {
+		taskID = &v
+	}
+
+	comments, err := h.service.List(userID, c.Param("id"), taskID)
+	if err != nil {
+		c.JSON(statusFor(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"comments": comments})
+}
+
+// AddComment handles POST /api/plan/:id/comments
+// ({"body": "Looks good, @priya?", "task_id": "...", "parent_id": "..."})
+func (h *CommentHandler) AddComment(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req service.CommentInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	comment, err := h.service.Add(userID, c.Param("id"), req)
+	if err != nil {
+		c.JSON(statusFor(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// EditComment handles PATCH /api/comments/:id ({"body": "..."})
+func (h *CommentHandler) EditComment(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req struct {
+		Body string `json:"body"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	comment, err := h.service.Edit(userID, c.Param("id"), req.Body)
+	if err != nil {
+		c.JSON(statusFor(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, comment)
+}
+
+// DeleteComment handles DELETE /api/comments/:id
+func (h *CommentHandler) DeleteComment(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	comment, err := h.service.Delete(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(statusFor(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, comment)
+}
+
+func statusFor(err error) int {
+	switch err {
+	case service.ErrNotAuthor, service.ErrCannotDelete, planRepository.ErrReadOnly, planRepository.ErrNotOwner:
+		return http.StatusForbidden
+	}
+	switch err.Error() {
+	case "comment not found", "plan not found", "task not found":
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Comment is a Markdown note on a plan or one of its tasks. A reply points
+// at the comment it answers. Edits keep the earlier bodies in History. A
+// deleted comment loses its body and history but stays in place, so its
+// replies keep a parent.
+type Comment struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	PlanID     string             `bson:"plan_id" json:"plan_id"`
+	TaskID     string             `bson:"task_id,omitempty" json:"task_id,omitempty"` // empty for comments on the plan itself
+	ParentID   string             `bson:"parent_id,omitempty" json:"parent_id,omitempty"`
+	AuthorID   string             `bson:"author_id" json:"author_id"`
+	AuthorName string             `bson:"author_name,omitempty" json:"author_name,omitempty"`
+	Body       string             `bson:"body" json:"body"`
+	Mentions   []string           `bson:"mentions,omitempty" json:"mentions,omitempty"` // user IDs
+	History    []Revision         `bson:"history,omitempty" json:"history,omitempty"`   // oldest first
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	EditedAt   *time.Time         `bson:"edited_at,omitempty" json:"edited_at,omitempty"`
+	DeletedAt  *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	DeletedBy  string             `bson:"deleted_by,omitempty" json:"deleted_by,omitempty"`
+
+	Replies []Comment `bson:"-" json:"replies,omitempty"`
+}
+
+// Revision is a body a comment had until it was edited at At
+type Revision struct {
+	Body string    `bson:"body" json:"body"`
+	At   time.Time `bson:"at" json:"at"`
+}
+
+// Deleted reports whether the comment was deleted
+func (c *Comment) Deleted() bool {
+	return c.DeletedAt != nil
+}
+
+// Threads nests replies under the comments they answer. Comments must be
+// sorted oldest first; replies whose parent is missing become top-level.
+func Threads(comments []Comment) []Comment {
+	children := map[string][]Comment{}
+	known := map[string]bool{}
+	for _, c := range comments {
+		known[c.ID.Hex()] = true
+	}
+	var roots []Comment
+	for _, c := range comments {
+		if c.ParentID != "" && known[c.ParentID] {
+			children[c.ParentID] = append(children[c.ParentID], c)
+		} else {
+			roots = append(roots, c)
+		}
+	}
+
+	var attach func(list []Comment) []Comment
+	attach = func(list []Comment) []Comment {
+		for i := range list {
+			list[i].Replies = attach(children[list[i].ID.Hex()])
+		}
+		return list
+	}
+	return attach(roots)
+}
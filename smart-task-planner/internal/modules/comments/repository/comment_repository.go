@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"smart-task-planner/internal/modules/comments/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CommentRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewCommentRepository(db *mongo.Database) *CommentRepository {
+	return &CommentRepository{Collection: db.Collection("comments")}
+}
+
+func (r *CommentRepository) EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.Collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "plan_id", Value: 1}, {Key: "task_id", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "plan_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	return err
+}
+
+func (r *CommentRepository) Create(c *models.Comment) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c.ID = primitive.NewObjectID()
+	_, err := r.Collection.InsertOne(ctx, c)
+	return err
+}
+
+func (r *CommentRepository) Get(commentID string) (*models.Comment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(commentID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid comment ID: %v", err)
+	}
+
+	var c models.Comment
+	if err := r.Collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&c); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("comment not found")
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ForPlan returns a plan's comments oldest first. A non-nil taskID narrows
+// them to one task, "" to the comments on the plan itself.
+func (r *CommentRepository) ForPlan(planID string, taskID *string) ([]models.Comment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"plan_id": planID}
+	if taskID != nil {
+		if *taskID == "" {
+			filter["task_id"] = bson.M{"$exists": false}
+		} else {
+			filter["task_id"] = *taskID
+		}
+	}
+	cursor, err := r.Collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	comments := []models.Comment{}
+	if err := cursor.All(ctx, &comments); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// Recent returns the newest comments that aren't deleted across planIDs,
+// newest first
+func (r *CommentRepository) Recent(planIDs []string, limit int64) ([]models.Comment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := r.Collection.Find(ctx,
+		bson.M{"plan_id": bson.M{"$in": planIDs}, "deleted_at": bson.M{"$exists": false}},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	comments := []models.Comment{}
+	if err := cursor.All(ctx, &comments); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// Edit replaces the body of a comment that isn't deleted and keeps the old
+// one as a revision
+func (r *CommentRepository) Edit(c *models.Comment, body string, mentions []string, at time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rev := models.Revision{Body: c.Body, At: at}
+	res, err := r.Collection.UpdateOne(ctx,
+		bson.M{"_id": c.ID, "body": c.Body, "deleted_at": bson.M{"$exists": false}},
+		bson.M{
+			"$set":  bson.M{"body": body, "mentions": mentions, "edited_at": at},
+			"$push": bson.M{"history": rev},
+		})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("comment was changed or deleted meanwhile, try again")
+	}
+	c.History = append(c.History, rev)
+	c.Body, c.Mentions, c.EditedAt = body, mentions, &at
+	return nil
+}
+
+// Delete empties a comment and drops its history, so nothing it said can
+// be read afterwards
+func (r *CommentRepository) Delete(c *models.Comment, by string, at time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := r.Collection.UpdateOne(ctx,
+		bson.M{"_id": c.ID, "deleted_at": bson.M{"$exists": false}},
+		bson.M{
+			"$set":   bson.M{"body": "", "deleted_at": at, "deleted_by": by},
+			"$unset": bson.M{"mentions": "", "history": ""},
+		})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("comment not found")
+	}
+	c.Body, c.Mentions, c.History, c.DeletedAt, c.DeletedBy = "", nil, nil, &at, by
+	return nil
+}
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"smart-task-planner/internal/middleware"
+	"smart-task-planner/internal/modules/comments/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterCommentRoutes(router *gin.Engine, handler *handlers.CommentHandler) {
+	api := router.Group("/api")
+	api.Use(middleware.JWTAuth())
+	{
+		// Threads on a plan and its tasks
+		api.GET("/plan/:id/comments", handler.GetComments)
+		api.POST("/plan/:id/comments", handler.AddComment)
+
+		// Only the author edits; the author or a plan owner deletes
+		api.PATCH("/comments/:id", handler.EditComment)
+		api.DELETE("/comments/:id", handler.DeleteComment)
+	}
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"smart-task-planner/internal/events"
+	"smart-task-planner/internal/modules/comments/models"
+	"smart-task-planner/internal/modules/comments/repository"
+	notificationModels "smart-task-planner/internal/modules/notifications/models"
+	notificationRepository "smart-task-planner/internal/modules/notifications/repository"
+	planModels "smart-task-planner/internal/modules/plan/models"
+	"smart-task-planner/internal/modules/plan/people"
+	planRepository "smart-task-planner/internal/modules/plan/repository"
+)
+
+const (
+	maxBodyLength = 10000
+	excerptLength = 140
+)
+
+var (
+	ErrNotAuthor    = fmt.Errorf("only the author can edit a comment")
+	ErrCannotDelete = fmt.Errorf("only the author or an owner of the plan can delete a comment")
+)
+
+// mentionRe finds @priya, @priya.patel and @priya@example.com, but not the
+// domain of an email address written in the text
+var mentionRe = regexp.MustCompile(`(?:^|[^\w@.])@(\w[\w.+-]*(?:@[\w-]+(?:\.[\w-]+)+)?)`)
+
+type CommentService struct {
+	Repo          *repository.CommentRepository
+	PlanRepo      *planRepository.PlanRepository
+	Notifications *notificationRepository.NotificationRepository
+}
+
+func NewCommentService(repo *repository.CommentRepository, planRepo *planRepository.PlanRepository, notifications *notificationRepository.NotificationRepository) *CommentService {
+	return &CommentService{Repo: repo, PlanRepo: planRepo, Notifications: notifications}
+}
+
+// CommentInput is a new comment. Without TaskID it is on the plan itself;
+// with ParentID it answers that comment, on the same task.
+type CommentInput struct {
+	TaskID   string `json:"task_id"`
+	ParentID string `json:"parent_id"`
+	Body     string `json:"body"`
+}
+
+// List returns a plan's comment threads, oldest first. A non-nil taskID
+// narrows them to one task, "" to the comments on the plan itself.
+func (s *CommentService) List(userID, planID string, taskID *string) ([]models.Comment, error) {
+	plan, err := s.PlanRepo.GetByIDForUser(planID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if taskID != nil && *taskID != "" {
+		if _, ok := planRepository.Flatten(plan.Tasks)[*taskID]; !ok {
+			return nil, fmt.Errorf("task not found")
+		}
+	}
+	comments, err := s.Repo.ForPlan(plan.ID.Hex(), taskID)
+	if err != nil {
+		return nil, err
+	}
+	return models.Threads(comments), nil
+}
+
+// Add comments on a plan or one of its tasks. Everyone who can see the plan
+// can comment, viewers included. Mentioned users are notified.
+func (s *CommentService) Add(userID, planID string, in CommentInput) (*models.Comment, error) {
+	body, err := cleanBody(in.Body)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := s.PlanRepo.GetByIDForUser(planID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.ParentID != "" {
+		parent, err := s.Repo.Get(in.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.PlanID != plan.ID.Hex() {
+			return nil, fmt.Errorf("comment not found")
+		}
+		if in.TaskID == "" {
+			in.TaskID = parent.TaskID
+		} else if in.TaskID != parent.TaskID {
+			return nil, fmt.Errorf("a reply must be on the same task as the comment it answers")
+		}
+	}
+	if in.TaskID != "" {
+		if _, ok := planRepository.Flatten(plan.Tasks)[in.TaskID]; !ok {
+			return nil, fmt.Errorf("task not found")
+		}
+	}
+
+	audience, err := people.Of(plan, s.PlanRepo)
+	if err != nil {
+		return nil, err
+	}
+	c := &models.Comment{
+		PlanID:     plan.ID.Hex(),
+		TaskID:     in.TaskID,
+		ParentID:   in.ParentID,
+		AuthorID:   userID,
+		AuthorName: authorName(audience, userID),
+		Body:       body,
+		Mentions:   mentions(audience, body, userID),
+		CreatedAt:  time.Now(),
+	}
+	if err := s.Repo.Create(c); err != nil {
+		return nil, err
+	}
+
+	s.notify(plan, c, c.Mentions)
+	s.publish(events.CommentCreated, plan, c, userID)
+	return c, nil
+}
+
+// Edit replaces the body of the user's own comment. The old body is kept in
+// its history, and only people mentioned for the first time are notified.
+func (s *CommentService) Edit(userID, commentID, body string) (*models.Comment, error) {
+	c, plan, err := s.load(userID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if c.AuthorID != userID {
+		return nil, ErrNotAuthor
+	}
+	if c.Deleted() {
+		return nil, fmt.Errorf("deleted comments can't be edited")
+	}
+	if body, err = cleanBody(body); err != nil {
+		return nil, err
+	}
+	if body == c.Body {
+		return c, nil
+	}
+
+	audience, err := people.Of(plan, s.PlanRepo)
+	if err != nil {
+		return nil, err
+	}
+	before := c.Mentions
+	if err := s.Repo.Edit(c, body, mentions(audience, body, userID), time.Now()); err != nil {
+		return nil, err
+	}
+
+	var added []string
+	for _, id := range c.Mentions {
+		if !slices.Contains(before, id) {
+			added = append(added, id)
+		}
+	}
+	s.notify(plan, c, added)
+	s.publish(events.CommentUpdated, plan, c, userID)
+	return c, nil
+}
+
+// Delete empties a comment and its history but keeps it, so replies stay in
+// their thread. Authors delete their own comments, plan owners any.
+func (s *CommentService) Delete(userID, commentID string) (*models.Comment, error) {
+	c, plan, err := s.load(userID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if c.AuthorID != userID && plan.Role != planModels.RoleOwner {
+		return nil, ErrCannotDelete
+	}
+	if err := s.Repo.Delete(c, userID, time.Now()); err != nil {
+		return nil, err
+	}
+
+	s.publish(events.CommentDeleted, plan, c, userID)
+	return c, nil
+}
+
+// load returns a comment and its plan, as long as the user can see the plan
+func (s *CommentService) load(userID, commentID string) (*models.Comment, *planModels.Plan, error) {
+	c, err := s.Repo.Get(commentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	plan, err := s.PlanRepo.GetByIDForUser(c.PlanID, userID)
+	if err != nil {
+		if err.Error() == "plan not found" {
+			return nil, nil, fmt.Errorf("comment not found")
+		}
+		return nil, nil, err
+	}
+	return c, plan, nil
+}
+
+// notify creates a mention notification for each of userIDs except the
+// author. They go out with the user's other notifications, so quiet hours
+// and digests apply.
+func (s *CommentService) notify(plan *planModels.Plan, c *models.Comment, userIDs []string) {
+	if s.Notifications == nil {
+		return
+	}
+	on := fmt.Sprintf("%q", plan.Goal)
+	if ft, ok := planRepository.Flatten(plan.Tasks)[c.TaskID]; ok {
+		on = fmt.Sprintf("%q (%s)", ft.Task.Title, plan.Goal)
+	}
+	for _, id := range userIDs {
+		if id == c.AuthorID {
+			continue
+		}
+		n := &notificationModels.Notification{
+			UserID:    id,
+			Kind:      notificationModels.KindMention,
+			Title:     fmt.Sprintf("%s mentioned you on %s", c.AuthorName, on),
+			Message:   excerpt(c.Body),
+			PlanID:    c.PlanID,
+			TaskID:    c.TaskID,
+			DedupeKey: "mention:" + c.ID.Hex(),
+			Status:    notificationModels.StatusPending,
+			CreatedAt: time.Now(),
+		}
+		if _, err := s.Notifications.Insert(n); err != nil {
+			log.Printf("❌ Failed to notify user %s of a mention: %v", id, err)
+		}
+	}
+}
+
+func (s *CommentService) publish(eventType string, plan *planModels.Plan, c *models.Comment, actorID string) {
+	events.Publish(events.Event{
+		Type:   eventType,
+		UserID: plan.UserID,
+		PlanID: c.PlanID,
+		Data: map[string]interface{}{
+			"plan_id":  c.PlanID,
+			"task_id":  c.TaskID,
+			"actor_id": actorID,
+			"comment":  c,
+		},
+	})
+}
+
+// mentions resolves the @names in body to people who can see the plan.
+// A name that matches nobody, or more than one person, stays plain text.
+func mentions(audience []people.Person, body, authorID string) []string {
+	var ids []string
+	for _, m := range mentionRe.FindAllStringSubmatch(body, -1) {
+		matches := people.Match(audience, strings.TrimRight(m[1], ".-"), authorID)
+		if len(matches) == 1 && !slices.Contains(ids, matches[0].UserID) {
+			ids = append(ids, matches[0].UserID)
+		}
+	}
+	return ids
+}
+
+func authorName(audience []people.Person, userID string) string {
+	for _, p := range audience {
+		if p.UserID == userID {
+			return p.DisplayName()
+		}
+	}
+	return ""
+}
+
+func cleanBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return "", fmt.Errorf("comment body is required")
+	}
+	if utf8.RuneCountInString(body) > maxBodyLength {
+		return "", fmt.Errorf("comment body must be at most %d characters", maxBodyLength)
+	}
+	return body, nil
+}
+
+// excerpt is the start of a body on one line, for notifications
+func excerpt(body string) string {
+	text := strings.Join(strings.Fields(body), " ")
+	if utf8.RuneCountInString(text) <= excerptLength {
+		return text
+	}
+	return string([]rune(text)[:excerptLength-1]) + "…"
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+
+	"smart-task-planner/internal/modules/estimates/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EstimateHandler struct {
+	service *service.EstimateService
+}
+
+func NewEstimateHandler(svc *service.EstimateService) *EstimateHandler {
+	return &EstimateHandler{service: svc}
+}
+
+// GetProfile handles GET /api/estimates/profile
+func (h *EstimateHandler) GetProfile(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	profile, err := h.service.Profile(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// Estimate handles POST /api/estimates
+func (h *EstimateHandler) Estimate(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req struct {
+		Tasks []service.TaskInput `json:"tasks" binding:"required,min=1,max=100,dive"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tasks, err := h.service.Estimate(userID, req.Tasks)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tasks": tasks})
+}
+
+// GetPlanEstimates handles GET /api/estimates/plans/:id
+func (h *EstimateHandler) GetPlanEstimates(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	tasks, err := h.service.Plan(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"plan_id": c.Param("id"), "tasks": tasks})
+}
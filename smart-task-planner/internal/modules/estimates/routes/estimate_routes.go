@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"smart-task-planner/internal/middleware"
+	"smart-task-planner/internal/modules/estimates/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterEstimateRoutes(router *gin.Engine, handler *handlers.EstimateHandler) {
+	api := router.Group("/api/estimates")
+	api.Use(middleware.JWTAuth())
+	{
+		api.GET("/profile", handler.GetProfile)
+		api.POST("/", handler.Estimate)
+		api.GET("/plans/:id", handler.GetPlanEstimates)
+	}
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"smart-task-planner/internal/modules/plan/ai"
+	"smart-task-planner/internal/modules/plan/estimate"
+	"smart-task-planner/internal/modules/plan/models"
+	"smart-task-planner/internal/modules/plan/progress"
+	"smart-task-planner/internal/modules/plan/repository"
+	timeRepository "smart-task-planner/internal/modules/timetracking/repository"
+)
+
+type EstimateService struct {
+	Repo     *repository.PlanRepository
+	TimeRepo *timeRepository.TimeEntryRepository
+}
+
+func NewEstimateService(repo *repository.PlanRepository, timeRepo *timeRepository.TimeEntryRepository) *EstimateService {
+	return &EstimateService{Repo: repo, TimeRepo: timeRepo}
+}
+
+// TaskInput is a task to estimate. EstimatedHours is the user's own guess
+// and may be 0.
+type TaskInput struct {
+	Title          string  `json:"title" binding:"required"`
+	Description    string  `json:"description"`
+	EstimatedHours float64 `json:"estimated_hours"`
+}
+
+// Calibrated is one task's learned estimate
+type Calibrated struct {
+	TaskID          string              `json:"task_id,omitempty"`
+	Title           string              `json:"title"`
+	EstimatedHours  float64             `json:"estimated_hours"` // as given
+	CalibratedHours float64             `json:"calibrated_hours"`
+	Estimate        models.EstimateInfo `json:"estimate"`
+}
+
+// Profile returns the user's learned correction factor with the finished
+// tasks it came from
+func (s *EstimateService) Profile(userID string) (*estimate.Profile, error) {
+	plans, err := s.Repo.GetAllByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user plans: %v", err)
+	}
+	hours, err := s.TimeRepo.HoursByTask(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tracked time: %v", err)
+	}
+	return estimate.Learn(plans, userID, hours, ai.DefaultEmbedder())
+}
+
+// Estimate calibrates tasks that aren't in a plan yet
+func (s *EstimateService) Estimate(userID string, inputs []TaskInput) ([]Calibrated, error) {
+	profile, err := s.Profile(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]models.Task, len(inputs))
+	for i, in := range inputs {
+		if in.EstimatedHours < 0 {
+			return nil, fmt.Errorf("estimated_hours can't be negative")
+		}
+		tasks[i] = models.Task{Title: in.Title, Description: in.Description, EstimatedHours: in.EstimatedHours}
+	}
+	return calibrated(profile, tasks), nil
+}
+
+// Plan calibrates the open tasks of a plan. Their stored estimates are
+// left alone.
+func (s *EstimateService) Plan(userID, planID string) ([]Calibrated, error) {
+	plan, err := s.Repo.GetByIDForUser(planID, userID)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := s.Profile(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// leaf tasks only; a parent's effort is its subtasks'
+	var open []models.Task
+	var walk func([]models.Task)
+	walk = func(tasks []models.Task) {
+		for _, t := range tasks {
+			walk(t.SubTasks)
+			if len(t.SubTasks) == 0 && !progress.IsCompleted(t) && !strings.EqualFold(t.Status, "Cancelled") {
+				open = append(open, t)
+			}
+		}
+	}
+	walk(plan.Tasks)
+	return calibrated(profile, open), nil
+}
+
+func calibrated(profile *estimate.Profile, tasks []models.Task) []Calibrated {
+	hours, infos := profile.EstimateTasks(tasks)
+	out := make([]Calibrated, len(tasks))
+	for i, t := range tasks {
+		out[i] = Calibrated{
+			Title:           t.Title,
+			EstimatedHours:  estimate.RawHours(t),
+			CalibratedHours: hours[i],
+			Estimate:        infos[i],
+		}
+		if !t.ID.IsZero() {
+			out[i].TaskID = t.ID.Hex()
+		}
+	}
+	return out
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+
+	"smart-task-planner/internal/modules/fields/models"
+	"smart-task-planner/internal/modules/fields/repository"
+	"smart-task-planner/internal/modules/fields/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FieldHandler struct {
+	service *service.FieldService
+}
+
+func NewFieldHandler(svc *service.FieldService) *FieldHandler {
+	return &FieldHandler{service: svc}
+}
+
+// GetFields handles GET /api/fields
+func (h *FieldHandler) GetFields(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	defs, err := h.service.List(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"fields": defs})
+}
+
+// CreateField handles POST /api/fields
+func (h *FieldHandler) CreateField(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req struct {
+		Key     string   `json:"key" binding:"required"`
+		Name    string   `json:"name"`
+		Type    string   `json:"type" binding:"required"`
+		Options []string `json:"options"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	d, err := h.service.Create(userID, models.FieldDefinition{Key: req.Key, Name: req.Name, Type: req.Type, Options: req.Options})
+	if err == repository.ErrFieldExists {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, d)
+}
+
+// UpdateField handles PATCH /api/fields/:key
+func (h *FieldHandler) UpdateField(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req service.FieldUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	d, err := h.service.Update(userID, c.Param("key"), req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "field not found" {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, d)
+}
+
+// DeleteField handles DELETE /api/fields/:key
+func (h *FieldHandler) DeleteField(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	if err := h.service.Delete(userID, c.Param("key")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Field deleted"})
+}
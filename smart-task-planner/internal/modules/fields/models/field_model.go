@@ -0,0 +1,125 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Field types
+const (
+	TypeText   = "text"
+	TypeNumber = "number"
+	TypeDate   = "date"
+	TypeSelect = "select"
+)
+
+const maxTextLength = 500
+
+var keyRe = regexp.MustCompile(`^[a-z][a-z0-9_]{0,39}$`)
+
+// FieldDefinition is a custom task field a user defined. Tasks store values
+// under Key.
+type FieldDefinition struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    string             `bson:"user_id" json:"-"`
+	Key       string             `bson:"key" json:"key"`
+	Name      string             `bson:"name" json:"name"`
+	Type      string             `bson:"type" json:"type"`
+	Options   []string           `bson:"options,omitempty" json:"options,omitempty"` // the choices of a select field
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// Validate checks a definition and tidies its name and options
+func (d *FieldDefinition) Validate() error {
+	d.Key = strings.TrimSpace(d.Key)
+	if !keyRe.MatchString(d.Key) {
+		return fmt.Errorf("key must be lower-case letters, digits or _ and start with a letter")
+	}
+	if d.Name = strings.TrimSpace(d.Name); d.Name == "" {
+		d.Name = d.Key
+	}
+
+	switch d.Type {
+	case TypeText, TypeNumber, TypeDate:
+		d.Options = nil
+	case TypeSelect:
+		var options []string
+		seen := map[string]bool{}
+		for _, o := range d.Options {
+			o = strings.TrimSpace(o)
+			if o == "" || seen[strings.ToLower(o)] {
+				continue
+			}
+			seen[strings.ToLower(o)] = true
+			options = append(options, o)
+		}
+		if len(options) == 0 {
+			return fmt.Errorf("a select field needs options")
+		}
+		d.Options = options
+	default:
+		return fmt.Errorf("type must be text, number, date or select")
+	}
+	return nil
+}
+
+// Coerce checks a value against the field's type and returns it in its
+// stored form: a float64 for numbers, a YYYY-MM-DD string for dates, the
+// option as defined for selects.
+func (d *FieldDefinition) Coerce(v interface{}) (interface{}, error) {
+	switch d.Type {
+	case TypeText:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s must be text", d.Key)
+		}
+		if s = strings.TrimSpace(s); len(s) > maxTextLength {
+			return nil, fmt.Errorf("%s is longer than %d characters", d.Key, maxTextLength)
+		}
+		return s, nil
+	case TypeNumber:
+		switch n := v.(type) {
+		case float64:
+			return n, nil
+		case int:
+			return float64(n), nil
+		case int64:
+			return float64(n), nil
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(n), 64); err == nil {
+				return f, nil
+			}
+		}
+		return nil, fmt.Errorf("%s must be a number", d.Key)
+	case TypeDate:
+		switch t := v.(type) {
+		case time.Time:
+			return t.Format("2006-01-02"), nil
+		case string:
+			t = strings.TrimSpace(t)
+			if parsed, err := time.Parse("2006-01-02", t); err == nil {
+				return parsed.Format("2006-01-02"), nil
+			}
+			if parsed, err := time.Parse(time.RFC3339, t); err == nil {
+				return parsed.Format("2006-01-02"), nil
+			}
+		}
+		return nil, fmt.Errorf("%s must be a date like 2025-10-31", d.Key)
+	case TypeSelect:
+		if s, ok := v.(string); ok {
+			for _, o := range d.Options {
+				if strings.EqualFold(o, strings.TrimSpace(s)) {
+					return o, nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("%s must be one of %s", d.Key, strings.Join(d.Options, ", "))
+	}
+	return nil, fmt.Errorf("unknown field type %q", d.Type)
+}
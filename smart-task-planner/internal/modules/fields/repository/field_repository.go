@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"smart-task-planner/internal/modules/fields/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrFieldExists is returned when a user already has a field with the key
+var ErrFieldExists = fmt.Errorf("a field with this key already exists")
+
+type FieldRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewFieldRepository(db *mongo.Database) *FieldRepository {
+	return &FieldRepository{Collection: db.Collection("field_definitions")}
+}
+
+func (r *FieldRepository) EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.Collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "key", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// List returns the user's field definitions in the order they were created
+func (r *FieldRepository) List(userID string) ([]models.FieldDefinition, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := r.Collection.Find(ctx, bson.M{"user_id": userID}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	defs := []models.FieldDefinition{}
+	if err := cursor.All(ctx, &defs); err != nil {
+		return nil, err
+	}
+	return defs, nil
+}
+
+// ByKey returns the user's definitions indexed by key
+func (r *FieldRepository) ByKey(userID string) (map[string]models.FieldDefinition, error) {
+	defs, err := r.List(userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]models.FieldDefinition, len(defs))
+	for _, d := range defs {
+		out[d.Key] = d
+	}
+	return out, nil
+}
+
+func (r *FieldRepository) Get(userID, key string) (*models.FieldDefinition, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var d models.FieldDefinition
+	if err := r.Collection.FindOne(ctx, bson.M{"user_id": userID, "key": key}).Decode(&d); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("field not found")
+		}
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *FieldRepository) Insert(d *models.FieldDefinition) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	d.ID = primitive.NewObjectID()
+	if _, err := r.Collection.InsertOne(ctx, d); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrFieldExists
+		}
+		return err
+	}
+	return nil
+}
+
+// Save replaces a stored definition
+func (r *FieldRepository) Save(d *models.FieldDefinition) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := r.Collection.ReplaceOne(ctx, bson.M{"_id": d.ID, "user_id": d.UserID}, d)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("field not found")
+	}
+	return nil
+}
+
+func (r *FieldRepository) Delete(userID, key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := r.Collection.DeleteOne(ctx, bson.M{"user_id": userID, "key": key})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return fmt.Errorf("field not found")
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"smart-task-planner/internal/middleware"
+	"smart-task-planner/internal/modules/fields/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterFieldRoutes(router *gin.Engine, handler *handlers.FieldHandler) {
+	api := router.Group("/api/fields")
+	api.Use(middleware.JWTAuth())
+	{
+		// per-user custom task field definitions
+		api.GET("/", handler.GetFields)
+		api.POST("/", handler.CreateField)
+		api.PATCH("/:key", handler.UpdateField)
+		api.DELETE("/:key", handler.DeleteField)
+	}
+}
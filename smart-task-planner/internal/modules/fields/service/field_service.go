@@ -0,0 +1,82 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"smart-task-planner/internal/modules/fields/models"
+	"smart-task-planner/internal/modules/fields/repository"
+)
+
+// maxFields caps the custom fields per user
+const maxFields = 50
+
+type FieldService struct {
+	Repo *repository.FieldRepository
+}
+
+func NewFieldService(repo *repository.FieldRepository) *FieldService {
+	return &FieldService{Repo: repo}
+}
+
+func (s *FieldService) List(userID string) ([]models.FieldDefinition, error) {
+	return s.Repo.List(userID)
+}
+
+func (s *FieldService) Create(userID string, d models.FieldDefinition) (*models.FieldDefinition, error) {
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+	existing, err := s.Repo.List(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= maxFields {
+		return nil, fmt.Errorf("you can define at most %d fields", maxFields)
+	}
+
+	now := time.Now()
+	d.UserID, d.CreatedAt, d.UpdatedAt = userID, now, now
+	if err := s.Repo.Insert(&d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// FieldUpdate renames a field or changes the options of a select field.
+// Keys and types are fixed because tasks store values under them.
+type FieldUpdate struct {
+	Name    *string   `json:"name"`
+	Options *[]string `json:"options"`
+}
+
+func (s *FieldService) Update(userID, key string, req FieldUpdate) (*models.FieldDefinition, error) {
+	d, err := s.Repo.Get(userID, key)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		d.Name = *req.Name
+	}
+	if req.Options != nil {
+		if d.Type != models.TypeSelect {
+			return nil, fmt.Errorf("only select fields have options")
+		}
+		d.Options = *req.Options
+	}
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+
+	d.UpdatedAt = time.Now()
+	if err := s.Repo.Save(d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Delete removes a definition. Values already on tasks are kept but no
+// longer validated or offered to the AI.
+func (s *FieldService) Delete(userID, key string) error {
+	return s.Repo.Delete(userID, key)
+}
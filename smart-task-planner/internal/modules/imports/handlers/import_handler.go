@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"smart-task-planner/internal/modules/imports/service"
+	"smart-task-planner/internal/modules/plan/importers"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxImportBytes = 20 << 20
+
+type ImportHandler struct {
+	service *service.ImportService
+}
+
+func NewImportHandler(svc *service.ImportService) *ImportHandler {
+	return &ImportHandler{service: svc}
+}
+
+// Sources lists the supported export formats
+func (h *ImportHandler) Sources(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"sources": h.service.Sources()})
+}
+
+// Preview handles POST /api/import/preview?source=...; nothing is saved
+func (h *ImportHandler) Preview(c *gin.Context) {
+	data, filename, ok := readUpload(c)
+	if !ok {
+		return
+	}
+
+	res, err := h.service.Preview(param(c, "source"), data, filename, options(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"preview":    res,
+		"plan_count": len(res.Plans),
+		"task_count": res.TaskCount(),
+	})
+}
+
+// Import handles POST /api/import/?source=...&plans=0,2
+func (h *ImportHandler) Import(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	data, filename, ok := readUpload(c)
+	if !ok {
+		return
+	}
+
+	var selected []int
+	if v := param(c, "plans"); v != "" {
+		for _, part := range strings.Split(v, ",") {
+			i, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "plans must be a comma separated list of preview indexes"})
+				return
+			}
+			selected = append(selected, i)
+		}
+	}
+
+	res, err := h.service.Import(userID, param(c, "source"), data, filename, options(c), selected)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+// readUpload takes a multipart "file" field or the raw body
+func readUpload(c *gin.Context) ([]byte, string, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+
+	var data []byte
+	var filename string
+	var err error
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fileHeader, ferr := c.FormFile("file")
+		if ferr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file field required"})
+			return nil, "", false
+		}
+		f, ferr := fileHeader.Open()
+		if ferr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": ferr.Error()})
+			return nil, "", false
+		}
+		defer f.Close()
+		filename = fileHeader.Filename
+		data, err = io.ReadAll(f)
+	} else {
+		data, err = io.ReadAll(c.Request.Body)
+	}
+	if err != nil || len(data) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Export file required (max 20 MB)"})
+		return nil, "", false
+	}
+	return data, filename, true
+}
+
+func options(c *gin.Context) importers.Options {
+	split, _ := strconv.ParseBool(param(c, "split_lists"))
+	return importers.Options{Goal: param(c, "goal"), SplitLists: split}
+}
+
+// param reads a multipart form value, falling back to the query string
+func param(c *gin.Context, key string) string {
+	return c.DefaultPostForm(key, c.Query(key))
+}
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"smart-task-planner/internal/middleware"
+	"smart-task-planner/internal/modules/imports/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterImportRoutes(router *gin.Engine, handler *handlers.ImportHandler) {
+	api := router.Group("/api/import")
+	api.Use(middleware.JWTAuth())
+	{
+		api.GET("/sources", handler.Sources)
+
+		// Map an export and show what would be created
+		api.POST("/preview", handler.Preview)
+
+		// Map and save
+		api.POST("/", handler.Import)
+	}
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"fmt"
+
+	"smart-task-planner/internal/modules/plan/importers"
+	"smart-task-planner/internal/modules/plan/models"
+	"smart-task-planner/internal/modules/plan/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ImportService struct {
+	Repo *repository.PlanRepository
+}
+
+func NewImportService(repo *repository.PlanRepository) *ImportService {
+	return &ImportService{Repo: repo}
+}
+
+// Imported is the outcome of a committed import.
+type Imported struct {
+	Source   string               `json:"source"`
+	Plans    []models.Plan        `json:"plans"`
+	Unmapped []importers.Unmapped `json:"unmapped_fields"`
+	Skipped  int                  `json:"skipped"`
+	Warnings []string             `json:"warnings,omitempty"`
+}
+
+func (s *ImportService) Sources() []string {
+	return importers.Names()
+}
+
+// Preview maps an export without saving anything
+func (s *ImportService) Preview(source string, data []byte, filename string, opts importers.Options) (*importers.Result, error) {
+	return importers.Run(source, data, filename, opts)
+}
+
+// Import maps an export and saves the resulting plans for the user. When
+// selected is non-empty only the plans at those preview indexes are saved.
+func (s *ImportService) Import(userID, source string, data []byte, filename string, opts importers.Options, selected []int) (*Imported, error) {
+	res, err := importers.Run(source, data, filename, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	plans := res.Plans
+	if len(selected) > 0 {
+		plans = nil
+		for _, i := range selected {
+			if i < 0 || i >= len(res.Plans) {
+				return nil, fmt.Errorf("plan index %d out of range (0-%d)", i, len(res.Plans)-1)
+			}
+			plans = append(plans, res.Plans[i])
+		}
+	}
+	if len(plans) == 0 {
+		return nil, fmt.Errorf("nothing to import")
+	}
+
+	out := &Imported{
+		Source:   res.Source,
+		Plans:    []models.Plan{},
+		Unmapped: res.Unmapped,
+		Skipped:  res.Skipped,
+		Warnings: res.Warnings,
+	}
+	for _, p := range plans {
+		plan := p
+		plan.UserID = userID
+		assignIDs(plan.Tasks)
+		if err := s.Repo.Create(&plan); err != nil {
+			return out, fmt.Errorf("saved %d of %d plans: %v", len(out.Plans), len(plans), err)
+		}
+		out.Plans = append(out.Plans, plan)
+	}
+	return out, nil
+}
+
+func assignIDs(tasks []models.Task) {
+	for i := range tasks {
+		tasks[i].ID = primitive.NewObjectID()
+		assignIDs(tasks[i].SubTasks)
+	}
+}
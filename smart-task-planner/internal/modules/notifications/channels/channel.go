@@ -0,0 +1,44 @@
+package channels
+
+import (
+	"time"
+
+	"smart-task-planner/internal/modules/notifications/models"
+	"smart-task-planner/internal/modules/notifications/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Recipient is who a batch of notifications is for
+type Recipient struct {
+	UserID   string
+	Name     string
+	Email    string
+	Location *time.Location
+}
+
+// Channel delivers notifications somewhere. A batch holds one notification,
+// or a whole day's worth when the user is on the digest.
+type Channel interface {
+	Name() string
+	Send(to Recipient, batch []models.Notification, digest bool) error
+}
+
+// InApp puts notifications in the /api/notifications inbox
+type InApp struct {
+	Repo *repository.NotificationRepository
+}
+
+func NewInApp(repo *repository.NotificationRepository) *InApp {
+	return &InApp{Repo: repo}
+}
+
+func (c *InApp) Name() string { return models.ChannelInApp }
+
+func (c *InApp) Send(to Recipient, batch []models.Notification, digest bool) error {
+	ids := make([]primitive.ObjectID, len(batch))
+	for i, n := range batch {
+		ids[i] = n.ID
+	}
+	return c.Repo.ShowInApp(ids)
+}
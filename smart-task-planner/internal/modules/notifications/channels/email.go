@@ -0,0 +1,151 @@
+package channels
+
+import (
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+
+	"smart-task-planner/config"
+	"smart-task-planner/internal/modules/notifications/models"
+)
+
+// SMTPConfig is read from SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD
+// and SMTP_FROM. Port 465 uses implicit TLS, other ports STARTTLS when offered.
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// SMTPConfigFromEnv returns nil when SMTP_HOST isn't set, which disables email
+func SMTPConfigFromEnv() *SMTPConfig {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return nil
+	}
+	cfg := &SMTPConfig{
+		Host:     host,
+		Port:     os.Getenv("SMTP_PORT"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	}
+	if cfg.Port == "" {
+		cfg.Port = "587"
+	}
+	if cfg.From == "" {
+		cfg.From = cfg.Username
+	}
+	return cfg
+}
+
+// Email sends notifications over SMTP, one message per batch
+type Email struct {
+	cfg *SMTPConfig
+}
+
+func NewEmail(cfg *SMTPConfig) *Email {
+	return &Email{cfg: cfg}
+}
+
+func (c *Email) Name() string { return models.ChannelEmail }
+
+func (c *Email) Send(to Recipient, batch []models.Notification, digest bool) error {
+	if to.Email == "" {
+		return fmt.Errorf("user has no email address")
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+
+	subject := batch[0].Title
+	if digest || len(batch) > 1 {
+		subject = fmt.Sprintf("Your daily digest: %d task alert(s)", len(batch))
+	}
+	return c.send(to.Email, subject, emailBody(to, batch))
+}
+
+func emailBody(to Recipient, batch []models.Notification) string {
+	var b strings.Builder
+	if to.Name != "" {
+		fmt.Fprintf(&b, "Hi %s,\n\n", to.Name)
+	}
+	for _, n := range batch {
+		fmt.Fprintf(&b, "* %s\n  %s\n", n.Title, n.Message)
+		if n.PlanID != "" {
+			fmt.Fprintf(&b, "  %s/api/plan/%s\n", config.AppConfig.BaseURL, n.PlanID)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("-- \nSmart Task Planner\nChange when and how you are notified: PUT /api/notifications/preferences\n")
+	return b.String()
+}
+
+// SendMessage sends a plain message outside of notification batches
+func (c *Email) SendMessage(to, subject, body string) error {
+	return c.send(to, subject, body)
+}
+
+func (c *Email) send(to, subject, body string) error {
+	msg := strings.Join([]string{
+		"From: " + c.cfg.From,
+		"To: " + to,
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"Content-Transfer-Encoding: 8bit",
+		"",
+		strings.ReplaceAll(body, "\n", "\r\n"),
+	}, "\r\n")
+
+	var auth smtp.Auth
+	if c.cfg.Username != "" {
+		auth = smtp.PlainAuth("", c.cfg.Username, c.cfg.Password, c.cfg.Host)
+	}
+	addr := net.JoinHostPort(c.cfg.Host, c.cfg.Port)
+	if c.cfg.Port != "465" {
+		return smtp.SendMail(addr, auth, c.cfg.From, []string{to}, []byte(msg))
+	}
+
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 30 * time.Second}, "tcp", addr, &tls.Config{ServerName: c.cfg.Host})
+	if err != nil {
+		return err
+	}
+	client, err := smtp.NewClient(conn, c.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(c.cfg.From); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte(msg)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
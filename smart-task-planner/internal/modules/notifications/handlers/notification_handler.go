@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"smart-task-planner/internal/modules/notifications/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	service *service.NotificationService
+}
+
+func NewNotificationHandler(svc *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{service: svc}
+}
+
+// Inbox handles GET /api/notifications?unread=true&limit=20
+func (h *NotificationHandler) Inbox(c *gin.Context) {
+	userID := c.GetString("user_id")
+	unreadOnly := c.Query("unread") == "true"
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	list, unread, err := h.service.Inbox(userID, unreadOnly, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"notifications": list, "count": len(list), "unread_count": unread})
+}
+
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	h.setRead(c, true)
+}
+
+func (h *NotificationHandler) MarkUnread(c *gin.Context) {
+	h.setRead(c, false)
+}
+
+func (h *NotificationHandler) setRead(c *gin.Context, read bool) {
+	userID := c.GetString("user_id")
+
+	n, err := h.service.SetRead(userID, c.Param("id"), read)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, n)
+}
+
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	count, err := h.service.MarkAllRead(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"marked_read": count})
+}
+
+func (h *NotificationHandler) Delete(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	if err := h.service.Delete(userID, c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notification deleted"})
+}
+
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	prefs, err := h.service.GetPreferences(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+// UpdatePreferences handles PUT /api/notifications/preferences. Fields left
+// out of the body keep their current value.
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	prefs, err := h.service.GetPreferences(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch preferences"})
+		return
+	}
+	if err := c.ShouldBindJSON(prefs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated, err := h.service.UpdatePreferences(userID, prefs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	KindReminder = "reminder"
+	KindOverdue  = "overdue"
+	KindMention  = "mention"
+
+	StatusPending = "pending"
+	StatusSent    = "sent"
+
+	ChannelInApp = "in_app"
+	ChannelEmail = "email"
+)
+
+// Notification is one alert about a task, or a mention in a comment. It is created pending and sent
+// through the user's channels once quiet hours or the digest allow it; quiet hours don't hold back
+// the inbox.
+type Notification struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    string             `bson:"user_id" json:"user_id"`
+	Kind      string             `bson:"kind" json:"kind"`
+	Title     string             `bson:"title" json:"title"`
+	Message   string             `bson:"message" json:"message"`
+	PlanID    string             `bson:"plan_id,omitempty" json:"plan_id,omitempty"`
+	TaskID    string             `bson:"task_id,omitempty" json:"task_id,omitempty"`
+	Deadline  *time.Time         `bson:"deadline,omitempty" json:"deadline,omitempty"`
+	DedupeKey string             `bson:"dedupe_key,omitempty" json:"-"`
+	Status    string             `bson:"status" json:"status"`
+	Channels  []string           `bson:"channels,omitempty" json:"channels,omitempty"` // channels it went out on
+	LastError string             `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	InApp     bool               `bson:"in_app" json:"-"` // shown in the inbox
+	Read      bool               `bson:"read" json:"read"`
+	ReadAt    *time.Time         `bson:"read_at,omitempty" json:"read_at,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	SentAt    *time.Time         `bson:"sent_at,omitempty" json:"sent_at,omitempty"`
+}
+
+// Preferences control when and how a user is notified. Users without
+// stored preferences get DefaultPreferences.
+type Preferences struct {
+	UserID       string     `bson:"user_id" json:"-"`
+	LeadHours    []int      `bson:"lead_hours" json:"lead_hours"`   // remind this many hours before a deadline
+	Overdue      bool       `bson:"overdue" json:"overdue"`         // alert once a deadline has passed
+	QuietStart   string     `bson:"quiet_start" json:"quiet_start"` // HH:MM, empty for no quiet hours
+	QuietEnd     string     `bson:"quiet_end" json:"quiet_end"`     // HH:MM, may be before QuietStart (overnight)
+	TimeZone     string     `bson:"time_zone" json:"time_zone"`     // IANA name for quiet hours and the digest
+	Channels     []string   `bson:"channels" json:"channels"`       // in_app, email
+	Digest       bool       `bson:"digest" json:"digest"`           // batch alerts into one daily delivery
+	DigestHour   int        `bson:"digest_hour" json:"digest_hour"` // local hour the digest goes out
+	LastDigestAt *time.Time `bson:"last_digest_at,omitempty" json:"last_digest_at,omitempty"`
+	UpdatedAt    time.Time  `bson:"updated_at" json:"updated_at"`
+}
+
+func DefaultPreferences(userID string) *Preferences {
+	return &Preferences{
+		UserID:     userID,
+		LeadHours:  []int{24},
+		Overdue:    true,
+		QuietStart: "22:00",
+		QuietEnd:   "07:00",
+		TimeZone:   "UTC",
+		Channels:   []string{ChannelInApp, ChannelEmail},
+		DigestHour: 8,
+	}
+}
@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"smart-task-planner/internal/modules/notifications/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NotificationRepository stores notifications (outbox and inbox in one) and
+// per-user notification preferences
+type NotificationRepository struct {
+	Notifications *mongo.Collection
+	Preferences   *mongo.Collection
+}
+
+func NewNotificationRepository(db *mongo.Database) *NotificationRepository {
+	return &NotificationRepository{
+		Notifications: db.Collection("notifications"),
+		Preferences:   db.Collection("notification_preferences"),
+	}
+}
+
+func (r *NotificationRepository) EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := r.Preferences.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}); err != nil {
+		return err
+	}
+	_, err := r.Notifications.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "in_app", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "user_id", Value: 1}}},
+		{
+			// a reminder for the same task, deadline and lead time is only created once
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "dedupe_key", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"dedupe_key": bson.M{"$type": "string"}}),
+		},
+	})
+	return err
+}
+
+// Insert stores a new pending notification. It reports false without an
+// error when one with the same dedupe key already exists.
+func (r *NotificationRepository) Insert(n *models.Notification) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	n.ID = primitive.NewObjectID()
+	if _, err := r.Notifications.InsertOne(ctx, n); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// PendingUsers lists users with notifications waiting to be sent
+func (r *NotificationRepository) PendingUsers() ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	values, err := r.Notifications.Distinct(ctx, "user_id", bson.M{"status": models.StatusPending})
+	if err != nil {
+		return nil, err
+	}
+	users := make([]string, 0, len(values))
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			users = append(users, s)
+		}
+	}
+	return users, nil
+}
+
+// Pending returns the user's unsent notifications, oldest first
+func (r *NotificationRepository) Pending(userID string) ([]models.Notification, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := r.Notifications.Find(ctx, bson.M{"user_id": userID, "status": models.StatusPending},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var list []models.Notification
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// MarkSent records which channels a batch went out on
+func (r *NotificationRepository) MarkSent(ids []primitive.ObjectID, channels []string, at time.Time, sendErr string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	set := bson.M{"status": models.StatusSent, "channels": channels, "sent_at": at}
+	if sendErr != "" {
+		set["last_error"] = sendErr
+	}
+	_, err := r.Notifications.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, bson.M{"$set": set})
+	return err
+}
+
+// ShowInApp makes notifications visible in the inbox
+func (r *NotificationRepository) ShowInApp(ids []primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.Notifications.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, bson.M{"$set": bson.M{"in_app": true}})
+	return err
+}
+
+// Inbox returns the newest in-app notifications
+func (r *NotificationRepository) Inbox(userID string, unreadOnly bool, limit int64) ([]models.Notification, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID, "in_app": true}
+	if unreadOnly {
+		filter["read"] = false
+	}
+	cursor, err := r.Notifications.Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	list := []models.Notification{}
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *NotificationRepository) UnreadCount(userID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return r.Notifications.CountDocuments(ctx, bson.M{"user_id": userID, "in_app": true, "read": false})
+}
+
+func (r *NotificationRepository) SetRead(userID, notificationID string, read bool) (*models.Notification, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(notificationID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notification ID: %v", err)
+	}
+
+	update := bson.M{"$set": bson.M{"read": true, "read_at": time.Now()}}
+	if !read {
+		update = bson.M{"$set": bson.M{"read": false}, "$unset": bson.M{"read_at": ""}}
+	}
+
+	var n models.Notification
+	err = r.Notifications.FindOneAndUpdate(ctx, bson.M{"_id": objID, "user_id": userID, "in_app": true}, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&n)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("notification not found")
+		}
+		return nil, err
+	}
+	return &n, nil
+}
+
+// MarkAllRead marks the whole inbox read and returns how many changed
+func (r *NotificationRepository) MarkAllRead(userID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := r.Notifications.UpdateMany(ctx, bson.M{"user_id": userID, "in_app": true, "read": false},
+		bson.M{"$set": bson.M{"read": true, "read_at": time.Now()}})
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// Delete hides a notification from the inbox. The document stays so the
+// same reminder isn't created again.
+func (r *NotificationRepository) Delete(userID, notificationID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(notificationID)
+	if err != nil {
+		return fmt.Errorf("invalid notification ID: %v", err)
+	}
+
+	res, err := r.Notifications.UpdateOne(ctx, bson.M{"_id": objID, "user_id": userID, "in_app": true},
+		bson.M{"$set": bson.M{"in_app": false}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("notification not found")
+	}
+	return nil
+}
+
+// GetPreferences returns the stored preferences or the defaults
+func (r *NotificationRepository) GetPreferences(userID string) (*models.Preferences, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var p models.Preferences
+	if err := r.Preferences.FindOne(ctx, bson.M{"user_id": userID}).Decode(&p); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return models.DefaultPreferences(userID), nil
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *NotificationRepository) SavePreferences(p *models.Preferences) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.Preferences.ReplaceOne(ctx, bson.M{"user_id": p.UserID}, p, options.Replace().SetUpsert(true))
+	return err
+}
+
+// SetLastDigest remembers when the user's digest last went out. Digests
+// are opt-in, so the preferences document always exists here.
+func (r *NotificationRepository) SetLastDigest(userID string, at time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.Preferences.UpdateOne(ctx, bson.M{"user_id": userID}, bson.M{"$set": bson.M{"last_digest_at": at}})
+	return err
+}
+
+// Discard deletes pending notifications that no longer apply (the task was
+// finished before they went out)
+func (r *NotificationRepository) Discard(ids []primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.Notifications.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}, "status": models.StatusPending})
+	return err
+}
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"smart-task-planner/internal/middleware"
+	"smart-task-planner/internal/modules/notifications/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterNotificationRoutes(router *gin.Engine, handler *handlers.NotificationHandler) {
+	api := router.Group("/api/notifications")
+	api.Use(middleware.JWTAuth())
+	{
+		// Inbox
+		api.GET("/", handler.Inbox)
+		api.POST("/read-all", handler.MarkAllRead)
+		api.POST("/:id/read", handler.MarkRead)
+		api.POST("/:id/unread", handler.MarkUnread)
+		api.DELETE("/:id", handler.Delete)
+
+		// Lead times, quiet hours, channels and digest
+		api.GET("/preferences", handler.GetPreferences)
+		api.PUT("/preferences", handler.UpdatePreferences)
+	}
+}
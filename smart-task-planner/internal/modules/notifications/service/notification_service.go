@@ -0,0 +1,424 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	authRepository "smart-task-planner/internal/modules/auth/repository"
+	"smart-task-planner/internal/modules/notifications/channels"
+	"smart-task-planner/internal/modules/notifications/models"
+	"smart-task-planner/internal/modules/notifications/repository"
+	"smart-task-planner/internal/modules/plan/agenda"
+	planModels "smart-task-planner/internal/modules/plan/models"
+	planRepository "smart-task-planner/internal/modules/plan/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	tickInterval = 5 * time.Minute
+
+	// overdue alerts are only raised for deadlines missed within this window,
+	// so long-forgotten plans don't flood the inbox
+	overdueWindow = 7 * 24 * time.Hour
+
+	maxLeadHours = 30 * 24
+)
+
+type NotificationService struct {
+	Repo     *repository.NotificationRepository
+	PlanRepo *planRepository.PlanRepository
+	channels map[string]channels.Channel
+}
+
+func NewNotificationService(repo *repository.NotificationRepository, planRepo *planRepository.PlanRepository, chans ...channels.Channel) *NotificationService {
+	s := &NotificationService{Repo: repo, PlanRepo: planRepo, channels: map[string]channels.Channel{}}
+	for _, c := range chans {
+		s.channels[c.Name()] = c
+	}
+	return s
+}
+
+// Inbox returns in-app notifications together with the unread count
+func (s *NotificationService) Inbox(userID string, unreadOnly bool, limit int) ([]models.Notification, int64, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	list, err := s.Repo.Inbox(userID, unreadOnly, int64(limit))
+	if err != nil {
+		return nil, 0, err
+	}
+	unread, err := s.Repo.UnreadCount(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return list, unread, nil
+}
+
+func (s *NotificationService) SetRead(userID, notificationID string, read bool) (*models.Notification, error) {
+	return s.Repo.SetRead(userID, notificationID, read)
+}
+
+func (s *NotificationService) MarkAllRead(userID string) (int64, error) {
+	return s.Repo.MarkAllRead(userID)
+}
+
+func (s *NotificationService) Delete(userID, notificationID string) error {
+	return s.Repo.Delete(userID, notificationID)
+}
+
+func (s *NotificationService) GetPreferences(userID string) (*models.Preferences, error) {
+	return s.Repo.GetPreferences(userID)
+}
+
+// UpdatePreferences validates and stores the user's preferences
+func (s *NotificationService) UpdatePreferences(userID string, p *models.Preferences) (*models.Preferences, error) {
+	current, err := s.Repo.GetPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(p.LeadHours) > 5 {
+		return nil, fmt.Errorf("at most 5 lead times allowed")
+	}
+	for _, h := range p.LeadHours {
+		if h < 1 || h > maxLeadHours {
+			return nil, fmt.Errorf("lead_hours must be between 1 and %d", maxLeadHours)
+		}
+	}
+	if (p.QuietStart == "") != (p.QuietEnd == "") {
+		return nil, fmt.Errorf("quiet_start and quiet_end must be set together")
+	}
+	for _, v := range []string{p.QuietStart, p.QuietEnd} {
+		if _, ok := clockMinutes(v); v != "" && !ok {
+			return nil, fmt.Errorf("quiet hours must be HH:MM, got %q", v)
+		}
+	}
+	if p.TimeZone == "" {
+		p.TimeZone = "UTC"
+	}
+	if _, err := time.LoadLocation(p.TimeZone); err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", p.TimeZone)
+	}
+	for _, c := range p.Channels {
+		if c != models.ChannelInApp && c != models.ChannelEmail {
+			return nil, fmt.Errorf("unknown channel %q (in_app, email)", c)
+		}
+	}
+	if p.DigestHour < 0 || p.DigestHour > 23 {
+		return nil, fmt.Errorf("digest_hour must be between 0 and 23")
+	}
+
+	sort.Ints(p.LeadHours)
+	p.UserID = userID
+	p.LastDigestAt = current.LastDigestAt
+	p.UpdatedAt = time.Now()
+	if err := s.Repo.SavePreferences(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Start checks deadlines and sends due notifications right away and then
+// every few minutes until ctx is cancelled.
+func (s *NotificationService) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(tickInterval)
+		defer ticker.Stop()
+		for {
+			s.Tick(time.Now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Tick creates reminders and overdue alerts that are due at now, then sends
+// whatever quiet hours and digests allow.
+func (s *NotificationService) Tick(now time.Time) {
+	open, err := s.scan(now)
+	if err != nil {
+		log.Println("❌ Deadline scan failed:", err)
+		return
+	}
+	if err := s.dispatch(now, open); err != nil {
+		log.Println("❌ Notification dispatch failed:", err)
+	}
+}
+
+// scan walks every plan and creates the notifications due at now. It
+// returns the IDs of tasks that are still open, so alerts about tasks
+// finished in the meantime can be dropped before they go out.
+func (s *NotificationService) scan(now time.Time) (map[string]bool, error) {
+	prefs := map[string]*models.Preferences{}
+	open := map[string]bool{}
+
+	err := s.PlanRepo.ForEach(func(plan *planModels.Plan) error {
+		editors, err := s.PlanRepo.AudienceWith(plan, planModels.RoleEditor)
+		if err != nil {
+			return err
+		}
+		return s.scanTasks(plan, plan.Tasks, "", editors, prefs, now, open)
+	})
+	return open, err
+}
+
+// scanTasks alerts the assignee of a task, or of its parent, as long as they
+// can still edit the plan. Unassigned tasks alert every editor.
+func (s *NotificationService) scanTasks(plan *planModels.Plan, tasks []planModels.Task, inherited string, editors []string, prefs map[string]*models.Preferences, now time.Time, open map[string]bool) error {
+	for _, t := range tasks {
+		assignee := agenda.Assignee(t, inherited)
+		if err := s.scanTasks(plan, t.SubTasks, assignee, editors, prefs, now, open); err != nil {
+			return err
+		}
+		if isDone(t.Status) {
+			continue
+		}
+		open[t.ID.Hex()] = true
+		if t.Deadline.IsZero() {
+			continue
+		}
+
+		to := editors
+		if slices.Contains(editors, assignee) {
+			to = []string{assignee}
+		}
+		for _, userID := range to {
+			p, ok := prefs[userID]
+			if !ok {
+				var err error
+				if p, err = s.Repo.GetPreferences(userID); err != nil {
+					return err
+				}
+				prefs[userID] = p
+			}
+			n := alertFor(plan, t, userID, p, now)
+			if n == nil {
+				continue
+			}
+			if _, err := s.Repo.Insert(n); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// alertFor builds the notification userID needs about a task at now, if
+// any. Only the tightest lead time that has been reached produces a reminder.
+func alertFor(plan *planModels.Plan, t planModels.Task, userID string, p *models.Preferences, now time.Time) *models.Notification {
+	loc := location(p)
+	deadline := t.Deadline
+	n := &models.Notification{
+		UserID:    userID,
+		PlanID:    plan.ID.Hex(),
+		TaskID:    t.ID.Hex(),
+		Deadline:  &deadline,
+		Status:    models.StatusPending,
+		CreatedAt: now,
+	}
+
+	left := deadline.Sub(now)
+	if left <= 0 {
+		if !p.Overdue || -left > overdueWindow {
+			return nil
+		}
+		n.Kind = models.KindOverdue
+		n.Title = "Overdue: " + t.Title
+		n.Message = fmt.Sprintf("%s (%s) was due %s.", t.Title, plan.Goal, deadline.In(loc).Format("Mon, Jan 2 15:04 MST"))
+		n.DedupeKey = fmt.Sprintf("overdue:%s:%d", n.TaskID, deadline.Unix())
+		return n
+	}
+
+	leads := append([]int{}, p.LeadHours...)
+	sort.Ints(leads)
+	for _, h := range leads {
+		if left <= time.Duration(h)*time.Hour {
+			n.Kind = models.KindReminder
+			n.Title = "Due " + humanize(left) + ": " + t.Title
+			n.Message = fmt.Sprintf("%s (%s) is due %s.", t.Title, plan.Goal, deadline.In(loc).Format("Mon, Jan 2 15:04 MST"))
+			n.DedupeKey = fmt.Sprintf("reminder:%s:%d:%d", n.TaskID, deadline.Unix(), h)
+			return n
+		}
+	}
+	return nil
+}
+
+// dispatch sends pending notifications of every user whose digest schedule
+// allows it at now. During quiet hours they only appear in the inbox; the
+// other channels wait until quiet hours end.
+func (s *NotificationService) dispatch(now time.Time, open map[string]bool) error {
+	users, err := s.Repo.PendingUsers()
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range users {
+		p, err := s.Repo.GetPreferences(userID)
+		if err != nil {
+			return err
+		}
+		loc := location(p)
+		if p.Digest && !digestDue(p, now.In(loc)) {
+			continue
+		}
+
+		pending, err := s.Repo.Pending(userID)
+		if err != nil {
+			return err
+		}
+		var batch []models.Notification
+		var stale []primitive.ObjectID
+		for _, n := range pending {
+			// mentions stay worth reading after the task is done
+			if n.TaskID != "" && n.Kind != models.KindMention && !open[n.TaskID] {
+				stale = append(stale, n.ID)
+				continue
+			}
+			batch = append(batch, n)
+		}
+		if len(stale) > 0 {
+			if err := s.Repo.Discard(stale); err != nil {
+				return err
+			}
+		}
+
+		to := s.recipient(userID, loc)
+		if inQuietHours(p, now.In(loc)) {
+			s.showInApp(p, to, batch)
+			continue
+		}
+		if p.Digest {
+			if len(batch) > 0 {
+				s.send(p, to, batch, true, now)
+			}
+			if err := s.Repo.SetLastDigest(userID, now); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, n := range batch {
+			s.send(p, to, []models.Notification{n}, false, now)
+		}
+	}
+	return nil
+}
+
+// send delivers a batch on each of the user's channels. Channel errors are
+// logged and stored but don't hold the batch back, so a broken mail server
+// can't cause the same alert to be resent every few minutes.
+func (s *NotificationService) send(p *models.Preferences, to channels.Recipient, batch []models.Notification, digest bool, now time.Time) {
+	var sent, errs []string
+	for _, name := range p.Channels {
+		c, ok := s.channels[name]
+		if !ok {
+			continue
+		}
+		if err := c.Send(to, batch, digest); err != nil {
+			log.Printf("⚠️  %s notification for user %s failed: %v", name, to.UserID, err)
+			errs = append(errs, name+": "+err.Error())
+			continue
+		}
+		sent = append(sent, name)
+	}
+
+	ids := make([]primitive.ObjectID, len(batch))
+	for i, n := range batch {
+		ids[i] = n.ID
+	}
+	if err := s.Repo.MarkSent(ids, sent, now, strings.Join(errs, "; ")); err != nil {
+		log.Printf("❌ Failed to mark notifications sent for user %s: %v", to.UserID, err)
+	}
+}
+
+// showInApp puts notifications in the inbox ahead of the user's other
+// channels. They stay pending, so send delivers them everywhere later.
+func (s *NotificationService) showInApp(p *models.Preferences, to channels.Recipient, batch []models.Notification) {
+	c, ok := s.channels[models.ChannelInApp]
+	if !ok || !slices.Contains(p.Channels, models.ChannelInApp) {
+		return
+	}
+	var fresh []models.Notification
+	for _, n := range batch {
+		if !n.InApp {
+			fresh = append(fresh, n)
+		}
+	}
+	if len(fresh) == 0 {
+		return
+	}
+	if err := c.Send(to, fresh, false); err != nil {
+		log.Printf("⚠️  in_app notification for user %s failed: %v", to.UserID, err)
+	}
+}
+
+func (s *NotificationService) recipient(userID string, loc *time.Location) channels.Recipient {
+	to := channels.Recipient{UserID: userID, Location: loc}
+	if user, err := authRepository.GetUserByID(userID); err == nil {
+		to.Name, to.Email = user.Name, user.Email
+	}
+	return to
+}
+
+func isDone(status string) bool {
+	return strings.EqualFold(status, "Completed") || strings.EqualFold(status, "Cancelled")
+}
+
+func location(p *models.Preferences) *time.Location {
+	if loc, err := time.LoadLocation(p.TimeZone); err == nil && p.TimeZone != "" {
+		return loc
+	}
+	return time.UTC
+}
+
+// inQuietHours handles windows that wrap past midnight (22:00-07:00)
+func inQuietHours(p *models.Preferences, local time.Time) bool {
+	start, ok1 := clockMinutes(p.QuietStart)
+	end, ok2 := clockMinutes(p.QuietEnd)
+	if !ok1 || !ok2 || start == end {
+		return false
+	}
+	m := local.Hour()*60 + local.Minute()
+	if start < end {
+		return m >= start && m < end
+	}
+	return m >= start || m < end
+}
+
+// digestDue reports whether the most recent digest slot hasn't been sent yet
+func digestDue(p *models.Preferences, local time.Time) bool {
+	slot := time.Date(local.Year(), local.Month(), local.Day(), p.DigestHour, 0, 0, 0, local.Location())
+	if local.Before(slot) {
+		slot = slot.AddDate(0, 0, -1)
+	}
+	return p.LastDigestAt == nil || p.LastDigestAt.Before(slot)
+}
+
+func clockMinutes(v string) (int, bool) {
+	t, err := time.Parse("15:04", v)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+func humanize(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return "in less than an hour"
+	case d < 2*time.Hour:
+		return "in 1 hour"
+	case d < 48*time.Hour:
+		return fmt.Sprintf("in %d hours", int(d.Hours()))
+	default:
+		return fmt.Sprintf("in %d days", int(d.Hours()/24))
+	}
+}
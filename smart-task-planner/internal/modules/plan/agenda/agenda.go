@@ -0,0 +1,225 @@
+package agenda
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"smart-task-planner/internal/modules/plan/models"
+	"smart-task-planner/internal/modules/plan/progress"
+)
+
+// Views
+const (
+	Today      = "today"
+	Week       = "week"
+	Overdue    = "overdue"
+	NoDeadline = "no_deadline"
+	Assigned   = "assigned" // everything assigned to the user, dated or not
+
+	// the rolling window of Upcoming, not a view of its own
+	upcoming = "upcoming"
+)
+
+var Views = []string{Today, Week, Overdue, NoDeadline, Assigned}
+
+// NormalizeView maps aliases ("this_week", "no-deadline", "") onto a view name
+func NormalizeView(view string) (string, error) {
+	v := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(view)), "-", "_")
+	switch v {
+	case "":
+		return Today, nil
+	case "this_week":
+		return Week, nil
+	case "undated", "someday":
+		return NoDeadline, nil
+	case "mine", "my_tasks", "assigned_to_me":
+		return Assigned, nil
+	}
+	for _, known := range Views {
+		if v == known {
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf("unknown agenda view %q (use today, week, overdue, no_deadline or assigned)", view)
+}
+
+// Item is one open task or subtask without its subtree
+type Item struct {
+	TaskID         string     `json:"task_id"`
+	Title          string     `json:"title"`
+	Status         string     `json:"status"`
+	Priority       string     `json:"priority,omitempty"`
+	Deadline       *time.Time `json:"deadline,omitempty"`
+	EstimatedHours float64    `json:"estimated_hours,omitempty"`
+	Tags           []string   `json:"tags,omitempty"`
+	AssigneeID     string     `json:"assignee_id,omitempty"` // set or inherited from a parent task
+	Path           []string   `json:"path"`                  // titles of the parent tasks
+	Overdue        bool       `json:"overdue"`
+}
+
+// Group holds a plan's items in agenda order
+type Group struct {
+	PlanID string `json:"plan_id"`
+	Goal   string `json:"goal"`
+	Items  []Item `json:"items"`
+}
+
+type Agenda struct {
+	View     string     `json:"view"`
+	TimeZone string     `json:"time_zone"`
+	From     *time.Time `json:"from,omitempty"` // deadline window in the user's time zone
+	To       *time.Time `json:"to,omitempty"`
+	Total    int        `json:"total"`
+	Groups   []Group    `json:"groups"`
+}
+
+// Window returns the deadline range [from, to) of a dated view. Days start at
+// local midnight and weeks run Monday to Sunday.
+func Window(view string, now time.Time) (from, to time.Time) {
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	switch view {
+	case Today:
+		return today, today.AddDate(0, 0, 1)
+	case Week:
+		daysToMonday := (int(today.Weekday()) + 6) % 7
+		return today, today.AddDate(0, 0, 7-daysToMonday)
+	}
+	return time.Time{}, time.Time{}
+}
+
+// Build flattens the unfinished tasks of every plan at every depth and keeps
+// those in the view. now must be in the user's time zone. Today and week also
+// list tasks that fell due earlier the same day; overdue is everything past
+// its deadline. Tasks assigned to someone other than userID are left out.
+func Build(plans []models.Plan, view, userID string, now time.Time) *Agenda {
+	from, to := Window(view, now)
+	return build(plans, view, userID, now, from, to)
+}
+
+// Upcoming lists the open tasks due between now and days later, whatever
+// the day of the week, such as what the assistant calls "due soon"
+func Upcoming(plans []models.Plan, userID string, now time.Time, days int) *Agenda {
+	return build(plans, upcoming, userID, now, now, now.AddDate(0, 0, days))
+}
+
+func build(plans []models.Plan, view, userID string, now, from, to time.Time) *Agenda {
+	a := &Agenda{View: view, TimeZone: now.Location().String(), Groups: []Group{}}
+	if !from.IsZero() {
+		a.From, a.To = &from, &to
+	}
+
+	keep := func(t models.Task) bool {
+		switch view {
+		case Today, Week, upcoming:
+			return !t.Deadline.IsZero() && !t.Deadline.Before(from) && t.Deadline.Before(to)
+		case Overdue:
+			return progress.IsOverdue(t, now)
+		case NoDeadline:
+			return t.Deadline.IsZero()
+		case Assigned:
+			return true
+		}
+		return false
+	}
+	mine := func(assignee string) bool {
+		if view == Assigned {
+			return assignee == userID
+		}
+		return assignee == "" || assignee == userID
+	}
+
+	for _, plan := range plans {
+		g := Group{PlanID: plan.ID.Hex(), Goal: plan.Goal}
+		var walk func(tasks []models.Task, path []string, assignee string)
+		walk = func(tasks []models.Task, path []string, assignee string) {
+			for _, t := range tasks {
+				a := Assignee(t, assignee)
+				if open(t) && keep(t) && mine(a) {
+					item := toItem(t, path, now)
+					item.AssigneeID = a
+					g.Items = append(g.Items, item)
+				}
+				walk(t.SubTasks, append(path, t.Title), a)
+			}
+		}
+		walk(plan.Tasks, nil, "")
+		if len(g.Items) == 0 {
+			continue
+		}
+
+		sort.SliceStable(g.Items, func(i, j int) bool { return before(g.Items[i], g.Items[j]) })
+		a.Groups = append(a.Groups, g)
+		a.Total += len(g.Items)
+	}
+
+	// the plan with the most pressing first item leads
+	sort.SliceStable(a.Groups, func(i, j int) bool {
+		return before(a.Groups[i].Items[0], a.Groups[j].Items[0])
+	})
+	return a
+}
+
+// Assignee is who does t: its own assignee, or else the inherited one of
+// its parent
+func Assignee(t models.Task, inherited string) string {
+	if t.AssigneeID != "" {
+		return t.AssigneeID
+	}
+	return inherited
+}
+
+func open(t models.Task) bool {
+	return !progress.IsCompleted(t) && !strings.EqualFold(t.Status, "Cancelled")
+}
+
+func toItem(t models.Task, path []string, now time.Time) Item {
+	item := Item{
+		TaskID:         t.ID.Hex(),
+		Title:          t.Title,
+		Status:         t.Status,
+		Priority:       t.Priority,
+		EstimatedHours: t.EstimatedHours,
+		Tags:           t.Tags,
+		Path:           append([]string{}, path...),
+		Overdue:        progress.IsOverdue(t, now),
+	}
+	if !t.Deadline.IsZero() {
+		d := t.Deadline.In(now.Location())
+		item.Deadline = &d
+	}
+	return item
+}
+
+// before orders by deadline (undated last), then priority, then title
+func before(a, b Item) bool {
+	switch {
+	case a.Deadline != nil && b.Deadline == nil:
+		return true
+	case a.Deadline == nil && b.Deadline != nil:
+		return false
+	case a.Deadline != nil && !a.Deadline.Equal(*b.Deadline):
+		return a.Deadline.Before(*b.Deadline)
+	}
+	if pa, pb := PriorityRank(a.Priority), PriorityRank(b.Priority); pa != pb {
+		return pa < pb
+	}
+	return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+}
+
+// PriorityRank sorts P0 first and tasks without a priority after P3
+func PriorityRank(p string) int {
+	switch strings.ToUpper(p) {
+	case "P0":
+		return 0
+	case "P1":
+		return 1
+	case "P2":
+		return 2
+	case "P3":
+		return 3
+	}
+	return 4
+}
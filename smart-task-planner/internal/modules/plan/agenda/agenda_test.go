@@ -0,0 +1,157 @@
+package agenda
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"smart-task-planner/internal/modules/plan/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Sunday 9 March 2025, mid-afternoon
+var sunday = time.Date(2025, 3, 9, 15, 0, 0, 0, time.UTC)
+
+func at(day, hour int) time.Time {
+	return time.Date(2025, 3, day, hour, 0, 0, 0, time.UTC)
+}
+
+func task(title string, deadline time.Time) models.Task {
+	return models.Task{ID: primitive.NewObjectID(), Title: title, Status: "Pending", Deadline: deadline}
+}
+
+func samplePlans() []models.Plan {
+	done := task("Done", at(9, 18))
+	done.Status = "Completed"
+	dropped := task("Dropped", at(9, 18))
+	dropped.Status = "Cancelled"
+	bobs := task("Bob's task", at(9, 19))
+	bobs.AssigneeID = "bob"
+	annsPart := task("Ann's part", at(10, 12))
+	annsPart.AssigneeID = "ann"
+	bobs.SubTasks = []models.Task{task("Bob's subtask", at(9, 19)), annsPart}
+	urgent := task("Urgent", at(11, 9))
+	urgent.Priority = "P0"
+
+	return []models.Plan{
+		{ID: primitive.NewObjectID(), Goal: "Home", Tasks: []models.Task{
+			task("Earlier today", at(9, 9)),
+			task("Tonight", at(9, 20)),
+			task("Monday", at(10, 10)),
+			task("Last week", at(5, 10)),
+			task("Someday", time.Time{}),
+			done,
+			dropped,
+			bobs,
+		}},
+		{ID: primitive.NewObjectID(), Goal: "Work", Tasks: []models.Task{
+			urgent,
+			task("Also Tuesday", at(11, 9)),
+		}},
+	}
+}
+
+func titles(a *Agenda) []string {
+	var out []string
+	for _, g := range a.Groups {
+		for _, item := range g.Items {
+			out = append(out, item.Title)
+		}
+	}
+	return out
+}
+
+func TestWindow(t *testing.T) {
+	tests := []struct {
+		name     string
+		view     string
+		now      time.Time
+		from, to time.Time
+	}{
+		{"today", Today, sunday, at(9, 0), at(10, 0)},
+		{"week on a sunday", Week, sunday, at(9, 0), at(10, 0)},
+		{"week on a monday", Week, at(3, 8), at(3, 0), at(10, 0)},
+		{"undated view", Overdue, sunday, time.Time{}, time.Time{}},
+	}
+	for _, tt := range tests {
+		from, to := Window(tt.view, tt.now)
+		if !from.Equal(tt.from) || !to.Equal(tt.to) {
+			t.Errorf("%s: %v to %v, want %v to %v", tt.name, from, to, tt.from, tt.to)
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		view string
+		want []string
+	}{
+		{Today, []string{"Earlier today", "Tonight"}},
+		{Week, []string{"Earlier today", "Tonight"}},
+		{Overdue, []string{"Last week", "Earlier today"}},
+		{NoDeadline, []string{"Someday"}},
+		{Assigned, []string{"Ann's part"}},
+	}
+	for _, tt := range tests {
+		a := Build(samplePlans(), tt.view, "ann", sunday)
+		if got := titles(a); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: %v, want %v", tt.view, got, tt.want)
+		}
+		if a.Total != len(tt.want) {
+			t.Errorf("%s: total %d, want %d", tt.view, a.Total, len(tt.want))
+		}
+	}
+}
+
+func TestBuildItems(t *testing.T) {
+	a := Build(samplePlans(), Assigned, "ann", sunday)
+	item := a.Groups[0].Items[0]
+	if !slices.Equal(item.Path, []string{"Bob's task"}) || item.AssigneeID != "ann" {
+		t.Errorf("item %+v", item)
+	}
+
+	a = Build(samplePlans(), Today, "bob", sunday)
+	want := []string{"Earlier today", "Bob's subtask", "Bob's task", "Tonight"}
+	if got := titles(a); !slices.Equal(got, want) {
+		t.Errorf("bob today: %v, want %v (subtasks inherit the assignee)", got, want)
+	}
+}
+
+func TestUpcoming(t *testing.T) {
+	a := Upcoming(samplePlans(), "ann", sunday, 7)
+	// rolling, so Sunday still sees the coming week; ties go by priority
+	want := []string{"Tonight", "Monday", "Ann's part", "Urgent", "Also Tuesday"}
+	if got := titles(a); !slices.Equal(got, want) {
+		t.Errorf("upcoming %v, want %v", got, want)
+	}
+	if len(a.Groups) != 2 || a.Groups[0].Goal != "Home" {
+		t.Errorf("groups %+v", a.Groups)
+	}
+	for _, g := range a.Groups {
+		for _, item := range g.Items {
+			if item.Overdue {
+				t.Errorf("%s is overdue", item.Title)
+			}
+		}
+	}
+}
+
+func TestNormalizeView(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", Today},
+		{"This-Week", Week},
+		{"someday", NoDeadline},
+		{"assigned_to_me", Assigned},
+		{"overdue", Overdue},
+		{"later", ""},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeView(tt.in)
+		if got != tt.want || (err != nil) != (tt.want == "") {
+			t.Errorf("%q: %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
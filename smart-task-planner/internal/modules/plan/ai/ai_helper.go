@@ -1,8 +1,7 @@
 package ai
 
 import (
-	"context"
-	"fmt"
+	"math"
 	"os"
 	"strings"
 	"sync"
@@ -28,42 +27,47 @@ func getClient() *openai.Client {
 	return client
 }
 
-// AskOpenAIForBestGoal asks OpenAI to match a user message to a goal
-func AskOpenAIForBestGoal(message string, goals []string) (string, error) {
-	if len(goals) == 0 {
-		return "", fmt.Errorf("no goals provided")
-	}
-
-	ctx := context.Background()
-prompt := fmt.Sprintf(`
-You are a goal-matching assistant for a task planning app.
-
-User message: "%s"
-
-Here are the available goals:
-%s
+// Embedder turns text into vectors that can be compared with Cosine.
+// Vectors produced by different models are not comparable, so callers
+// store Model() next to every vector.
+type Embedder interface {
+	Model() string
+	Embed(texts []string) ([][]float32, error)
+}
 
-Rules:
-- Choose exactly ONE goal from the list that best matches the user's message.
-- Respond with the goal **exactly as written above**, no extra text.
-- If none of the goals are relevant, reply with "NONE" (in uppercase, by itself).
-`, message, strings.Join(goals, "\n"))
+var (
+	embedder     Embedder
+	embedderOnce sync.Once
+)
 
-	resp, err := getClient().CreateChatCompletion(ctx, openai.ChatCompletionRequest{
-		Model: openai.GPT4oMini,
-		Messages: []openai.ChatCompletionMessage{
-			{Role: "system", Content: "You are a precise AI that matches user intents to existing goals."},
-			{Role: "user", Content: prompt},
-		},
+// DefaultEmbedder picks the embedder from EMBEDDING_PROVIDER ("local" or
+// "openai"). The local embedder is the default so matching works offline.
+func DefaultEmbedder() Embedder {
+	embedderOnce.Do(func() {
+		switch strings.ToLower(os.Getenv("EMBEDDING_PROVIDER")) {
+		case "openai":
+			embedder = &OpenAIEmbedder{}
+		default:
+			embedder = NewLocalEmbedder()
+		}
 	})
-	if err != nil {
-		return "", fmt.Errorf("OpenAI API error: %v", err)
-	}
+	return embedder
+}
 
-	answer := strings.TrimSpace(resp.Choices[0].Message.Content)
-	if strings.EqualFold(answer, "NONE") {
-		return "", fmt.Errorf("AI could not determine a matching goal")
+// Cosine returns the cosine similarity of two vectors, 0 when either is empty
+// or their lengths differ.
+func Cosine(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
 	}
-
-	return answer, nil
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
 }
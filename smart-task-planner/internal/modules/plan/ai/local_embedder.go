@@ -0,0 +1,121 @@
+package ai
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+const localDimensions = 512
+
+// stopWords are dropped before hashing; they carry no goal-identifying meaning.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "my": true, "me": true, "i": true, "im": true,
+	"to": true, "of": true, "on": true, "in": true, "for": true, "and": true, "or": true,
+	"is": true, "am": true, "are": true, "be": true, "by": true, "with": true, "at": true,
+	"this": true, "that": true, "it": true, "its": true, "thing": true, "stuff": true,
+	"goal": true, "plan": true, "project": true, "how": true, "what": true, "whats": true,
+	"doing": true, "do": true, "about": true, "please": true, "show": true, "days": true,
+	"day": true, "behind": true, "progress": true, "so": true, "far": true, "one": true,
+}
+
+// LocalEmbedder is a deterministic feature-hashing embedder. It hashes word
+// stems, word bigrams and character trigrams into a fixed-size vector, so
+// "my marathon thing" lands close to "Run a marathon in March" without any
+// network access.
+type LocalEmbedder struct {
+	dims int
+}
+
+func NewLocalEmbedder() *LocalEmbedder {
+	return &LocalEmbedder{dims: localDimensions}
+}
+
+func (e *LocalEmbedder) Model() string {
+	return fmt.Sprintf("local-hash-v1-%d", e.dims)
+}
+
+func (e *LocalEmbedder) Embed(texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		out[i] = e.embedOne(text)
+	}
+	return out, nil
+}
+
+func (e *LocalEmbedder) embedOne(text string) []float32 {
+	vec := make([]float32, e.dims)
+	words := tokenize(text)
+
+	for i, w := range words {
+		e.add(vec, "w:"+w, 1.0)
+
+		// Trigrams give partial credit for typos and word variants
+		padded := "^" + w + "$"
+		runes := []rune(padded)
+		for j := 0; j+3 <= len(runes); j++ {
+			e.add(vec, "c:"+string(runes[j:j+3]), 0.3)
+		}
+
+		if i > 0 {
+			e.add(vec, "b:"+words[i-1]+"_"+w, 0.5)
+		}
+	}
+
+	var norm float64
+	for _, v := range vec {
+		norm += float64(v) * float64(v)
+	}
+	if norm > 0 {
+		n := float32(math.Sqrt(norm))
+		for i := range vec {
+			vec[i] /= n
+		}
+	}
+	return vec
+}
+
+// add hashes a feature into the vector, using a second hash bit as the sign
+// so that collisions tend to cancel out instead of accumulating.
+func (e *LocalEmbedder) add(vec []float32, feature string, weight float32) {
+	h := fnv.New64a()
+	h.Write([]byte(feature))
+	sum := h.Sum64()
+	idx := int(sum % uint64(e.dims))
+	if (sum>>63)&1 == 1 {
+		weight = -weight
+	}
+	vec[idx] += weight
+}
+
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	var words []string
+	for _, f := range fields {
+		if stopWords[f] || len(f) < 2 {
+			continue
+		}
+		words = append(words, stem(f))
+	}
+	return words
+}
+
+// stem strips a few common English suffixes; crude but stable.
+func stem(w string) string {
+	for _, suffix := range []string{"ing", "ed", "es", "s"} {
+		if len(w) > len(suffix)+2 && strings.HasSuffix(w, suffix) {
+			w = strings.TrimSuffix(w, suffix)
+			// running -> runn -> run
+			if n := len(w); n > 2 && w[n-1] == w[n-2] && !strings.ContainsRune("aeiouls", rune(w[n-1])) {
+				w = w[:n-1]
+			}
+			return w
+		}
+	}
+	return w
+}
@@ -0,0 +1,38 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// OpenAIEmbedder uses the OpenAI embeddings API. Enable it with
+// EMBEDDING_PROVIDER=openai.
+type OpenAIEmbedder struct{}
+
+func (e *OpenAIEmbedder) Model() string {
+	return string(openai.SmallEmbedding3)
+}
+
+func (e *OpenAIEmbedder) Embed(texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	resp, err := getClient().CreateEmbeddings(context.Background(), openai.EmbeddingRequest{
+		Input: texts,
+		Model: openai.SmallEmbedding3,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("OpenAI embeddings error: %v", err)
+	}
+
+	out := make([][]float32, len(texts))
+	for _, d := range resp.Data {
+		if d.Index >= 0 && d.Index < len(out) {
+			out[d.Index] = d.Embedding
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,221 @@
+package attributes
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	fieldModels "smart-task-planner/internal/modules/fields/models"
+	"smart-task-planner/internal/modules/plan/models"
+)
+
+// Priorities, most urgent first
+var Priorities = []string{"P0", "P1", "P2", "P3"}
+
+// Statuses a task can have
+var Statuses = []string{"Pending", "In Progress", "Completed", "Cancelled"}
+
+var (
+	priorityRe = regexp.MustCompile(`(?i)\bp([0-3])\b`)
+	hashtagRe  = regexp.MustCompile(`(?:^|\s)#([\w-]+)`)
+	contextRe  = regexp.MustCompile(`(?:^|\s)(@[\w-]+)`)
+)
+
+// NormalizePriority accepts P0-P3 in any case, a bare 0-3 or a word
+// (urgent, high, medium, low). "" and "none" clear the priority.
+func NormalizePriority(p string) (string, error) {
+	switch v := strings.ToLower(strings.TrimSpace(p)); v {
+	case "", "none":
+		return "", nil
+	case "p0", "0", "urgent", "critical":
+		return "P0", nil
+	case "p1", "1", "high":
+		return "P1", nil
+	case "p2", "2", "medium", "normal":
+		return "P2", nil
+	case "p3", "3", "low":
+		return "P3", nil
+	}
+	return "", fmt.Errorf("priority must be P0, P1, P2 or P3")
+}
+
+// NormalizeStatus accepts a status in any case, with "_" or "-" for the
+// space of In Progress
+func NormalizeStatus(status string) (string, error) {
+	v := strings.NewReplacer("_", " ", "-", " ").Replace(strings.TrimSpace(status))
+	for _, s := range Statuses {
+		if strings.EqualFold(v, s) {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("status must be one of %s", strings.Join(Statuses, ", "))
+}
+
+// NormalizeTags trims tags, drops a leading # and duplicates (ignoring case)
+func NormalizeTags(tags []string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, t := range tags {
+		t = strings.TrimPrefix(strings.TrimSpace(t), "#")
+		if t == "" || seen[strings.ToLower(t)] {
+			continue
+		}
+		seen[strings.ToLower(t)] = true
+		out = append(out, t)
+	}
+	return out
+}
+
+// NormalizeContexts lower-cases contexts and makes sure they start with @
+func NormalizeContexts(contexts []string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, c := range contexts {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if c = strings.TrimPrefix(c, "@"); c == "" || strings.ContainsAny(c, " \t") {
+			continue
+		}
+		if c = "@" + c; !seen[c] {
+			seen[c] = true
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Contexts are a task's GTD contexts. Before contexts were a field of
+// their own they were written as @tags, so those count too.
+func Contexts(t models.Task) []string {
+	var legacy []string
+	for _, tag := range t.Tags {
+		if strings.HasPrefix(tag, "@") {
+			legacy = append(legacy, tag)
+		}
+	}
+	return NormalizeContexts(append(append([]string{}, t.Contexts...), legacy...))
+}
+
+// SetFields validates values against the user's field definitions and
+// stores them on t. A nil value removes the field.
+func SetFields(t *models.Task, values map[string]interface{}, defs map[string]fieldModels.FieldDefinition) error {
+	for key, v := range values {
+		if v == nil {
+			delete(t.Fields, key)
+			continue
+		}
+		def, ok := defs[key]
+		if !ok {
+			return fmt.Errorf("unknown field %q", key)
+		}
+		value, err := def.Coerce(v)
+		if err != nil {
+			return err
+		}
+		if t.Fields == nil {
+			t.Fields = map[string]interface{}{}
+		}
+		t.Fields[key] = value
+	}
+	if len(t.Fields) == 0 {
+		t.Fields = nil
+	}
+	return nil
+}
+
+// FieldValue writes a stored field value as text for matching: numbers
+// without trailing zeros, strings lower-cased.
+func FieldValue(v interface{}) string {
+	switch t := v.(type) {
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case int32:
+		return strconv.Itoa(int(t))
+	case int64:
+		return strconv.FormatInt(t, 10)
+	case string:
+		return strings.ToLower(strings.TrimSpace(t))
+	}
+	return strings.ToLower(fmt.Sprint(v))
+}
+
+// FieldMatches reports whether a stored value equals want. Numbers compare
+// numerically, so "3" matches 3.0.
+func FieldMatches(stored interface{}, want string) bool {
+	if stored == nil {
+		return false
+	}
+	if _, isNumber := stored.(float64); isNumber {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(want), 64); err == nil {
+			return FieldValue(stored) == FieldValue(f)
+		}
+	}
+	return FieldValue(stored) == FieldValue(want)
+}
+
+// Labels collects the distinct priorities, tags, contexts and "key=value"
+// field values of a task tree, for plan summaries. Tags and values are
+// lower-cased so listings can match them exactly.
+func Labels(tasks []models.Task) (priorities, tags, contexts, fields []string) {
+	sets := [4]map[string]bool{{}, {}, {}, {}}
+	var walk func([]models.Task)
+	walk = func(ts []models.Task) {
+		for _, t := range ts {
+			if t.Priority != "" {
+				sets[0][t.Priority] = true
+			}
+			for _, tag := range t.Tags {
+				sets[1][strings.ToLower(tag)] = true
+			}
+			for _, c := range Contexts(t) {
+				sets[2][c] = true
+			}
+			for k, v := range t.Fields {
+				sets[3][k+"="+FieldValue(v)] = true
+			}
+			walk(t.SubTasks)
+		}
+	}
+	walk(tasks)
+
+	out := make([][]string, 4)
+	for i, set := range sets {
+		out[i] = []string{}
+		for v := range set {
+			out[i] = append(out[i], v)
+		}
+		sort.Strings(out[i])
+	}
+	return out[0], out[1], out[2], out[3]
+}
+
+// Filters are the labels written inline in a search or command message
+type Filters struct {
+	Text       string
+	Priorities []string
+	Tags       []string
+	Contexts   []string
+}
+
+// ExtractFilters pulls P0-P3, #tags and @contexts out of text, leaving the
+// words to search for: "P1 #writing @computer blog" -> "blog".
+func ExtractFilters(text string) Filters {
+	var f Filters
+	for _, m := range priorityRe.FindAllStringSubmatch(text, -1) {
+		f.Priorities = append(f.Priorities, "P"+m[1])
+	}
+	for _, m := range hashtagRe.FindAllStringSubmatch(text, -1) {
+		f.Tags = append(f.Tags, m[1])
+	}
+	for _, m := range contextRe.FindAllStringSubmatch(text, -1) {
+		f.Contexts = append(f.Contexts, m[1])
+	}
+	f.Contexts = NormalizeContexts(f.Contexts)
+
+	text = priorityRe.ReplaceAllString(text, " ")
+	text = hashtagRe.ReplaceAllString(text, " ")
+	text = contextRe.ReplaceAllString(text, " ")
+	f.Text = strings.Join(strings.Fields(text), " ")
+	return f
+}
@@ -9,3 +9,38 @@ type PlanResponse struct {
 	Goal  string      `json:"goal"`
 	Tasks interface{} `json:"tasks"`
 }
+
+// ListPlansRequest is the query string of GET /api/plan/
+type ListPlansRequest struct {
+	Workspace   string            `form:"workspace"` // a workspace ID or "personal"
+	Cursor      string            `form:"cursor"`
+	Limit       int               `form:"limit"`
+	Status      string            `form:"status"`
+	Query       string            `form:"q"`
+	CreatedFrom string            `form:"created_from"`
+	CreatedTo   string            `form:"created_to"`
+	Priority    string            `form:"priority"` // comma-separated, any of
+	Tag         string            `form:"tag"`      // comma-separated, all of
+	Context     string            `form:"context"`  // comma-separated, all of
+	Fields      map[string]string `form:"-"`        // field.<key>=value
+	Sort        string            `form:"sort"`
+	Order       string            `form:"order"`
+	View        string            `form:"view"`
+}
+
+// UpdateTaskAttributesRequest is the body of PATCH /api/plan/:id/tasks/:task_id.
+// Omitted fields are left alone; tags and contexts replace the current ones
+// and a null field value removes that field.
+type UpdateTaskAttributesRequest struct {
+	Priority *string                `json:"priority"`
+	Tags     *[]string              `json:"tags"`
+	Contexts *[]string              `json:"contexts"`
+	Fields   map[string]interface{} `json:"fields"`
+}
+
+// AssignTaskRequest is the body of PUT /api/plan/:id/tasks/:task_id/assignee.
+// Either assignee_id ("" unassigns) or assignee, a name, email or "me".
+type AssignTaskRequest struct {
+	AssigneeID *string `json:"assignee_id"`
+	Assignee   string  `json:"assignee"`
+}
@@ -0,0 +1,350 @@
+package estimate
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"smart-task-planner/internal/modules/plan/ai"
+	"smart-task-planner/internal/modules/plan/models"
+	"smart-task-planner/internal/modules/plan/progress"
+)
+
+const (
+	// WorkHoursPerDay converts effort into calendar days when scheduling
+	WorkHoursPerDay = 8.0
+
+	minFactor = 0.25
+	maxFactor = 4.0
+
+	// the learned factor is pulled towards 1 as if this many tasks had
+	// been exactly on estimate, so a few outliers can't swing it
+	priorSamples = 5
+
+	similarThreshold = 0.45
+	maxSimilar       = 5
+)
+
+// Methods
+const (
+	MethodCorrection = "correction"
+	MethodSimilar    = "similar_tasks"
+	MethodBlended    = "blended"
+	MethodPrompt     = "prompt"
+	MethodNone       = "none"
+)
+
+// Sample is a finished task the user tracked time on
+type Sample struct {
+	PlanID         string    `json:"plan_id"`
+	TaskID         string    `json:"task_id"`
+	Title          string    `json:"title"`
+	EstimatedHours float64   `json:"estimated_hours,omitempty"` // before calibration; 0 if there was none
+	ActualHours    float64   `json:"actual_hours"`              // the user's own tracked hours
+	vector         []float32 // title + description embedding
+}
+
+// Profile is what the user's history says about their estimates
+type Profile struct {
+	Factor     float64  `json:"factor"`     // typical actual / estimated hours; 1 means estimates are right
+	Samples    int      `json:"samples"`    // finished tasks with both an estimate and tracked time
+	Spread     float64  `json:"spread"`     // standard deviation of log(actual / estimated)
+	Confidence float64  `json:"confidence"` // in Factor, 0 to 1
+	Level      string   `json:"level"`
+	History    []Sample `json:"-"` // every finished task with tracked time
+
+	embedder ai.Embedder
+}
+
+// Learn builds the user's profile from the completed tasks they did: tasks
+// assigned to them, and unassigned tasks of plans they created. hours holds
+// the user's own tracked hours by task ID, so time collaborators tracked
+// doesn't count. Tasks are compared by embedding, so similarity works with
+// the local (keyword) embedder as well as OpenAI's.
+func Learn(plans []models.Plan, userID string, hours map[string]float64, embedder ai.Embedder) (*Profile, error) {
+	p := &Profile{Factor: 1, embedder: embedder}
+
+	var texts []string
+	var missing []int
+	var logs []float64
+	for _, plan := range plans {
+		sameModel := plan.EmbeddingModel == embedder.Model()
+		var walk func(tasks []models.Task, assignee string)
+		walk = func(tasks []models.Task, assignee string) {
+			for _, t := range tasks {
+				doneBy := assignee
+				if t.AssigneeID != "" {
+					doneBy = t.AssigneeID
+				}
+				walk(t.SubTasks, doneBy)
+				if doneBy == "" && plan.UserID == userID {
+					doneBy = userID
+				}
+				actual := hours[t.ID.Hex()]
+				if doneBy != userID || !progress.IsCompleted(t) || actual <= 0 {
+					continue
+				}
+				s := Sample{
+					PlanID:         plan.ID.Hex(),
+					TaskID:         t.ID.Hex(),
+					Title:          t.Title,
+					EstimatedHours: RawHours(t),
+					ActualHours:    actual,
+				}
+				if sameModel && len(t.Embedding) > 0 {
+					s.vector = t.Embedding
+				} else {
+					texts = append(texts, t.Title+". "+t.Description)
+					missing = append(missing, len(p.History))
+				}
+				if s.EstimatedHours > 0 {
+					logs = append(logs, math.Log(s.ActualHours/s.EstimatedHours))
+				}
+				p.History = append(p.History, s)
+			}
+		}
+		walk(plan.Tasks, "")
+	}
+
+	if len(texts) > 0 {
+		vecs, err := embedder.Embed(texts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed task history: %v", err)
+		}
+		for i, idx := range missing {
+			p.History[idx].vector = vecs[i]
+		}
+	}
+
+	p.Samples = len(logs)
+	if p.Samples > 0 {
+		var sum float64
+		for _, l := range logs {
+			sum += l
+		}
+		mean := sum / float64(p.Samples)
+		var variance float64
+		for _, l := range logs {
+			variance += (l - mean) * (l - mean)
+		}
+		p.Spread = round(math.Sqrt(variance/float64(p.Samples)), 100)
+
+		shrunk := sum / float64(p.Samples+priorSamples)
+		p.Factor = round(clamp(math.Exp(shrunk), minFactor, maxFactor), 100)
+		p.Confidence = round(float64(p.Samples)/float64(p.Samples+priorSamples)/(1+p.Spread), 100)
+	}
+	p.Level = Level(p.Confidence)
+	return p, nil
+}
+
+// RawHours is the estimate a task was given before any calibration
+func RawHours(t models.Task) float64 {
+	if t.Estimate != nil && t.Estimate.RawHours > 0 {
+		return t.Estimate.RawHours
+	}
+	return t.EstimatedHours
+}
+
+// Match is a finished task similar to the one being estimated
+type Match struct {
+	Sample
+	Similarity float64 `json:"similarity"`
+}
+
+// Similar returns up to limit finished tasks close to text, best first
+func (p *Profile) Similar(text string, limit int) []Match {
+	if len(p.History) == 0 || strings.TrimSpace(text) == "" {
+		return nil
+	}
+	vecs, err := p.embedder.Embed([]string{text})
+	if err != nil {
+		return nil
+	}
+	return p.similar(vecs[0], limit)
+}
+
+func (p *Profile) similar(vec []float32, limit int) []Match {
+	var out []Match
+	for _, s := range p.History {
+		if sim := ai.Cosine(vec, s.vector); sim >= similarThreshold {
+			out = append(out, Match{Sample: s, Similarity: round(sim, 1000)})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Similarity > out[j].Similarity })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+// Estimate calibrates one task. The user's correction factor scales the
+// given estimate; similar finished tasks give a second opinion from their
+// tracked hours. Both are blended by confidence. Hours is 0 when neither
+// is available.
+func (p *Profile) Estimate(title, description string, estimated float64) (float64, models.EstimateInfo) {
+	hours, info := p.estimateAll([]string{title + ". " + description}, []float64{estimated})
+	return hours[0], info[0]
+}
+
+// EstimateTasks calibrates tasks in one embedding call. It returns the
+// calibrated hours alongside their explanations.
+func (p *Profile) EstimateTasks(tasks []models.Task) ([]float64, []models.EstimateInfo) {
+	texts := make([]string, len(tasks))
+	raw := make([]float64, len(tasks))
+	for i, t := range tasks {
+		texts[i] = t.Title + ". " + t.Description
+		raw[i] = RawHours(t)
+	}
+	return p.estimateAll(texts, raw)
+}
+
+func (p *Profile) estimateAll(texts []string, raw []float64) ([]float64, []models.EstimateInfo) {
+	var vecs [][]float32
+	if len(p.History) > 0 {
+		vecs, _ = p.embedder.Embed(texts)
+	}
+
+	hours := make([]float64, len(texts))
+	infos := make([]models.EstimateInfo, len(texts))
+	for i := range texts {
+		var vec []float32
+		if i < len(vecs) {
+			vec = vecs[i]
+		}
+		hours[i], infos[i] = p.calibrate(raw[i], vec)
+	}
+	return hours, infos
+}
+
+func (p *Profile) calibrate(raw float64, vec []float32) (float64, models.EstimateInfo) {
+	info := models.EstimateInfo{RawHours: raw, Factor: p.Factor, Method: MethodNone}
+
+	var corrected, corrConf float64
+	if raw > 0 {
+		corrected = raw * p.Factor
+		corrConf = p.Confidence
+	}
+
+	var similar, simConf float64
+	if vec != nil {
+		matches := p.similar(vec, maxSimilar)
+		var weight float64
+		for _, m := range matches {
+			similar += m.ActualHours * m.Similarity
+			weight += m.Similarity
+			info.Basis = append(info.Basis, m.Title)
+		}
+		if weight > 0 {
+			similar /= weight
+			simConf = similarConfidence(matches)
+		}
+	}
+
+	var hours float64
+	switch {
+	case corrected > 0 && similar > 0:
+		info.Method = MethodBlended
+		if corrConf+simConf > 0 {
+			hours = (corrected*corrConf + similar*simConf) / (corrConf + simConf)
+		} else {
+			hours = corrected
+		}
+		info.Confidence = 1 - (1-corrConf)*(1-simConf)
+	case corrected > 0:
+		info.Method = MethodCorrection
+		hours = corrected
+		info.Confidence = corrConf
+	case similar > 0:
+		info.Method = MethodSimilar
+		hours = similar
+		info.Confidence = simConf
+	default:
+		hours = raw
+	}
+
+	info.Confidence = round(info.Confidence, 100)
+	info.Level = Level(info.Confidence)
+	return roundQuarter(hours), info
+}
+
+// PromptHint describes the user's pace for a planning prompt, with the
+// finished tasks most similar to goal as examples. It is empty without
+// history.
+func (p *Profile) PromptHint(goal string) (string, []Match) {
+	var b strings.Builder
+	if p.Samples > 0 {
+		fmt.Fprintf(&b, "Their tasks have taken about %.2gx their estimated time (%d finished tasks, %s confidence).\n",
+			p.Factor, p.Samples, p.Level)
+	}
+	matches := p.Similar(goal, maxSimilar)
+	if len(matches) > 0 {
+		b.WriteString("Similar tasks they finished:\n")
+		for _, m := range matches {
+			if m.EstimatedHours > 0 {
+				fmt.Fprintf(&b, "- %s: estimated %.1f h, took %.1f h\n", m.Title, m.EstimatedHours, m.ActualHours)
+			} else {
+				fmt.Fprintf(&b, "- %s: took %.1f h\n", m.Title, m.ActualHours)
+			}
+		}
+	}
+	return b.String(), matches
+}
+
+// FromPrompt explains an estimate an AI made with PromptHint in its prompt.
+// raw is its estimate for anyone, expected what it expects this user to
+// need. expected is kept within the factor limits of raw, so learning from
+// raw later stays sound.
+func (p *Profile) FromPrompt(raw, expected float64, examples []Match) (float64, models.EstimateInfo) {
+	info := models.EstimateInfo{RawHours: raw, Factor: p.Factor, Method: MethodPrompt}
+	if raw > 0 {
+		expected = clamp(expected, raw*minFactor, raw*maxFactor)
+	}
+	var corrConf float64
+	if p.Samples > 0 {
+		corrConf = p.Confidence
+	}
+	for _, m := range examples {
+		info.Basis = append(info.Basis, m.Title)
+	}
+	info.Confidence = round(1-(1-corrConf)*(1-similarConfidence(examples)), 100)
+	info.Level = Level(info.Confidence)
+	return roundQuarter(expected), info
+}
+
+// similarConfidence trusts similar tasks as much as they are alike on
+// average, less while there are only a few
+func similarConfidence(matches []Match) float64 {
+	if len(matches) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, m := range matches {
+		sum += m.Similarity
+	}
+	n := float64(len(matches))
+	return sum / n * n / (n + 2)
+}
+
+// Level names a confidence
+func Level(confidence float64) string {
+	switch {
+	case confidence >= 0.6:
+		return "high"
+	case confidence >= 0.3:
+		return "medium"
+	}
+	return "low"
+}
+
+func clamp(v, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, v))
+}
+
+func round(v, scale float64) float64 {
+	return math.Round(v*scale) / scale
+}
+
+func roundQuarter(v float64) float64 {
+	return math.Round(v*4) / 4
+}
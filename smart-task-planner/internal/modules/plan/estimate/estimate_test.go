@@ -0,0 +1,217 @@
+package estimate
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"smart-task-planner/internal/modules/plan/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// keywords embeds a text as which of its words it contains
+type keywords []string
+
+func (k keywords) Model() string { return "test-keywords" }
+
+func (k keywords) Embed(texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		vec := make([]float32, len(k))
+		for j, w := range k {
+			if strings.Contains(strings.ToLower(text), w) {
+				vec[j] = 1
+			}
+		}
+		out[i] = vec
+	}
+	return out, nil
+}
+
+var embedder = keywords{"blog", "deploy", "call"}
+
+func done(title string, estimated float64) models.Task {
+	return models.Task{ID: primitive.NewObjectID(), Title: title, Status: "Completed", EstimatedHours: estimated}
+}
+
+func TestLearn(t *testing.T) {
+	hours := map[string]float64{}
+	track := func(task models.Task, h float64) models.Task {
+		hours[task.ID.Hex()] = h
+		return task
+	}
+
+	calibrated := done("Deploy the site", 6)
+	calibrated.Estimate = &models.EstimateInfo{RawHours: 4}
+	bobs := done("Bob's review", 1)
+	bobs.AssigneeID = "bob"
+	bobs.SubTasks = []models.Task{track(done("Bob's subtask", 1), 5)}
+	open := done("Open blog task", 1)
+	open.Status = "Pending"
+	forAnn := done("Call the printer", 1)
+	forAnn.AssigneeID = "ann"
+
+	plans := []models.Plan{
+		{UserID: "ann", Tasks: []models.Task{
+			track(done("Write blog post", 2), 4),
+			track(done("Write blog outline", 1), 2),
+			track(calibrated, 4), // learns from the raw 4h, not the calibrated 6h
+			track(bobs, 3),
+			track(open, 3),
+			done("Untracked", 2),
+			track(done("Unestimated blog edit", 0), 1),
+		}},
+		// a workspace plan bob created, with a task assigned to ann
+		{UserID: "bob", WorkspaceID: "ws", Tasks: []models.Task{track(forAnn, 1), track(done("Bob's own", 1), 8)}},
+	}
+
+	p, err := Learn(plans, "ann", hours, embedder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.History) != 5 || p.Samples != 4 {
+		t.Fatalf("history %d, samples %d; want 5 and 4", len(p.History), p.Samples)
+	}
+	// ratios 2, 2, 1, 1: the mean log is pulled towards 0 by 5 prior samples
+	if p.Factor != 1.17 || p.Spread != 0.35 || p.Confidence != 0.33 || p.Level != "medium" {
+		t.Errorf("profile %+v", p)
+	}
+	for _, s := range p.History {
+		if s.vector == nil {
+			t.Errorf("%s was not embedded", s.Title)
+		}
+	}
+}
+
+func TestLearnFactor(t *testing.T) {
+	tests := []struct {
+		name   string
+		ratio  float64 // actual / estimated of every sample
+		n      int
+		factor float64
+		level  string
+	}{
+		{"no history", 1, 0, 1, "low"},
+		{"on estimate", 1, 10, 1, "high"},
+		{"few slow tasks", 2, 1, 1.12, "low"},
+		{"many slow tasks", 2, 20, 1.74, "high"},
+		{"far too slow", 100, 20, maxFactor, "high"},
+		{"far too fast", 0.001, 20, minFactor, "high"},
+	}
+	for _, tt := range tests {
+		hours := map[string]float64{}
+		var tasks []models.Task
+		for i := 0; i < tt.n; i++ {
+			task := done("Task", 2)
+			hours[task.ID.Hex()] = 2 * tt.ratio
+			tasks = append(tasks, task)
+		}
+		p, err := Learn([]models.Plan{{UserID: "ann", Tasks: tasks}}, "ann", hours, embedder)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Factor != tt.factor || p.Level != tt.level {
+			t.Errorf("%s: factor %v (%s), want %v (%s)", tt.name, p.Factor, p.Level, tt.factor, tt.level)
+		}
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	history := []Sample{{Title: "Write blog post", EstimatedHours: 2, ActualHours: 4, vector: []float32{1, 0, 0}}}
+	tests := []struct {
+		name       string
+		profile    Profile
+		title      string
+		estimated  float64
+		hours      float64
+		method     string
+		confidence float64
+	}{
+		{"nothing to go on", Profile{Factor: 1}, "Call the bank", 0, 0, MethodNone, 0},
+		{"correction only", Profile{Factor: 1.5, Confidence: 0.5}, "Call the bank", 2, 3, MethodCorrection, 0.5},
+		{"similar only", Profile{Factor: 1, History: history}, "Blog about Go", 0, 4, MethodSimilar, 0.33},
+		// (3 * 0.5 + 4 * 0.33) / 0.83 = 3.4, rounded to a quarter hour
+		{"blended", Profile{Factor: 1.5, Confidence: 0.5, History: history}, "Blog about Go", 2, 3.5, MethodBlended, 0.67},
+		{"nothing similar", Profile{Factor: 1.5, Confidence: 0.5, History: history}, "Deploy", 2, 3, MethodCorrection, 0.5},
+	}
+	for _, tt := range tests {
+		p := tt.profile
+		p.embedder = embedder
+		hours, info := p.Estimate(tt.title, "", tt.estimated)
+		if hours != tt.hours || info.Method != tt.method || info.Confidence != tt.confidence {
+			t.Errorf("%s: %vh by %s at %v, want %vh by %s at %v", tt.name, hours, info.Method, info.Confidence, tt.hours, tt.method, tt.confidence)
+		}
+		if info.RawHours != tt.estimated || info.Level != Level(info.Confidence) {
+			t.Errorf("%s: info %+v", tt.name, info)
+		}
+	}
+}
+
+func TestPromptHint(t *testing.T) {
+	p := &Profile{Factor: 1.5, Samples: 4, Level: "medium", embedder: embedder, History: []Sample{
+		{Title: "Write blog post", EstimatedHours: 2, ActualHours: 4, vector: []float32{1, 0, 0}},
+		{Title: "Blog without estimate", ActualHours: 1, vector: []float32{1, 0, 0}},
+		{Title: "Deploy", EstimatedHours: 1, ActualHours: 1, vector: []float32{0, 1, 0}},
+	}}
+	hint, examples := p.PromptHint("Start a blog")
+	for _, want := range []string{
+		"about 1.5x their estimated time (4 finished tasks, medium confidence)",
+		"- Write blog post: estimated 2.0 h, took 4.0 h\n",
+		"- Blog without estimate: took 1.0 h\n",
+	} {
+		if !strings.Contains(hint, want) {
+			t.Errorf("hint %q lacks %q", hint, want)
+		}
+	}
+	if len(examples) != 2 || strings.Contains(hint, "Deploy") {
+		t.Errorf("examples %+v", examples)
+	}
+
+	empty := &Profile{Factor: 1, embedder: embedder}
+	if hint, examples := empty.PromptHint("Start a blog"); hint != "" || examples != nil {
+		t.Errorf("hint without history: %q", hint)
+	}
+}
+
+func TestFromPrompt(t *testing.T) {
+	p := &Profile{Factor: 1.5, Samples: 4, Confidence: 0.5}
+	examples := []Match{{Sample: Sample{Title: "Write blog post"}, Similarity: 1}}
+	tests := []struct {
+		name          string
+		raw, expected float64
+		hours         float64
+	}{
+		{"as expected", 2, 3.1, 3},
+		{"capped at 4x", 2, 20, 8},
+		{"floored at 0.25x", 8, 0.5, 2},
+		{"no typical estimate", 0, 3, 3},
+	}
+	for _, tt := range tests {
+		hours, info := p.FromPrompt(tt.raw, tt.expected, examples)
+		if hours != tt.hours {
+			t.Errorf("%s: %vh, want %vh", tt.name, hours, tt.hours)
+		}
+		if info.Method != MethodPrompt || info.RawHours != tt.raw || info.Confidence != 0.67 || len(info.Basis) != 1 {
+			t.Errorf("%s: info %+v", tt.name, info)
+		}
+	}
+}
+
+func TestLevel(t *testing.T) {
+	tests := []struct {
+		confidence float64
+		want       string
+	}{
+		{0, "low"},
+		{0.29, "low"},
+		{0.3, "medium"},
+		{0.6, "high"},
+		{math.Inf(1), "high"},
+	}
+	for _, tt := range tests {
+		if got := Level(tt.confidence); got != tt.want {
+			t.Errorf("Level(%v) = %s, want %s", tt.confidence, got, tt.want)
+		}
+	}
+}
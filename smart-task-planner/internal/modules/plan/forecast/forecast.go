@@ -0,0 +1,208 @@
+package forecast
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"smart-task-planner/internal/modules/plan/agenda"
+	"smart-task-planner/internal/modules/plan/models"
+	"smart-task-planner/internal/modules/plan/progress"
+)
+
+const (
+	StatusOK                  = "ok"
+	StatusComplete            = "complete"
+	StatusInsufficientHistory = "insufficient_history"
+	defaultTrials             = 5000
+	defaultWindowDays         = 42
+	defaultMinCompletions     = 3
+	maxSimulatedDays          = 3650
+)
+
+// Options tunes the Monte Carlo simulation. Zero values fall back to defaults.
+type Options struct {
+	Trials         int
+	WindowDays     int
+	MinCompletions int
+}
+
+// Forecast is the simulated finish date distribution for a single plan.
+type Forecast struct {
+	PlanID            string     `json:"plan_id"`
+	Goal              string     `json:"goal"`
+	Status            string     `json:"status"`
+	RemainingTasks    int        `json:"remaining_tasks"`
+	CompletedTasks    int        `json:"completed_tasks"`
+	HistoryDays       int        `json:"history_days"`
+	HistoryCompleted  int        `json:"history_completed"`
+	AvgDailyVelocity  float64    `json:"avg_daily_velocity"`
+	Trials            int        `json:"trials"`
+	P50               *time.Time `json:"p50,omitempty"`
+	P85               *time.Time `json:"p85,omitempty"`
+	P95               *time.Time `json:"p95,omitempty"`
+	LastDeadline      *time.Time `json:"last_deadline,omitempty"`
+	OnTimeProbability *float64   `json:"on_time_probability,omitempty"`
+}
+
+// CompletionTimes collects the completion timestamps of every task and
+// subtask userID completed across the given plans: those assigned to them,
+// directly or through a parent, and unassigned ones on plans they own.
+func CompletionTimes(plans []models.Plan, userID string) []time.Time {
+	var times []time.Time
+	for _, p := range plans {
+		var walk func(tasks []models.Task, assignee string)
+		walk = func(tasks []models.Task, assignee string) {
+			for _, t := range tasks {
+				doneBy := agenda.Assignee(t, assignee)
+				walk(t.SubTasks, doneBy)
+				if doneBy == "" && p.UserID == userID {
+					doneBy = userID
+				}
+				if doneBy == userID && progress.IsCompleted(t) && t.CompletedAt != nil {
+					times = append(times, *t.CompletedAt)
+				}
+			}
+		}
+		walk(p.Tasks, "")
+	}
+	return times
+}
+
+// DailyThroughput buckets completions into per-day counts over the trailing
+// window. The window starts at the first completion inside it so that days
+// before the user started working don't drag the velocity down.
+func DailyThroughput(completions []time.Time, now time.Time, windowDays int) []int {
+	if windowDays <= 0 {
+		windowDays = defaultWindowDays
+	}
+	today := startOfDay(now)
+	windowStart := today.AddDate(0, 0, -(windowDays - 1))
+
+	counts := make([]int, windowDays)
+	first := -1
+	for _, c := range completions {
+		day := startOfDay(c.In(now.Location()))
+		if day.Before(windowStart) || day.After(today) {
+			continue
+		}
+		idx := int(day.Sub(windowStart).Hours() / 24)
+		if idx < 0 || idx >= windowDays {
+			continue
+		}
+		counts[idx]++
+		if first == -1 || idx < first {
+			first = idx
+		}
+	}
+	if first == -1 {
+		return nil
+	}
+	return counts[first:]
+}
+
+// Simulate runs the Monte Carlo forecast for one plan using the user's
+// historical completion timestamps.
+func Simulate(plan models.Plan, completions []time.Time, now time.Time, opts Options) Forecast {
+	if opts.Trials <= 0 {
+		opts.Trials = defaultTrials
+	}
+	if opts.WindowDays <= 0 {
+		opts.WindowDays = defaultWindowDays
+	}
+	if opts.MinCompletions <= 0 {
+		opts.MinCompletions = defaultMinCompletions
+	}
+
+	summary := progress.Calculate(plan.Tasks, now)
+	remaining := summary.Remaining
+	lastDeadline := progress.LastDeadline(plan.Tasks)
+
+	fc := Forecast{
+		PlanID:         plan.ID.Hex(),
+		Goal:           plan.Goal,
+		RemainingTasks: remaining,
+		CompletedTasks: summary.Completed,
+	}
+	if !lastDeadline.IsZero() {
+		d := lastDeadline
+		fc.LastDeadline = &d
+	}
+
+	if remaining == 0 {
+		fc.Status = StatusComplete
+		p := 1.0
+		fc.OnTimeProbability = &p
+		return fc
+	}
+
+	samples := DailyThroughput(completions, now, opts.WindowDays)
+	total := 0
+	for _, s := range samples {
+		total += s
+	}
+	fc.HistoryDays = len(samples)
+	fc.HistoryCompleted = total
+	if total < opts.MinCompletions {
+		fc.Status = StatusInsufficientHistory
+		return fc
+	}
+	fc.AvgDailyVelocity = math.Round(float64(total)/float64(len(samples))*100) / 100
+
+	// Seed from the plan ID so repeated requests on unchanged data agree.
+	rng := rand.New(rand.NewSource(seedFor(plan.ID.Hex())))
+	days := make([]int, opts.Trials)
+	for i := range days {
+		done, d := 0, 0
+		for done < remaining && d < maxSimulatedDays {
+			done += samples[rng.Intn(len(samples))]
+			d++
+		}
+		days[i] = d
+	}
+	sort.Ints(days)
+
+	// Day 1 of a trial is today, so a trial that finishes in d days lands on today+d-1.
+	today := startOfDay(now)
+	p50 := today.AddDate(0, 0, percentile(days, 0.50)-1)
+	p85 := today.AddDate(0, 0, percentile(days, 0.85)-1)
+	p95 := today.AddDate(0, 0, percentile(days, 0.95)-1)
+	fc.P50, fc.P85, fc.P95 = &p50, &p85, &p95
+	fc.Trials = opts.Trials
+	fc.Status = StatusOK
+
+	if fc.LastDeadline != nil {
+		deadlineDay := startOfDay(fc.LastDeadline.In(now.Location()))
+		onTime := 0
+		for _, d := range days {
+			if !today.AddDate(0, 0, d-1).After(deadlineDay) {
+				onTime++
+			}
+		}
+		p := math.Round(float64(onTime)/float64(len(days))*1000) / 1000
+		fc.OnTimeProbability = &p
+	}
+
+	return fc
+}
+
+func percentile(sorted []int, p float64) int {
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return sorted[idx]
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+func seedFor(id string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(id))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,43 @@
+package forecast
+
+import (
+	"testing"
+	"time"
+
+	"smart-task-planner/internal/modules/plan/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCompletionTimes(t *testing.T) {
+	at := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	done := func(title, assignee string, subtasks ...models.Task) models.Task {
+		return models.Task{ID: primitive.NewObjectID(), Title: title, Status: "Completed", CompletedAt: &at, AssigneeID: assignee, SubTasks: subtasks}
+	}
+	plans := []models.Plan{
+		{UserID: "ann", Tasks: []models.Task{
+			done("mine", ""),
+			done("bob's", "bob", done("bob's subtask", "")),
+			{ID: primitive.NewObjectID(), Title: "open", Status: "Pending"},
+		}},
+		// a workspace plan bob created
+		{UserID: "bob", WorkspaceID: "ws", Tasks: []models.Task{
+			done("bob's own", ""),
+			done("assigned to ann", "ann", done("ann's subtask", ""), done("handed to bob", "bob")),
+		}},
+	}
+
+	tests := []struct {
+		user string
+		want int
+	}{
+		{"ann", 3},
+		{"bob", 4},
+		{"carol", 0},
+	}
+	for _, tt := range tests {
+		if got := len(CompletionTimes(plans, tt.user)); got != tt.want {
+			t.Errorf("%s: %d completions, want %d", tt.user, got, tt.want)
+		}
+	}
+}
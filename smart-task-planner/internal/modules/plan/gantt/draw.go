@@ -0,0 +1,239 @@
+package gantt
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	padding     = 16.0
+	titleHeight = 28.0
+	axisHeight  = 34.0
+	labelWidth  = 280.0
+	rowHeight   = 26.0
+	barHeight   = 14.0
+	summaryBar  = 8.0
+	indentWidth = 14.0
+	charWidth   = 7.0 // matches the 7x13 raster font; SVG text is close enough
+	targetWidth = 960.0
+
+	// maxSide caps either side of a chart, about 14 years at the narrowest
+	// day width or 600 rows
+	maxSide = 16000.0
+
+	colorText     = "#1f2937"
+	colorMuted    = "#6b7280"
+	colorGrid     = "#e5e7eb"
+	colorWeekend  = "#f9fafb"
+	colorToday    = "#dc2626"
+	colorOverdue  = "#ef4444"
+	colorSummary  = "#374151"
+	colorLink     = "#4b5563"
+	colorRowShade = "#f3f4f6"
+)
+
+var statusColors = map[string]string{
+	"pending":     "#9ca3af",
+	"in progress": "#3b82f6",
+	"completed":   "#22c55e",
+	"cancelled":   "#d1d5db",
+}
+
+type point struct{ X, Y float64 }
+
+// canvas is the small set of primitives both the SVG and PNG backends
+// implement, so the chart is drawn by one routine.
+type canvas interface {
+	rect(x, y, w, h float64, fill string)
+	strokeRect(x, y, w, h float64, stroke string, width float64)
+	line(x1, y1, x2, y2 float64, stroke string, width float64, dashed bool)
+	polyline(pts []point, stroke string, width float64)
+	polygon(pts []point, fill string)
+	text(x, y float64, s string, fill string, anchor string, bold bool)
+}
+
+// geometry maps dates and rows to pixels
+type geometry struct {
+	chart    *Chart
+	dayWidth float64
+	days     int
+	width    float64
+	height   float64
+	plotX    float64
+	plotY    float64
+}
+
+// ErrTooLarge is returned for charts whose span or row count would need an
+// image larger than maxSide pixels
+var ErrTooLarge = fmt.Errorf("chart is too large to draw: it may span about 14 years and 600 rows at most")
+
+func newGeometry(c *Chart) (geometry, error) {
+	days := int(math.Round(c.End.Sub(c.Start).Hours() / 24))
+	if days < 1 {
+		days = 1
+	}
+	dayWidth := math.Max(3, math.Min(36, targetWidth/float64(days)))
+	g := geometry{
+		chart:    c,
+		dayWidth: dayWidth,
+		days:     days,
+		plotX:    padding + labelWidth,
+		plotY:    padding + titleHeight + axisHeight,
+	}
+	g.width = g.plotX + float64(days)*dayWidth + padding
+	g.height = g.plotY + float64(len(c.Rows))*rowHeight + padding
+	if g.width > maxSide || g.height > maxSide {
+		return geometry{}, ErrTooLarge
+	}
+	return g, nil
+}
+
+func (g geometry) x(t time.Time) float64 {
+	return g.plotX + t.Sub(g.chart.Start).Hours()/24*g.dayWidth
+}
+
+func (g geometry) rowY(i int) float64 {
+	return g.plotY + float64(i)*rowHeight
+}
+
+// render draws the whole chart onto cv
+func render(c *Chart, g geometry, cv canvas) {
+	cv.rect(0, 0, g.width, g.height, "#ffffff")
+	cv.text(padding, padding+16, truncate(c.Goal, int((g.width-2*padding)/charWidth)), colorText, "start", true)
+
+	plotBottom := g.plotY + float64(len(c.Rows))*rowHeight
+
+	// zebra rows
+	for i := range c.Rows {
+		if i%2 == 1 {
+			cv.rect(padding, g.rowY(i), g.width-2*padding, rowHeight, colorRowShade)
+		}
+	}
+
+	// weekends and axis ticks
+	step, monthly := tickStep(g.dayWidth)
+	for d := 0; d < g.days; d++ {
+		day := c.Start.AddDate(0, 0, d)
+		x := g.x(day)
+		if g.dayWidth >= 8 && (day.Weekday() == time.Saturday || day.Weekday() == time.Sunday) {
+			cv.rect(x, g.plotY, g.dayWidth, plotBottom-g.plotY, colorWeekend)
+		}
+
+		tick := false
+		label := ""
+		switch {
+		case monthly:
+			tick = day.Day() == 1
+			label = day.Format("Jan 2006")
+		case step == 7:
+			tick = day.Weekday() == time.Monday
+			label = day.Format("Jan 2")
+		default:
+			tick = true
+			label = day.Format("2")
+			if day.Day() == 1 || d == 0 {
+				cv.text(x+2, g.plotY-axisHeight+12, day.Format("Jan"), colorMuted, "start", true)
+			}
+		}
+		if tick {
+			cv.line(x, g.plotY-6, x, plotBottom, colorGrid, 1, false)
+			cv.text(x+2, g.plotY-10, label, colorMuted, "start", false)
+		}
+	}
+	cv.line(padding, g.plotY, g.width-padding, g.plotY, colorGrid, 1, false)
+
+	// labels and bars
+	for i, r := range c.Rows {
+		y := g.rowY(i)
+		indent := float64(r.Depth) * indentWidth
+		maxChars := int((labelWidth - indent - 8) / charWidth)
+		labelColor := colorText
+		if r.Overdue {
+			labelColor = colorOverdue
+		}
+		cv.text(padding+indent, y+rowHeight/2+4, truncate(r.Title, maxChars), labelColor, "start", r.Summary)
+
+		if r.Start == nil {
+			cv.text(g.plotX+4, y+rowHeight/2+4, "no deadline", colorMuted, "start", false)
+			continue
+		}
+
+		x1, x2 := g.x(*r.Start), g.x(*r.End)
+		if r.Summary {
+			by := y + (rowHeight-summaryBar)/2
+			cv.rect(x1, by, x2-x1, summaryBar, colorSummary)
+			// bracket ends
+			cv.polygon([]point{{x1, by + summaryBar}, {x1 + 6, by + summaryBar}, {x1, by + summaryBar + 5}}, colorSummary)
+			cv.polygon([]point{{x2, by + summaryBar}, {x2 - 6, by + summaryBar}, {x2, by + summaryBar + 5}}, colorSummary)
+		} else {
+			by := y + (rowHeight-barHeight)/2
+			cv.rect(x1, by, x2-x1, barHeight, statusColor(r.Status))
+			if r.Overdue {
+				cv.strokeRect(x1, by, x2-x1, barHeight, colorOverdue, 2)
+			}
+		}
+	}
+
+	// dependency arrows: from the end of the prerequisite to the start of the dependent
+	index := map[string]int{}
+	for i, r := range c.Rows {
+		index[r.TaskID] = i
+	}
+	for _, l := range c.Links {
+		from, to := c.Rows[index[l.From]], c.Rows[index[l.To]]
+		if from.End == nil || to.Start == nil {
+			continue
+		}
+		fx, fy := g.x(*from.End), g.rowY(index[l.From])+rowHeight/2
+		tx, ty := g.x(*to.Start), g.rowY(index[l.To])+rowHeight/2
+		elbow := math.Max(fx+6, tx-8)
+		pts := []point{{fx, fy}, {elbow, fy}, {elbow, ty}, {tx - 1, ty}}
+		if elbow > tx-8 {
+			// dependent starts before its prerequisite ends: route around
+			midY := fy + (ty-fy)/2
+			pts = []point{{fx, fy}, {fx + 6, fy}, {fx + 6, midY}, {tx - 8, midY}, {tx - 8, ty}, {tx - 1, ty}}
+		}
+		cv.polyline(pts, colorLink, 1.2)
+		cv.polygon([]point{{tx, ty}, {tx - 6, ty - 4}, {tx - 6, ty + 4}}, colorLink)
+	}
+
+	// today marker
+	if !c.Today.Before(c.Start) && c.Today.Before(c.End) {
+		x := g.x(c.Today)
+		cv.line(x, g.plotY-4, x, plotBottom, colorToday, 1.5, true)
+		cv.text(x, g.plotY-axisHeight+12, "today", colorToday, "middle", true)
+	}
+}
+
+// tickStep picks daily, weekly or monthly ticks for the zoom level
+func tickStep(dayWidth float64) (int, bool) {
+	switch {
+	case dayWidth >= 22:
+		return 1, false
+	case dayWidth >= 6:
+		return 7, false
+	}
+	return 30, true
+}
+
+func statusColor(status string) string {
+	if c, ok := statusColors[strings.ToLower(status)]; ok {
+		return c
+	}
+	return statusColors["pending"]
+}
+
+// truncate uses ASCII dots since the raster font has no ellipsis glyph
+func truncate(s string, max int) string {
+	if max < 4 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:max-3]) + "..."
+}
@@ -0,0 +1,181 @@
+package gantt
+
+import (
+	"math"
+	"time"
+
+	"smart-task-planner/internal/modules/plan/models"
+	"smart-task-planner/internal/modules/plan/progress"
+)
+
+const defaultHoursPerDay = 8.0
+
+// Bar is one row of the chart. Start/End are whole days: a task due on
+// Oct 20 ends at Oct 21 00:00 so its bar covers the 20th.
+type Bar struct {
+	TaskID    string     `json:"task_id"`
+	ParentID  string     `json:"parent_id,omitempty"`
+	Title     string     `json:"title"`
+	Depth     int        `json:"depth"`
+	Status    string     `json:"status"`
+	Start     *time.Time `json:"start,omitempty"`
+	End       *time.Time `json:"end,omitempty"`
+	Deadline  *time.Time `json:"deadline,omitempty"`
+	Overdue   bool       `json:"overdue"`
+	Summary   bool       `json:"summary"` // spans its subtasks
+	Estimated bool       `json:"estimated"`
+}
+
+// Link is a dependency arrow from the task that must finish first.
+type Link struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Chart is the laid-out timeline shared by the JSON, SVG and PNG outputs.
+type Chart struct {
+	PlanID string    `json:"plan_id"`
+	Goal   string    `json:"goal"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Today  time.Time `json:"today"`
+	Rows   []Bar     `json:"rows"`
+	Links  []Link    `json:"links"`
+}
+
+// Options control how bar lengths are derived.
+type Options struct {
+	HoursPerDay float64 // working hours per day when converting estimates; default 8
+}
+
+// Build lays out a plan. Tasks only carry deadlines, so a bar starts
+// EstimatedHours/HoursPerDay days before its deadline (tracked hours once
+// the task is completed); without an estimate
+// it starts where the previous sibling ended (one day at least). Parents
+// with subtasks become summary bars spanning their children. Tasks with no
+// deadline anywhere below them get a row without a bar.
+func Build(plan *models.Plan, now time.Time, opts Options) *Chart {
+	if opts.HoursPerDay <= 0 {
+		opts.HoursPerDay = defaultHoursPerDay
+	}
+
+	c := &Chart{PlanID: plan.ID.Hex(), Goal: plan.Goal, Today: now, Rows: []Bar{}, Links: []Link{}}
+	c.addTasks(plan.Tasks, "", 0, nil, now, opts)
+
+	known := map[string]bool{}
+	for _, r := range c.Rows {
+		known[r.TaskID] = true
+	}
+	var collect func(tasks []models.Task)
+	collect = func(tasks []models.Task) {
+		for _, t := range tasks {
+			for _, dep := range t.DependsOn {
+				if known[dep.Hex()] {
+					c.Links = append(c.Links, Link{From: dep.Hex(), To: t.ID.Hex()})
+				}
+			}
+			collect(t.SubTasks)
+		}
+	}
+	collect(plan.Tasks)
+
+	c.Start, c.End = c.span(now)
+	return c
+}
+
+// addTasks appends rows for tasks (depth-first) and returns the span they cover
+func (c *Chart) addTasks(tasks []models.Task, parentID string, depth int, prevEnd *time.Time, now time.Time, opts Options) (*time.Time, *time.Time) {
+	var first, last *time.Time
+	for _, t := range tasks {
+		idx := len(c.Rows)
+		bar := Bar{
+			TaskID:   t.ID.Hex(),
+			ParentID: parentID,
+			Title:    t.Title,
+			Depth:    depth,
+			Status:   t.Status,
+			Overdue:  progress.IsOverdue(t, now),
+			Summary:  len(t.SubTasks) > 0,
+		}
+		c.Rows = append(c.Rows, bar)
+
+		var start, end *time.Time
+		if !t.Deadline.IsZero() {
+			d := t.Deadline
+			bar.Deadline = &d
+			e := dayStart(t.Deadline).AddDate(0, 0, 1)
+			s := e.AddDate(0, 0, -1)
+			switch hours := progress.EffortHours(t); {
+			case hours > 0:
+				days := int(math.Ceil(hours / opts.HoursPerDay))
+				s = e.AddDate(0, 0, -days)
+				bar.Estimated = true
+			case prevEnd != nil && prevEnd.Before(s):
+				s = *prevEnd
+			}
+			start, end = &s, &e
+		}
+
+		childStart, childEnd := c.addTasks(t.SubTasks, bar.TaskID, depth+1, start, now, opts)
+		start = minTime(start, childStart)
+		end = maxTime(end, childEnd)
+
+		bar.Start, bar.End = start, end
+		c.Rows[idx] = bar
+
+		if end != nil {
+			prevEnd = end
+		}
+		first = minTime(first, start)
+		last = maxTime(last, end)
+	}
+	return first, last
+}
+
+// span pads the bars (and today) by a day on each side
+func (c *Chart) span(now time.Time) (time.Time, time.Time) {
+	today := dayStart(now)
+	start, end := today, today.AddDate(0, 0, 1)
+	hasBars := false
+	for _, r := range c.Rows {
+		if r.Start == nil {
+			continue
+		}
+		hasBars = true
+		if r.Start.Before(start) {
+			start = *r.Start
+		}
+		if r.End.After(end) {
+			end = *r.End
+		}
+	}
+	if !hasBars {
+		return today.AddDate(0, 0, -3), today.AddDate(0, 0, 14)
+	}
+	return start.AddDate(0, 0, -1), end.AddDate(0, 0, 1)
+}
+
+func dayStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func minTime(a, b *time.Time) *time.Time {
+	if a == nil {
+		return b
+	}
+	if b == nil || a.Before(*b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b *time.Time) *time.Time {
+	if a == nil {
+		return b
+	}
+	if b == nil || a.After(*b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,144 @@
+package gantt
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strconv"
+	"unicode/utf8"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// PNG rasterizes the same drawing as SVG, in pure Go.
+func PNG(c *Chart) ([]byte, error) {
+	g, err := newGeometry(c)
+	if err != nil {
+		return nil, err
+	}
+	w, h := int(math.Ceil(g.width)), int(math.Ceil(g.height))
+	cv := &pngCanvas{img: image.NewRGBA(image.Rect(0, 0, w, h))}
+	render(c, g, cv)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, cv.img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type pngCanvas struct {
+	img *image.RGBA
+}
+
+// fill rasterizes a polygon; the rasterizer only covers the shape's
+// bounding box, which keeps many small shapes cheap.
+func (p *pngCanvas) fill(pts []point, col string) {
+	minX, minY, maxX, maxY := pts[0].X, pts[0].Y, pts[0].X, pts[0].Y
+	for _, pt := range pts[1:] {
+		minX, minY = math.Min(minX, pt.X), math.Min(minY, pt.Y)
+		maxX, maxY = math.Max(maxX, pt.X), math.Max(maxY, pt.Y)
+	}
+	box := image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX))+1, int(math.Ceil(maxY))+1).
+		Intersect(p.img.Bounds())
+	if box.Empty() {
+		return
+	}
+
+	z := vector.NewRasterizer(box.Dx(), box.Dy())
+	z.DrawOp = draw.Over
+	ox, oy := float64(box.Min.X), float64(box.Min.Y)
+	z.MoveTo(float32(pts[0].X-ox), float32(pts[0].Y-oy))
+	for _, pt := range pts[1:] {
+		z.LineTo(float32(pt.X-ox), float32(pt.Y-oy))
+	}
+	z.ClosePath()
+	z.Draw(p.img, box, image.NewUniform(parseHex(col)), image.Point{})
+}
+
+func (p *pngCanvas) rect(x, y, w, h float64, fill string) {
+	if w <= 0 || h <= 0 {
+		return
+	}
+	p.fill([]point{{x, y}, {x + w, y}, {x + w, y + h}, {x, y + h}}, fill)
+}
+
+func (p *pngCanvas) strokeRect(x, y, w, h float64, stroke string, width float64) {
+	p.line(x, y, x+w, y, stroke, width, false)
+	p.line(x+w, y, x+w, y+h, stroke, width, false)
+	p.line(x+w, y+h, x, y+h, stroke, width, false)
+	p.line(x, y+h, x, y, stroke, width, false)
+}
+
+// line is drawn as a thin quad; dashes as a row of short quads
+func (p *pngCanvas) line(x1, y1, x2, y2 float64, stroke string, width float64, dashed bool) {
+	length := math.Hypot(x2-x1, y2-y1)
+	if length == 0 {
+		return
+	}
+	if !dashed {
+		p.segment(x1, y1, x2, y2, stroke, width)
+		return
+	}
+	dx, dy := (x2-x1)/length, (y2-y1)/length
+	for d := 0.0; d < length; d += 7 {
+		end := math.Min(d+4, length)
+		p.segment(x1+dx*d, y1+dy*d, x1+dx*end, y1+dy*end, stroke, width)
+	}
+}
+
+func (p *pngCanvas) segment(x1, y1, x2, y2 float64, stroke string, width float64) {
+	length := math.Hypot(x2-x1, y2-y1)
+	nx, ny := -(y2-y1)/length*width/2, (x2-x1)/length*width/2
+	p.fill([]point{{x1 + nx, y1 + ny}, {x2 + nx, y2 + ny}, {x2 - nx, y2 - ny}, {x1 - nx, y1 - ny}}, stroke)
+}
+
+func (p *pngCanvas) polyline(pts []point, stroke string, width float64) {
+	for i := 1; i < len(pts); i++ {
+		p.line(pts[i-1].X, pts[i-1].Y, pts[i].X, pts[i].Y, stroke, width, false)
+	}
+}
+
+func (p *pngCanvas) polygon(pts []point, fill string) {
+	p.fill(pts, fill)
+}
+
+func (p *pngCanvas) text(x, y float64, s string, fill string, anchor string, bold bool) {
+	face := basicfont.Face7x13
+	width := float64(utf8.RuneCountInString(s) * face.Advance)
+	switch anchor {
+	case "middle":
+		x -= width / 2
+	case "end":
+		x -= width
+	}
+	d := &font.Drawer{
+		Dst:  p.img,
+		Src:  image.NewUniform(parseHex(fill)),
+		Face: face,
+		Dot:  fixed.P(int(math.Round(x)), int(math.Round(y))),
+	}
+	d.DrawString(s)
+	if bold {
+		d.Dot = fixed.P(int(math.Round(x))+1, int(math.Round(y)))
+		d.DrawString(s)
+	}
+}
+
+// parseHex reads #rrggbb
+func parseHex(s string) color.RGBA {
+	if len(s) != 7 || s[0] != '#' {
+		return color.RGBA{A: 255}
+	}
+	v, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return color.RGBA{A: 255}
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}
+}
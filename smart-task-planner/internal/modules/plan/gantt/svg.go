@@ -0,0 +1,74 @@
+package gantt
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// SVG renders the chart as a standalone SVG document.
+func SVG(c *Chart) ([]byte, error) {
+	g, err := newGeometry(c)
+	if err != nil {
+		return nil, err
+	}
+	cv := &svgCanvas{}
+	fmt.Fprintf(&cv.buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %s %s" font-family="DejaVu Sans Mono, Menlo, Consolas, monospace" font-size="12">`+"\n",
+		num(g.width), num(g.height), num(g.width), num(g.height))
+	fmt.Fprintf(&cv.buf, "<title>%s</title>\n", html.EscapeString(c.Goal))
+	render(c, g, cv)
+	cv.buf.WriteString("</svg>\n")
+	return cv.buf.Bytes(), nil
+}
+
+type svgCanvas struct {
+	buf bytes.Buffer
+}
+
+func (s *svgCanvas) rect(x, y, w, h float64, fill string) {
+	fmt.Fprintf(&s.buf, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s"/>`+"\n", num(x), num(y), num(w), num(h), fill)
+}
+
+func (s *svgCanvas) strokeRect(x, y, w, h float64, stroke string, width float64) {
+	fmt.Fprintf(&s.buf, `<rect x="%s" y="%s" width="%s" height="%s" fill="none" stroke="%s" stroke-width="%s"/>`+"\n",
+		num(x), num(y), num(w), num(h), stroke, num(width))
+}
+
+func (s *svgCanvas) line(x1, y1, x2, y2 float64, stroke string, width float64, dashed bool) {
+	dash := ""
+	if dashed {
+		dash = ` stroke-dasharray="4 3"`
+	}
+	fmt.Fprintf(&s.buf, `<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="%s" stroke-width="%s"%s/>`+"\n",
+		num(x1), num(y1), num(x2), num(y2), stroke, num(width), dash)
+}
+
+func (s *svgCanvas) polyline(pts []point, stroke string, width float64) {
+	fmt.Fprintf(&s.buf, `<polyline points="%s" fill="none" stroke="%s" stroke-width="%s"/>`+"\n", points(pts), stroke, num(width))
+}
+
+func (s *svgCanvas) polygon(pts []point, fill string) {
+	fmt.Fprintf(&s.buf, `<polygon points="%s" fill="%s"/>`+"\n", points(pts), fill)
+}
+
+func (s *svgCanvas) text(x, y float64, str string, fill string, anchor string, bold bool) {
+	weight := ""
+	if bold {
+		weight = ` font-weight="bold"`
+	}
+	fmt.Fprintf(&s.buf, `<text x="%s" y="%s" fill="%s" text-anchor="%s"%s>%s</text>`+"\n",
+		num(x), num(y), fill, anchor, weight, html.EscapeString(str))
+}
+
+func points(pts []point) string {
+	parts := make([]string, len(pts))
+	for i, p := range pts {
+		parts[i] = num(p.X) + "," + num(p.Y)
+	}
+	return strings.Join(parts, " ")
+}
+
+func num(f float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", f), "0"), ".")
+}
@@ -1,10 +1,19 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+
 	"smart-task-planner/internal/modules/plan/dto"
+	"smart-task-planner/internal/modules/plan/gantt"
 	"smart-task-planner/internal/modules/plan/models"
+	"smart-task-planner/internal/modules/plan/repository"
 	"smart-task-planner/internal/modules/plan/service"
+	"smart-task-planner/internal/modules/plan/transfer"
 
 	"github.com/gin-gonic/gin"
 )
@@ -42,8 +51,9 @@ func (h *PlanHandler) GenerateDraftPlan(c *gin.Context) {
 
 // ConfirmPlanRequest is used when user confirms the draft plan
 type ConfirmPlanRequest struct {
-	Goal  string       `json:"goal" binding:"required"`
-	Tasks []models.Task `json:"tasks" binding:"required"`
+	Goal        string        `json:"goal" binding:"required"`
+	Tasks       []models.Task `json:"tasks" binding:"required"`
+	WorkspaceID string        `json:"workspace_id"` // optional, saves the plan in a workspace
 }
 
 // ConfirmPlan saves the confirmed plan to the database
@@ -56,8 +66,12 @@ func (h *PlanHandler) ConfirmPlan(c *gin.Context) {
 		return
 	}
 
-	plan, err := h.service.ConfirmPlan(userID, req.Goal, req.Tasks)
+	plan, err := h.service.ConfirmPlan(userID, req.WorkspaceID, req.Goal, req.Tasks)
 	if err != nil {
+		if err.Error() == "you can't add plans to this workspace" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to confirm plan"})
 		return
 	}
@@ -65,20 +79,69 @@ func (h *PlanHandler) ConfirmPlan(c *gin.Context) {
 	c.JSON(http.StatusCreated, plan)
 }
 
-// GetPlans fetches all plans for the current user
+// GetPlans lists the plans the current user can see
+// (?workspace=&cursor=&limit=&status=&q=&created_from=&created_to=&priority=&tag=
+// &context=&field.<key>=&sort=&order=&view=). With cursor or limit it returns
+// one page; without, a plain array of every matching plan, as it always did.
 func (h *PlanHandler) GetPlans(c *gin.Context) {
 	userID := c.GetString("user_id")
 
-	plans, err := h.service.GetAllPlans(userID)
+	var req dto.ListPlansRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Fields = fieldParams(c)
+
+	_, hasCursor := c.GetQuery("cursor")
+	_, hasLimit := c.GetQuery("limit")
+	var result interface{}
+	var err error
+	if hasCursor || hasLimit {
+		result, err = h.service.ListPlans(userID, req)
+	} else {
+		result, err = h.service.ListAllPlans(userID, req)
+	}
 	if err != nil {
+		var queryErr *repository.QueryError
+		if errors.As(err, &queryErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch plans"})
 		return
 	}
 
-	c.JSON(http.StatusOK, plans)
+	c.JSON(http.StatusOK, result)
+}
+
+// GetPlan fetches a single plan of the current user
+func (h *PlanHandler) GetPlan(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	plan, err := h.service.GetPlan(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+func (h *PlanHandler) DeletePlan(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	if err := h.service.DeletePlan(userID, c.Param("id")); err != nil {
+		c.JSON(errStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Plan deleted"})
 }
 
 func (h *PlanHandler) RefineTask(c *gin.Context) {
+	userID := c.GetString("user_id")
+
 	var req struct {
 		TaskID string `json:"task_id" binding:"required"`
 	}
@@ -88,7 +151,7 @@ func (h *PlanHandler) RefineTask(c *gin.Context) {
 		return
 	}
 
-	task, err := h.service.GetTaskDetails(req.TaskID)
+	task, err := h.service.GetTaskDetails(userID, req.TaskID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -104,6 +167,8 @@ func (h *PlanHandler) RefineTask(c *gin.Context) {
 }
 
 func (h *PlanHandler) UpdateTaskStatus(c *gin.Context) {
+	userID := c.GetString("user_id")
+
 	var req struct {
 		TaskID string `json:"task_id" binding:"required"`
 		Status string `json:"status" binding:"required"`
@@ -114,9 +179,9 @@ func (h *PlanHandler) UpdateTaskStatus(c *gin.Context) {
 		return
 	}
 
-	task, err := h.service.UpdateTaskStatus(req.TaskID, req.Status)
+	task, err := h.service.UpdateTaskStatus(userID, req.TaskID, req.Status)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -124,13 +189,14 @@ func (h *PlanHandler) UpdateTaskStatus(c *gin.Context) {
 }
 
 func (h *PlanHandler) GetTaskDetails(c *gin.Context) {
+	userID := c.GetString("user_id")
 	taskID := c.Query("task_id")
 	if taskID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "task_id query param required"})
 		return
 	}
 
-	task, err := h.service.GetTaskDetails(taskID)
+	task, err := h.service.GetTaskDetails(userID, taskID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -140,6 +206,8 @@ func (h *PlanHandler) GetTaskDetails(c *gin.Context) {
 }
 
 func (h *PlanHandler) AddSubTasks(c *gin.Context) {
+	userID := c.GetString("user_id")
+
 	var req struct {
 		PlanID   string         `json:"plan_id" binding:"required"`
 		TaskID   string         `json:"task_id" binding:"required"`
@@ -151,12 +219,279 @@ func (h *PlanHandler) AddSubTasks(c *gin.Context) {
 		return
 	}
 
-	updatedPlan, err := h.service.AddSubTasks(req.PlanID, req.TaskID, req.SubTasks)
+	updatedPlan, err := h.service.AddSubTasks(userID, req.PlanID, req.TaskID, req.SubTasks)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, updatedPlan)
 }
 
+// GetForecast returns P50/P85/P95 finish dates for a plan
+func (h *PlanHandler) GetForecast(c *gin.Context) {
+	userID := c.GetString("user_id")
+	planID := c.Param("id")
+
+	fc, err := h.service.ForecastPlan(userID, planID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, fc)
+}
+
+// GetProgress returns weighted progress with a per-milestone breakdown
+func (h *PlanHandler) GetProgress(c *gin.Context) {
+	userID := c.GetString("user_id")
+	weighting := c.DefaultQuery("weighting", "count")
+
+	result, err := h.service.GetProgress(userID, c.Param("id"), weighting)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ExportPlan handles GET /api/plan/:id/export?format=markdown|csv|json
+func (h *PlanHandler) ExportPlan(c *gin.Context) {
+	userID := c.GetString("user_id")
+	planID := c.Param("id")
+
+	data, format, err := h.service.ExportPlan(userID, planID, c.DefaultQuery("format", "json"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="plan-%s.%s"`, planID, transfer.Extension(format)))
+	c.Data(http.StatusOK, transfer.ContentType(format), data)
+}
+
+// ImportPlan handles POST /api/plan/import with a multipart "file" field or a
+// raw body. ?format= is optional (detected otherwise), ?goal= renames the plan.
+func (h *PlanHandler) ImportPlan(c *gin.Context) {
+	userID := c.GetString("user_id")
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, 5<<20)
+
+	var data []byte
+	var filename string
+	var err error
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fileHeader, ferr := c.FormFile("file")
+		if ferr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file field required"})
+			return
+		}
+		f, ferr := fileHeader.Open()
+		if ferr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": ferr.Error()})
+			return
+		}
+		defer f.Close()
+		filename = fileHeader.Filename
+		data, err = io.ReadAll(f)
+	} else {
+		data, err = io.ReadAll(c.Request.Body)
+	}
+	if err != nil || len(data) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Import file required (max 5 MB)"})
+		return
+	}
+
+	format := c.DefaultPostForm("format", c.Query("format"))
+	if format == "" {
+		switch {
+		case strings.Contains(c.ContentType(), "markdown"):
+			format = transfer.FormatMarkdown
+		case strings.Contains(c.ContentType(), "csv"):
+			format = transfer.FormatCSV
+		case strings.Contains(c.ContentType(), "json"):
+			format = transfer.FormatJSON
+		}
+	}
+
+	plan, err := h.service.ImportPlan(userID, data, format, filename, c.DefaultPostForm("goal", c.Query("goal")))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, plan)
+}
+
+// GetTimeline returns the laid-out rows, bars and dependency links as JSON
+func (h *PlanHandler) GetTimeline(c *gin.Context) {
+	chart, ok := h.timeline(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, chart)
+}
+
+// GanttSVG handles GET /api/plan/:id/gantt.svg
+func (h *PlanHandler) GanttSVG(c *gin.Context) {
+	chart, ok := h.timeline(c)
+	if !ok {
+		return
+	}
+	data, err := gantt.SVG(chart)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "image/svg+xml", data)
+}
+
+// GanttPNG handles GET /api/plan/:id/gantt.png
+func (h *PlanHandler) GanttPNG(c *gin.Context) {
+	chart, ok := h.timeline(c)
+	if !ok {
+		return
+	}
+
+	data, err := gantt.PNG(chart)
+	if errors.Is(err, gantt.ErrTooLarge) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render chart"})
+		return
+	}
+	c.Data(http.StatusOK, "image/png", data)
+}
+
+// timeline builds the chart for :id; ?hours_per_day= changes how estimates become bar lengths
+func (h *PlanHandler) timeline(c *gin.Context) (*gantt.Chart, bool) {
+	userID := c.GetString("user_id")
+	hoursPerDay, _ := strconv.ParseFloat(c.Query("hours_per_day"), 64)
+
+	chart, err := h.service.Timeline(userID, c.Param("id"), hoursPerDay)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return chart, true
+}
+
+// SetDependencies handles PUT /api/plan/:id/tasks/:task_id/dependencies
+func (h *PlanHandler) SetDependencies(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req struct {
+		DependsOn []string `json:"depends_on"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, err := h.service.SetDependencies(userID, c.Param("id"), c.Param("task_id"), req.DependsOn)
+	if err != nil {
+		c.JSON(errStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// AssignTask handles PUT /api/plan/:id/tasks/:task_id/assignee
+// ({"assignee_id": "..."}, {"assignee": "Priya"} or {"assignee_id": ""} to unassign)
+func (h *PlanHandler) AssignTask(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req dto.AssignTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	task, err := h.service.AssignTask(userID, c.Param("id"), c.Param("task_id"), req)
+	if err != nil {
+		c.JSON(errStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// SetRecurrence handles PUT /api/plan/:id/tasks/:task_id/recurrence
+func (h *PlanHandler) SetRecurrence(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req struct {
+		RRule string `json:"rrule"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, err := h.service.SetRecurrence(userID, c.Param("id"), c.Param("task_id"), req.RRule)
+	if err != nil {
+		c.JSON(errStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// UpdateTaskAttributes handles PATCH /api/plan/:id/tasks/:task_id
+// ({"priority", "tags", "contexts", "fields"})
+func (h *PlanHandler) UpdateTaskAttributes(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req dto.UpdateTaskAttributesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	task, err := h.service.UpdateTaskAttributes(userID, c.Param("id"), c.Param("task_id"), req)
+	if err != nil {
+		c.JSON(errStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// GetHabits handles GET /api/plan/:id/habits
+func (h *PlanHandler) GetHabits(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	habits, err := h.service.Habits(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"habits": habits})
+}
+
+// fieldParams reads custom field filters written as field.<key>=value
+func fieldParams(c *gin.Context) map[string]string {
+	fields := map[string]string{}
+	for name, values := range c.Request.URL.Query() {
+		if key, ok := strings.CutPrefix(name, "field."); ok && key != "" && len(values) > 0 {
+			fields[key] = values[0]
+		}
+	}
+	return fields
+}
+
+// errStatus answers 403 when the user may see the plan but not make this
+// change, and 409 when someone else changed it first
+func errStatus(err error, fallback int) int {
+	if errors.Is(err, repository.ErrReadOnly) || errors.Is(err, repository.ErrNotOwner) {
+		return http.StatusForbidden
+	}
+	if errors.Is(err, repository.ErrConflict) {
+		return http.StatusConflict
+	}
+	return fallback
+}
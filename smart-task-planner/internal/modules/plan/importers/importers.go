@@ -0,0 +1,198 @@
+package importers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"smart-task-planner/internal/modules/plan/models"
+)
+
+const (
+	statusPending    = "Pending"
+	statusInProgress = "In Progress"
+	statusCompleted  = "Completed"
+)
+
+// Importer converts another tool's export into plans. Imported plans have
+// no IDs or owner yet; the caller assigns them when saving.
+type Importer interface {
+	Name() string
+	Detect(data []byte, filename string) bool
+	Import(data []byte, opts Options) (*Result, error)
+}
+
+// Options tune how an export is mapped.
+type Options struct {
+	Goal       string // plan name for single-project sources without one (Todoist CSV)
+	SplitLists bool   // Trello: one plan per list instead of per board
+}
+
+// Unmapped is a source field that had data but no place in a Plan.
+type Unmapped struct {
+	Field   string `json:"field"`
+	Count   int    `json:"count"`
+	Example string `json:"example,omitempty"`
+}
+
+// Result is the preview of an import: the plans that would be created and
+// everything that was dropped on the way.
+type Result struct {
+	Source   string        `json:"source"`
+	Plans    []models.Plan `json:"plans"`
+	Unmapped []Unmapped    `json:"unmapped_fields"`
+	Skipped  int           `json:"skipped"` // deleted, archived or template items
+	Warnings []string      `json:"warnings,omitempty"`
+}
+
+// TaskCount counts every task and subtask across the result's plans.
+func (r *Result) TaskCount() int {
+	var count func(tasks []models.Task) int
+	count = func(tasks []models.Task) int {
+		n := len(tasks)
+		for _, t := range tasks {
+			n += count(t.SubTasks)
+		}
+		return n
+	}
+	total := 0
+	for _, p := range r.Plans {
+		total += count(p.Tasks)
+	}
+	return total
+}
+
+var registry = []Importer{
+	todoistJSON{},
+	todoistCSV{},
+	trello{},
+	taskwarrior{},
+}
+
+// Names lists the supported sources.
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for _, imp := range registry {
+		names = append(names, imp.Name())
+	}
+	return names
+}
+
+// Run imports data with the named importer, or detects the source when
+// name is empty.
+func Run(name string, data []byte, filename string, opts Options) (*Result, error) {
+	var imp Importer
+	if name == "" {
+		for _, candidate := range registry {
+			if candidate.Detect(data, filename) {
+				imp = candidate
+				break
+			}
+		}
+		if imp == nil {
+			return nil, fmt.Errorf("could not detect the export format (supported: %s)", strings.Join(Names(), ", "))
+		}
+	} else {
+		for _, candidate := range registry {
+			if candidate.Name() == strings.ToLower(name) {
+				imp = candidate
+			}
+		}
+		if imp == nil {
+			return nil, fmt.Errorf("unknown source %q (supported: %s)", name, strings.Join(Names(), ", "))
+		}
+	}
+
+	if opts.Goal == "" && filename != "" {
+		opts.Goal = goalFromFilename(filename)
+	}
+	res, err := imp.Import(data, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", imp.Name(), err)
+	}
+	res.Source = imp.Name()
+	if res.Plans == nil {
+		res.Plans = []models.Plan{}
+	}
+	if res.Unmapped == nil {
+		res.Unmapped = []Unmapped{}
+	}
+	return res, nil
+}
+
+// report collects unmapped fields while an importer walks its input.
+type report struct {
+	fields map[string]*Unmapped
+	order  []string
+}
+
+func newReport() *report {
+	return &report{fields: map[string]*Unmapped{}}
+}
+
+// drop records that field had a value that could not be mapped
+func (r *report) drop(field string, value interface{}) {
+	example := fmt.Sprint(value)
+	if example == "" || example == "<nil>" || example == "[]" || example == "map[]" || example == "0" || example == "false" {
+		return
+	}
+	u, ok := r.fields[field]
+	if !ok {
+		if len(example) > 80 {
+			example = example[:77] + "..."
+		}
+		u = &Unmapped{Field: field, Example: example}
+		r.fields[field] = u
+		r.order = append(r.order, field)
+	}
+	u.Count++
+}
+
+func (r *report) list() []Unmapped {
+	out := make([]Unmapped, 0, len(r.order))
+	for _, f := range r.order {
+		out = append(out, *r.fields[f])
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Count > out[j].Count })
+	return out
+}
+
+// parseDate accepts the date and date-time shapes the supported tools emit.
+func parseDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, false
+	}
+	layouts := []string{
+		time.RFC3339Nano,
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04:05",
+		"2006-01-02 15:04",
+		"2006-01-02",
+		"20060102T150405Z",
+		"Jan 2 2006",
+		"Jan 2, 2006",
+		"January 2 2006",
+		"January 2, 2006",
+		"2 Jan 2006",
+		"2 January 2006",
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func appendParagraph(desc, extra string) string {
+	extra = strings.TrimSpace(extra)
+	if extra == "" {
+		return desc
+	}
+	if desc == "" {
+		return extra
+	}
+	return desc + "\n\n" + extra
+}
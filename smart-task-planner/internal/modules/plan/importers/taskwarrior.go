@@ -0,0 +1,189 @@
+package importers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"smart-task-planner/internal/modules/plan/models"
+)
+
+// fields Taskwarrior sets on its own that carry nothing worth importing
+var taskwarriorIgnored = map[string]bool{
+	"id": true, "uuid": true, "entry": true, "modified": true, "urgency": true,
+	"mask": true, "imask": true, "rtype": true,
+}
+
+// taskwarrior reads `task export` output (a JSON array, or one object per
+// line from older versions). The top-level project becomes the plan and
+// sub-projects ("home.garden") become grouping tasks; tasks without a
+// project go to an "Inbox" plan.
+type taskwarrior struct{}
+
+func (taskwarrior) Name() string { return "taskwarrior" }
+
+func (taskwarrior) Detect(data []byte, _ string) bool {
+	objs, err := taskwarriorObjects(data)
+	if err != nil || len(objs) == 0 {
+		return false
+	}
+	_, uuid := objs[0]["uuid"]
+	_, desc := objs[0]["description"]
+	return uuid && desc
+}
+
+func (taskwarrior) Import(data []byte, _ Options) (*Result, error) {
+	objs, err := taskwarriorObjects(data)
+	if err != nil {
+		return nil, err
+	}
+
+	rep := newReport()
+	res := &Result{}
+
+	// plans[project] -> tree of sub-project groups
+	type group struct {
+		name     string
+		tasks    []models.Task
+		children []*group
+		index    map[string]*group
+	}
+	newGroup := func(name string) *group { return &group{name: name, index: map[string]*group{}} }
+	plans := map[string]*group{}
+	var planOrder []string
+
+	for _, obj := range objs {
+		status, _ := obj["status"].(string)
+		switch status {
+		case "deleted":
+			res.Skipped++
+			continue
+		case "recurring":
+			// the template; its generated instances are exported separately
+			res.Skipped++
+			rep.drop("recur", obj["recur"])
+			continue
+		}
+
+		t := models.Task{Status: statusPending}
+		t.Title, _ = obj["description"].(string)
+		if status == "completed" {
+			t.Status = statusCompleted
+			if end, ok := parseDate(str(obj["end"])); ok {
+				t.CompletedAt = &end
+			}
+		} else if _, active := obj["start"]; active {
+			t.Status = statusInProgress
+		}
+		if due, ok := parseDate(str(obj["due"])); ok {
+			t.Deadline = due
+		}
+		if tags, ok := obj["tags"].([]interface{}); ok {
+			for _, tag := range tags {
+				if s, ok := tag.(string); ok {
+					t.Tags = append(t.Tags, s)
+				}
+			}
+		}
+		if notes, ok := obj["annotations"].([]interface{}); ok {
+			for _, n := range notes {
+				if m, ok := n.(map[string]interface{}); ok {
+					t.Description = appendParagraph(t.Description, str(m["description"]))
+				}
+			}
+		}
+
+		for key, value := range obj {
+			switch key {
+			case "description", "status", "end", "start", "due", "tags", "annotations", "project":
+			default:
+				if !taskwarriorIgnored[key] {
+					rep.drop(key, value)
+				}
+			}
+		}
+
+		project := str(obj["project"])
+		parts := strings.Split(project, ".")
+		top := parts[0]
+		if top == "" {
+			top = "Inbox"
+		}
+		g, ok := plans[top]
+		if !ok {
+			g = newGroup(top)
+			plans[top] = g
+			planOrder = append(planOrder, top)
+		}
+		for _, sub := range parts[1:] {
+			child, ok := g.index[sub]
+			if !ok {
+				child = newGroup(sub)
+				g.index[sub] = child
+				g.children = append(g.children, child)
+			}
+			g = child
+		}
+		g.tasks = append(g.tasks, t)
+	}
+
+	var build func(g *group) []models.Task
+	build = func(g *group) []models.Task {
+		tasks := g.tasks
+		for _, child := range g.children {
+			sub := build(child)
+			tasks = append(tasks, models.Task{Title: child.name, Status: groupStatus(sub), SubTasks: sub})
+		}
+		sort.SliceStable(tasks, func(i, j int) bool { return earlierDeadline(tasks[i], tasks[j]) })
+		return tasks
+	}
+	for _, name := range planOrder {
+		res.Plans = append(res.Plans, models.Plan{Goal: name, Tasks: build(plans[name])})
+	}
+
+	res.Unmapped = rep.list()
+	return res, nil
+}
+
+// taskwarriorObjects accepts a JSON array or newline-delimited objects
+func taskwarriorObjects(data []byte) ([]map[string]interface{}, error) {
+	trimmed := bytes.TrimSpace(data)
+	var objs []map[string]interface{}
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		if err := json.Unmarshal(trimmed, &objs); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %v", err)
+		}
+		return objs, nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSuffix(bytes.TrimSpace(scanner.Bytes()), []byte(","))
+		if len(line) == 0 {
+			continue
+		}
+		var obj map[string]interface{}
+		if err := json.Unmarshal(line, &obj); err != nil {
+			return nil, fmt.Errorf("invalid JSON line: %v", err)
+		}
+		objs = append(objs, obj)
+	}
+	return objs, scanner.Err()
+}
+
+func str(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+// earlierDeadline keeps dated tasks first, in deadline order
+func earlierDeadline(a, b models.Task) bool {
+	if a.Deadline.IsZero() != b.Deadline.IsZero() {
+		return !a.Deadline.IsZero()
+	}
+	return a.Deadline.Before(b.Deadline)
+}
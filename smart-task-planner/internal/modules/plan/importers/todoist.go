@@ -0,0 +1,401 @@
+package importers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"smart-task-planner/internal/modules/plan/models"
+)
+
+// flexID accepts the numeric IDs of Sync API v8 and the string IDs of v9+.
+type flexID string
+
+func (id *flexID) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*id = ""
+		return nil
+	}
+	*id = flexID(strings.Trim(string(b), `"`))
+	return nil
+}
+
+type todoistDue struct {
+	Date        string `json:"date"`
+	String      string `json:"string"`
+	IsRecurring bool   `json:"is_recurring"`
+}
+
+type todoistItem struct {
+	ID             flexID          `json:"id"`
+	ProjectID      flexID          `json:"project_id"`
+	SectionID      flexID          `json:"section_id"`
+	ParentID       flexID          `json:"parent_id"`
+	Content        string          `json:"content"`
+	Description    string          `json:"description"`
+	Checked        interface{}     `json:"checked"` // bool in v9, 0/1 in v8
+	IsDeleted      interface{}     `json:"is_deleted"`
+	Due            *todoistDue     `json:"due"`
+	Labels         []string        `json:"labels"`
+	Priority       int             `json:"priority"`
+	ChildOrder     int             `json:"child_order"`
+	CompletedAt    string          `json:"completed_at"`
+	ResponsibleUID flexID          `json:"responsible_uid"`
+	Duration       json.RawMessage `json:"duration"`
+}
+
+type todoistProject struct {
+	ID         flexID      `json:"id"`
+	Name       string      `json:"name"`
+	ParentID   flexID      `json:"parent_id"`
+	IsDeleted  interface{} `json:"is_deleted"`
+	IsArchived interface{} `json:"is_archived"`
+	ChildOrder int         `json:"child_order"`
+}
+
+type todoistBackup struct {
+	Projects []todoistProject `json:"projects"`
+	Items    []todoistItem    `json:"items"`
+	Sections []struct {
+		ID   flexID `json:"id"`
+		Name string `json:"name"`
+	} `json:"sections"`
+	Notes []struct {
+		ItemID  flexID      `json:"item_id"`
+		Content string      `json:"content"`
+		Deleted interface{} `json:"is_deleted"`
+	} `json:"notes"`
+}
+
+// todoistJSON reads a Sync API dump (projects, items, sections, notes).
+// Each project becomes a plan, sub-projects are named "Parent / Child".
+type todoistJSON struct{}
+
+func (todoistJSON) Name() string { return "todoist_json" }
+
+func (todoistJSON) Detect(data []byte, _ string) bool {
+	var probe map[string]json.RawMessage
+	if json.Unmarshal(data, &probe) != nil {
+		return false
+	}
+	_, items := probe["items"]
+	_, projects := probe["projects"]
+	return items && projects
+}
+
+func (todoistJSON) Import(data []byte, _ Options) (*Result, error) {
+	var backup todoistBackup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+
+	rep := newReport()
+	res := &Result{}
+
+	sections := map[flexID]string{}
+	for _, s := range backup.Sections {
+		sections[s.ID] = s.Name
+	}
+	comments := map[flexID][]string{}
+	for _, n := range backup.Notes {
+		if !truthy(n.Deleted) && strings.TrimSpace(n.Content) != "" {
+			comments[n.ItemID] = append(comments[n.ItemID], n.Content)
+		}
+	}
+
+	projects := map[flexID]todoistProject{}
+	for _, p := range backup.Projects {
+		projects[p.ID] = p
+	}
+	projectName := func(p todoistProject) string {
+		name := p.Name
+		for seen := 0; p.ParentID != "" && seen < 10; seen++ {
+			parent, ok := projects[p.ParentID]
+			if !ok {
+				break
+			}
+			name = parent.Name + " / " + name
+			p = parent
+		}
+		return name
+	}
+
+	// group live items by project, then nest them by parent_id
+	sort.SliceStable(backup.Items, func(i, j int) bool { return backup.Items[i].ChildOrder < backup.Items[j].ChildOrder })
+	tasks := map[flexID]*todoistNode{}
+	var order []flexID
+	for _, item := range backup.Items {
+		if truthy(item.IsDeleted) {
+			res.Skipped++
+			continue
+		}
+		tasks[item.ID] = &todoistNode{item: item, task: todoistTask(item, sections, comments[item.ID], rep)}
+		order = append(order, item.ID)
+	}
+
+	rootsByProject := map[flexID][]*todoistNode{}
+	for _, id := range order {
+		node := tasks[id]
+		if parent, ok := tasks[node.item.ParentID]; ok && node.item.ParentID != "" {
+			parent.children = append(parent.children, node)
+			continue
+		}
+		rootsByProject[node.item.ProjectID] = append(rootsByProject[node.item.ProjectID], node)
+	}
+
+	sort.SliceStable(backup.Projects, func(i, j int) bool { return backup.Projects[i].ChildOrder < backup.Projects[j].ChildOrder })
+	for _, p := range backup.Projects {
+		if truthy(p.IsDeleted) {
+			continue
+		}
+		roots := rootsByProject[p.ID]
+		if len(roots) == 0 {
+			continue
+		}
+		if truthy(p.IsArchived) {
+			res.Warnings = append(res.Warnings, fmt.Sprintf("project %q is archived; imported anyway", p.Name))
+		}
+		res.Plans = append(res.Plans, models.Plan{Goal: projectName(p), Tasks: buildTodoist(roots)})
+		delete(rootsByProject, p.ID)
+	}
+	// sorted so the same backup always imports the same way
+	for _, projectID := range slices.Sorted(maps.Keys(rootsByProject)) {
+		roots := rootsByProject[projectID]
+		res.Warnings = append(res.Warnings, fmt.Sprintf("%d tasks reference unknown project %s", len(roots), projectID))
+		res.Plans = append(res.Plans, models.Plan{Goal: "Todoist " + string(projectID), Tasks: buildTodoist(roots)})
+	}
+
+	res.Unmapped = rep.list()
+	return res, nil
+}
+
+type todoistNode struct {
+	item     todoistItem
+	task     models.Task
+	children []*todoistNode
+}
+
+func buildTodoist(nodes []*todoistNode) []models.Task {
+	var out []models.Task
+	for _, n := range nodes {
+		t := n.task
+		t.SubTasks = buildTodoist(n.children)
+		out = append(out, t)
+	}
+	return out
+}
+
+func todoistTask(item todoistItem, sections map[flexID]string, comments []string, rep *report) models.Task {
+	t := models.Task{
+		Title:       item.Content,
+		Description: item.Description,
+		Status:      statusPending,
+		Tags:        item.Labels,
+	}
+	if section := sections[item.SectionID]; section != "" {
+		t.Tags = append(t.Tags, section)
+	}
+	for _, c := range comments {
+		t.Description = appendParagraph(t.Description, "Comment: "+c)
+	}
+
+	if truthy(item.Checked) {
+		t.Status = statusCompleted
+		if at, ok := parseDate(item.CompletedAt); ok {
+			t.CompletedAt = &at
+		}
+	}
+	if item.Due != nil {
+		if d, ok := parseDate(item.Due.Date); ok {
+			t.Deadline = d
+		} else {
+			rep.drop("due.date", item.Due.Date)
+		}
+		if item.Due.IsRecurring {
+			rep.drop("due.string (recurrence)", item.Due.String)
+		}
+	}
+
+	// the API counts backwards: 4 is p1, the highest, and 1 is none
+	t.Priority = todoistPriority(5 - item.Priority)
+	rep.drop("responsible_uid", item.ResponsibleUID)
+	if len(item.Duration) > 0 && string(item.Duration) != "null" {
+		rep.drop("duration", string(item.Duration))
+	}
+	return t
+}
+
+// todoistPriority maps Todoist's p1 to p3 onto P0 to P2; p4 is no priority
+func todoistPriority(p int) string {
+	if p < 1 || p > 3 {
+		return ""
+	}
+	return "P" + strconv.Itoa(p-1)
+}
+
+func truthy(v interface{}) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case float64:
+		return b != 0
+	case string:
+		return b == "1" || strings.EqualFold(b, "true")
+	}
+	return false
+}
+
+// todoistCSV reads the per-project CSV from Todoist's export/backup.
+// Nesting comes from the INDENT column, sections become tags and notes are
+// appended to the preceding task.
+type todoistCSV struct{}
+
+func (todoistCSV) Name() string { return "todoist_csv" }
+
+func (todoistCSV) Detect(data []byte, _ string) bool {
+	header, _, _ := bytes.Cut(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")), []byte("\n"))
+	h := strings.ToUpper(string(header))
+	return strings.Contains(h, "TYPE") && strings.Contains(h, "CONTENT") && strings.Contains(h, "INDENT")
+}
+
+func (todoistCSV) Import(data []byte, opts Options) (*Result, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %v", err)
+	}
+	if len(rows) < 2 {
+		return nil, fmt.Errorf("CSV has no rows")
+	}
+
+	col := map[string]int{}
+	for i, name := range rows[0] {
+		col[strings.ToUpper(strings.TrimSpace(name))] = i
+	}
+	get := func(row []string, name string) string {
+		if i, ok := col[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	rep := newReport()
+	res := &Result{}
+
+	type node struct {
+		task     models.Task
+		children []*node
+	}
+	var roots []*node
+	var stack []*node // stack[i] is the latest task at indent i+1
+	var last *node
+	section := ""
+
+	for _, row := range rows[1:] {
+		switch strings.ToLower(get(row, "TYPE")) {
+		case "section":
+			section = get(row, "CONTENT")
+			stack = nil
+			continue
+		case "note":
+			if last != nil {
+				last.task.Description = appendParagraph(last.task.Description, "Comment: "+get(row, "CONTENT"))
+			} else {
+				rep.drop("note", get(row, "CONTENT"))
+			}
+			continue
+		case "task":
+		default:
+			continue
+		}
+
+		content := get(row, "CONTENT")
+		t := models.Task{Title: content, Description: get(row, "DESCRIPTION"), Status: statusPending}
+
+		// labels are inline as @label in CONTENT
+		var words []string
+		for _, w := range strings.Fields(content) {
+			if strings.HasPrefix(w, "@") && len(w) > 1 {
+				t.Tags = append(t.Tags, w[1:])
+			} else {
+				words = append(words, w)
+			}
+		}
+		if len(t.Tags) > 0 {
+			t.Title = strings.Join(words, " ")
+		}
+		if section != "" {
+			t.Tags = append(t.Tags, section)
+		}
+
+		if date := get(row, "DATE"); date != "" {
+			if d, ok := parseDate(date); ok {
+				t.Deadline = d
+			} else {
+				rep.drop("DATE", date)
+			}
+		}
+		if p, _ := strconv.Atoi(get(row, "PRIORITY")); p > 0 {
+			// the CSV uses 1 as highest and 4 as none
+			t.Priority = todoistPriority(p)
+		}
+		rep.drop("RESPONSIBLE", get(row, "RESPONSIBLE"))
+		rep.drop("DURATION", get(row, "DURATION"))
+
+		indent, _ := strconv.Atoi(get(row, "INDENT"))
+		if indent < 1 {
+			indent = 1
+		}
+		if indent > len(stack)+1 {
+			indent = len(stack) + 1
+		}
+		n := &node{task: t}
+		stack = append(stack[:indent-1], n)
+		if indent == 1 {
+			roots = append(roots, n)
+		} else {
+			parent := stack[indent-2]
+			parent.children = append(parent.children, n)
+		}
+		last = n
+	}
+
+	var build func(nodes []*node) []models.Task
+	build = func(nodes []*node) []models.Task {
+		var out []models.Task
+		for _, n := range nodes {
+			t := n.task
+			t.SubTasks = build(n.children)
+			out = append(out, t)
+		}
+		return out
+	}
+
+	goal := opts.Goal
+	if goal == "" {
+		goal = "Todoist import " + time.Now().Format("2006-01-02")
+	}
+	if len(roots) > 0 {
+		res.Plans = []models.Plan{{Goal: goal, Tasks: build(roots)}}
+	}
+	res.Unmapped = rep.list()
+	return res, nil
+}
+
+// goalFromFilename turns "Home Renovation [123].csv" into "Home Renovation".
+func goalFromFilename(filename string) string {
+	name := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	if i := strings.LastIndex(name, " ["); i > 0 {
+		name = name[:i]
+	}
+	return strings.TrimSpace(name)
+}
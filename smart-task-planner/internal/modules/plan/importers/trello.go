@@ -0,0 +1,236 @@
+package importers
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"smart-task-planner/internal/modules/plan/models"
+)
+
+type trelloBoard struct {
+	Name  string `json:"name"`
+	Desc  string `json:"desc"`
+	Lists []struct {
+		ID     string  `json:"id"`
+		Name   string  `json:"name"`
+		Closed bool    `json:"closed"`
+		Pos    float64 `json:"pos"`
+	} `json:"lists"`
+	Cards      []trelloCard `json:"cards"`
+	Checklists []struct {
+		ID         string  `json:"id"`
+		IDCard     string  `json:"idCard"`
+		Name       string  `json:"name"`
+		Pos        float64 `json:"pos"`
+		CheckItems []struct {
+			Name  string  `json:"name"`
+			State string  `json:"state"`
+			Pos   float64 `json:"pos"`
+			Due   string  `json:"due"`
+		} `json:"checkItems"`
+	} `json:"checklists"`
+	Actions []struct {
+		Type string `json:"type"`
+		Data struct {
+			Text string `json:"text"`
+			Card struct {
+				ID string `json:"id"`
+			} `json:"card"`
+		} `json:"data"`
+	} `json:"actions"`
+}
+
+type trelloCard struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Desc        string  `json:"desc"`
+	IDList      string  `json:"idList"`
+	Closed      bool    `json:"closed"`
+	Due         string  `json:"due"`
+	DueComplete bool    `json:"dueComplete"`
+	Start       string  `json:"start"`
+	Pos         float64 `json:"pos"`
+	Labels      []struct {
+		Name  string `json:"name"`
+		Color string `json:"color"`
+	} `json:"labels"`
+	IDMembers        []string          `json:"idMembers"`
+	Attachments      []json.RawMessage `json:"attachments"`
+	CustomFieldItems []json.RawMessage `json:"customFieldItems"`
+}
+
+// trello reads a board's "Export as JSON". Cards become tasks and checklist
+// items their subtasks; the list a card sits in becomes a tag, and lists
+// named like Done / Doing set the status. With SplitLists every open list
+// is its own plan.
+type trello struct{}
+
+func (trello) Name() string { return "trello" }
+
+func (trello) Detect(data []byte, _ string) bool {
+	var probe map[string]json.RawMessage
+	if json.Unmarshal(data, &probe) != nil {
+		return false
+	}
+	_, cards := probe["cards"]
+	_, lists := probe["lists"]
+	return cards && lists
+}
+
+func (trello) Import(data []byte, opts Options) (*Result, error) {
+	var board trelloBoard
+	if err := json.Unmarshal(data, &board); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+
+	rep := newReport()
+	res := &Result{}
+
+	sort.SliceStable(board.Lists, func(i, j int) bool { return board.Lists[i].Pos < board.Lists[j].Pos })
+	listNames := map[string]string{}
+	closedLists := map[string]bool{}
+	for _, l := range board.Lists {
+		listNames[l.ID] = l.Name
+		closedLists[l.ID] = l.Closed
+	}
+
+	sort.SliceStable(board.Checklists, func(i, j int) bool { return board.Checklists[i].Pos < board.Checklists[j].Pos })
+	checklists := map[string][]models.Task{}
+	checklistCount := map[string]int{}
+	for _, cl := range board.Checklists {
+		checklistCount[cl.IDCard]++
+	}
+	for _, cl := range board.Checklists {
+		sort.SliceStable(cl.CheckItems, func(i, j int) bool { return cl.CheckItems[i].Pos < cl.CheckItems[j].Pos })
+		var items []models.Task
+		for _, ci := range cl.CheckItems {
+			item := models.Task{Title: ci.Name, Status: statusPending}
+			if ci.State == "complete" {
+				item.Status = statusCompleted
+			}
+			if d, ok := parseDate(ci.Due); ok {
+				item.Deadline = d
+			}
+			items = append(items, item)
+		}
+		if checklistCount[cl.IDCard] == 1 {
+			checklists[cl.IDCard] = append(checklists[cl.IDCard], items...)
+			continue
+		}
+		// several checklists on one card: keep each as a grouping subtask
+		group := models.Task{Title: cl.Name, Status: groupStatus(items), SubTasks: items}
+		checklists[cl.IDCard] = append(checklists[cl.IDCard], group)
+	}
+
+	comments := map[string][]string{}
+	for i := len(board.Actions) - 1; i >= 0; i-- { // actions are newest first
+		a := board.Actions[i]
+		if a.Type == "commentCard" && a.Data.Text != "" {
+			comments[a.Data.Card.ID] = append(comments[a.Data.Card.ID], a.Data.Text)
+		}
+	}
+
+	sort.SliceStable(board.Cards, func(i, j int) bool { return board.Cards[i].Pos < board.Cards[j].Pos })
+	byList := map[string][]models.Task{}
+	for _, card := range board.Cards {
+		if card.Closed || closedLists[card.IDList] {
+			res.Skipped++
+			continue
+		}
+
+		listName := listNames[card.IDList]
+		t := models.Task{
+			Title:       card.Name,
+			Description: card.Desc,
+			Status:      listStatus(listName),
+			SubTasks:    checklists[card.ID],
+		}
+		if card.DueComplete {
+			t.Status = statusCompleted
+		}
+		if d, ok := parseDate(card.Due); ok {
+			t.Deadline = d
+		}
+		for _, c := range comments[card.ID] {
+			t.Description = appendParagraph(t.Description, "Comment: "+c)
+		}
+		for _, l := range card.Labels {
+			if l.Name != "" {
+				t.Tags = append(t.Tags, l.Name)
+			} else if l.Color != "" {
+				t.Tags = append(t.Tags, l.Color)
+			}
+		}
+		if !opts.SplitLists && listName != "" {
+			t.Tags = append(t.Tags, listName)
+		}
+
+		rep.drop("start", card.Start)
+		rep.drop("idMembers", strings.Join(card.IDMembers, ","))
+		if len(card.Attachments) > 0 {
+			rep.drop("attachments", fmt.Sprintf("%d attachment(s)", len(card.Attachments)))
+		}
+		if len(card.CustomFieldItems) > 0 {
+			rep.drop("customFieldItems", fmt.Sprintf("%d value(s)", len(card.CustomFieldItems)))
+		}
+
+		byList[card.IDList] = append(byList[card.IDList], t)
+	}
+
+	boardName := board.Name
+	if boardName == "" {
+		boardName = opts.Goal
+	}
+	if boardName == "" {
+		boardName = "Trello board"
+	}
+	if opts.SplitLists {
+		for _, l := range board.Lists {
+			if tasks := byList[l.ID]; len(tasks) > 0 {
+				res.Plans = append(res.Plans, models.Plan{Goal: boardName + " / " + l.Name, Tasks: tasks})
+			}
+		}
+	} else {
+		var tasks []models.Task
+		for _, l := range board.Lists {
+			tasks = append(tasks, byList[l.ID]...)
+		}
+		if len(tasks) > 0 {
+			res.Plans = []models.Plan{{Goal: boardName, Tasks: tasks}}
+		}
+	}
+	rep.drop("board.desc", board.Desc)
+
+	res.Unmapped = rep.list()
+	return res, nil
+}
+
+// listStatus reads status from common Kanban list names
+func listStatus(list string) string {
+	l := strings.ToLower(list)
+	switch {
+	case strings.Contains(l, "done"), strings.Contains(l, "complete"), strings.Contains(l, "finished"):
+		return statusCompleted
+	case strings.Contains(l, "doing"), strings.Contains(l, "progress"), strings.Contains(l, "active"):
+		return statusInProgress
+	}
+	return statusPending
+}
+
+func groupStatus(items []models.Task) string {
+	done := 0
+	for _, it := range items {
+		if it.Status == statusCompleted {
+			done++
+		}
+	}
+	switch {
+	case len(items) > 0 && done == len(items):
+		return statusCompleted
+	case done > 0:
+		return statusInProgress
+	}
+	return statusPending
+}
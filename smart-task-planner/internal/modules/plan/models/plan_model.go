@@ -11,13 +11,164 @@ type Task struct {
 	Description string             `bson:"description" json:"description"`
 	Status      string             `bson:"status" json:"status"`
 	Deadline    time.Time          `bson:"deadline" json:"deadline"`
+	CompletedAt *time.Time         `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+
+	EstimatedHours float64 `bson:"estimated_hours,omitempty" json:"estimated_hours,omitempty"`
+	ActualHours    float64 `bson:"actual_hours,omitempty" json:"actual_hours,omitempty"` // tracked time, kept up to date by time tracking
+
+	Estimate *EstimateInfo `bson:"estimate,omitempty" json:"estimate,omitempty"` // how EstimatedHours was calibrated, if it was
+	Tags     []string      `bson:"tags,omitempty" json:"tags,omitempty"`
+	Priority string        `bson:"priority,omitempty" json:"priority,omitempty"` // P0 (most urgent) to P3
+	Contexts []string      `bson:"contexts,omitempty" json:"contexts,omitempty"` // GTD contexts: @home, @computer
+
+	// AssigneeID is the user doing the task. Subtasks without one are done
+	// by their parent's assignee.
+	AssigneeID string `bson:"assignee_id,omitempty" json:"assignee_id,omitempty"`
+
+	// Fields holds values of the user's custom fields by key: a string for
+	// text, select and date (YYYY-MM-DD) fields, a float64 for numbers
+	Fields map[string]interface{} `bson:"fields,omitempty" json:"fields,omitempty"`
+
+	DependsOn []primitive.ObjectID `bson:"depends_on,omitempty" json:"depends_on,omitempty"` // tasks in the same plan that must finish first
+
+	// Recurring tasks are a series of occurrences. Only the latest one holds
+	// the rule; completing it moves the rule to a newly created next one.
+	Recurrence *Recurrence        `bson:"recurrence,omitempty" json:"recurrence,omitempty"`
+	SeriesID   primitive.ObjectID `bson:"series_id,omitempty" json:"series_id,omitzero"`    // ID of the first occurrence
+	Occurrence int                `bson:"occurrence,omitempty" json:"occurrence,omitempty"` // 1 for the first occurrence
+
+	Embedding []float32 `bson:"embedding,omitempty" json:"-"` // title + description vector for goal matching
 
 	SubTasks []Task `bson:"sub_tasks,omitempty" json:"sub_tasks,omitempty"` // ✅ new field
 }
 
+// EstimateInfo explains a calibrated estimate
+type EstimateInfo struct {
+	RawHours   float64  `bson:"raw_hours,omitempty" json:"raw_hours,omitempty"` // estimate before calibration
+	Factor     float64  `bson:"factor" json:"factor"`                           // the user's actual/estimated ratio
+	Confidence float64  `bson:"confidence" json:"confidence"`                   // 0 to 1
+	Level      string   `bson:"level" json:"level"`                             // low, medium or high
+	Method     string   `bson:"method" json:"method"`                           // correction, similar_tasks, blended, prompt or none
+	Basis      []string `bson:"basis,omitempty" json:"basis,omitempty"`         // similar finished tasks it drew on
+}
+
+// Recurrence is a subset of iCalendar RRULE: daily or weekly, every
+// Interval days or weeks, optionally on given weekdays, ending after Count
+// occurrences or at Until.
+type Recurrence struct {
+	Freq     string     `bson:"freq" json:"freq"`                             // daily or weekly
+	Interval int        `bson:"interval,omitempty" json:"interval,omitempty"` // 1 when unset
+	ByDay    []string   `bson:"by_day,omitempty" json:"by_day,omitempty"`     // MO, TU, ... for weekly rules
+	Until    *time.Time `bson:"until,omitempty" json:"until,omitempty"`
+	Count    int        `bson:"count,omitempty" json:"count,omitempty"` // total occurrences, the first included
+}
+
 type Plan struct {
 	ID     primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	UserID string             `bson:"user_id" json:"user_id"`
 	Goal   string             `bson:"goal" json:"goal"`
 	Tasks  []Task             `bson:"tasks" json:"tasks"`
+
+	// A plan belongs to UserID unless it is in a workspace; then the
+	// members' workspace roles apply and UserID is whoever created it.
+	// Either kind can also be shared with individual users.
+	WorkspaceID string  `bson:"workspace_id,omitempty" json:"workspace_id,omitempty"`
+	SharedWith  []Share `bson:"shared_with,omitempty" json:"shared_with,omitempty"`
+	Role        string  `bson:"-" json:"role,omitempty"` // the requesting user's role, set when loaded for a user
+
+	GoalEmbedding  []float32 `bson:"goal_embedding,omitempty" json:"-"`
+	EmbeddingModel string    `bson:"embedding_model,omitempty" json:"-"`
+
+	// Version is the sync sequence number of the plan's last change. It only
+	// ever grows and is shared by all plans, so it doubles as a sync cursor.
+	Version        int64            `bson:"version" json:"version"`
+	CreatedVersion int64            `bson:"created_version,omitempty" json:"-"`
+	CreatedAt      time.Time        `bson:"created_at,omitempty" json:"created_at,omitzero"`
+	UpdatedAt      time.Time        `bson:"updated_at,omitempty" json:"updated_at,omitzero"`
+	FieldVersions  map[string]int64 `bson:"field_versions,omitempty" json:"-"` // "goal", "<task>", "<task>:<field>", "granted:<user>" -> version of last change
+
+	// Summary is recomputed from Tasks on every write so plan listings can
+	// filter and sort on it in the database.
+	Summary PlanSummary `bson:"summary" json:"summary"`
+}
+
+// Share gives one user access to a plan as an editor or viewer
+type Share struct {
+	UserID string `bson:"user_id" json:"user_id"`
+	Role   string `bson:"role" json:"role"`
+}
+
+// Roles on plans and workspaces. Viewers read, editors also change tasks,
+// owners also delete, share and move plans and manage workspace members.
+const (
+	RoleOwner  = "owner"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+var roleRank = map[string]int{RoleViewer: 1, RoleEditor: 2, RoleOwner: 3}
+
+// ValidRole reports whether role is one of the roles above
+func ValidRole(role string) bool {
+	return roleRank[role] > 0
+}
+
+// RoleAtLeast reports whether role grants everything min does
+func RoleAtLeast(role, min string) bool {
+	return roleRank[role] >= roleRank[min] && roleRank[role] > 0
+}
+
+// Plan statuses. A plan is overdue when it is active and its next deadline
+// has passed; that depends on the clock, so it is never stored.
+const (
+	PlanActive    = "active"
+	PlanCompleted = "completed"
+	PlanOverdue   = "overdue"
+)
+
+type PlanSummary struct {
+	Status         string     `bson:"status" json:"status"`
+	TotalTasks     int        `bson:"total_tasks" json:"total_tasks"`
+	CompletedTasks int        `bson:"completed_tasks" json:"completed_tasks"`
+	Progress       int        `bson:"progress" json:"progress"`                     // percent of tasks completed
+	NextDeadline   *time.Time `bson:"next_deadline" json:"next_deadline,omitempty"` // earliest deadline of an unfinished task
+	Scheduled      bool       `bson:"scheduled" json:"-"`                           // NextDeadline is set; sorts undated plans last
+
+	// what the plan's tasks are labelled with, so listings can filter on it
+	Priorities  []string `bson:"priorities" json:"priorities,omitempty"`
+	Tags        []string `bson:"tags" json:"tags,omitempty"`
+	Contexts    []string `bson:"contexts" json:"contexts,omitempty"`
+	FieldValues []string `bson:"field_values" json:"-"` // "key=value"
+}
+
+// Tombstone records a deleted plan or task so sync clients can drop it.
+type Tombstone struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	UserID    string             `bson:"user_id" json:"-"`
+	Kind      string             `bson:"kind" json:"-"` // plan or task
+	PlanID    string             `bson:"plan_id" json:"plan_id"`
+	TaskID    string             `bson:"task_id,omitempty" json:"task_id,omitempty"`
+	Version   int64              `bson:"version" json:"version"`
+	DeletedAt time.Time          `bson:"deleted_at" json:"deleted_at"`
+}
+
+// DependencyCycle reports whether start can reach itself by following deps,
+// which maps a task to the tasks it depends on
+func DependencyCycle(deps map[string][]string, start string) bool {
+	visited := map[string]bool{}
+	stack := []string{start}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, next := range deps[id] {
+			if next == start {
+				return true
+			}
+			if !visited[next] {
+				visited[next] = true
+				stack = append(stack, next)
+			}
+		}
+	}
+	return false
 }
@@ -0,0 +1,81 @@
+package people
+
+import (
+	"strings"
+
+	authRepository "smart-task-planner/internal/modules/auth/repository"
+	"smart-task-planner/internal/modules/plan/models"
+	"smart-task-planner/internal/modules/plan/repository"
+)
+
+// Person is someone who can see a plan, and so can be mentioned in its
+// comments and, with the editor role, assigned its tasks
+type Person struct {
+	UserID string `json:"user_id"`
+	Name   string `json:"name,omitempty"`
+	Email  string `json:"email,omitempty"`
+}
+
+// DisplayName is the name to show for p, falling back to the email and ID
+func (p Person) DisplayName() string {
+	switch {
+	case p.Name != "":
+		return p.Name
+	case p.Email != "":
+		return p.Email
+	}
+	return p.UserID
+}
+
+// Of lists everyone who can see plan with their name and email
+func Of(plan *models.Plan, repo *repository.PlanRepository) ([]Person, error) {
+	ids, err := repo.Audience(plan)
+	if err != nil {
+		return nil, err
+	}
+	return named(ids), nil
+}
+
+// Assignable lists who can be assigned plan's tasks: everyone who can edit
+// it, since an assignee has to be able to update their tasks
+func Assignable(plan *models.Plan, repo *repository.PlanRepository) ([]Person, error) {
+	ids, err := repo.AudienceWith(plan, models.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	return named(ids), nil
+}
+
+func named(ids []string) []Person {
+	people := make([]Person, 0, len(ids))
+	for _, id := range ids {
+		p := Person{UserID: id}
+		if user, err := authRepository.GetUserByID(id); err == nil {
+			p.Name, p.Email = user.Name, user.Email
+		}
+		people = append(people, p)
+	}
+	return people
+}
+
+// Match finds who a name means: "me", a full name, a first name, an email
+// or the part of an email before the @
+func Match(people []Person, who, userID string) []Person {
+	who = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(who), "@"))
+	var out []Person
+	for _, p := range people {
+		name := strings.ToLower(p.Name)
+		email := strings.ToLower(p.Email)
+		first := ""
+		if fields := strings.Fields(name); len(fields) > 0 {
+			first = fields[0]
+		}
+		local, _, _ := strings.Cut(email, "@")
+		switch {
+		case (who == "me" || who == "myself") && p.UserID == userID,
+			who == name, who == email, who == first, who == local, who == strings.ToLower(p.UserID):
+			out = append(out, p)
+		}
+	}
+	return out
+}
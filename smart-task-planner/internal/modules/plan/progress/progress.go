@@ -0,0 +1,196 @@
+package progress
+
+import (
+	"strings"
+	"time"
+
+	"smart-task-planner/internal/modules/plan/models"
+)
+
+// Summary holds the progress counters for a task tree. Every task and
+// subtask counts as one unit.
+type Summary struct {
+	Total          int     `json:"total_tasks"`
+	Completed      int     `json:"completed_tasks"`
+	Remaining      int     `json:"remaining_tasks"`
+	Overdue        int     `json:"overdue_tasks"`
+	EstimateHours  float64 `json:"estimate_hours"`
+	RemainingHours float64 `json:"remaining_hours"`
+	Percentage     int     `json:"completion_percentage"`
+}
+
+// IsCompleted reports whether a task status counts as done.
+func IsCompleted(t models.Task) bool {
+	return strings.EqualFold(t.Status, "Completed")
+}
+
+// IsOverdue reports whether an unfinished task is past its deadline.
+func IsOverdue(t models.Task, now time.Time) bool {
+	return !IsCompleted(t) && !t.Deadline.IsZero() && t.Deadline.Before(now)
+}
+
+// EffortHours is what a task took or is expected to take: the tracked time
+// once it is completed, the estimate until then.
+func EffortHours(t models.Task) float64 {
+	if IsCompleted(t) && t.ActualHours > 0 {
+		return t.ActualHours
+	}
+	return t.EstimatedHours
+}
+
+// RemainingHours is the estimate minus the time already tracked. A task
+// that ran over its estimate reports 0, i.e. unknown.
+func RemainingHours(t models.Task) float64 {
+	if IsCompleted(t) || t.ActualHours >= t.EstimatedHours {
+		return 0
+	}
+	return t.EstimatedHours - t.ActualHours
+}
+
+// Calculate walks the task tree and returns its progress summary.
+func Calculate(tasks []models.Task, now time.Time) Summary {
+	var s Summary
+	var walk func([]models.Task)
+	walk = func(ts []models.Task) {
+		for _, t := range ts {
+			s.Total++
+			s.EstimateHours += t.EstimatedHours
+			if IsCompleted(t) {
+				s.Completed++
+			} else {
+				s.RemainingHours += RemainingHours(t)
+				if IsOverdue(t, now) {
+					s.Overdue++
+				}
+			}
+			walk(t.SubTasks)
+		}
+	}
+	walk(tasks)
+
+	s.Remaining = s.Total - s.Completed
+	if s.Total > 0 {
+		s.Percentage = (s.Completed * 100) / s.Total
+	}
+	return s
+}
+
+// LastDeadline returns the latest deadline anywhere in the task tree.
+func LastDeadline(tasks []models.Task) time.Time {
+	var last time.Time
+	for _, t := range tasks {
+		if t.Deadline.After(last) {
+			last = t.Deadline
+		}
+		if sub := LastDeadline(t.SubTasks); sub.After(last) {
+			last = sub
+		}
+	}
+	return last
+}
+
+// Weighting modes for CalculateWeighted.
+const (
+	WeightCount  = "count"  // every task and subtask counts as one
+	WeightEffort = "effort" // leaf tasks weighted by estimated hours
+	WeightLeaf   = "leaf"   // only leaf tasks count, one each
+)
+
+// defaultEffortHours is used for leaves without an estimate in effort mode.
+const defaultEffortHours = 1.0
+
+// Weighted is a progress figure under a specific weighting mode.
+type Weighted struct {
+	Mode            string  `json:"mode"`
+	TotalWeight     float64 `json:"total_weight"`
+	CompletedWeight float64 `json:"completed_weight"`
+	Percentage      int     `json:"completion_percentage"`
+	Unestimated     int     `json:"unestimated_tasks,omitempty"`
+}
+
+// Milestone is the progress of one top-level task and everything below it.
+type Milestone struct {
+	TaskID   string    `json:"task_id"`
+	Title    string    `json:"title"`
+	Status   string    `json:"status"`
+	Deadline time.Time `json:"deadline"`
+	Summary  Summary   `json:"summary"`
+	Weighted Weighted  `json:"weighted"`
+}
+
+// ValidMode reports whether mode is a known weighting mode.
+func ValidMode(mode string) bool {
+	switch mode {
+	case WeightCount, WeightEffort, WeightLeaf:
+		return true
+	}
+	return false
+}
+
+// CalculateWeighted computes progress under the given weighting mode. In the
+// leaf-based modes a leaf counts as done when it or any ancestor is completed.
+func CalculateWeighted(tasks []models.Task, mode string) Weighted {
+	if !ValidMode(mode) {
+		mode = WeightCount
+	}
+	w := Weighted{Mode: mode}
+
+	var walk func(ts []models.Task, ancestorDone bool)
+	walk = func(ts []models.Task, ancestorDone bool) {
+		for _, t := range ts {
+			done := IsCompleted(t)
+			isLeaf := len(t.SubTasks) == 0
+
+			switch mode {
+			case WeightCount:
+				w.TotalWeight++
+				if done {
+					w.CompletedWeight++
+				}
+			case WeightLeaf:
+				if isLeaf {
+					w.TotalWeight++
+					if done || ancestorDone {
+						w.CompletedWeight++
+					}
+				}
+			case WeightEffort:
+				if isLeaf {
+					hours := t.EstimatedHours
+					if hours <= 0 {
+						hours = defaultEffortHours
+						w.Unestimated++
+					}
+					w.TotalWeight += hours
+					if done || ancestorDone {
+						w.CompletedWeight += hours
+					}
+				}
+			}
+			walk(t.SubTasks, done || ancestorDone)
+		}
+	}
+	walk(tasks, false)
+
+	if w.TotalWeight > 0 {
+		w.Percentage = int(w.CompletedWeight * 100 / w.TotalWeight)
+	}
+	return w
+}
+
+// Milestones breaks progress down per top-level task.
+func Milestones(tasks []models.Task, mode string, now time.Time) []Milestone {
+	milestones := make([]Milestone, 0, len(tasks))
+	for _, t := range tasks {
+		tree := []models.Task{t}
+		milestones = append(milestones, Milestone{
+			TaskID:   t.ID.Hex(),
+			Title:    t.Title,
+			Status:   t.Status,
+			Deadline: t.Deadline,
+			Summary:  Calculate(tree, now),
+			Weighted: CalculateWeighted(tree, mode),
+		})
+	}
+	return milestones
+}
@@ -0,0 +1,430 @@
+package recurrence
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"smart-task-planner/internal/modules/plan/models"
+	"smart-task-planner/internal/modules/plan/progress"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Frequencies
+const (
+	Daily  = "daily"
+	Weekly = "weekly"
+)
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// dayOrder sorts BYDAY Monday first, like the agenda's weeks
+var dayOrder = []string{"MO", "TU", "WE", "TH", "FR", "SA", "SU"}
+
+// Parse reads an RRULE such as "FREQ=WEEKLY;BYDAY=MO,WE,FR;COUNT=12" or
+// "RRULE:FREQ=DAILY;INTERVAL=3;UNTIL=20251231". Only FREQ (DAILY or
+// WEEKLY), INTERVAL, BYDAY, COUNT and UNTIL are supported.
+func Parse(rule string) (*models.Recurrence, error) {
+	rule = strings.TrimSpace(rule)
+	rule = strings.TrimPrefix(strings.TrimPrefix(rule, "RRULE:"), "rrule:")
+	if rule == "" {
+		return nil, fmt.Errorf("empty recurrence rule")
+	}
+
+	r := &models.Recurrence{}
+	for _, part := range strings.Split(rule, ";") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rule part %q", part)
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToUpper(strings.TrimSpace(key)) {
+		case "FREQ":
+			r.Freq = strings.ToLower(value)
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("INTERVAL must be a number")
+			}
+			r.Interval = n
+		case "BYDAY":
+			r.ByDay = strings.Split(value, ",")
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("COUNT must be a number")
+			}
+			r.Count = n
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return nil, err
+			}
+			r.Until = &until
+		default:
+			return nil, fmt.Errorf("unsupported rule part %s (FREQ, INTERVAL, BYDAY, COUNT, UNTIL)", key)
+		}
+	}
+	if err := Validate(r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func parseUntil(v string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102", "2006-01-02", time.RFC3339} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("UNTIL must be a date like 20251231")
+}
+
+// Validate checks a rule and normalizes it in place
+func Validate(r *models.Recurrence) error {
+	r.Freq = strings.ToLower(strings.TrimSpace(r.Freq))
+	if r.Freq != Daily && r.Freq != Weekly {
+		return fmt.Errorf("FREQ must be DAILY or WEEKLY")
+	}
+	if r.Interval < 0 || r.Interval > 365 {
+		return fmt.Errorf("INTERVAL must be between 1 and 365")
+	}
+	if r.Interval == 1 {
+		r.Interval = 0
+	}
+	if r.Count < 0 {
+		return fmt.Errorf("COUNT must be positive")
+	}
+	if r.Count > 0 && r.Until != nil {
+		return fmt.Errorf("use either COUNT or UNTIL, not both")
+	}
+
+	if len(r.ByDay) > 0 {
+		if r.Freq != Weekly {
+			return fmt.Errorf("BYDAY needs FREQ=WEEKLY")
+		}
+		seen := map[string]bool{}
+		for _, d := range r.ByDay {
+			d = strings.ToUpper(strings.TrimSpace(d))
+			if _, ok := weekdays[d]; !ok {
+				return fmt.Errorf("unknown weekday %q in BYDAY", d)
+			}
+			seen[d] = true
+		}
+		r.ByDay = r.ByDay[:0]
+		for _, d := range dayOrder {
+			if seen[d] {
+				r.ByDay = append(r.ByDay, d)
+			}
+		}
+	}
+	return nil
+}
+
+// Format writes a rule back as an RRULE string
+func Format(r *models.Recurrence) string {
+	parts := []string{"FREQ=" + strings.ToUpper(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		parts = append(parts, "BYDAY="+strings.Join(r.ByDay, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Describe puts a rule in words: "every 2 weeks on Mon, Thu, 10 times"
+func Describe(r *models.Recurrence) string {
+	unit := "day"
+	if r.Freq == Weekly {
+		unit = "week"
+	}
+	s := "every " + unit
+	if r.Interval > 1 {
+		s = fmt.Sprintf("every %d %ss", r.Interval, unit)
+	}
+	if len(r.ByDay) > 0 {
+		names := make([]string, len(r.ByDay))
+		for i, d := range r.ByDay {
+			names[i] = weekdays[d].String()[:3]
+		}
+		s += " on " + strings.Join(names, ", ")
+	}
+	if r.Count > 0 {
+		s += fmt.Sprintf(", %d times", r.Count)
+	}
+	if r.Until != nil {
+		s += ", until " + r.Until.Format("2006-01-02")
+	}
+	return s
+}
+
+// Next returns the occurrence after prev, which was occurrence number n of
+// the series. ok is false once the series has ended.
+func Next(r *models.Recurrence, prev time.Time, n int) (next time.Time, ok bool) {
+	if r.Count > 0 && n >= r.Count {
+		return time.Time{}, false
+	}
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	switch {
+	case r.Freq == Daily:
+		next = prev.AddDate(0, 0, interval)
+	case len(r.ByDay) == 0:
+		next = prev.AddDate(0, 0, 7*interval)
+	default:
+		next = nextByDay(r.ByDay, prev, interval)
+	}
+
+	if r.Until != nil {
+		until := *r.Until
+		if until.Hour() == 0 && until.Minute() == 0 && until.Second() == 0 {
+			// a plain date includes that whole day
+			until = until.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+		if next.After(until) {
+			return time.Time{}, false
+		}
+	}
+	return next, true
+}
+
+// nextByDay finds the next listed weekday later in prev's week, or the
+// first one in the week interval weeks on
+func nextByDay(days []string, prev time.Time, interval int) time.Time {
+	want := map[time.Weekday]bool{}
+	for _, d := range days {
+		want[weekdays[d]] = true
+	}
+	offset := (int(prev.Weekday()) + 6) % 7 // days since Monday
+	for d := 1; offset+d < 7; d++ {
+		if c := prev.AddDate(0, 0, d); want[c.Weekday()] {
+			return c
+		}
+	}
+	monday := prev.AddDate(0, 0, 7*interval-offset)
+	for d := 0; d < 7; d++ {
+		if c := monday.AddDate(0, 0, d); want[c.Weekday()] {
+			return c
+		}
+	}
+	return monday
+}
+
+func finished(t models.Task) bool {
+	return progress.IsCompleted(t) || strings.EqualFold(t.Status, "Cancelled")
+}
+
+// Advance creates the next occurrence of every recurring task that was
+// completed or missed, at any depth, and returns the updated list. An
+// occurrence is missed once the one after it is due; it is cancelled and
+// the series resumes at the occurrence that is current at now, so a long
+// break leaves gaps rather than a pile of overdue copies. Running it again
+// changes nothing.
+func Advance(tasks []models.Task, now time.Time) []models.Task {
+	// the loop also visits appended occurrences, so chains catch up
+	for i := 0; i < len(tasks); i++ {
+		tasks[i].SubTasks = Advance(tasks[i].SubTasks, now)
+
+		t := tasks[i]
+		if t.Recurrence == nil || t.Deadline.IsZero() {
+			continue
+		}
+		if t.ID.IsZero() {
+			t.ID = primitive.NewObjectID()
+		}
+		if t.SeriesID.IsZero() {
+			t.SeriesID = t.ID
+		}
+		if t.Occurrence == 0 {
+			t.Occurrence = 1
+		}
+
+		next, ok := Next(t.Recurrence, t.Deadline, t.Occurrence)
+		missed := ok && !finished(t) && !next.After(now)
+		if !ok || (!finished(t) && !missed) {
+			tasks[i] = t
+			continue
+		}
+		if missed {
+			t.Status = "Cancelled"
+		}
+
+		n := t.Occurrence + 1
+		for {
+			after, more := Next(t.Recurrence, next, n)
+			if !more || after.After(now) {
+				break
+			}
+			next, n = after, n+1
+		}
+
+		occ := occurrence(t, next, n)
+		t.Recurrence = nil
+		tasks[i] = t
+		tasks = append(tasks, occ)
+	}
+	return tasks
+}
+
+// Due reports whether Advance would add an occurrence at now
+func Due(tasks []models.Task, now time.Time) bool {
+	for _, t := range tasks {
+		if Due(t.SubTasks, now) {
+			return true
+		}
+		if t.Recurrence == nil || t.Deadline.IsZero() {
+			continue
+		}
+		next, ok := Next(t.Recurrence, t.Deadline, number(t))
+		if ok && (finished(t) || !next.After(now)) {
+			return true
+		}
+	}
+	return false
+}
+
+// occurrence copies a task for a later date. Subtasks come along as a
+// fresh checklist, shifted by the same amount.
+func occurrence(t models.Task, deadline time.Time, n int) models.Task {
+	shift := deadline.Sub(t.Deadline)
+	occ := copyTask(t, shift)
+	occ.Deadline = deadline
+	occ.Recurrence = t.Recurrence
+	occ.SeriesID = t.SeriesID
+	occ.Occurrence = n
+	return occ
+}
+
+func copyTask(t models.Task, shift time.Duration) models.Task {
+	c := models.Task{
+		ID:             primitive.NewObjectID(),
+		Title:          t.Title,
+		Description:    t.Description,
+		Status:         "Pending",
+		EstimatedHours: t.EstimatedHours,
+		Estimate:       t.Estimate,
+		Tags:           append([]string(nil), t.Tags...),
+		Priority:       t.Priority,
+		Contexts:       append([]string(nil), t.Contexts...),
+		Fields:         maps.Clone(t.Fields),
+		AssigneeID:     t.AssigneeID,
+		Embedding:      t.Embedding,
+	}
+	if !t.Deadline.IsZero() {
+		c.Deadline = t.Deadline.Add(shift)
+	}
+	for _, sub := range t.SubTasks {
+		c.SubTasks = append(c.SubTasks, copyTask(sub, shift))
+	}
+	return c
+}
+
+// Habit is how well a recurring series is kept up
+type Habit struct {
+	SeriesID       string     `json:"series_id"`
+	Title          string     `json:"title"`
+	Rule           string     `json:"rule"`
+	RRule          string     `json:"rrule"`
+	Due            int        `json:"due_occurrences"` // completed plus missed
+	Completed      int        `json:"completed"`
+	Missed         int        `json:"missed"`
+	CompletionRate int        `json:"completion_rate"` // percent of due occurrences completed
+	CurrentStreak  int        `json:"current_streak"`
+	LongestStreak  int        `json:"longest_streak"`
+	NextDue        *time.Time `json:"next_due,omitempty"`
+	Active         bool       `json:"active"` // the series has occurrences left
+}
+
+// Habits reports streaks and completion rates of the recurring series in a
+// task tree. Occurrence numbers that were skipped count as missed. The
+// current occurrence counts once it is completed.
+func Habits(tasks []models.Task) []Habit {
+	series := map[primitive.ObjectID][]models.Task{}
+	var order []primitive.ObjectID
+	var walk func([]models.Task)
+	walk = func(ts []models.Task) {
+		for _, t := range ts {
+			if !t.SeriesID.IsZero() {
+				if _, ok := series[t.SeriesID]; !ok {
+					order = append(order, t.SeriesID)
+				}
+				series[t.SeriesID] = append(series[t.SeriesID], t)
+			}
+			walk(t.SubTasks)
+		}
+	}
+	walk(tasks)
+
+	habits := []Habit{}
+	for _, id := range order {
+		occs := series[id]
+		sort.SliceStable(occs, func(i, j int) bool { return number(occs[i]) < number(occs[j]) })
+
+		latest := occs[len(occs)-1]
+		h := Habit{SeriesID: id.Hex(), Title: latest.Title}
+		if latest.Recurrence != nil {
+			h.Rule, h.RRule = Describe(latest.Recurrence), Format(latest.Recurrence)
+			_, more := Next(latest.Recurrence, latest.Deadline, number(latest))
+			h.Active = more || !finished(latest)
+		}
+
+		byNumber := map[int]models.Task{}
+		for _, o := range occs {
+			byNumber[number(o)] = o
+		}
+		streak := 0
+		for n := 1; n <= number(latest); n++ {
+			o, ok := byNumber[n]
+			switch {
+			case ok && progress.IsCompleted(o):
+				h.Completed++
+				streak++
+			case !ok || finished(o):
+				h.Missed++
+				streak = 0
+			default:
+				// the open occurrence can still be done
+				deadline := o.Deadline
+				h.NextDue = &deadline
+				continue
+			}
+			if streak > h.LongestStreak {
+				h.LongestStreak = streak
+			}
+		}
+		h.CurrentStreak = streak
+		h.Due = h.Completed + h.Missed
+		if h.Due > 0 {
+			h.CompletionRate = h.Completed * 100 / h.Due
+		}
+		habits = append(habits, h)
+	}
+	return habits
+}
+
+func number(t models.Task) int {
+	if t.Occurrence == 0 {
+		return 1
+	}
+	return t.Occurrence
+}
@@ -0,0 +1,166 @@
+package recurrence
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"smart-task-planner/internal/modules/plan/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func day(m time.Month, d int) time.Time {
+	return time.Date(2025, m, d, 9, 0, 0, 0, time.UTC)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string // Format of the parsed rule
+		err  string // substring of the expected error, "" for none
+	}{
+		{rule: "FREQ=DAILY", want: "FREQ=DAILY"},
+		{rule: "rrule:freq=weekly;byday=fr,mo;interval=2", want: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR"},
+		{rule: "RRULE:FREQ=DAILY;INTERVAL=1;COUNT=5", want: "FREQ=DAILY;COUNT=5"},
+		{rule: "FREQ=WEEKLY;UNTIL=20251231", want: "FREQ=WEEKLY;UNTIL=20251231T000000Z"},
+		{rule: "", err: "empty"},
+		{rule: "FREQ=HOURLY", err: "DAILY or WEEKLY"},
+		{rule: "FREQ=DAILY;BYDAY=MO", err: "needs FREQ=WEEKLY"},
+		{rule: "FREQ=WEEKLY;BYDAY=XX", err: "unknown weekday"},
+		{rule: "FREQ=DAILY;COUNT=3;UNTIL=20251231", err: "not both"},
+		{rule: "FREQ=DAILY;INTERVAL=400", err: "INTERVAL"},
+		{rule: "FREQ=DAILY;BYMONTH=2", err: "unsupported"},
+		{rule: "FREQ", err: "invalid rule part"},
+	}
+	for _, tt := range tests {
+		r, err := Parse(tt.rule)
+		switch {
+		case tt.err != "":
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%q: error %v, want %q", tt.rule, err, tt.err)
+			}
+		case err != nil:
+			t.Errorf("%q: %v", tt.rule, err)
+		case Format(r) != tt.want:
+			t.Errorf("%q: formatted as %q, want %q", tt.rule, Format(r), tt.want)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	r, _ := Parse("FREQ=WEEKLY;INTERVAL=2;BYDAY=TH,MO;COUNT=10")
+	if got, want := Describe(r), "every 2 weeks on Mon, Thu, 10 times"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestNext(t *testing.T) {
+	until := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		rule *models.Recurrence
+		prev time.Time
+		n    int
+		want time.Time // zero when the series has ended
+	}{
+		{"every third day", &models.Recurrence{Freq: Daily, Interval: 3}, day(3, 3), 1, day(3, 6)},
+		{"weekly", &models.Recurrence{Freq: Weekly}, day(3, 3), 1, day(3, 10)},
+		{"later in the week", &models.Recurrence{Freq: Weekly, ByDay: []string{"MO", "WE", "FR"}}, day(3, 3), 1, day(3, 5)},
+		{"into next week", &models.Recurrence{Freq: Weekly, ByDay: []string{"MO", "WE", "FR"}}, day(3, 7), 1, day(3, 10)},
+		{"every other week", &models.Recurrence{Freq: Weekly, Interval: 2, ByDay: []string{"MO", "FR"}}, day(3, 7), 1, day(3, 17)},
+		{"count reached", &models.Recurrence{Freq: Daily, Count: 3}, day(3, 5), 3, time.Time{}},
+		{"count left", &models.Recurrence{Freq: Daily, Count: 3}, day(3, 4), 2, day(3, 5)},
+		{"until includes the whole day", &models.Recurrence{Freq: Daily, Until: &until}, day(3, 9), 1, day(3, 10)},
+		{"past until", &models.Recurrence{Freq: Daily, Until: &until}, day(3, 10), 2, time.Time{}},
+	}
+	for _, tt := range tests {
+		next, ok := Next(tt.rule, tt.prev, tt.n)
+		if ok != !tt.want.IsZero() || !next.Equal(tt.want) {
+			t.Errorf("%s: %v %v, want %v", tt.name, next, ok, tt.want)
+		}
+	}
+}
+
+func TestAdvanceCompleted(t *testing.T) {
+	task := models.Task{
+		ID: primitive.NewObjectID(), Title: "Water plants", Status: "Completed", Deadline: day(3, 3),
+		Contexts:   []string{"@home"},
+		Recurrence: &models.Recurrence{Freq: Weekly},
+		SubTasks:   []models.Task{{ID: primitive.NewObjectID(), Title: "Fill can", Status: "Completed", Deadline: day(3, 3)}},
+	}
+	now := day(3, 4)
+	tasks := Advance([]models.Task{task}, now)
+
+	if len(tasks) != 2 {
+		t.Fatalf("%d tasks, want 2", len(tasks))
+	}
+	done, next := tasks[0], tasks[1]
+	if done.Recurrence != nil || done.SeriesID != task.ID || done.Occurrence != 1 {
+		t.Errorf("finished occurrence %+v", done)
+	}
+	if next.Recurrence == nil || next.SeriesID != task.ID || next.Occurrence != 2 || !next.Deadline.Equal(day(3, 10)) {
+		t.Errorf("next occurrence %+v", next)
+	}
+	if next.Status != "Pending" || next.Contexts[0] != "@home" || next.ID == task.ID {
+		t.Errorf("next occurrence is not a fresh copy: %+v", next)
+	}
+	if sub := next.SubTasks[0]; sub.Status != "Pending" || !sub.Deadline.Equal(day(3, 10)) || sub.ID == task.SubTasks[0].ID {
+		t.Errorf("subtask %+v", sub)
+	}
+
+	if Due(tasks, now) {
+		t.Error("still due after advancing")
+	}
+	if again := Advance(tasks, now); len(again) != 2 {
+		t.Errorf("advancing twice added %d tasks", len(again)-2)
+	}
+}
+
+func TestAdvanceMissed(t *testing.T) {
+	task := models.Task{ID: primitive.NewObjectID(), Title: "Stretch", Status: "Pending", Deadline: day(3, 1), Recurrence: &models.Recurrence{Freq: Daily}}
+	now := day(3, 10).Add(time.Hour)
+	if !Due([]models.Task{task}, now) {
+		t.Fatal("a missed occurrence should be due")
+	}
+	tasks := Advance([]models.Task{task}, now)
+
+	if len(tasks) != 2 || tasks[0].Status != "Cancelled" {
+		t.Fatalf("tasks %+v", tasks)
+	}
+	// a long break leaves a gap rather than nine overdue copies
+	if next := tasks[1]; next.Occurrence != 10 || !next.Deadline.Equal(day(3, 10)) {
+		t.Errorf("resumed at occurrence %d on %v", next.Occurrence, next.Deadline)
+	}
+
+	open := models.Task{ID: primitive.NewObjectID(), Title: "Stretch", Status: "Pending", Deadline: day(3, 10), Recurrence: &models.Recurrence{Freq: Daily}}
+	if got := Advance([]models.Task{open}, now); len(got) != 1 {
+		t.Errorf("an open current occurrence was advanced")
+	}
+}
+
+func TestHabits(t *testing.T) {
+	series := primitive.NewObjectID()
+	occ := func(n int, status string) models.Task {
+		return models.Task{ID: primitive.NewObjectID(), Title: "Run", Status: status, SeriesID: series, Occurrence: n, Deadline: day(3, n)}
+	}
+	current := occ(6, "Pending")
+	current.Recurrence = &models.Recurrence{Freq: Daily, Count: 10}
+	// occurrence 4 was never created, so it counts as missed
+	tasks := []models.Task{occ(2, "Completed"), occ(1, "Completed"), occ(3, "Cancelled"), occ(5, "Completed"), current}
+
+	habits := Habits(tasks)
+	if len(habits) != 1 {
+		t.Fatalf("%d habits", len(habits))
+	}
+	h := habits[0]
+	if h.Completed != 3 || h.Missed != 2 || h.Due != 5 || h.CompletionRate != 60 {
+		t.Errorf("counts %+v", h)
+	}
+	if h.CurrentStreak != 1 || h.LongestStreak != 2 {
+		t.Errorf("streaks %d and %d, want 1 and 2", h.CurrentStreak, h.LongestStreak)
+	}
+	if h.NextDue == nil || !h.NextDue.Equal(day(3, 6)) || !h.Active || h.RRule != "FREQ=DAILY;COUNT=10" {
+		t.Errorf("habit %+v", h)
+	}
+}
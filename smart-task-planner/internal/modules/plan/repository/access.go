@@ -0,0 +1,330 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"smart-task-planner/internal/events"
+	"smart-task-planner/internal/modules/plan/models"
+	workspaceModels "smart-task-planner/internal/modules/workspaces/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrReadOnly is returned when a viewer tries to change a plan
+	ErrReadOnly = fmt.Errorf("you can only view this plan")
+
+	// ErrNotOwner is returned when an editor tries to delete, share or move a plan
+	ErrNotOwner = fmt.Errorf("only an owner of this plan can do that")
+)
+
+// Access is what one user can reach: their personal plans, every plan of
+// the workspaces they belong to and the plans shared with them.
+type Access struct {
+	UserID     string
+	Workspaces map[string]string // workspace ID -> the user's role in it
+}
+
+// Access loads the user's workspace memberships
+func (r *PlanRepository) Access(userID string) (*Access, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := r.Workspaces.Find(ctx, bson.M{"members.user_id": userID},
+		options.Find().SetProjection(bson.M{"members": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var spaces []workspaceModels.Workspace
+	if err := cursor.All(ctx, &spaces); err != nil {
+		return nil, err
+	}
+	a := &Access{UserID: userID, Workspaces: map[string]string{}}
+	for _, w := range spaces {
+		if m := w.Member(userID); m != nil {
+			a.Workspaces[w.ID.Hex()] = m.Role
+		}
+	}
+	return a, nil
+}
+
+// Filter matches the plans the user holds at least role on
+func (a *Access) Filter(role string) bson.M {
+	or := bson.A{bson.M{"user_id": a.UserID, "workspace_id": bson.M{"$in": bson.A{nil, ""}}}}
+
+	var spaces []string
+	for id, r := range a.Workspaces {
+		if models.RoleAtLeast(r, role) {
+			spaces = append(spaces, id)
+		}
+	}
+	if len(spaces) > 0 {
+		or = append(or, bson.M{"workspace_id": bson.M{"$in": spaces}})
+	}
+
+	// shares never make anyone an owner
+	var shared []string
+	for _, r := range []string{models.RoleEditor, models.RoleViewer} {
+		if models.RoleAtLeast(r, role) {
+			shared = append(shared, r)
+		}
+	}
+	if len(shared) > 0 {
+		or = append(or, bson.M{"shared_with": bson.M{"$elemMatch": bson.M{"user_id": a.UserID, "role": bson.M{"$in": shared}}}})
+	}
+	return bson.M{"$or": or}
+}
+
+// Role is the user's strongest role on plan, "" when they have no access
+func (a *Access) Role(plan *models.Plan) string {
+	role := ""
+	if plan.WorkspaceID == "" {
+		if plan.UserID == a.UserID {
+			return models.RoleOwner
+		}
+	} else {
+		role = a.Workspaces[plan.WorkspaceID]
+	}
+	for _, s := range plan.SharedWith {
+		if s.UserID == a.UserID && s.Role != models.RoleOwner && models.ValidRole(s.Role) &&
+			(role == "" || models.RoleAtLeast(s.Role, role)) {
+			role = s.Role
+		}
+	}
+	return role
+}
+
+// FilterFor matches the plans userID holds at least role on
+func (r *PlanRepository) FilterFor(userID, role string) (bson.M, error) {
+	a, err := r.Access(userID)
+	if err != nil {
+		return nil, err
+	}
+	return a.Filter(role), nil
+}
+
+// GetByIDForRole loads a plan the user holds at least role on. A plan they
+// can see but not change gives ErrReadOnly or ErrNotOwner, not "not found".
+func (r *PlanRepository) GetByIDForRole(planID, userID, role string) (*models.Plan, error) {
+	plan, err := r.GetByIDForUser(planID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !models.RoleAtLeast(plan.Role, role) {
+		if role == models.RoleOwner {
+			return nil, ErrNotOwner
+		}
+		return nil, ErrReadOnly
+	}
+	return plan, nil
+}
+
+// SetAccess moves a plan into a workspace, or out of one when workspaceID
+// is "" (it then belongs to ownerID), and replaces who it is shared with.
+// Users who lose access get tombstones through Mutate.
+func (r *PlanRepository) SetAccess(planID primitive.ObjectID, ownerID, workspaceID string, shares []models.Share) (*models.Plan, error) {
+	plan, err := r.Mutate(bson.M{"_id": planID}, func(plan *models.Plan) error {
+		if workspaceID == "" && plan.WorkspaceID != "" {
+			plan.UserID = ownerID
+		}
+		plan.WorkspaceID = workspaceID
+		plan.SharedWith = shares
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	publishUpdated(plan, "access_changed")
+	return plan, nil
+}
+
+// Audience lists everyone who can see plan
+func (r *PlanRepository) Audience(plan *models.Plan) ([]string, error) {
+	var users []string
+	if plan.WorkspaceID == "" {
+		users = append(users, plan.UserID)
+	} else {
+		members, err := r.workspaceMembers(plan.WorkspaceID)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range members {
+			users = append(users, m.UserID)
+		}
+	}
+	for _, s := range plan.SharedWith {
+		if !contains(users, s.UserID) {
+			users = append(users, s.UserID)
+		}
+	}
+	return users, nil
+}
+
+// AudienceWith lists everyone who holds at least role on plan
+func (r *PlanRepository) AudienceWith(plan *models.Plan, role string) ([]string, error) {
+	var users []string
+	if plan.WorkspaceID == "" {
+		users = append(users, plan.UserID)
+	} else {
+		members, err := r.workspaceMembers(plan.WorkspaceID)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range members {
+			if models.RoleAtLeast(m.Role, role) {
+				users = append(users, m.UserID)
+			}
+		}
+	}
+	for _, s := range plan.SharedWith {
+		if models.RoleAtLeast(s.Role, role) && !contains(users, s.UserID) {
+			users = append(users, s.UserID)
+		}
+	}
+	return users, nil
+}
+
+// EventAudience is who should hear about an event: the audience it names,
+// else everyone who can see its plan, else just its user
+func (r *PlanRepository) EventAudience(e events.Event) []string {
+	if len(e.Audience) > 0 {
+		return e.Audience
+	}
+	if e.PlanID != "" {
+		if users, err := r.AudienceOf(e.PlanID); err == nil && len(users) > 0 {
+			return users
+		}
+	}
+	return []string{e.UserID}
+}
+
+// AudienceOf lists everyone who can see the plan with the given ID
+func (r *PlanRepository) AudienceOf(planID string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(planID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid plan ID: %v", err)
+	}
+	var plan models.Plan
+	err = r.Collection.FindOne(ctx, bson.M{"_id": objID},
+		options.FindOne().SetProjection(bson.M{"user_id": 1, "workspace_id": 1, "shared_with": 1})).Decode(&plan)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("plan not found")
+		}
+		return nil, err
+	}
+	return r.Audience(&plan)
+}
+
+// CountInWorkspace counts the plans of a workspace
+func (r *PlanRepository) CountInWorkspace(workspaceID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return r.Collection.CountDocuments(ctx, bson.M{"workspace_id": workspaceID})
+}
+
+// ForgetWorkspace leaves plan tombstones for a user who was removed from a
+// workspace, so their sync clients drop its plans. Plans still shared with
+// them directly are kept.
+func (r *PlanRepository) ForgetWorkspace(workspaceID, userID string) error {
+	a, err := r.Access(userID)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.Collection.Find(ctx, bson.M{"workspace_id": workspaceID},
+		options.Find().SetProjection(bson.M{"user_id": 1, "workspace_id": 1, "shared_with": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var plans []models.Plan
+	if err := cursor.All(ctx, &plans); err != nil {
+		return err
+	}
+	var lost []string
+	for i := range plans {
+		if a.Role(&plans[i]) == "" {
+			lost = append(lost, plans[i].ID.Hex())
+		}
+	}
+	if len(lost) == 0 {
+		return nil
+	}
+
+	version, err := r.NextVersion(ctx)
+	if err != nil {
+		return err
+	}
+	docs := make([]interface{}, len(lost))
+	for i, planID := range lost {
+		docs[i] = models.Tombstone{UserID: userID, Kind: TombstonePlan, PlanID: planID, Version: version, DeletedAt: time.Now()}
+	}
+	_, err = r.Tombstones.InsertMany(ctx, docs)
+	return err
+}
+
+// GrantWorkspace moves every plan of a workspace to a new version that
+// records userID's grant, so a member who just joined downloads them on
+// their next sync, even after tombstones from an earlier membership. Plans
+// already shared with them directly are left alone.
+func (r *PlanRepository) GrantWorkspace(workspaceID, userID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	version, err := r.NextVersion(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = r.Collection.UpdateMany(ctx,
+		bson.M{"workspace_id": workspaceID, "shared_with.user_id": bson.M{"$ne": userID}},
+		bson.M{"$set": bson.M{
+			"version":                            version,
+			"updated_at":                         time.Now(),
+			"field_versions." + GrantKey(userID): version,
+		}},
+	)
+	return err
+}
+
+func (r *PlanRepository) workspaceMembers(workspaceID string) ([]workspaceModels.Member, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workspace ID: %v", err)
+	}
+	var w workspaceModels.Workspace
+	err = r.Workspaces.FindOne(ctx, bson.M{"_id": objID},
+		options.FindOne().SetProjection(bson.M{"members": 1})).Decode(&w)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return w.Members, err
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"smart-task-planner/internal/modules/plan/ai"
+	"smart-task-planner/internal/modules/plan/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	defaultMatchThreshold = 0.30 // minimum similarity to accept a match
+	defaultMatchMargin    = 0.05 // lead the best match needs over the runner-up
+	taskMatchDiscount     = 0.85 // a task hit counts slightly less than a goal hit
+	maxCandidates         = 5
+)
+
+// GoalCandidate is a plan that may be what the user referred to
+type GoalCandidate struct {
+	PlanID      string  `json:"plan_id"`
+	Goal        string  `json:"goal"`
+	Score       float64 `json:"score"`
+	MatchedTask string  `json:"matched_task,omitempty"`
+}
+
+// AmbiguousGoalError is returned when no plan is a confident match; the
+// candidates should be shown to the user for disambiguation.
+type AmbiguousGoalError struct {
+	Message    string
+	Candidates []GoalCandidate
+}
+
+// NoneReferenced reports whether the message didn't resemble any goal at all,
+// e.g. "what's my progress?".
+func (e *AmbiguousGoalError) NoneReferenced() bool {
+	for _, c := range e.Candidates {
+		if c.Score > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *AmbiguousGoalError) Error() string {
+	if len(e.Candidates) == 0 {
+		return fmt.Sprintf("no goal matches %q", e.Message)
+	}
+	var goals []string
+	for _, c := range e.Candidates {
+		goals = append(goals, c.Goal)
+	}
+	return fmt.Sprintf("could not tell which goal you meant, candidates: %s", strings.Join(goals, "; "))
+}
+
+// FindGoal resolves a free-text reference ("my marathon thing") to one of
+// the plans the user can see by embedding similarity over goals and task
+// titles.
+func (r *PlanRepository) FindGoal(userID, message string) (*models.Plan, error) {
+	return r.FindGoalForRole(userID, message, models.RoleViewer)
+}
+
+// FindGoalForRole is FindGoal among the plans the user holds at least role
+// on, so a command that changes a plan never picks one they can only view.
+func (r *PlanRepository) FindGoalForRole(userID, message, role string) (*models.Plan, error) {
+	all, err := r.GetAllByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user plans: %v", err)
+	}
+	var plans []models.Plan
+	for _, p := range all {
+		if models.RoleAtLeast(p.Role, role) {
+			plans = append(plans, p)
+		}
+	}
+	if len(plans) == 0 {
+		return nil, fmt.Errorf("no plans found for this user")
+	}
+
+	candidates, err := r.RankGoals(plans, message)
+	if err != nil {
+		return nil, err
+	}
+
+	threshold := envFloat("GOAL_MATCH_THRESHOLD", defaultMatchThreshold)
+	margin := envFloat("GOAL_MATCH_MARGIN", defaultMatchMargin)
+
+	best := candidates[0]
+	confident := best.Score >= threshold &&
+		(len(candidates) == 1 || best.Score-candidates[1].Score >= margin)
+	if !confident {
+		// Offer the closest plans; when nothing scored, offer the first few so
+		// the user still has something to pick from
+		var shortlist []GoalCandidate
+		for _, c := range candidates {
+			if len(shortlist) == maxCandidates || (c.Score <= 0 && best.Score > 0) {
+				break
+			}
+			shortlist = append(shortlist, c)
+		}
+		return nil, &AmbiguousGoalError{Message: message, Candidates: shortlist}
+	}
+
+	for i := range plans {
+		if plans[i].ID.Hex() == best.PlanID {
+			return &plans[i], nil
+		}
+	}
+	return nil, fmt.Errorf("matched plan not found: %s", best.PlanID)
+}
+
+// RankGoals scores every plan against the message, best first. Plans whose
+// stored vectors are missing or from another model are embedded on the fly
+// and written back.
+func (r *PlanRepository) RankGoals(plans []models.Plan, message string) ([]GoalCandidate, error) {
+	embedder := ai.DefaultEmbedder()
+
+	for i := range plans {
+		if planNeedsEmbedding(&plans[i], embedder.Model()) {
+			if err := embedPlan(&plans[i], embedder); err != nil {
+				return nil, err
+			}
+			r.saveEmbeddings(&plans[i])
+		}
+	}
+
+	vecs, err := embedder.Embed([]string{message})
+	if err != nil {
+		return nil, err
+	}
+	query := vecs[0]
+
+	candidates := make([]GoalCandidate, 0, len(plans))
+	for _, p := range plans {
+		c := GoalCandidate{PlanID: p.ID.Hex(), Goal: p.Goal, Score: ai.Cosine(query, p.GoalEmbedding)}
+
+		var walk func([]models.Task)
+		walk = func(tasks []models.Task) {
+			for _, t := range tasks {
+				if s := ai.Cosine(query, t.Embedding) * taskMatchDiscount; s > c.Score {
+					c.Score = s
+					c.MatchedTask = t.Title
+				}
+				walk(t.SubTasks)
+			}
+		}
+		walk(p.Tasks)
+
+		c.Score = math.Round(c.Score*1000) / 1000
+		candidates = append(candidates, c)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+	return candidates, nil
+}
+
+// embedPlan fills in the goal vector and any missing task vectors
+func embedPlan(plan *models.Plan, embedder ai.Embedder) error {
+	modelChanged := plan.EmbeddingModel != embedder.Model()
+
+	texts := []string{plan.Goal}
+	var targets []*models.Task
+	var collect func([]models.Task)
+	collect = func(tasks []models.Task) {
+		for i := range tasks {
+			if modelChanged || len(tasks[i].Embedding) == 0 {
+				texts = append(texts, tasks[i].Title+". "+tasks[i].Description)
+				targets = append(targets, &tasks[i])
+			}
+			collect(tasks[i].SubTasks)
+		}
+	}
+	collect(plan.Tasks)
+
+	vecs, err := embedder.Embed(texts)
+	if err != nil {
+		return fmt.Errorf("failed to embed plan: %v", err)
+	}
+	plan.GoalEmbedding = vecs[0]
+	plan.EmbeddingModel = embedder.Model()
+	for i, t := range targets {
+		t.Embedding = vecs[i+1]
+	}
+	return nil
+}
+
+func planNeedsEmbedding(plan *models.Plan, model string) bool {
+	if plan.EmbeddingModel != model || len(plan.GoalEmbedding) == 0 {
+		return true
+	}
+	missing := false
+	var walk func([]models.Task)
+	walk = func(tasks []models.Task) {
+		for _, t := range tasks {
+			if len(t.Embedding) == 0 {
+				missing = true
+				return
+			}
+			walk(t.SubTasks)
+		}
+	}
+	walk(plan.Tasks)
+	return missing
+}
+
+// dropStaleEmbeddings clears the vectors of a goal or tasks whose text
+// changed between before and after, so they are embedded again
+func dropStaleEmbeddings(before, after *models.Plan) {
+	if before.Goal != after.Goal {
+		after.GoalEmbedding = nil
+	}
+	old := Flatten(before.Tasks)
+	for id, ft := range Flatten(after.Tasks) {
+		if prev, ok := old[id]; ok && (prev.Task.Title != ft.Task.Title || prev.Task.Description != ft.Task.Description) {
+			ft.Task.Embedding = nil
+		}
+	}
+}
+
+// embeddingFields maps the path of every vector in a plan to the vector,
+// e.g. "tasks.1.sub_tasks.0.embedding"
+func embeddingFields(plan *models.Plan) bson.M {
+	set := bson.M{
+		"goal_embedding":  plan.GoalEmbedding,
+		"embedding_model": plan.EmbeddingModel,
+	}
+	var walk func(prefix string, tasks []models.Task)
+	walk = func(prefix string, tasks []models.Task) {
+		for i, t := range tasks {
+			path := prefix + "." + strconv.Itoa(i)
+			set[path+".embedding"] = t.Embedding
+			walk(path+".sub_tasks", t.SubTasks)
+		}
+	}
+	walk("tasks", plan.Tasks)
+	return set
+}
+
+// saveEmbeddings persists vectors computed during matching. Only the vectors
+// are written, and only while the plan is unchanged; a failed or skipped
+// write just costs a recompute next time.
+func (r *PlanRepository) saveEmbeddings(plan *models.Plan) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, _ = r.Collection.UpdateOne(ctx,
+		bson.M{"_id": plan.ID, "version": versionIs(plan.Version)},
+		bson.M{"$set": embeddingFields(plan)})
+}
+
+func envFloat(key string, def float64) float64 {
+	if v, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDropStaleEmbeddings(t *testing.T) {
+	vec := []float32{1, 0}
+	before := testPlan()
+	before.GoalEmbedding = vec
+	before.Tasks[0].Embedding = vec
+	before.Tasks[0].SubTasks[0].Embedding = vec
+	before.Tasks[1].Embedding = vec
+
+	after := clone(before)
+	after.Tasks[0].SubTasks[0].Title = "Buy trail shoes"
+	after.Tasks[1].Status = "Completed"
+	dropStaleEmbeddings(before, after)
+
+	if after.GoalEmbedding == nil || after.Tasks[0].Embedding == nil || after.Tasks[1].Embedding == nil {
+		t.Error("unchanged text lost its vector")
+	}
+	if after.Tasks[0].SubTasks[0].Embedding != nil {
+		t.Error("renamed subtask kept its vector")
+	}
+
+	after = clone(before)
+	after.Goal = "Run a marathon"
+	dropStaleEmbeddings(before, after)
+	if after.GoalEmbedding != nil {
+		t.Error("changed goal kept its vector")
+	}
+}
+
+func TestEmbeddingFields(t *testing.T) {
+	plan := testPlan()
+	set := embeddingFields(plan)
+	var keys []string
+	for k := range set {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	want := []string{"embedding_model", "goal_embedding", "tasks.0.embedding", "tasks.0.sub_tasks.0.embedding", "tasks.1.embedding"}
+	if !slices.Equal(keys, want) {
+		t.Errorf("fields %v, want %v", keys, want)
+	}
+}
@@ -0,0 +1,358 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"smart-task-planner/internal/modules/plan/attributes"
+	"smart-task-planner/internal/modules/plan/models"
+	"smart-task-planner/internal/modules/plan/progress"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Sort orders for List
+const (
+	SortCreated  = "created"
+	SortDeadline = "deadline"
+	SortProgress = "progress"
+)
+
+// Views for List: the whole task tree, top-level tasks only, or no tasks
+const (
+	ViewFull    = "full"
+	ViewCompact = "compact"
+	ViewSummary = "summary"
+)
+
+// WorkspacePersonal as ListQuery.Workspace lists plans outside any workspace
+const WorkspacePersonal = "personal"
+
+// ListQuery filters and orders a page of the plans a user can see. Empty
+// fields don't filter.
+type ListQuery struct {
+	Workspace   string // a workspace ID or WorkspacePersonal
+	Status      string // active, completed or overdue
+	Goal        string // case-insensitive substring of the goal
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Priorities  []string          // plans with a task of any of these priorities
+	Tags        []string          // plans whose tasks carry all of these tags
+	Contexts    []string          // ... all of these contexts
+	Fields      map[string]string // ... and these custom field values
+	Sort        string
+	Desc        bool
+	View        string
+	Cursor      string // from the previous page
+	Limit       int
+}
+
+// sortKey is one field of a sort order and how to read it off a plan
+type sortKey struct {
+	field string
+	dir   int
+	value func(p *models.Plan) interface{}
+}
+
+func sortKeys(sort string, desc bool) ([]sortKey, error) {
+	dir := 1
+	if desc {
+		dir = -1
+	}
+	id := sortKey{"_id", dir, func(p *models.Plan) interface{} { return p.ID }}
+
+	switch sort {
+	case SortCreated:
+		return []sortKey{
+			{"created_at", dir, func(p *models.Plan) interface{} { return p.CreatedAt }},
+			id,
+		}, nil
+	case SortDeadline:
+		return []sortKey{
+			{"summary.scheduled", -1, func(p *models.Plan) interface{} { return p.Summary.Scheduled }},
+			{"summary.next_deadline", dir, func(p *models.Plan) interface{} { return p.Summary.NextDeadline }},
+			id,
+		}, nil
+	case SortProgress:
+		return []sortKey{
+			{"summary.progress", dir, func(p *models.Plan) interface{} { return p.Summary.Progress }},
+			id,
+		}, nil
+	}
+	return nil, InvalidQuery("unknown sort %q (use created, deadline or progress)", sort)
+}
+
+// QueryError is a List error caused by the query rather than the database
+type QueryError struct {
+	msg string
+}
+
+func (e *QueryError) Error() string {
+	return e.msg
+}
+
+// InvalidQuery reports a problem with a List query
+func InvalidQuery(format string, a ...interface{}) error {
+	return &QueryError{msg: fmt.Sprintf(format, a...)}
+}
+
+// listCursor is the sort position of the last plan on a page
+type listCursor struct {
+	Sort   string `bson:"s"`
+	Desc   bool   `bson:"d"`
+	Values bson.A `bson:"v"`
+}
+
+func encodeCursor(c listCursor) (string, error) {
+	data, err := bson.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(s string) (*listCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, InvalidQuery("invalid cursor")
+	}
+	var c listCursor
+	if err := bson.Unmarshal(data, &c); err != nil {
+		return nil, InvalidQuery("invalid cursor")
+	}
+	return &c, nil
+}
+
+// after matches documents that come after values in the given order:
+// (k1 > v1) or (k1 = v1 and k2 > v2) or ...
+func after(keys []sortKey, values bson.A) bson.M {
+	var or bson.A
+	for i, k := range keys {
+		clause := bson.M{}
+		for j := 0; j < i; j++ {
+			clause[keys[j].field] = values[j]
+		}
+		op := "$gt"
+		if k.dir < 0 {
+			op = "$lt"
+		}
+		clause[k.field] = bson.M{op: values[i]}
+		or = append(or, clause)
+	}
+	return bson.M{"$or": or}
+}
+
+// List returns one page of the plans the user can see and the cursor of the next page
+// ("" on the last page).
+func (r *PlanRepository) List(userID string, q ListQuery, now time.Time) ([]models.Plan, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	keys, err := sortKeys(q.Sort, q.Desc)
+	if err != nil {
+		return nil, "", err
+	}
+	access, err := r.Access(userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	and := bson.A{access.Filter(models.RoleViewer)}
+	switch q.Workspace {
+	case "":
+	case WorkspacePersonal:
+		and = append(and, bson.M{"workspace_id": bson.M{"$in": bson.A{nil, ""}}})
+	default:
+		and = append(and, bson.M{"workspace_id": q.Workspace})
+	}
+	switch q.Status {
+	case "":
+	case models.PlanActive, models.PlanCompleted:
+		and = append(and, bson.M{"summary.status": q.Status})
+	case models.PlanOverdue:
+		and = append(and, bson.M{"summary.status": models.PlanActive, "summary.next_deadline": bson.M{"$lt": now}})
+	default:
+		return nil, "", InvalidQuery("unknown status %q (use active, completed or overdue)", q.Status)
+	}
+	if goal := strings.TrimSpace(q.Goal); goal != "" {
+		and = append(and, bson.M{"goal": primitive.Regex{Pattern: regexp.QuoteMeta(goal), Options: "i"}})
+	}
+	if q.CreatedFrom != nil || q.CreatedTo != nil {
+		created := bson.M{}
+		if q.CreatedFrom != nil {
+			created["$gte"] = *q.CreatedFrom
+		}
+		if q.CreatedTo != nil {
+			created["$lt"] = *q.CreatedTo
+		}
+		and = append(and, bson.M{"created_at": created})
+	}
+	if len(q.Priorities) > 0 {
+		and = append(and, bson.M{"summary.priorities": bson.M{"$in": q.Priorities}})
+	}
+	if len(q.Tags) > 0 {
+		tags := make([]string, len(q.Tags))
+		for i, t := range q.Tags {
+			tags[i] = strings.ToLower(t)
+		}
+		and = append(and, bson.M{"summary.tags": bson.M{"$all": tags}})
+	}
+	if len(q.Contexts) > 0 {
+		and = append(and, bson.M{"summary.contexts": bson.M{"$all": q.Contexts}})
+	}
+	for key, value := range q.Fields {
+		// numbers are summarized as "3", so "3.0" has to match that too
+		forms := bson.A{key + "=" + attributes.FieldValue(value)}
+		if f, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+			forms = append(forms, key+"="+attributes.FieldValue(f))
+		}
+		and = append(and, bson.M{"summary.field_values": bson.M{"$in": forms}})
+	}
+	if q.Cursor != "" {
+		c, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		if c.Sort != q.Sort || c.Desc != q.Desc || len(c.Values) != len(keys) {
+			return nil, "", InvalidQuery("cursor belongs to a different sort order")
+		}
+		and = append(and, after(keys, c.Values))
+	}
+
+	sort := bson.D{}
+	for _, k := range keys {
+		sort = append(sort, bson.E{Key: k.field, Value: k.dir})
+	}
+	projection := bson.M{"goal_embedding": 0, "field_versions": 0, "tasks.embedding": 0}
+	switch q.View {
+	case ViewFull, "":
+	case ViewCompact:
+		projection["tasks.sub_tasks"] = 0
+	case ViewSummary:
+		projection = bson.M{"tasks": 0, "goal_embedding": 0, "field_versions": 0}
+	default:
+		return nil, "", InvalidQuery("unknown view %q (use full, compact or summary)", q.View)
+	}
+
+	cursor, err := r.Collection.Find(ctx, bson.M{"$and": and}, options.Find().
+		SetSort(sort).
+		SetProjection(projection).
+		SetLimit(int64(q.Limit)+1))
+	if err != nil {
+		return nil, "", err
+	}
+	defer cursor.Close(ctx)
+
+	plans := []models.Plan{}
+	if err := cursor.All(ctx, &plans); err != nil {
+		return nil, "", err
+	}
+	for i := range plans {
+		plans[i].Role = access.Role(&plans[i])
+	}
+	if len(plans) <= q.Limit {
+		return plans, "", nil
+	}
+
+	plans = plans[:q.Limit]
+	last := &plans[len(plans)-1]
+	values := bson.A{}
+	for _, k := range keys {
+		values = append(values, k.value(last))
+	}
+	next, err := encodeCursor(listCursor{Sort: q.Sort, Desc: q.Desc, Values: values})
+	return plans, next, err
+}
+
+// summarize derives a plan's Summary from its tasks. Cancelled tasks count
+// as finished for the status and next deadline, but not towards progress.
+func summarize(plan *models.Plan) {
+	s := progress.Calculate(plan.Tasks, time.Time{})
+
+	var next *time.Time
+	unfinished := 0
+	var walk func([]models.Task)
+	walk = func(tasks []models.Task) {
+		for _, t := range tasks {
+			if !progress.IsCompleted(t) && !strings.EqualFold(t.Status, "Cancelled") {
+				unfinished++
+				if !t.Deadline.IsZero() && (next == nil || t.Deadline.Before(*next)) {
+					d := t.Deadline
+					next = &d
+				}
+			}
+			walk(t.SubTasks)
+		}
+	}
+	walk(plan.Tasks)
+
+	status := models.PlanActive
+	if s.Total > 0 && unfinished == 0 {
+		status = models.PlanCompleted
+	}
+	plan.Summary = models.PlanSummary{
+		Status:         status,
+		TotalTasks:     s.Total,
+		CompletedTasks: s.Completed,
+		Progress:       s.Percentage,
+		NextDeadline:   next,
+		Scheduled:      next != nil,
+	}
+	plan.Summary.Priorities, plan.Summary.Tags, plan.Summary.Contexts, plan.Summary.FieldValues = attributes.Labels(plan.Tasks)
+}
+
+// BackfillSummaries fills in the summary and creation time of plans written
+// before listings could filter on them (or on task labels).
+func (r *PlanRepository) BackfillSummaries() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	cursor, err := r.Collection.Find(ctx, bson.M{"$or": bson.A{
+		bson.M{"summary": bson.M{"$exists": false}},
+		bson.M{"created_at": bson.M{"$exists": false}},
+		bson.M{"summary.tags": bson.M{"$exists": false}},
+	}}, options.Find().SetProjection(bson.M{"goal_embedding": 0, "field_versions": 0}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var writes []mongo.WriteModel
+	for cursor.Next(ctx) {
+		var plan models.Plan
+		if err := cursor.Decode(&plan); err != nil {
+			return err
+		}
+		summarize(&plan)
+		set := bson.M{"summary": plan.Summary}
+		if plan.CreatedAt.IsZero() {
+			set["created_at"] = plan.ID.Timestamp()
+		}
+		// a plan saved meanwhile got its summary from that save
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": plan.ID, "version": versionIs(plan.Version)}).
+			SetUpdate(bson.M{"$set": set}))
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	if len(writes) == 0 {
+		return nil
+	}
+
+	res, err := r.Collection.BulkWrite(ctx, writes)
+	if err != nil {
+		return err
+	}
+	log.Printf("Backfilled summaries of %d plans", res.MatchedCount)
+	return nil
+}
@@ -6,21 +6,28 @@ import (
 	"smart-task-planner/internal/modules/plan/models"
 	"time"
 	"fmt"
-	"strings"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"smart-task-planner/internal/modules/plan/ai"
+	"smart-task-planner/internal/modules/plan/recurrence"
+	"smart-task-planner/internal/events"
 )
 
 type PlanRepository struct {
 	Collection *mongo.Collection
+	Tombstones *mongo.Collection
+	Counters   *mongo.Collection
+	Workspaces *mongo.Collection // read for membership; owned by the workspaces module
 }
 
 func NewPlanRepository(db *mongo.Database) *PlanRepository {
 	return &PlanRepository{
 		Collection: db.Collection("plans"),
+		Tombstones: db.Collection("plan_tombstones"),
+		Counters:   db.Collection("counters"),
+		Workspaces: db.Collection("workspaces"),
 	}
 }
 
@@ -28,19 +35,60 @@ func (r *PlanRepository) Create(plan *models.Plan) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	_, err := r.Collection.InsertOne(ctx, plan)
+	if plan.ID.IsZero() {
+		plan.ID = primitive.NewObjectID()
+	}
+	version, err := r.NextVersion(ctx)
+	if err != nil {
+		return err
+	}
+	plan.Tasks = recurrence.Advance(plan.Tasks, time.Now())
+	stampNew(plan, version, time.Now())
+	refreshEmbeddings(plan)
+
+	_, err = r.Collection.InsertOne(ctx, plan)
 	if err != nil {
 		log.Println("Error creating plan:", err)
+		return err
+	}
+
+	events.Publish(events.Event{
+		Type:   events.PlanCreated,
+		UserID: plan.UserID,
+		PlanID: plan.ID.Hex(),
+		Data: map[string]interface{}{
+			"plan_id":    plan.ID.Hex(),
+			"goal":       plan.Goal,
+			"task_count": len(plan.Tasks),
+		},
+	})
+	return nil
+}
+
+// refreshEmbeddings embeds the goal and any tasks without a vector. Failures
+// are logged and left for FindGoal to backfill.
+func refreshEmbeddings(plan *models.Plan) {
+	embedder := ai.DefaultEmbedder()
+	if !planNeedsEmbedding(plan, embedder.Model()) {
+		return
+	}
+	if err := embedPlan(plan, embedder); err != nil {
+		log.Println("Error embedding plan:", err)
 	}
-	return err
 }
 
-// GetAllByUser fetches plans belonging to a specific user
+// GetAllByUser fetches every plan the user can see: their own, those of
+// their workspaces and those shared with them
 func (r *PlanRepository) GetAllByUser(userID string) ([]models.Plan, error) {
+	a, err := r.Access(userID)
+	if err != nil {
+		return nil, err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	cursor, err := r.Collection.Find(ctx, bson.M{"user_id": userID})
+	cursor, err := r.Collection.Find(ctx, a.Filter(models.RoleViewer))
 	if err != nil {
 		return nil, err
 	}
@@ -50,104 +98,161 @@ func (r *PlanRepository) GetAllByUser(userID string) ([]models.Plan, error) {
 	if err := cursor.All(ctx, &plans); err != nil {
 		return nil, err
 	}
+	for i := range plans {
+		plans[i].Role = a.Role(&plans[i])
+	}
 	return plans, nil
 }
 
-func (r *PlanRepository) AddSubTasks(planID, taskID string, subtasks []models.Task) (*models.Plan, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// ForEach streams every plan in the collection to fn, used by background jobs
+func (r *PlanRepository) ForEach(fn func(plan *models.Plan) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
 
-	var plan models.Plan
-	objectID, err := primitive.ObjectIDFromHex(planID)
+	cursor, err := r.Collection.Find(ctx, bson.M{})
 	if err != nil {
-		return nil, err
+		return err
 	}
+	defer cursor.Close(ctx)
 
-	if err := r.Collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&plan); err != nil {
-		return nil, err
+	for cursor.Next(ctx) {
+		var plan models.Plan
+		if err := cursor.Decode(&plan); err != nil {
+			return err
+		}
+		if err := fn(&plan); err != nil {
+			return err
+		}
 	}
+	return cursor.Err()
+}
 
-	// Recursively find the target task
-	var addSubs func([]models.Task) []models.Task
-	addSubs = func(tasks []models.Task) []models.Task {
-		for i := range tasks {
-			if tasks[i].ID.Hex() == taskID {
-				tasks[i].SubTasks = append(tasks[i].SubTasks, subtasks...)
-				return tasks
-			}
-			tasks[i].SubTasks = addSubs(tasks[i].SubTasks)
+// AdvanceRecurring saves every plan with a recurring task that is due for
+// its next occurrence. Mutate does the work; this catches up on occurrences
+// missed while nobody edited the plan.
+func (r *PlanRepository) AdvanceRecurring(now time.Time) (int, error) {
+	var due []primitive.ObjectID
+	err := r.ForEach(func(plan *models.Plan) error {
+		if recurrence.Due(plan.Tasks, now) {
+			due = append(due, plan.ID)
 		}
-		return tasks
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
 
-	plan.Tasks = addSubs(plan.Tasks)
+	advanced := 0
+	for _, id := range due {
+		plan, err := r.Mutate(bson.M{"_id": id}, func(*models.Plan) error { return nil })
+		if err != nil {
+			log.Println("Error advancing recurring tasks:", err)
+			continue
+		}
+		publishUpdated(plan, "recurring_advanced")
+		advanced++
+	}
+	return advanced, nil
+}
 
-	_, err = r.Collection.UpdateOne(
-		ctx,
-		bson.M{"_id": objectID},
-		bson.M{"$set": bson.M{"tasks": plan.Tasks}},
-	)
+// GetByIDForUser fetches a single plan, making sure the user can see it
+func (r *PlanRepository) GetByIDForUser(planID, userID string) (*models.Plan, error) {
+	objectID, err := primitive.ObjectIDFromHex(planID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid plan ID: %v", err)
+	}
+	a, err := r.Access(userID)
 	if err != nil {
 		return nil, err
 	}
 
-	return &plan, nil
-}
-
-func (r *PlanRepository) FindGoalByAI(userID, message string) (*models.Plan, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// 1️⃣ Fetch all plans for the user
-	cursor, err := r.Collection.Find(ctx, bson.M{"user_id": userID})
+	filter := a.Filter(models.RoleViewer)
+	filter["_id"] = objectID
+	var plan models.Plan
+	err = r.Collection.FindOne(ctx, filter).Decode(&plan)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch user plans: %v", err)
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("plan not found")
+		}
+		return nil, err
 	}
-	defer cursor.Close(ctx)
+	plan.Role = a.Role(&plan)
+	return &plan, nil
+}
 
-	var plans []models.Plan
-	if err := cursor.All(ctx, &plans); err != nil {
-		return nil, fmt.Errorf("failed to decode plans: %v", err)
+func (r *PlanRepository) AddSubTasks(userID, planID, taskID string, subtasks []models.Task) (*models.Plan, error) {
+	objectID, err := primitive.ObjectIDFromHex(planID)
+	if err != nil {
+		return nil, err
 	}
-
-	if len(plans) == 0 {
-		return nil, fmt.Errorf("no plans found for this user")
+	filter, err := r.FilterFor(userID, models.RoleEditor)
+	if err != nil {
+		return nil, err
 	}
+	filter["_id"] = objectID
 
-	// 2️⃣ Create a list of plan goals
-	var goalList []string
-	for _, p := range plans {
-		goalList = append(goalList, p.Goal)
+	// Recursively find the target task
+	var addSubs func([]models.Task) []models.Task
+	addSubs = func(tasks []models.Task) []models.Task {
+		for i := range tasks {
+			if tasks[i].ID.Hex() == taskID {
+				tasks[i].SubTasks = append(tasks[i].SubTasks, subtasks...)
+				return tasks
+			}
+			tasks[i].SubTasks = addSubs(tasks[i].SubTasks)
+		}
+		return tasks
 	}
 
-	// 3️⃣ Ask the AI which goal best matches the user message
-	bestGoal, err := ai.AskOpenAIForBestGoal(message, goalList)
+	plan, err := r.Mutate(filter, func(plan *models.Plan) error {
+		plan.Tasks = addSubs(plan.Tasks)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// 4️⃣ Find the plan object corresponding to the chosen goal
-	for _, p := range plans {
-		if strings.EqualFold(p.Goal, bestGoal) {
-			return &p, nil
-		}
-	}
-
-	return nil, fmt.Errorf("AI suggested goal not found: %s", bestGoal)
+	publishUpdated(plan, "subtasks_added")
+	return plan, nil
 }
 
+// UpdatePlan saves the tasks of an already loaded plan. It fails with
+// ErrConflict when someone saved the plan since it was loaded, instead of
+// overwriting their change. A plan loaded for a viewer can't be saved.
 func (r *PlanRepository) UpdatePlan(plan *models.Plan) (*models.Plan, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	_, err := r.Collection.UpdateOne(
-		ctx,
-		bson.M{"_id": plan.ID},             // match by plan ID
-		bson.M{"$set": bson.M{"tasks": plan.Tasks}}, // update tasks
-	)
+	if plan.Role != "" && !models.RoleAtLeast(plan.Role, models.RoleEditor) {
+		return nil, ErrReadOnly
+	}
+	updated, err := r.Mutate(bson.M{"_id": plan.ID}, func(current *models.Plan) error {
+		if current.Version != plan.Version {
+			return ErrConflict
+		}
+		current.Tasks = plan.Tasks
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return plan, nil
+	publishUpdated(updated, "tasks_updated")
+	updated.Role = plan.Role
+	return updated, nil
+}
+
+// publishUpdated tells listeners (live clients, webhooks) that a plan's tasks changed
+func publishUpdated(plan *models.Plan, change string) {
+	events.Publish(events.Event{
+		Type:   events.PlanUpdated,
+		UserID: plan.UserID,
+		PlanID: plan.ID.Hex(),
+		Data: map[string]interface{}{
+			"plan_id":    plan.ID.Hex(),
+			"goal":       plan.Goal,
+			"change":     change,
+			"task_count": len(plan.Tasks),
+		},
+	})
 }
@@ -0,0 +1,511 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"smart-task-planner/internal/events"
+	"smart-task-planner/internal/modules/plan/models"
+	"smart-task-planner/internal/modules/plan/recurrence"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	TombstonePlan = "plan"
+	TombstoneTask = "task"
+
+	// Mutate retries this often when another writer got in between
+	mutateAttempts = 3
+)
+
+// ErrConflict is returned when a plan kept changing under Mutate
+var ErrConflict = fmt.Errorf("plan was modified concurrently, try again")
+
+func (r *PlanRepository) EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// version serves sync; the rest back the sort orders of List
+	if _, err := r.Collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "version", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "summary.scheduled", Value: -1}, {Key: "summary.next_deadline", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "summary.progress", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "summary.status", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "summary.priorities", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "summary.tags", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "summary.contexts", Value: 1}}},
+		// plans reached through a workspace or a share
+		{Keys: bson.D{{Key: "workspace_id", Value: 1}, {Key: "version", Value: 1}}},
+		{Keys: bson.D{{Key: "shared_with.user_id", Value: 1}, {Key: "version", Value: 1}}},
+	}); err != nil {
+		return err
+	}
+	_, err := r.Tombstones.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "version", Value: 1}},
+	})
+	return err
+}
+
+// NextVersion hands out the next sync sequence number
+func (r *PlanRepository) NextVersion(ctx context.Context) (int64, error) {
+	var counter struct {
+		Value int64 `bson:"value"`
+	}
+	err := r.Counters.FindOneAndUpdate(ctx,
+		bson.M{"_id": "plan_version"},
+		bson.M{"$inc": bson.M{"value": 1}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	return counter.Value, err
+}
+
+// Mutate loads the plan matching filter, lets fn change it and saves the
+// result with a new version, per-field versions and tombstones for removed
+// tasks. The write only succeeds if nobody else saved the plan in between;
+// otherwise fn runs again on the fresh copy. Nothing is written when fn
+// leaves the plan as it was.
+func (r *PlanRepository) Mutate(filter bson.M, fn func(plan *models.Plan) error) (*models.Plan, error) {
+	for attempt := 0; attempt < mutateAttempts; attempt++ {
+		plan, saved, err := r.mutateOnce(filter, fn)
+		if err != nil {
+			return nil, err
+		}
+		if saved {
+			return plan, nil
+		}
+	}
+	return nil, ErrConflict
+}
+
+func (r *PlanRepository) mutateOnce(filter bson.M, fn func(plan *models.Plan) error) (*models.Plan, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	raw, err := r.Collection.FindOne(ctx, filter).Raw()
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, false, fmt.Errorf("plan not found")
+		}
+		return nil, false, err
+	}
+	// two decodes give fn a deep copy to change and keep the original to diff against
+	var before, after models.Plan
+	if err := bson.Unmarshal(raw, &before); err != nil {
+		return nil, false, err
+	}
+	if err := bson.Unmarshal(raw, &after); err != nil {
+		return nil, false, err
+	}
+
+	if err := fn(&after); err != nil {
+		return nil, false, err
+	}
+	after.Tasks = recurrence.Advance(after.Tasks, time.Now())
+	if !planChanged(&before, &after) {
+		return &after, true, nil
+	}
+
+	version, err := r.NextVersion(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	now := time.Now()
+	removed := stamp(&before, &after, version, now)
+
+	var previous, audience []string
+	if len(removed) > 0 || accessChanged(&before, &after) {
+		if audience, err = r.Audience(&after); err != nil {
+			return nil, false, err
+		}
+	}
+	if accessChanged(&before, &after) {
+		if previous, err = r.Audience(&before); err != nil {
+			return nil, false, err
+		}
+		for _, userID := range audience {
+			if !contains(previous, userID) {
+				after.FieldVersions[GrantKey(userID)] = version
+			}
+		}
+		for _, userID := range previous {
+			if !contains(audience, userID) {
+				delete(after.FieldVersions, GrantKey(userID))
+			}
+		}
+	}
+	dropStaleEmbeddings(&before, &after)
+	refreshEmbeddings(&after)
+
+	res, err := r.Collection.UpdateOne(ctx,
+		bson.M{"_id": before.ID, "version": versionIs(before.Version)},
+		bson.M{"$set": bson.M{
+			"user_id":         after.UserID,
+			"workspace_id":    after.WorkspaceID,
+			"shared_with":     after.SharedWith,
+			"goal":            after.Goal,
+			"tasks":           after.Tasks,
+			"version":         after.Version,
+			"updated_at":      after.UpdatedAt,
+			"field_versions":  after.FieldVersions,
+			"summary":         after.Summary,
+			"goal_embedding":  after.GoalEmbedding,
+			"embedding_model": after.EmbeddingModel,
+		}},
+	)
+	if err != nil {
+		return nil, false, err
+	}
+	if res.MatchedCount == 0 {
+		return nil, false, nil
+	}
+
+	// every sync client that can see the plan drops the removed tasks, and
+	// users who just lost access drop the whole plan
+	var docs []interface{}
+	for _, id := range removed {
+		for _, userID := range audience {
+			docs = append(docs, models.Tombstone{
+				UserID:    userID,
+				Kind:      TombstoneTask,
+				PlanID:    after.ID.Hex(),
+				TaskID:    id,
+				Version:   version,
+				DeletedAt: now,
+			})
+		}
+	}
+	for _, userID := range previous {
+		if !contains(audience, userID) {
+			docs = append(docs, models.Tombstone{UserID: userID, Kind: TombstonePlan, PlanID: after.ID.Hex(), Version: version, DeletedAt: now})
+		}
+	}
+	if len(docs) > 0 {
+		if _, err := r.Tombstones.InsertMany(ctx, docs); err != nil {
+			return nil, false, err
+		}
+	}
+	return &after, true, nil
+}
+
+// Delete removes a plan the user owns and leaves a tombstone for the sync
+// clients of everyone who could see it
+func (r *PlanRepository) Delete(userID, planID string) error {
+	plan, err := r.GetByIDForRole(planID, userID, models.RoleOwner)
+	if err != nil {
+		return err
+	}
+	audience, err := r.Audience(plan)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := r.Collection.DeleteOne(ctx, bson.M{"_id": plan.ID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return fmt.Errorf("plan not found")
+	}
+
+	version, err := r.NextVersion(ctx)
+	if err != nil {
+		return err
+	}
+	docs := make([]interface{}, len(audience))
+	for i, user := range audience {
+		docs[i] = models.Tombstone{
+			UserID:    user,
+			Kind:      TombstonePlan,
+			PlanID:    planID,
+			Version:   version,
+			DeletedAt: time.Now(),
+		}
+	}
+	if len(docs) > 0 {
+		if _, err := r.Tombstones.InsertMany(ctx, docs); err != nil {
+			return err
+		}
+	}
+
+	events.Publish(events.Event{
+		Type:     events.PlanDeleted,
+		UserID:   plan.UserID,
+		PlanID:   planID,
+		Audience: audience,
+		Data:     map[string]interface{}{"plan_id": planID},
+	})
+	return nil
+}
+
+// ChangedSince returns the plans the user can see that changed after
+// version, oldest change first
+func (r *PlanRepository) ChangedSince(userID string, version int64, limit int64) ([]models.Plan, error) {
+	a, err := r.Access(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := a.Filter(models.RoleViewer)
+	if version > 0 {
+		filter["version"] = bson.M{"$gt": version}
+	}
+	cursor, err := r.Collection.Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "version", Value: 1}, {Key: "_id", Value: 1}}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	plans := []models.Plan{}
+	if err := cursor.All(ctx, &plans); err != nil {
+		return nil, err
+	}
+	for i := range plans {
+		plans[i].Role = a.Role(&plans[i])
+	}
+	return plans, nil
+}
+
+// TombstonesSince returns deletions after version, oldest first
+func (r *PlanRepository) TombstonesSince(userID string, version int64, limit int64) ([]models.Tombstone, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.Tombstones.Find(ctx, bson.M{"user_id": userID, "version": bson.M{"$gt": version}},
+		options.Find().SetSort(bson.D{{Key: "version", Value: 1}}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	tombstones := []models.Tombstone{}
+	if err := cursor.All(ctx, &tombstones); err != nil {
+		return nil, err
+	}
+	return tombstones, nil
+}
+
+// FindTombstone reports whether a plan (taskID empty) or task was deleted
+func (r *PlanRepository) FindTombstone(userID, planID, taskID string) (*models.Tombstone, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID, "plan_id": planID, "kind": TombstonePlan}
+	if taskID != "" {
+		filter = bson.M{"user_id": userID, "plan_id": planID, "task_id": taskID, "kind": TombstoneTask}
+	}
+	var t models.Tombstone
+	if err := r.Tombstones.FindOne(ctx, filter).Decode(&t); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// stampNew versions a plan that is about to be inserted
+func stampNew(plan *models.Plan, version int64, now time.Time) {
+	plan.Version = version
+	plan.CreatedVersion = version
+	plan.CreatedAt = now
+	plan.UpdatedAt = now
+	plan.FieldVersions = map[string]int64{}
+	summarize(plan)
+}
+
+// FlatTask is a task at any depth together with its parent
+type FlatTask struct {
+	ParentID string
+	Task     *models.Task
+}
+
+// Flatten indexes every task of a plan by ID
+func Flatten(tasks []models.Task) map[string]FlatTask {
+	out := map[string]FlatTask{}
+	var walk func(parent string, tasks []models.Task)
+	walk = func(parent string, tasks []models.Task) {
+		for i := range tasks {
+			t := &tasks[i]
+			out[t.ID.Hex()] = FlatTask{ParentID: parent, Task: t}
+			walk(t.ID.Hex(), t.SubTasks)
+		}
+	}
+	walk("", tasks)
+	return out
+}
+
+// TaskFields are the task fields versioned for conflict detection
+var TaskFields = []string{"title", "description", "status", "deadline", "completed_at", "estimated_hours", "actual_hours", "tags", "priority", "contexts", "fields", "depends_on", "recurrence", "assignee_id", "parent"}
+
+func taskField(ft FlatTask, field string) interface{} {
+	t := ft.Task
+	switch field {
+	case "title":
+		return t.Title
+	case "description":
+		return t.Description
+	case "status":
+		return t.Status
+	case "deadline":
+		return t.Deadline.UTC()
+	case "completed_at":
+		if t.CompletedAt == nil {
+			return nil
+		}
+		return t.CompletedAt.UTC()
+	case "estimated_hours":
+		return t.EstimatedHours
+	case "actual_hours":
+		return t.ActualHours
+	case "priority":
+		return t.Priority
+	case "contexts":
+		if len(t.Contexts) == 0 {
+			return []string(nil)
+		}
+		return t.Contexts
+	case "fields":
+		if len(t.Fields) == 0 {
+			return map[string]interface{}(nil)
+		}
+		return t.Fields
+	case "tags":
+		if len(t.Tags) == 0 {
+			return []string(nil)
+		}
+		return t.Tags
+	case "depends_on":
+		if len(t.DependsOn) == 0 {
+			return []primitive.ObjectID(nil)
+		}
+		return t.DependsOn
+	case "recurrence":
+		return t.Recurrence
+	case "assignee_id":
+		return t.AssigneeID
+	case "parent":
+		return ft.ParentID
+	}
+	return nil
+}
+
+// FieldKey is the FieldVersions key of a task field ("" for the task itself)
+func FieldKey(taskID, field string) string {
+	if field == "" {
+		return taskID
+	}
+	return taskID + ":" + field
+}
+
+// GrantKey is the FieldVersions key holding the version at which userID
+// gained access to the plan, so their sync clients download it in full
+func GrantKey(userID string) string {
+	return "granted:" + userID
+}
+
+// FieldVersion is the version a field last changed at (0 if never tracked)
+func FieldVersion(plan *models.Plan, key string) int64 {
+	return plan.FieldVersions[key]
+}
+
+// versionIs matches a plan still at version v. Legacy plans have no
+// version field yet.
+func versionIs(v int64) bson.M {
+	if v != 0 {
+		return bson.M{"$eq": v}
+	}
+	return bson.M{"$in": bson.A{v, nil}}
+}
+
+func planChanged(before, after *models.Plan) bool {
+	if before.Goal != after.Goal || accessChanged(before, after) {
+		return true
+	}
+	old, cur := Flatten(before.Tasks), Flatten(after.Tasks)
+	if len(old) != len(cur) {
+		return true
+	}
+	for id, ft := range cur {
+		prev, ok := old[id]
+		if !ok {
+			return true
+		}
+		for _, f := range TaskFields {
+			if !reflect.DeepEqual(taskField(prev, f), taskField(ft, f)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// accessChanged reports whether a plan changed owner, workspace or shares
+func accessChanged(before, after *models.Plan) bool {
+	if before.UserID != after.UserID || before.WorkspaceID != after.WorkspaceID || len(before.SharedWith) != len(after.SharedWith) {
+		return true
+	}
+	for i := range before.SharedWith {
+		if before.SharedWith[i] != after.SharedWith[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// stamp sets after's version and marks every changed field with it. It
+// returns the IDs of tasks that disappeared.
+func stamp(before, after *models.Plan, version int64, now time.Time) []string {
+	fv := map[string]int64{}
+	for k, v := range before.FieldVersions {
+		fv[k] = v
+	}
+	if before.Goal != after.Goal {
+		fv["goal"] = version
+	}
+
+	old, cur := Flatten(before.Tasks), Flatten(after.Tasks)
+	for id, ft := range cur {
+		prev, ok := old[id]
+		if !ok {
+			fv[FieldKey(id, "")] = version
+			continue
+		}
+		for _, f := range TaskFields {
+			if !reflect.DeepEqual(taskField(prev, f), taskField(ft, f)) {
+				fv[FieldKey(id, f)] = version
+			}
+		}
+	}
+
+	var removed []string
+	for id := range old {
+		if _, ok := cur[id]; ok {
+			continue
+		}
+		removed = append(removed, id)
+		delete(fv, FieldKey(id, ""))
+		for _, f := range TaskFields {
+			delete(fv, FieldKey(id, f))
+		}
+	}
+
+	after.Version = version
+	after.UpdatedAt = now
+	after.FieldVersions = fv
+	summarize(after)
+	return removed
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"smart-task-planner/internal/modules/plan/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func testPlan() *models.Plan {
+	sub := models.Task{ID: primitive.NewObjectID(), Title: "Buy shoes", Status: "Pending"}
+	return &models.Plan{
+		ID:   primitive.NewObjectID(),
+		Goal: "Run a half marathon",
+		Tasks: []models.Task{
+			{ID: primitive.NewObjectID(), Title: "Train", Status: "Pending", SubTasks: []models.Task{sub}},
+			{ID: primitive.NewObjectID(), Title: "Register", Status: "Pending", Tags: []string{"admin"}},
+		},
+		FieldVersions: map[string]int64{},
+	}
+}
+
+// clone is a deep enough copy for the fields the tests change
+func clone(p *models.Plan) *models.Plan {
+	c := *p
+	var copyTasks func([]models.Task) []models.Task
+	copyTasks = func(tasks []models.Task) []models.Task {
+		out := make([]models.Task, len(tasks))
+		for i, t := range tasks {
+			t.Tags = slices.Clone(t.Tags)
+			t.SubTasks = copyTasks(t.SubTasks)
+			out[i] = t
+		}
+		return out
+	}
+	c.Tasks = copyTasks(p.Tasks)
+	c.FieldVersions = map[string]int64{}
+	for k, v := range p.FieldVersions {
+		c.FieldVersions[k] = v
+	}
+	return &c
+}
+
+func TestStamp(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		change  func(p *models.Plan)
+		stamped []string // FieldVersions keys expected at the new version
+		removed int
+	}{
+		{
+			name:    "goal",
+			change:  func(p *models.Plan) { p.Goal = "Run a marathon" },
+			stamped: []string{"goal"},
+		},
+		{
+			name:    "one field of a subtask",
+			change:  func(p *models.Plan) { p.Tasks[0].SubTasks[0].Status = "Completed" },
+			stamped: []string{"sub:status"},
+		},
+		{
+			name: "two fields of one task",
+			change: func(p *models.Plan) {
+				p.Tasks[1].Title = "Register early"
+				p.Tasks[1].Tags = nil
+			},
+			stamped: []string{"register:title", "register:tags"},
+		},
+		{
+			name: "new task",
+			change: func(p *models.Plan) {
+				p.Tasks = append(p.Tasks, models.Task{ID: primitive.NewObjectID(), Title: "Rest"})
+			},
+			stamped: []string{"new"},
+		},
+		{
+			name:    "moved subtask",
+			change:  func(p *models.Plan) { p.Tasks[1].SubTasks, p.Tasks[0].SubTasks = p.Tasks[0].SubTasks, nil },
+			stamped: []string{"sub:parent"},
+		},
+		{
+			name:    "removed task",
+			change:  func(p *models.Plan) { p.Tasks = p.Tasks[:1] },
+			removed: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		before := testPlan()
+		before.FieldVersions[FieldKey(before.Tasks[1].ID.Hex(), "title")] = 3
+		after := clone(before)
+		tt.change(after)
+
+		names := map[string]string{
+			"goal":     "goal",
+			"train":    before.Tasks[0].ID.Hex(),
+			"sub":      before.Tasks[0].SubTasks[0].ID.Hex(),
+			"register": before.Tasks[1].ID.Hex(),
+		}
+		if len(after.Tasks) > 2 {
+			names["new"] = after.Tasks[2].ID.Hex()
+		}
+		key := func(k string) string {
+			name, field, _ := strings.Cut(k, ":")
+			if name == "goal" {
+				return "goal"
+			}
+			return FieldKey(names[name], field)
+		}
+
+		if !planChanged(before, after) {
+			t.Errorf("%s: planChanged = false", tt.name)
+		}
+		removed := stamp(before, after, 10, now)
+		if len(removed) != tt.removed {
+			t.Errorf("%s: removed %v, want %d", tt.name, removed, tt.removed)
+		}
+		if after.Version != 10 || !after.UpdatedAt.Equal(now) {
+			t.Errorf("%s: version %d at %v", tt.name, after.Version, after.UpdatedAt)
+		}
+
+		var want []string
+		for _, k := range tt.stamped {
+			want = append(want, key(k))
+		}
+		for k, v := range after.FieldVersions {
+			if v == 10 && !slices.Contains(want, k) {
+				t.Errorf("%s: %s stamped but unchanged", tt.name, k)
+			}
+		}
+		for _, k := range want {
+			if after.FieldVersions[k] != 10 {
+				t.Errorf("%s: %s not stamped", tt.name, k)
+			}
+		}
+
+		registerTitle := FieldKey(names["register"], "title")
+		if tt.removed > 0 {
+			if _, ok := after.FieldVersions[registerTitle]; ok {
+				t.Errorf("%s: field versions of the removed task kept", tt.name)
+			}
+		} else if !slices.Contains(want, registerTitle) && after.FieldVersions[registerTitle] != 3 {
+			t.Errorf("%s: earlier field version lost", tt.name)
+		}
+	}
+}
+
+func TestPlanChangedIgnoresNoOps(t *testing.T) {
+	before := testPlan()
+	after := clone(before)
+	after.Tasks[1].Tags = []string{"admin"}
+	if planChanged(before, after) {
+		t.Error("equal plans reported as changed")
+	}
+
+	after.Tasks[0].SubTasks[0].Tags = []string{}
+	if planChanged(before, after) {
+		t.Error("an empty list differs from no list")
+	}
+}
+
+func TestAccessChanged(t *testing.T) {
+	before := testPlan()
+	before.UserID = "u1"
+	tests := []struct {
+		name   string
+		change func(p *models.Plan)
+		want   bool
+	}{
+		{"nothing", func(p *models.Plan) {}, false},
+		{"owner", func(p *models.Plan) { p.UserID = "u2" }, true},
+		{"workspace", func(p *models.Plan) { p.WorkspaceID = "ws" }, true},
+		{"share", func(p *models.Plan) {
+			p.SharedWith = []models.Share{{UserID: "u3", Role: models.RoleViewer}}
+		}, true},
+	}
+	for _, tt := range tests {
+		after := clone(before)
+		tt.change(after)
+		if got := accessChanged(before, after); got != tt.want {
+			t.Errorf("%s: accessChanged = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -20,11 +20,41 @@ func RegisterPlanRoutes(router *gin.Engine, handler *handlers.PlanHandler) {
 		// Get all plans for current user
 		api.GET("/", handler.GetPlans)
 
+		// Get a single plan
+		api.GET("/:id", handler.GetPlan)
+		api.DELETE("/:id", handler.DeletePlan)
+
 		api.POST("/refine-task", handler.RefineTask)
 		api.POST("/update-task-status", handler.UpdateTaskStatus)
 		api.GET("/task-details", handler.GetTaskDetails)
 
 		api.POST("/add-subtasks", handler.AddSubTasks)
 
+		// Monte Carlo completion forecast
+		api.GET("/:id/forecast", handler.GetForecast)
+
+		// Weighted progress (?weighting=count|effort|leaf)
+		api.GET("/:id/progress", handler.GetProgress)
+
+		// Export / import in markdown, csv or json
+		api.GET("/:id/export", handler.ExportPlan)
+		api.POST("/import", handler.ImportPlan)
+
+		// Timeline (JSON) and server-rendered Gantt charts
+		api.GET("/:id/timeline", handler.GetTimeline)
+		api.GET("/:id/gantt.svg", handler.GanttSVG)
+		api.GET("/:id/gantt.png", handler.GanttPNG)
+		api.PUT("/:id/tasks/:task_id/dependencies", handler.SetDependencies)
+
+		// Priority, tags, contexts and custom fields
+		api.PATCH("/:id/tasks/:task_id", handler.UpdateTaskAttributes)
+
+		// Who does a task, at any depth
+		api.PUT("/:id/tasks/:task_id/assignee", handler.AssignTask)
+
+		// Recurring tasks (RRULE subset) and their streaks
+		api.PUT("/:id/tasks/:task_id/recurrence", handler.SetRecurrence)
+		api.GET("/:id/habits", handler.GetHabits)
+
 	}
 }
@@ -0,0 +1,290 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"smart-task-planner/internal/modules/plan/attributes"
+	"smart-task-planner/internal/modules/plan/models"
+	"smart-task-planner/internal/modules/plan/progress"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 200
+
+	titleWeight       = 3.0
+	tagWeight         = 2.0
+	descriptionWeight = 1.0
+	goalWeight        = 0.5
+	prefixFactor      = 0.5 // a prefix hit ("mara" -> "marathon") scores half
+	phraseBonus       = 2.0
+)
+
+// Source is anything that can list a user's plans. The search runs in Go over
+// whatever the source returns, so it behaves the same on every storage backend.
+type Source interface {
+	GetAllByUser(userID string) ([]models.Plan, error)
+}
+
+// Query combines full-text terms with structured filters. Empty fields don't filter.
+type Query struct {
+	Text         string            `json:"q,omitempty"`
+	Statuses     []string          `json:"status,omitempty"`
+	Tags         []string          `json:"tags,omitempty"`
+	Priorities   []string          `json:"priority,omitempty"`
+	Contexts     []string          `json:"contexts,omitempty"`
+	Fields       map[string]string `json:"fields,omitempty"`
+	PlanIDs      []string          `json:"plan_id,omitempty"`
+	DeadlineFrom *time.Time        `json:"deadline_from,omitempty"`
+	DeadlineTo   *time.Time        `json:"deadline_to,omitempty"`
+	Overdue      *bool             `json:"overdue,omitempty"`
+	Limit        int               `json:"limit,omitempty"`
+}
+
+// TaskResult is the matched task without its subtree.
+type TaskResult struct {
+	ID           string                 `json:"id"`
+	Title        string                 `json:"title"`
+	Description  string                 `json:"description"`
+	Status       string                 `json:"status"`
+	Deadline     time.Time              `json:"deadline"`
+	Tags         []string               `json:"tags,omitempty"`
+	Priority     string                 `json:"priority,omitempty"`
+	Contexts     []string               `json:"contexts,omitempty"`
+	Fields       map[string]interface{} `json:"fields,omitempty"`
+	SubTaskCount int                    `json:"sub_task_count"`
+}
+
+// Hit is one search result with its plan and the titles of its ancestors.
+type Hit struct {
+	PlanID  string     `json:"plan_id"`
+	Goal    string     `json:"goal"`
+	Path    []string   `json:"path"`
+	Depth   int        `json:"depth"`
+	Task    TaskResult `json:"task"`
+	Score   float64    `json:"score"`
+	Matched []string   `json:"matched_fields,omitempty"`
+	Overdue bool       `json:"overdue"`
+}
+
+// Result is the ranked, limited list of hits plus the total before limiting.
+type Result struct {
+	Query Query `json:"query"`
+	Total int   `json:"total"`
+	Hits  []Hit `json:"hits"`
+}
+
+// Search loads the user's plans from src and runs q over them.
+func Search(src Source, userID string, q Query, now time.Time) (*Result, error) {
+	plans, err := src.GetAllByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	return Run(plans, q, now), nil
+}
+
+// Run matches every task and subtask of the plans against q. All text terms
+// must appear somewhere in the task (or its goal); hits are ranked by score,
+// then by earliest deadline.
+func Run(plans []models.Plan, q Query, now time.Time) *Result {
+	terms := Tokenize(q.Text)
+	phrase := strings.ToLower(strings.TrimSpace(q.Text))
+
+	var hits []Hit
+	for _, plan := range plans {
+		if len(q.PlanIDs) > 0 && !containsFold(q.PlanIDs, plan.ID.Hex()) {
+			continue
+		}
+		goalTokens := Tokenize(plan.Goal)
+
+		var walk func(tasks []models.Task, path []string)
+		walk = func(tasks []models.Task, path []string) {
+			for _, t := range tasks {
+				if matchesFilters(t, q, now) {
+					score, matched, ok := scoreTask(t, goalTokens, terms, phrase)
+					if ok {
+						hits = append(hits, Hit{
+							PlanID:  plan.ID.Hex(),
+							Goal:    plan.Goal,
+							Path:    append([]string{}, path...),
+							Depth:   len(path),
+							Task:    toResult(t),
+							Score:   score,
+							Matched: matched,
+							Overdue: progress.IsOverdue(t, now),
+						})
+					}
+				}
+				walk(t.SubTasks, append(path, t.Title))
+			}
+		}
+		walk(plan.Tasks, nil)
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return earlier(hits[i].Task.Deadline, hits[j].Task.Deadline)
+	})
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+
+	res := &Result{Query: q, Total: len(hits), Hits: hits}
+	if len(hits) > limit {
+		res.Hits = hits[:limit]
+	}
+	if res.Hits == nil {
+		res.Hits = []Hit{}
+	}
+	return res
+}
+
+func matchesFilters(t models.Task, q Query, now time.Time) bool {
+	if len(q.Statuses) > 0 && !containsFold(q.Statuses, t.Status) {
+		return false
+	}
+	for _, tag := range q.Tags {
+		if !containsFold(t.Tags, tag) {
+			return false
+		}
+	}
+	if len(q.Priorities) > 0 && !containsFold(q.Priorities, t.Priority) {
+		return false
+	}
+	if len(q.Contexts) > 0 {
+		contexts := attributes.Contexts(t)
+		for _, c := range q.Contexts {
+			if !containsFold(contexts, c) {
+				return false
+			}
+		}
+	}
+	for key, want := range q.Fields {
+		if !attributes.FieldMatches(t.Fields[key], want) {
+			return false
+		}
+	}
+	if q.DeadlineFrom != nil && (t.Deadline.IsZero() || t.Deadline.Before(*q.DeadlineFrom)) {
+		return false
+	}
+	if q.DeadlineTo != nil && (t.Deadline.IsZero() || t.Deadline.After(*q.DeadlineTo)) {
+		return false
+	}
+	if q.Overdue != nil && progress.IsOverdue(t, now) != *q.Overdue {
+		return false
+	}
+	return true
+}
+
+// scoreTask returns the relevance of t for the terms. With no terms every
+// task that passed the filters matches with score 0.
+func scoreTask(t models.Task, goalTokens, terms []string, phrase string) (float64, []string, bool) {
+	if len(terms) == 0 {
+		return 0, nil, true
+	}
+
+	fields := []struct {
+		name   string
+		tokens []string
+		weight float64
+	}{
+		{"title", Tokenize(t.Title), titleWeight},
+		{"tags", Tokenize(strings.Join(t.Tags, " ")), tagWeight},
+		{"description", Tokenize(t.Description), descriptionWeight},
+		{"goal", goalTokens, goalWeight},
+	}
+
+	score := 0.0
+	matchedFields := map[string]bool{}
+	for _, term := range terms {
+		termScore := 0.0
+		for _, f := range fields {
+			if s := termMatch(term, f.tokens); s > 0 {
+				termScore += s * f.weight
+				matchedFields[f.name] = true
+			}
+		}
+		if termScore == 0 {
+			return 0, nil, false
+		}
+		score += termScore
+	}
+
+	if len(terms) > 1 {
+		if strings.Contains(strings.ToLower(t.Title), phrase) {
+			score += phraseBonus * titleWeight
+		} else if strings.Contains(strings.ToLower(t.Description), phrase) {
+			score += phraseBonus
+		}
+	}
+
+	var matched []string
+	for _, f := range fields {
+		if matchedFields[f.name] {
+			matched = append(matched, f.name)
+		}
+	}
+	return score, matched, true
+}
+
+func termMatch(term string, tokens []string) float64 {
+	best := 0.0
+	for _, tok := range tokens {
+		if tok == term {
+			return 1
+		}
+		if len(term) >= 3 && strings.HasPrefix(tok, term) {
+			best = prefixFactor
+		}
+	}
+	return best
+}
+
+// Tokenize lowercases text and splits it into letter/number runs.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+func toResult(t models.Task) TaskResult {
+	return TaskResult{
+		ID:           t.ID.Hex(),
+		Title:        t.Title,
+		Description:  t.Description,
+		Status:       t.Status,
+		Deadline:     t.Deadline,
+		Tags:         t.Tags,
+		Priority:     t.Priority,
+		Contexts:     attributes.Contexts(t),
+		Fields:       t.Fields,
+		SubTaskCount: len(t.SubTasks),
+	}
+}
+
+func containsFold(list []string, v string) bool {
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), v) {
+			return true
+		}
+	}
+	return false
+}
+
+// earlier orders real deadlines before missing ones
+func earlier(a, b time.Time) bool {
+	if a.IsZero() != b.IsZero() {
+		return !a.IsZero()
+	}
+	return a.Before(b)
+}
@@ -1,19 +1,42 @@
 package service
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"strings"
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"smart-task-planner/internal/mcp"
+	commentRepository "smart-task-planner/internal/modules/comments/repository"
+	fieldRepository "smart-task-planner/internal/modules/fields/repository"
+	"smart-task-planner/internal/modules/plan/attributes"
+	"smart-task-planner/internal/modules/plan/dto"
+	"smart-task-planner/internal/modules/plan/forecast"
+	"smart-task-planner/internal/modules/plan/gantt"
 	"smart-task-planner/internal/modules/plan/models"
+	"smart-task-planner/internal/modules/plan/recurrence"
 	"smart-task-planner/internal/modules/plan/repository"
+	"smart-task-planner/internal/modules/plan/transfer"
+)
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+
+	// how often missed occurrences of recurring tasks are rolled forward
+	recurrenceInterval = time.Hour
 )
 
 type PlanService struct {
-	Repo *repository.PlanRepository
+	Repo        *repository.PlanRepository
+	FieldRepo   *fieldRepository.FieldRepository
+	CommentRepo *commentRepository.CommentRepository
 }
 
-func NewPlanService(repo *repository.PlanRepository) *PlanService {
-	return &PlanService{Repo: repo}
+func NewPlanService(repo *repository.PlanRepository, fieldRepo *fieldRepository.FieldRepository, commentRepo *commentRepository.CommentRepository) *PlanService {
+	return &PlanService{Repo: repo, FieldRepo: fieldRepo, CommentRepo: commentRepo}
 }
 
 // GenerateDraftPlan calls MCP to create an AI-generated draft plan without saving to DB
@@ -41,8 +64,20 @@ func (s *PlanService) GenerateDraftPlan(userID string, goal string) ([]models.Ta
 	return plan.Tasks, nil
 }
 
-// ConfirmPlan saves the confirmed plan to the DB
-func (s *PlanService) ConfirmPlan(userID string, goal string, tasks []models.Task) (*models.Plan, error) {
+// ConfirmPlan saves the confirmed plan to the DB, in a workspace when
+// workspaceID is set
+func (s *PlanService) ConfirmPlan(userID, workspaceID string, goal string, tasks []models.Task) (*models.Plan, error) {
+	role := models.RoleOwner
+	if workspaceID != "" {
+		access, err := s.Repo.Access(userID)
+		if err != nil {
+			return nil, err
+		}
+		if role = access.Workspaces[workspaceID]; !models.RoleAtLeast(role, models.RoleEditor) {
+			return nil, fmt.Errorf("you can't add plans to this workspace")
+		}
+	}
+
 	// Assign new ObjectID to each task
 	for i := range tasks {
 		if tasks[i].ID.IsZero() {
@@ -51,22 +86,146 @@ func (s *PlanService) ConfirmPlan(userID string, goal string, tasks []models.Tas
 	}
 
 	plan := &models.Plan{
-		UserID: userID,
-		Goal:   goal,
-		Tasks:  tasks,
+		UserID:      userID,
+		WorkspaceID: workspaceID,
+		Goal:        goal,
+		Tasks:       tasks,
 	}
 
 	if err := s.Repo.Create(plan); err != nil {
 		return nil, err
 	}
+	plan.Role = role
 	return plan, nil
 }
 
-// GetAllPlans fetches all plans for a user
+// GetAllPlans fetches all plans the user can see
 func (s *PlanService) GetAllPlans(userID string) ([]models.Plan, error) {
 	return s.Repo.GetAllByUser(userID)
 }
 
+// PlanPage is one page of a plan listing
+type PlanPage struct {
+	Plans      []models.Plan `json:"plans"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+	HasMore    bool          `json:"has_more"`
+}
+
+// ListPlans returns a filtered, sorted page of the plans the user can see. Newest
+// plans come first unless another sort or order is asked for.
+func (s *PlanService) ListPlans(userID string, req dto.ListPlansRequest) (*PlanPage, error) {
+	q := repository.ListQuery{
+		Workspace: req.Workspace,
+		Status:    strings.ToLower(req.Status),
+		Goal:      req.Query,
+		Sort:      strings.ToLower(req.Sort),
+		View:      strings.ToLower(req.View),
+		Cursor:    req.Cursor,
+		Limit:     req.Limit,
+	}
+	if q.Sort == "" {
+		q.Sort = repository.SortCreated
+	}
+	switch strings.ToLower(req.Order) {
+	case "":
+		q.Desc = q.Sort == repository.SortCreated
+	case "asc":
+	case "desc":
+		q.Desc = true
+	default:
+		return nil, repository.InvalidQuery("order must be asc or desc")
+	}
+	if q.Limit <= 0 {
+		q.Limit = defaultListLimit
+	}
+	if q.Limit > maxListLimit {
+		q.Limit = maxListLimit
+	}
+
+	var err error
+	if q.CreatedFrom, err = parseListDate(req.CreatedFrom, false); err != nil {
+		return nil, repository.InvalidQuery("invalid created_from: %v", err)
+	}
+	if q.CreatedTo, err = parseListDate(req.CreatedTo, true); err != nil {
+		return nil, repository.InvalidQuery("invalid created_to: %v", err)
+	}
+
+	for _, p := range splitList(req.Priority) {
+		priority, err := attributes.NormalizePriority(p)
+		if err != nil {
+			return nil, repository.InvalidQuery("%v", err)
+		}
+		q.Priorities = append(q.Priorities, priority)
+	}
+	q.Tags = attributes.NormalizeTags(splitList(req.Tag))
+	q.Contexts = attributes.NormalizeContexts(splitList(req.Context))
+	if len(req.Fields) > 0 {
+		q.Fields = req.Fields
+	}
+
+	plans, next, err := s.Repo.List(userID, q, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &PlanPage{Plans: plans, NextCursor: next, HasMore: next != ""}, nil
+}
+
+// ListAllPlans returns every plan ListPlans would, one page after another
+func (s *PlanService) ListAllPlans(userID string, req dto.ListPlansRequest) ([]models.Plan, error) {
+	req.Limit = maxListLimit
+	plans := []models.Plan{}
+	for {
+		page, err := s.ListPlans(userID, req)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, page.Plans...)
+		if !page.HasMore {
+			return plans, nil
+		}
+		req.Cursor = page.NextCursor
+	}
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// parseListDate accepts RFC 3339 or a plain date. A plain date used as an
+// upper bound includes that whole day.
+func parseListDate(s string, end bool) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		if end {
+			t = t.AddDate(0, 0, 1)
+		}
+		return &t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, fmt.Errorf("use YYYY-MM-DD or RFC 3339")
+	}
+	return &t, nil
+}
+
+// GetPlan fetches a single plan the user can see
+func (s *PlanService) GetPlan(userID, planID string) (*models.Plan, error) {
+	return s.Repo.GetByIDForUser(planID, userID)
+}
+
+// DeletePlan removes a plan the user owns
+func (s *PlanService) DeletePlan(userID, planID string) error {
+	return s.Repo.Delete(userID, planID)
+}
+
 // GetUserGoals fetches all plans using the new MCP tool get_goal_data
 func (s *PlanService) GetUserGoals(userID string) (mcp.UserGoals, error) {
 	result, err := mcp.RunTool("get_goal_data", map[string]interface{}{"user_id": userID}, s.Repo)
@@ -95,8 +254,8 @@ func (s *PlanService) RefineTask(task models.Task) ([]models.Task, error) {
 	return tasks, nil
 }
 
-func (s *PlanService) UpdateTaskStatus(taskID, status string) (*models.Task, error) {
-	result, err := mcp.RunTool("update_task_status", map[string]interface{}{"task_id": taskID, "status": status}, s.Repo)
+func (s *PlanService) UpdateTaskStatus(userID, taskID, status string) (*models.Task, error) {
+	result, err := mcp.RunTool("update_task_status", map[string]interface{}{"user_id": userID, "task_id": taskID, "status": status}, s.Repo)
 	if err != nil {
 		return nil, err
 	}
@@ -108,8 +267,76 @@ func (s *PlanService) UpdateTaskStatus(taskID, status string) (*models.Task, err
 	return task, nil
 }
 
-func (s *PlanService) GetTaskDetails(taskID string) (*models.Task, error) {
-	result, err := mcp.RunTool("get_task_details", map[string]interface{}{"task_id": taskID}, s.Repo)
+// UpdateTaskAttributes sets a task's priority, tags, contexts and custom fields
+func (s *PlanService) UpdateTaskAttributes(userID, planID, taskID string, req dto.UpdateTaskAttributesRequest) (*models.Task, error) {
+	params := map[string]interface{}{
+		"user_id": userID,
+		"plan_id": planID,
+		"task_id": taskID,
+	}
+	if req.Priority != nil {
+		params["priority"] = *req.Priority
+		if *req.Priority == "" {
+			params["priority"] = "none"
+		}
+	}
+	if req.Tags != nil {
+		params["tags"] = *req.Tags
+	}
+	if req.Contexts != nil {
+		params["contexts"] = *req.Contexts
+	}
+	if len(req.Fields) > 0 {
+		params["fields"] = req.Fields
+	}
+
+	result, err := mcp.RunTool("update_task_attributes", params, s.Repo)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := result.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid data from MCP update_task_attributes")
+	}
+	task, ok := data["task"].(*models.Task)
+	if !ok {
+		return nil, fmt.Errorf("invalid data from MCP update_task_attributes")
+	}
+	return task, nil
+}
+
+// AssignTask sets or clears who does a task
+func (s *PlanService) AssignTask(userID, planID, taskID string, req dto.AssignTaskRequest) (*models.Task, error) {
+	params := map[string]interface{}{
+		"user_id":  userID,
+		"plan_id":  planID,
+		"task_id":  taskID,
+		"assignee": req.Assignee,
+	}
+	if req.AssigneeID != nil {
+		params["assignee_id"] = *req.AssigneeID
+	}
+
+	result, err := mcp.RunTool("assign_task", params, s.Repo)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := result.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid data from MCP assign_task")
+	}
+	if ambiguous, _ := data["needs_disambiguation"].(bool); ambiguous {
+		return nil, fmt.Errorf("%q matches more than one person, use assignee_id", req.Assignee)
+	}
+	task, ok := data["task"].(*models.Task)
+	if !ok {
+		return nil, fmt.Errorf("invalid data from MCP assign_task")
+	}
+	return task, nil
+}
+
+func (s *PlanService) GetTaskDetails(userID, taskID string) (*models.Task, error) {
+	result, err := mcp.RunTool("get_task_details", map[string]interface{}{"user_id": userID, "task_id": taskID}, s.Repo)
 	if err != nil {
 		return nil, err
 	}
@@ -121,7 +348,7 @@ func (s *PlanService) GetTaskDetails(taskID string) (*models.Task, error) {
 	return task, nil
 }
 
-func (s *PlanService) AddSubTasks(planID, taskID string, subtasks []models.Task) (*models.Plan, error) {
+func (s *PlanService) AddSubTasks(userID, planID, taskID string, subtasks []models.Task) (*models.Plan, error) {
 	for i := range subtasks {
 		if subtasks[i].ID.IsZero() {
 			subtasks[i].ID = primitive.NewObjectID()
@@ -131,5 +358,245 @@ func (s *PlanService) AddSubTasks(planID, taskID string, subtasks []models.Task)
 		}
 	}
 
-	return s.Repo.AddSubTasks(planID, taskID, subtasks)
+	return s.Repo.AddSubTasks(userID, planID, taskID, subtasks)
+}
+
+// ForecastPlan runs the Monte Carlo completion forecast for a single plan
+func (s *PlanService) ForecastPlan(userID, planID string) (*forecast.Forecast, error) {
+	result, err := mcp.RunTool("forecast_completion", map[string]interface{}{
+		"user_id": userID,
+		"plan_id": planID,
+	}, s.Repo)
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := result.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid data from MCP forecast_completion")
+	}
+	forecasts, ok := data["forecasts"].([]forecast.Forecast)
+	if !ok || len(forecasts) == 0 {
+		return nil, fmt.Errorf("invalid data from MCP forecast_completion")
+	}
+	return &forecasts[0], nil
+}
+
+// GetProgress returns weighted progress for an explicit plan plus the portfolio summary
+func (s *PlanService) GetProgress(userID, planID, weighting string) (map[string]interface{}, error) {
+	result, err := mcp.RunTool("get_user_progress", map[string]interface{}{
+		"user_id":   userID,
+		"plan_id":   planID,
+		"weighting": weighting,
+	}, s.Repo)
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := result.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid data from MCP get_user_progress")
+	}
+	return data, nil
+}
+
+// ExportPlan renders a plan with its comments as markdown, csv or json
+func (s *PlanService) ExportPlan(userID, planID, format string) ([]byte, string, error) {
+	format, err := transfer.NormalizeFormat(format)
+	if err != nil {
+		return nil, "", err
+	}
+	plan, err := s.Repo.GetByIDForUser(planID, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	comments, err := s.CommentRepo.ForPlan(plan.ID.Hex(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := transfer.Encode(plan, comments, format)
+	return data, format, err
+}
+
+// ImportPlan creates a new plan for the user from an export. An empty format
+// is detected from the file name or content; goal overrides the imported goal.
+// Custom fields the user hasn't defined are dropped, and so are assignees
+// other than the user, who is the only one with access to the new plan.
+func (s *PlanService) ImportPlan(userID string, data []byte, format, filename, goal string) (*models.Plan, error) {
+	if format == "" {
+		format = transfer.Detect(data, filename)
+	} else {
+		var err error
+		if format, err = transfer.NormalizeFormat(format); err != nil {
+			return nil, err
+		}
+	}
+
+	doc, err := transfer.Decode(data, format)
+	if err != nil {
+		return nil, err
+	}
+	if goal != "" {
+		doc.Goal = goal
+	}
+	defs, err := s.FieldRepo.ByKey(userID)
+	if err != nil {
+		return nil, err
+	}
+	var keep func([]models.Task)
+	keep = func(tasks []models.Task) {
+		for i := range tasks {
+			values := tasks[i].Fields
+			tasks[i].Fields = nil
+			for key, value := range values {
+				_ = attributes.SetFields(&tasks[i], map[string]interface{}{key: value}, defs)
+			}
+			if tasks[i].AssigneeID != userID {
+				tasks[i].AssigneeID = ""
+			}
+			keep(tasks[i].SubTasks)
+		}
+	}
+	keep(doc.Tasks)
+
+	plan := &models.Plan{
+		UserID: userID,
+		Goal:   doc.Goal,
+		Tasks:  doc.Tasks,
+	}
+	if err := s.Repo.Create(plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// Timeline lays out a plan's tasks on a date axis for the Gantt views
+func (s *PlanService) Timeline(userID, planID string, hoursPerDay float64) (*gantt.Chart, error) {
+	plan, err := s.Repo.GetByIDForUser(planID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return gantt.Build(plan, time.Now(), gantt.Options{HoursPerDay: hoursPerDay}), nil
+}
+
+// SetDependencies replaces the tasks a task depends on. All IDs must belong
+// to the same plan and the result must stay acyclic.
+func (s *PlanService) SetDependencies(userID, planID, taskID string, dependsOn []string) (*models.Plan, error) {
+	plan, err := s.Repo.GetByIDForUser(planID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := map[string]*models.Task{}
+	var index func(list []models.Task)
+	index = func(list []models.Task) {
+		for i := range list {
+			tasks[list[i].ID.Hex()] = &list[i]
+			index(list[i].SubTasks)
+		}
+	}
+	index(plan.Tasks)
+
+	target, ok := tasks[taskID]
+	if !ok {
+		return nil, fmt.Errorf("task not found in plan")
+	}
+
+	deps := []primitive.ObjectID{}
+	seen := map[string]bool{}
+	for _, id := range dependsOn {
+		dep, ok := tasks[id]
+		if !ok {
+			return nil, fmt.Errorf("dependency %s is not a task of this plan", id)
+		}
+		if id == taskID {
+			return nil, fmt.Errorf("a task can't depend on itself")
+		}
+		if !seen[id] {
+			seen[id] = true
+			deps = append(deps, dep.ID)
+		}
+	}
+	target.DependsOn = deps
+
+	if dependencyCycle(tasks, taskID) {
+		return nil, fmt.Errorf("dependencies would create a cycle")
+	}
+	return s.Repo.UpdatePlan(plan)
+}
+
+// dependencyCycle reports whether start can reach itself through DependsOn
+func dependencyCycle(tasks map[string]*models.Task, start string) bool {
+	deps := make(map[string][]string, len(tasks))
+	for id, t := range tasks {
+		for _, dep := range t.DependsOn {
+			deps[id] = append(deps[id], dep.Hex())
+		}
+	}
+	return models.DependencyCycle(deps, start)
+}
+
+// SetRecurrence makes a task repeat by an RRULE ("FREQ=WEEKLY;BYDAY=MO,WE,FR").
+// An empty rule stops the series after this occurrence. The task's deadline
+// is the first occurrence.
+func (s *PlanService) SetRecurrence(userID, planID, taskID, rule string) (*models.Plan, error) {
+	var rec *models.Recurrence
+	if strings.TrimSpace(rule) != "" {
+		var err error
+		if rec, err = recurrence.Parse(rule); err != nil {
+			return nil, err
+		}
+	}
+
+	plan, err := s.Repo.GetByIDForUser(planID, userID)
+	if err != nil {
+		return nil, err
+	}
+	tasks := repository.Flatten(plan.Tasks)
+	ft, ok := tasks[taskID]
+	if !ok {
+		return nil, fmt.Errorf("task not found in plan")
+	}
+	target := ft.Task
+	if rec != nil && target.Deadline.IsZero() {
+		return nil, fmt.Errorf("a recurring task needs a deadline for its first occurrence")
+	}
+	if !target.SeriesID.IsZero() {
+		for _, other := range tasks {
+			if other.Task.SeriesID == target.SeriesID && other.Task.Occurrence > target.Occurrence {
+				return nil, fmt.Errorf("only the latest occurrence of a series can change its rule")
+			}
+		}
+	}
+	target.Recurrence = rec
+	return s.Repo.UpdatePlan(plan)
+}
+
+// Habits reports streaks and completion rates of a plan's recurring tasks
+func (s *PlanService) Habits(userID, planID string) ([]recurrence.Habit, error) {
+	plan, err := s.Repo.GetByIDForUser(planID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return recurrence.Habits(plan.Tasks), nil
+}
+
+// StartRecurrence rolls recurring tasks forward right away and then every
+// hour until ctx is cancelled, so missed occurrences don't wait for the
+// next edit of their plan.
+func (s *PlanService) StartRecurrence(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(recurrenceInterval)
+		defer ticker.Stop()
+		for {
+			if _, err := s.Repo.AdvanceRecurring(time.Now()); err != nil {
+				log.Println("❌ Recurring task scan failed:", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
 }
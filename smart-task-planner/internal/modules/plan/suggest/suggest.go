@@ -0,0 +1,293 @@
+package suggest
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"smart-task-planner/internal/modules/plan/agenda"
+	"smart-task-planner/internal/modules/plan/attributes"
+	"smart-task-planner/internal/modules/plan/models"
+	"smart-task-planner/internal/modules/plan/progress"
+)
+
+const (
+	defaultLimit = 3
+	maxLimit     = 10
+)
+
+// Options describe the user's situation. Zero values mean "unknown" and
+// don't affect the ranking.
+type Options struct {
+	UserID           string   // tasks assigned to anyone else are skipped
+	AvailableMinutes int      // time the user has right now
+	Contexts         []string // where the user is / what they have, e.g. @home
+	Limit            int
+}
+
+// Suggestion is a ranked task with the reasons behind its score
+type Suggestion struct {
+	PlanID         string     `json:"plan_id"`
+	Goal           string     `json:"goal"`
+	TaskID         string     `json:"task_id"`
+	Title          string     `json:"title"`
+	Path           []string   `json:"path"`
+	Status         string     `json:"status"`
+	Priority       string     `json:"priority,omitempty"`
+	Deadline       *time.Time `json:"deadline,omitempty"`
+	EstimatedHours float64    `json:"estimated_hours,omitempty"`
+	Score          float64    `json:"score"`
+	Reasons        []string   `json:"reasons"`
+	Explanation    string     `json:"explanation"`
+}
+
+type Result struct {
+	Message     string       `json:"message"` // one-line summary of the top pick
+	Suggestions []Suggestion `json:"suggestions"`
+	Considered  int          `json:"considered"` // actionable tasks that were ranked
+	Blocked     int          `json:"blocked"`    // open tasks waiting on unfinished dependencies
+}
+
+// Rank scores every actionable task of the plans and returns the best ones.
+// A task is actionable when it is open, has no open subtasks and everything
+// it depends on is finished. The same input always gives the same order.
+func Rank(plans []models.Plan, opts Options, now time.Time) *Result {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	contexts := map[string]bool{}
+	for _, c := range opts.Contexts {
+		contexts[normalizeContext(c)] = true
+	}
+
+	res := &Result{Suggestions: []Suggestion{}}
+	var all []Suggestion
+	for _, plan := range plans {
+		tasks := map[string]*models.Task{}
+		dependents := map[string]int{}
+		var index func([]models.Task)
+		index = func(list []models.Task) {
+			for i := range list {
+				tasks[list[i].ID.Hex()] = &list[i]
+				for _, dep := range list[i].DependsOn {
+					dependents[dep.Hex()]++
+				}
+				index(list[i].SubTasks)
+			}
+		}
+		index(plan.Tasks)
+
+		var walk func(list []models.Task, path []string, assignee string)
+		walk = func(list []models.Task, path []string, assignee string) {
+			for _, t := range list {
+				childPath := append(path, t.Title)
+				if !open(t) {
+					continue
+				}
+				a := agenda.Assignee(t, assignee)
+				if hasOpen(t.SubTasks) {
+					walk(t.SubTasks, childPath, a)
+					continue
+				}
+				if opts.UserID != "" && a != "" && a != opts.UserID {
+					continue
+				}
+				if !ready(t, tasks) {
+					res.Blocked++
+					continue
+				}
+				s := score(t, dependents[t.ID.Hex()], opts.AvailableMinutes, contexts, now)
+				s.PlanID, s.Goal, s.Path = plan.ID.Hex(), plan.Goal, append([]string{}, path...)
+				all = append(all, s)
+			}
+		}
+		walk(plan.Tasks, nil, "")
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		a, b := all[i], all[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if (a.Deadline == nil) != (b.Deadline == nil) {
+			return a.Deadline != nil
+		}
+		if a.Deadline != nil && !a.Deadline.Equal(*b.Deadline) {
+			return a.Deadline.Before(*b.Deadline)
+		}
+		if a.Title != b.Title {
+			return a.Title < b.Title
+		}
+		return a.TaskID < b.TaskID
+	})
+
+	res.Considered = len(all)
+	if len(all) > limit {
+		all = all[:limit]
+	}
+	res.Suggestions = append(res.Suggestions, all...)
+
+	switch {
+	case len(all) > 0:
+		top := all[0]
+		res.Message = fmt.Sprintf("Work on %q (%s) next. %s", top.Title, top.Goal, top.Explanation)
+	case res.Blocked > 0:
+		res.Message = fmt.Sprintf("Every open task is waiting on another one; %d %s blocked.", res.Blocked, plural(res.Blocked, "task is", "tasks are"))
+	default:
+		res.Message = "Nothing left to do. Every task is finished."
+	}
+	return res
+}
+
+func score(t models.Task, dependents, availableMinutes int, contexts map[string]bool, now time.Time) Suggestion {
+	s := Suggestion{
+		TaskID:         t.ID.Hex(),
+		Title:          t.Title,
+		Status:         t.Status,
+		Priority:       t.Priority,
+		EstimatedHours: t.EstimatedHours,
+		Reasons:        []string{},
+	}
+	add := func(points float64, reason string) {
+		s.Score += points
+		if reason != "" {
+			s.Reasons = append(s.Reasons, reason)
+		}
+	}
+
+	// deadline urgency
+	if t.Deadline.IsZero() {
+		add(5, "")
+	} else {
+		d := t.Deadline
+		s.Deadline = &d
+		left := t.Deadline.Sub(now)
+		switch {
+		case left < 0:
+			add(50, "overdue by "+humanize(-left))
+		case left <= 24*time.Hour:
+			add(40, "due in "+humanize(left))
+		case left <= 72*time.Hour:
+			add(30, "due in "+humanize(left))
+		case left <= 7*24*time.Hour:
+			add(20, "due this week")
+		default:
+			add(10, "")
+		}
+	}
+
+	// no priority ranks between P2 and P3
+	switch agenda.PriorityRank(t.Priority) {
+	case 0:
+		add(30, "priority P0")
+	case 1:
+		add(20, "priority P1")
+	case 2:
+		add(10, "")
+	case 4:
+		add(5, "")
+	}
+
+	// remaining effort (estimate minus tracked time) against the time the user has
+	if availableMinutes > 0 {
+		remaining := progress.RemainingHours(t)
+		needed := int(math.Ceil(remaining * 60))
+		switch {
+		case remaining <= 0:
+		case needed <= availableMinutes:
+			add(15, fmt.Sprintf("fits in your %d min (~%d min)", availableMinutes, needed))
+		default:
+			add(-25, fmt.Sprintf("needs ~%d min, more than your %d min", needed, availableMinutes))
+		}
+	}
+
+	// contexts (@home, @computer) only count when the user gave contexts
+	if len(contexts) > 0 {
+		own := attributes.Contexts(t)
+		matched := ""
+		for _, c := range own {
+			if contexts[c] {
+				matched = c
+				break
+			}
+		}
+		switch {
+		case matched != "":
+			add(15, "matches "+matched)
+		case len(own) > 0:
+			add(-15, "needs "+strings.Join(own, ", "))
+		}
+	}
+
+	if dependents > 0 {
+		add(float64(3*min(dependents, 3)), fmt.Sprintf("unblocks %d %s", dependents, plural(dependents, "task", "tasks")))
+	}
+	if strings.EqualFold(t.Status, "In Progress") {
+		add(5, "already in progress")
+	}
+
+	if len(s.Reasons) == 0 {
+		s.Explanation = "Ready to start."
+	} else {
+		s.Explanation = strings.ToUpper(s.Reasons[0][:1]) + s.Reasons[0][1:]
+		if len(s.Reasons) > 1 {
+			s.Explanation += "; " + strings.Join(s.Reasons[1:], "; ")
+		}
+		s.Explanation += "."
+	}
+	return s
+}
+
+func open(t models.Task) bool {
+	return !progress.IsCompleted(t) && !strings.EqualFold(t.Status, "Cancelled")
+}
+
+func hasOpen(tasks []models.Task) bool {
+	for _, t := range tasks {
+		if open(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// ready reports whether every dependency is finished. Dependencies that no
+// longer exist don't block.
+func ready(t models.Task, tasks map[string]*models.Task) bool {
+	for _, dep := range t.DependsOn {
+		if d, ok := tasks[dep.Hex()]; ok && open(*d) {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeContext(c string) string {
+	return "@" + strings.TrimPrefix(strings.ToLower(strings.TrimSpace(c)), "@")
+}
+
+func humanize(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		m := int(d.Minutes())
+		return fmt.Sprintf("%d %s", m, plural(m, "minute", "minutes"))
+	case d < 48*time.Hour:
+		h := int(d.Hours())
+		return fmt.Sprintf("%d %s", h, plural(h, "hour", "hours"))
+	}
+	days := int(d.Hours() / 24)
+	return fmt.Sprintf("%d days", days)
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
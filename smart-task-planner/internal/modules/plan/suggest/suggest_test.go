@@ -0,0 +1,122 @@
+package suggest
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"smart-task-planner/internal/modules/plan/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var now = time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+
+func task(title string) models.Task {
+	return models.Task{ID: primitive.NewObjectID(), Title: title, Status: "Pending"}
+}
+
+func titles(r *Result) []string {
+	var out []string
+	for _, s := range r.Suggestions {
+		out = append(out, s.Title)
+	}
+	return out
+}
+
+func TestRank(t *testing.T) {
+	spec := task("Spec")
+	spec.Priority = "P0"
+	spec.Deadline = now.Add(12 * time.Hour)
+	build := task("Build")
+	build.DependsOn = []primitive.ObjectID{spec.ID}
+	chore := task("Overdue chore")
+	chore.Priority = "P3"
+	chore.Deadline = now.AddDate(0, 0, -2)
+	parent := task("Parent")
+	finished := task("Sub B")
+	finished.Status = "Completed"
+	parent.SubTasks = []models.Task{task("Sub A"), finished}
+	bobs := task("Bob's")
+	bobs.AssigneeID = "bob"
+	done := task("Done")
+	done.Status = "Completed"
+
+	plans := []models.Plan{{ID: primitive.NewObjectID(), Goal: "Ship", Tasks: []models.Task{build, chore, parent, bobs, done, spec}}}
+	r := Rank(plans, Options{UserID: "ann"}, now)
+
+	if want := []string{"Spec", "Overdue chore", "Sub A"}; !slices.Equal(titles(r), want) {
+		t.Fatalf("order %v, want %v", titles(r), want)
+	}
+	if r.Considered != 3 || r.Blocked != 1 {
+		t.Errorf("considered %d, blocked %d; want 3 and 1", r.Considered, r.Blocked)
+	}
+	top := r.Suggestions[0]
+	if !slices.Contains(top.Reasons, "priority P0") || !slices.Contains(top.Reasons, "unblocks 1 task") {
+		t.Errorf("reasons %v", top.Reasons)
+	}
+	if !strings.Contains(r.Message, `"Spec" (Ship)`) {
+		t.Errorf("message %q", r.Message)
+	}
+	if sub := r.Suggestions[2]; !slices.Equal(sub.Path, []string{"Parent"}) {
+		t.Errorf("path %v", sub.Path)
+	}
+}
+
+func TestRankTimeAndContexts(t *testing.T) {
+	call := task("Quick call")
+	call.EstimatedHours = 0.25
+	call.Contexts = []string{"@phone"}
+	tidy := task("Tidy up")
+	tidy.EstimatedHours = 0.5
+	tidy.Contexts = []string{"@home"}
+	essay := task("Long essay")
+	essay.EstimatedHours = 3
+
+	plans := []models.Plan{{ID: primitive.NewObjectID(), Goal: "Chores", Tasks: []models.Task{essay, call, tidy}}}
+	r := Rank(plans, Options{AvailableMinutes: 30, Contexts: []string{"Home"}}, now)
+
+	if want := []string{"Tidy up", "Quick call", "Long essay"}; !slices.Equal(titles(r), want) {
+		t.Fatalf("order %v, want %v", titles(r), want)
+	}
+	want := []string{"fits in your 30 min (~30 min)", "matches @home"}
+	if got := r.Suggestions[0].Reasons; !slices.Equal(got, want) {
+		t.Errorf("reasons %v, want %v", got, want)
+	}
+	if got := r.Suggestions[2].Explanation; got != "Needs ~180 min, more than your 30 min." {
+		t.Errorf("explanation %q", got)
+	}
+}
+
+func TestRankLimitAndTies(t *testing.T) {
+	var tasks []models.Task
+	for _, title := range []string{"C", "A", "B"} {
+		tasks = append(tasks, task(title))
+	}
+	plans := []models.Plan{{ID: primitive.NewObjectID(), Goal: "G", Tasks: tasks}}
+
+	r := Rank(plans, Options{Limit: 2}, now)
+	if want := []string{"A", "B"}; !slices.Equal(titles(r), want) {
+		t.Errorf("order %v, want %v", titles(r), want)
+	}
+	if r.Considered != 3 {
+		t.Errorf("considered %d", r.Considered)
+	}
+}
+
+func TestRankNothingToDo(t *testing.T) {
+	first, second := task("First"), task("Second")
+	first.DependsOn = []primitive.ObjectID{second.ID}
+	second.DependsOn = []primitive.ObjectID{first.ID}
+	r := Rank([]models.Plan{{Tasks: []models.Task{first, second}}}, Options{}, now)
+	if len(r.Suggestions) != 0 || r.Blocked != 2 || !strings.Contains(r.Message, "2 tasks are blocked") {
+		t.Errorf("blocked result %+v", r)
+	}
+
+	first.Status, second.Status = "Completed", "Cancelled"
+	r = Rank([]models.Plan{{Tasks: []models.Task{first, second}}}, Options{}, now)
+	if len(r.Suggestions) != 0 || !strings.Contains(r.Message, "Nothing left") {
+		t.Errorf("empty result %+v", r)
+	}
+}
@@ -0,0 +1,279 @@
+package transfer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	commentModels "smart-task-planner/internal/modules/comments/models"
+	"smart-task-planner/internal/modules/plan/models"
+	"smart-task-planner/internal/utils"
+)
+
+const (
+	pathSeparator = " > "
+	fieldPrefix   = "field:"
+)
+
+// csvHeader: one row per task or subtask. ref is the outline number
+// ("2.1" is the first subtask of the second task) and is what import uses
+// to rebuild the tree; parent_path is the human-readable version and is
+// used when ref is missing, e.g. for hand-written sheets. depends_on lists
+// refs. Every custom field in the plan gets a "field:<key>" column before
+// comments, which holds a task's comments for reading and is ignored on
+// import. Cells that a spreadsheet would run as a formula, such as contexts
+// starting with "@", are written with a leading "'" that import strips.
+var csvHeader = []string{
+	"goal", "ref", "parent_path", "title", "description", "status",
+	"deadline", "completed_at", "estimated_hours", "tags", "priority",
+	"contexts", "assignee_id", "depends_on", "recurrence", "series", "occurrence",
+}
+
+func encodeCSV(plan *models.Plan, threads map[string][]commentModels.Comment) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	keys := fieldKeys(plan.Tasks)
+	header := slices.Clone(csvHeader)
+	for _, k := range keys {
+		header = append(header, fieldPrefix+k)
+	}
+	if err := w.Write(append(header, "comments")); err != nil {
+		return nil, err
+	}
+	refs := newReferences(plan.Tasks)
+
+	var walk func(tasks []models.Task, ref string, path []string) error
+	walk = func(tasks []models.Task, ref string, path []string) error {
+		for i, t := range tasks {
+			taskRef := strconv.Itoa(i + 1)
+			if ref != "" {
+				taskRef = ref + "." + taskRef
+			}
+			estimate := ""
+			if t.EstimatedHours != 0 {
+				estimate = strconv.FormatFloat(t.EstimatedHours, 'f', -1, 64)
+			}
+			occurrence := ""
+			if t.Occurrence > 0 {
+				occurrence = strconv.Itoa(t.Occurrence)
+			}
+			row := []string{
+				plan.Goal,
+				taskRef,
+				strings.Join(path, pathSeparator),
+				t.Title,
+				t.Description,
+				t.Status,
+				formatDeadline(t.Deadline),
+				formatTimestamp(t.CompletedAt),
+				estimate,
+				strings.Join(t.Tags, ";"),
+				t.Priority,
+				strings.Join(t.Contexts, ";"),
+				t.AssigneeID,
+				strings.Join(refs.dependsOn(t), ";"),
+				formatRule(t.Recurrence),
+				refs.series[t.SeriesID],
+				occurrence,
+			}
+			for _, k := range keys {
+				value := ""
+				if v, ok := t.Fields[k]; ok {
+					value = formatFieldValue(v)
+				}
+				row = append(row, value)
+			}
+			row = append(row, csvComments(threads[t.ID.Hex()]))
+			for i := range row {
+				row[i] = utils.EscapeCSVCell(row[i])
+			}
+			if err := w.Write(row); err != nil {
+				return err
+			}
+			if err := walk(t.SubTasks, taskRef, append(path, t.Title)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(plan.Tasks, "", nil); err != nil {
+		return nil, err
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// csvComments writes a task's threads into one cell, a paragraph per
+// comment with replies marked by arrows
+func csvComments(comments []commentModels.Comment) string {
+	var parts []string
+	var walk func(comments []commentModels.Comment, depth int)
+	walk = func(comments []commentModels.Comment, depth int) {
+		for _, c := range comments {
+			text := strings.Repeat("↳ ", depth) + commentByline(c)
+			if !c.Deleted() {
+				text += ": " + c.Body
+			}
+			parts = append(parts, text)
+			walk(c.Replies, depth+1)
+		}
+	}
+	walk(comments, 0)
+	return strings.Join(parts, "\n\n")
+}
+
+func decodeCSV(data []byte) (*Document, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %v", err)
+	}
+	if len(rows) < 2 {
+		return nil, fmt.Errorf("CSV has no task rows")
+	}
+
+	col := map[string]int{}
+	for i, name := range rows[0] {
+		col[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := col["title"]; !ok {
+		return nil, fmt.Errorf("CSV is missing the title column")
+	}
+	get := func(row []string, name string) string {
+		if i, ok := col[name]; ok && i < len(row) {
+			return utils.UnescapeCSVCell(row[i])
+		}
+		return ""
+	}
+
+	// tasks are built as a flat node list first; children are attached
+	// at the end so slices don't move underneath parent pointers
+	type node struct {
+		task     models.Task
+		children []*node
+	}
+	var roots []*node
+	byRef := map[string]*node{}
+	byPath := map[string]*node{}
+
+	doc := &Document{}
+	for n, row := range rows[1:] {
+		line := n + 2
+		if doc.Goal == "" {
+			doc.Goal = get(row, "goal")
+		}
+		title := get(row, "title")
+		if strings.TrimSpace(title) == "" {
+			continue
+		}
+
+		deadline, err := parseDeadline(get(row, "deadline"))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid deadline", line)
+		}
+		completedAt, err := parseTimestamp(get(row, "completed_at"))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid completed_at", line)
+		}
+		var estimate float64
+		if v := strings.TrimSpace(get(row, "estimated_hours")); v != "" {
+			if estimate, err = strconv.ParseFloat(v, 64); err != nil {
+				return nil, fmt.Errorf("row %d: invalid estimated_hours", line)
+			}
+		}
+		rule, err := parseRule(get(row, "recurrence"))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid recurrence", line)
+		}
+		var occurrence int
+		if v := strings.TrimSpace(get(row, "occurrence")); v != "" {
+			if occurrence, err = strconv.Atoi(v); err != nil || occurrence < 0 {
+				return nil, fmt.Errorf("row %d: invalid occurrence", line)
+			}
+		}
+		var fields map[string]interface{}
+		for name, i := range col {
+			key, ok := strings.CutPrefix(name, fieldPrefix)
+			if !ok || i >= len(row) || strings.TrimSpace(row[i]) == "" {
+				continue
+			}
+			if fields == nil {
+				fields = map[string]interface{}{}
+			}
+			fields[key] = utils.UnescapeCSVCell(row[i])
+		}
+
+		ref := strings.TrimSpace(get(row, "ref"))
+		nd := &node{task: doc.newTask(models.Task{
+			Title:          title,
+			Description:    get(row, "description"),
+			Status:         defaultStatus(get(row, "status")),
+			Deadline:       deadline,
+			CompletedAt:    completedAt,
+			EstimatedHours: estimate,
+			Tags:           splitCell(get(row, "tags")),
+			Priority:       strings.TrimSpace(get(row, "priority")),
+			Contexts:       splitCell(get(row, "contexts")),
+			AssigneeID:     strings.TrimSpace(get(row, "assignee_id")),
+			Fields:         fields,
+			Recurrence:     rule,
+			Occurrence:     occurrence,
+		}, ref, splitCell(get(row, "depends_on")), get(row, "series"))}
+
+		var parent *node
+		parentPath := strings.TrimSpace(get(row, "parent_path"))
+		if ref != "" {
+			if i := strings.LastIndex(ref, "."); i >= 0 {
+				if parent = byRef[ref[:i]]; parent == nil {
+					return nil, fmt.Errorf("row %d: parent %s not found", line, ref[:i])
+				}
+			}
+			byRef[ref] = nd
+		} else if parentPath != "" {
+			if parent = byPath[parentPath]; parent == nil {
+				return nil, fmt.Errorf("row %d: parent %q not found", line, parentPath)
+			}
+		}
+
+		ownPath := title
+		if parentPath != "" {
+			ownPath = parentPath + pathSeparator + title
+		}
+		byPath[ownPath] = nd
+
+		if parent != nil {
+			parent.children = append(parent.children, nd)
+		} else {
+			roots = append(roots, nd)
+		}
+	}
+
+	var build func(nodes []*node) []models.Task
+	build = func(nodes []*node) []models.Task {
+		var out []models.Task
+		for _, nd := range nodes {
+			t := nd.task
+			t.SubTasks = build(nd.children)
+			out = append(out, t)
+		}
+		return out
+	}
+	doc.Tasks = build(roots)
+	return doc, nil
+}
+
+// splitCell reads a ";"-separated list
+func splitCell(cell string) []string {
+	var out []string
+	for _, v := range strings.Split(cell, ";") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
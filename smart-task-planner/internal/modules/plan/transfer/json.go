@@ -0,0 +1,197 @@
+package transfer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	commentModels "smart-task-planner/internal/modules/comments/models"
+	"smart-task-planner/internal/modules/plan/models"
+)
+
+const (
+	schemaName    = "smart-task-planner/plan"
+	schemaVersion = 2
+)
+
+// jsonDocument is the versioned export envelope. Internal fields such as
+// IDs, owners and embeddings are left out on purpose; tasks refer to each
+// other by outline number (ref) instead. Version 2 added priorities,
+// contexts, custom fields, assignees, dependencies and recurrence.
+type jsonDocument struct {
+	Schema     string    `json:"schema"`
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	Plan       jsonPlan  `json:"plan"`
+}
+
+type jsonPlan struct {
+	Goal     string        `json:"goal"`
+	Tasks    []jsonTask    `json:"tasks"`
+	Comments []jsonComment `json:"comments,omitempty"`
+}
+
+type jsonTask struct {
+	Ref            string                 `json:"ref,omitempty"` // "2.1" is the first subtask of the second task
+	Title          string                 `json:"title"`
+	Description    string                 `json:"description,omitempty"`
+	Status         string                 `json:"status"`
+	Deadline       string                 `json:"deadline,omitempty"`
+	CompletedAt    string                 `json:"completed_at,omitempty"`
+	EstimatedHours float64                `json:"estimated_hours,omitempty"`
+	Tags           []string               `json:"tags,omitempty"`
+	Priority       string                 `json:"priority,omitempty"`
+	Contexts       []string               `json:"contexts,omitempty"`
+	Fields         map[string]interface{} `json:"fields,omitempty"`
+	AssigneeID     string                 `json:"assignee_id,omitempty"`
+	DependsOn      []string               `json:"depends_on,omitempty"` // refs
+	Recurrence     string                 `json:"recurrence,omitempty"` // RRULE
+	Series         int                    `json:"series,omitempty"`     // numbers the recurring series within the export
+	Occurrence     int                    `json:"occurrence,omitempty"`
+	SubTasks       []jsonTask             `json:"sub_tasks,omitempty"`
+
+	Comments []jsonComment `json:"comments,omitempty"`
+}
+
+// jsonComment is written on export and ignored on import
+type jsonComment struct {
+	Author    string        `json:"author"`
+	Body      string        `json:"body,omitempty"`
+	CreatedAt string        `json:"created_at"`
+	EditedAt  string        `json:"edited_at,omitempty"`
+	Deleted   bool          `json:"deleted,omitempty"`
+	Replies   []jsonComment `json:"replies,omitempty"`
+}
+
+func encodeJSON(plan *models.Plan, threads map[string][]commentModels.Comment, now time.Time) ([]byte, error) {
+	doc := jsonDocument{
+		Schema:     schemaName,
+		Version:    schemaVersion,
+		ExportedAt: now.UTC(),
+		Plan: jsonPlan{
+			Goal:     plan.Goal,
+			Tasks:    toJSONTasks(plan.Tasks, threads, newReferences(plan.Tasks)),
+			Comments: toJSONComments(threads[""]),
+		},
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+func decodeJSON(data []byte) (*Document, error) {
+	var doc jsonDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+	if doc.Schema != schemaName {
+		return nil, fmt.Errorf("unknown schema %q", doc.Schema)
+	}
+	if doc.Version < 1 || doc.Version > schemaVersion {
+		return nil, fmt.Errorf("unsupported schema version %d", doc.Version)
+	}
+
+	out := &Document{Goal: doc.Plan.Goal}
+	tasks, err := fromJSONTasks(out, doc.Plan.Tasks)
+	if err != nil {
+		return nil, err
+	}
+	out.Tasks = tasks
+	return out, nil
+}
+
+func toJSONTasks(tasks []models.Task, threads map[string][]commentModels.Comment, refs references) []jsonTask {
+	out := make([]jsonTask, 0, len(tasks))
+	for _, t := range tasks {
+		series, _ := strconv.Atoi(refs.series[t.SeriesID])
+		out = append(out, jsonTask{
+			Ref:            refs.tasks[t.ID],
+			Title:          t.Title,
+			Description:    t.Description,
+			Status:         t.Status,
+			Deadline:       formatDeadline(t.Deadline),
+			CompletedAt:    formatTimestamp(t.CompletedAt),
+			EstimatedHours: t.EstimatedHours,
+			Tags:           t.Tags,
+			Priority:       t.Priority,
+			Contexts:       t.Contexts,
+			Fields:         t.Fields,
+			AssigneeID:     t.AssigneeID,
+			DependsOn:      refs.dependsOn(t),
+			Recurrence:     formatRule(t.Recurrence),
+			Series:         series,
+			Occurrence:     t.Occurrence,
+			SubTasks:       toJSONTasks(t.SubTasks, threads, refs),
+			Comments:       toJSONComments(threads[t.ID.Hex()]),
+		})
+	}
+	return out
+}
+
+func toJSONComments(comments []commentModels.Comment) []jsonComment {
+	var out []jsonComment
+	for _, c := range comments {
+		author := c.AuthorName
+		if author == "" {
+			author = c.AuthorID
+		}
+		out = append(out, jsonComment{
+			Author:    author,
+			Body:      c.Body,
+			CreatedAt: formatTimestamp(&c.CreatedAt),
+			EditedAt:  formatTimestamp(c.EditedAt),
+			Deleted:   c.Deleted(),
+			Replies:   toJSONComments(c.Replies),
+		})
+	}
+	return out
+}
+
+func fromJSONTasks(doc *Document, tasks []jsonTask) ([]models.Task, error) {
+	var out []models.Task
+	for _, t := range tasks {
+		deadline, err := parseDeadline(t.Deadline)
+		if err != nil {
+			return nil, fmt.Errorf("task %q: invalid deadline %q", t.Title, t.Deadline)
+		}
+		completedAt, err := parseTimestamp(t.CompletedAt)
+		if err != nil {
+			return nil, fmt.Errorf("task %q: invalid completed_at %q", t.Title, t.CompletedAt)
+		}
+		rule, err := parseRule(t.Recurrence)
+		if err != nil {
+			return nil, fmt.Errorf("task %q: invalid recurrence", t.Title)
+		}
+		subTasks, err := fromJSONTasks(doc, t.SubTasks)
+		if err != nil {
+			return nil, err
+		}
+		series := ""
+		if t.Series > 0 {
+			series = strconv.Itoa(t.Series)
+		}
+		out = append(out, doc.newTask(models.Task{
+			Title:          t.Title,
+			Description:    t.Description,
+			Status:         defaultStatus(t.Status),
+			Deadline:       deadline,
+			CompletedAt:    completedAt,
+			EstimatedHours: t.EstimatedHours,
+			Tags:           t.Tags,
+			Priority:       t.Priority,
+			Contexts:       t.Contexts,
+			Fields:         t.Fields,
+			AssigneeID:     t.AssigneeID,
+			Recurrence:     rule,
+			Occurrence:     max(t.Occurrence, 0),
+			SubTasks:       subTasks,
+		}, t.Ref, t.DependsOn, series))
+	}
+	return out, nil
+}
+
+func defaultStatus(status string) string {
+	if status == "" {
+		return "Pending"
+	}
+	return status
+}
@@ -0,0 +1,309 @@
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	commentModels "smart-task-planner/internal/modules/comments/models"
+	"smart-task-planner/internal/modules/plan/models"
+)
+
+const dueMarker = " — due "
+
+// Checkbox characters for the four built-in statuses. Any other status is
+// written as "[ ]" plus an explicit "Status:" line.
+var statusBoxes = map[string]string{
+	"Pending":     " ",
+	"In Progress": "/",
+	"Completed":   "x",
+	"Cancelled":   "-",
+}
+
+var taskLine = regexp.MustCompile(`^(\s*)- \[(.)\] (.*)$`)
+
+// encodeMarkdown writes the plan as a nested checklist:
+//
+//	# Goal
+//
+//	- [ ] Task title — due 2025-10-20
+//	  > description
+//	  Estimate: 3h
+//	  Tags: a, b
+//	  Priority: P1
+//	  Contexts: @home, @phone
+//	  Field budget: 1200
+//	  Assignee: 64f0c2...
+//	  Depends on: 2, 3.1
+//	  Repeats: FREQ=WEEKLY;BYDAY=MO
+//	  Series: 1, occurrence 4
+//	  Comment by Priya Patel, 2025-10-16 09:30 UTC:
+//	  | Is the budget approved?
+//	    Reply by Sam Lee, 2025-10-16 10:02 UTC (edited):
+//	    | Yes, see the sheet.
+//	  - [x] Subtask — due 2025-10-18
+//	    Completed: 2025-10-17T14:02:00Z
+//
+// Depends on lists outline numbers ("3.1" is the first subtask of the
+// third task). Comments on the plan itself come before the checklist. Every
+// line of a comment body starts with "|" so it can't be read back as task
+// data.
+func encodeMarkdown(plan *models.Plan, threads map[string][]commentModels.Comment) []byte {
+	refs := newReferences(plan.Tasks)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# %s\n\n", oneLine(plan.Goal))
+	if len(threads[""]) > 0 {
+		writeComments(&buf, threads[""], "")
+		buf.WriteString("\n")
+	}
+
+	var walk func(tasks []models.Task, depth int)
+	walk = func(tasks []models.Task, depth int) {
+		indent := strings.Repeat("  ", depth)
+		meta := indent + "  "
+		for _, t := range tasks {
+			box, known := statusBoxes[t.Status]
+			if !known {
+				box = " "
+			}
+			line := fmt.Sprintf("%s- [%s] %s", indent, box, oneLine(t.Title))
+			if d := formatDeadline(t.Deadline); d != "" {
+				line += dueMarker + d
+			}
+			buf.WriteString(line + "\n")
+
+			if t.Description != "" {
+				for _, l := range strings.Split(t.Description, "\n") {
+					if l == "" {
+						buf.WriteString(meta + ">\n")
+					} else {
+						buf.WriteString(meta + "> " + l + "\n")
+					}
+				}
+			}
+			if !known {
+				fmt.Fprintf(&buf, "%sStatus: %s\n", meta, oneLine(t.Status))
+			}
+			if t.EstimatedHours != 0 {
+				fmt.Fprintf(&buf, "%sEstimate: %sh\n", meta, strconv.FormatFloat(t.EstimatedHours, 'f', -1, 64))
+			}
+			if len(t.Tags) > 0 {
+				fmt.Fprintf(&buf, "%sTags: %s\n", meta, strings.Join(t.Tags, ", "))
+			}
+			if t.Priority != "" {
+				fmt.Fprintf(&buf, "%sPriority: %s\n", meta, t.Priority)
+			}
+			if len(t.Contexts) > 0 {
+				fmt.Fprintf(&buf, "%sContexts: %s\n", meta, strings.Join(t.Contexts, ", "))
+			}
+			for _, k := range slices.Sorted(maps.Keys(t.Fields)) {
+				fmt.Fprintf(&buf, "%sField %s: %s\n", meta, k, oneLine(formatFieldValue(t.Fields[k])))
+			}
+			if t.AssigneeID != "" {
+				fmt.Fprintf(&buf, "%sAssignee: %s\n", meta, t.AssigneeID)
+			}
+			if deps := refs.dependsOn(t); len(deps) > 0 {
+				fmt.Fprintf(&buf, "%sDepends on: %s\n", meta, strings.Join(deps, ", "))
+			}
+			if t.Recurrence != nil {
+				fmt.Fprintf(&buf, "%sRepeats: %s\n", meta, formatRule(t.Recurrence))
+			}
+			if series := refs.series[t.SeriesID]; series != "" || t.Occurrence > 0 {
+				fmt.Fprintf(&buf, "%sSeries: %s, occurrence %d\n", meta, series, t.Occurrence)
+			}
+			if t.CompletedAt != nil {
+				fmt.Fprintf(&buf, "%sCompleted: %s\n", meta, formatTimestamp(t.CompletedAt))
+			}
+			writeComments(&buf, threads[t.ID.Hex()], meta)
+			walk(t.SubTasks, depth+1)
+		}
+	}
+	walk(plan.Tasks, 0)
+	return buf.Bytes()
+}
+
+func writeComments(buf *bytes.Buffer, comments []commentModels.Comment, indent string) {
+	for _, c := range comments {
+		label := "Comment"
+		if c.ParentID != "" {
+			label = "Reply"
+		}
+		fmt.Fprintf(buf, "%s%s by %s:\n", indent, label, commentByline(c))
+		if !c.Deleted() {
+			for _, l := range strings.Split(c.Body, "\n") {
+				buf.WriteString(strings.TrimRight(indent+"| "+l, " ") + "\n")
+			}
+		}
+		writeComments(buf, c.Replies, indent+"  ")
+	}
+}
+
+func decodeMarkdown(data []byte) (*Document, error) {
+	type node struct {
+		indent   int
+		task     models.Task
+		desc     []string
+		deps     []string
+		series   string
+		children []*node
+	}
+	var roots []*node
+	var stack []*node // open tasks, innermost last
+
+	doc := &Document{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		raw := strings.TrimRight(strings.ReplaceAll(scanner.Text(), "\t", "    "), "\r")
+		trimmed := strings.TrimSpace(raw)
+		if trimmed == "" {
+			continue
+		}
+
+		if m := taskLine.FindStringSubmatch(raw); m != nil {
+			indent := len(m[1])
+			for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+				stack = stack[:len(stack)-1]
+			}
+
+			nd := &node{indent: indent, task: models.Task{Status: boxStatus(m[2])}}
+			title := m[3]
+			// a title that merely contains the marker keeps it
+			if i := strings.LastIndex(title, dueMarker); i >= 0 {
+				if deadline, err := parseDeadline(title[i+len(dueMarker):]); err == nil {
+					nd.task.Deadline = deadline
+					title = title[:i]
+				}
+			}
+			nd.task.Title = title
+
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, nd)
+			} else {
+				roots = append(roots, nd)
+			}
+			stack = append(stack, nd)
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "# ") && len(stack) == 0 && doc.Goal == "" {
+			doc.Goal = strings.TrimSpace(trimmed[2:])
+			continue
+		}
+		if len(stack) == 0 {
+			continue // free text outside the checklist
+		}
+
+		cur := stack[len(stack)-1]
+		if strings.HasPrefix(trimmed, ">") {
+			line := strings.TrimPrefix(strings.TrimLeft(raw, " "), ">")
+			cur.desc = append(cur.desc, strings.TrimPrefix(line, " "))
+			continue
+		}
+
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if name, ok := strings.CutPrefix(key, "Field "); ok {
+			if cur.task.Fields == nil {
+				cur.task.Fields = map[string]interface{}{}
+			}
+			cur.task.Fields[strings.TrimSpace(name)] = value
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "status":
+			cur.task.Status = value
+		case "estimate":
+			hours, err := strconv.ParseFloat(strings.TrimSuffix(value, "h"), 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid estimate %q", lineNo, value)
+			}
+			cur.task.EstimatedHours = hours
+		case "tags":
+			cur.task.Tags = append(cur.task.Tags, splitList(value)...)
+		case "priority":
+			cur.task.Priority = value
+		case "contexts":
+			cur.task.Contexts = splitList(value)
+		case "assignee":
+			cur.task.AssigneeID = value
+		case "depends on":
+			cur.deps = splitList(value)
+		case "repeats":
+			rule, err := parseRule(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid recurrence %q", lineNo, value)
+			}
+			cur.task.Recurrence = rule
+		case "series":
+			series, occurrence, _ := strings.Cut(value, ",")
+			cur.series = strings.TrimSpace(series)
+			if occurrence = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(occurrence), "occurrence")); occurrence != "" {
+				n, err := strconv.Atoi(occurrence)
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("line %d: invalid occurrence %q", lineNo, value)
+				}
+				cur.task.Occurrence = n
+			}
+		case "completed":
+			completedAt, err := parseTimestamp(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid completed time %q", lineNo, value)
+			}
+			cur.task.CompletedAt = completedAt
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var build func(nodes []*node) []models.Task
+	build = func(nodes []*node) []models.Task {
+		var out []models.Task
+		for _, nd := range nodes {
+			t := nd.task
+			t.Description = strings.Join(nd.desc, "\n")
+			t.SubTasks = build(nd.children)
+			out = append(out, doc.newTask(t, "", nd.deps, nd.series))
+		}
+		return out
+	}
+	doc.Tasks = build(roots)
+	return doc, nil
+}
+
+func boxStatus(box string) string {
+	for status, b := range statusBoxes {
+		if strings.EqualFold(b, box) {
+			return status
+		}
+	}
+	return "Pending"
+}
+
+// splitList reads a ", "-separated list
+func splitList(value string) []string {
+	var out []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// oneLine keeps titles on their checklist line
+func oneLine(s string) string {
+	return strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(s)
+}
@@ -0,0 +1,381 @@
+package transfer
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	commentModels "smart-task-planner/internal/modules/comments/models"
+	"smart-task-planner/internal/modules/plan/attributes"
+	"smart-task-planner/internal/modules/plan/models"
+	"smart-task-planner/internal/modules/plan/recurrence"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	FormatMarkdown = "markdown"
+	FormatCSV      = "csv"
+	FormatJSON     = "json"
+
+	dateLayout    = "2006-01-02"
+	commentLayout = "2006-01-02 15:04 UTC"
+)
+
+// Document is what an import yields: a goal and its task tree, with fresh
+// task IDs so the plan can be saved under any account.
+type Document struct {
+	Goal  string        `json:"goal"`
+	Tasks []models.Task `json:"tasks"`
+
+	// what the decoder read about links between tasks, resolved by link
+	// once the tree is complete
+	refs   map[string]primitive.ObjectID // explicit outline numbers
+	deps   map[primitive.ObjectID][]string
+	series map[primitive.ObjectID]string
+}
+
+// NormalizeFormat maps user input (md, markdown, csv, json) to a format
+// constant, or returns an error for anything else.
+func NormalizeFormat(format string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "md", "markdown":
+		return FormatMarkdown, nil
+	case "csv":
+		return FormatCSV, nil
+	case "json", "":
+		return FormatJSON, nil
+	}
+	return "", fmt.Errorf("unsupported format %q (use markdown, csv or json)", format)
+}
+
+// Detect guesses the format of an upload from its file name, falling back
+// to sniffing the content.
+func Detect(data []byte, filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".md", ".markdown":
+		return FormatMarkdown
+	case ".csv":
+		return FormatCSV
+	case ".json":
+		return FormatJSON
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		return FormatJSON
+	case bytes.HasPrefix(trimmed, []byte("#")), bytes.HasPrefix(trimmed, []byte("- [")):
+		return FormatMarkdown
+	}
+	return FormatCSV
+}
+
+// Encode renders a plan with its comments in the given format. Comments are
+// for reading only; Decode skips them.
+func Encode(plan *models.Plan, comments []commentModels.Comment, format string) ([]byte, error) {
+	threads := threadsByTask(comments)
+	switch format {
+	case FormatMarkdown:
+		return encodeMarkdown(plan, threads), nil
+	case FormatCSV:
+		return encodeCSV(plan, threads)
+	case FormatJSON:
+		return encodeJSON(plan, threads, time.Now())
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+// Decode parses an export back into a Document.
+func Decode(data []byte, format string) (*Document, error) {
+	var doc *Document
+	var err error
+	switch format {
+	case FormatMarkdown:
+		doc, err = decodeMarkdown(data)
+	case FormatCSV:
+		doc, err = decodeCSV(data)
+	case FormatJSON:
+		doc, err = decodeJSON(data)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(doc.Goal) == "" {
+		return nil, fmt.Errorf("import has no goal")
+	}
+	if len(doc.Tasks) == 0 {
+		return nil, fmt.Errorf("import has no tasks")
+	}
+	if err := doc.link(); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// newTask gives a decoded task its ID and remembers the outline numbers
+// of the tasks it depends on and the label of its series
+func (d *Document) newTask(t models.Task, ref string, dependsOn []string, series string) models.Task {
+	t.ID = primitive.NewObjectID()
+	if d.refs == nil {
+		d.refs = map[string]primitive.ObjectID{}
+		d.deps = map[primitive.ObjectID][]string{}
+		d.series = map[primitive.ObjectID]string{}
+	}
+	if ref = strings.TrimSpace(ref); ref != "" {
+		d.refs[ref] = t.ID
+	}
+	for _, dep := range dependsOn {
+		if dep = strings.TrimSpace(dep); dep != "" {
+			d.deps[t.ID] = append(d.deps[t.ID], dep)
+		}
+	}
+	if series = strings.TrimSpace(series); series != "" {
+		d.series[t.ID] = series
+	}
+	return t
+}
+
+// link turns outline numbers into DependsOn and series labels into
+// SeriesIDs, and checks priorities and contexts. Tasks without an explicit
+// ref are numbered by position.
+func (d *Document) link() error {
+	outline := map[string]primitive.ObjectID{}
+	var number func(tasks []models.Task, prefix string)
+	number = func(tasks []models.Task, prefix string) {
+		for i := range tasks {
+			if tasks[i].ID.IsZero() {
+				tasks[i].ID = primitive.NewObjectID()
+			}
+			ref := prefix + strconv.Itoa(i+1)
+			outline[ref] = tasks[i].ID
+			number(tasks[i].SubTasks, ref+".")
+		}
+	}
+	number(d.Tasks, "")
+	for ref, id := range d.refs {
+		outline[ref] = id
+	}
+
+	seriesIDs := map[string]primitive.ObjectID{}
+	graph := map[string][]string{}
+	var walk func(tasks []models.Task) error
+	walk = func(tasks []models.Task) error {
+		for i := range tasks {
+			t := &tasks[i]
+			for _, ref := range d.deps[t.ID] {
+				id, ok := outline[ref]
+				if !ok || id == t.ID {
+					return fmt.Errorf("task %q depends on unknown task %s", t.Title, ref)
+				}
+				if !containsID(t.DependsOn, id) {
+					t.DependsOn = append(t.DependsOn, id)
+					graph[t.ID.Hex()] = append(graph[t.ID.Hex()], id.Hex())
+				}
+			}
+			if label, ok := d.series[t.ID]; ok {
+				// the first occurrence in the file stands for the series
+				if _, seen := seriesIDs[label]; !seen {
+					seriesIDs[label] = t.ID
+				}
+				t.SeriesID = seriesIDs[label]
+			}
+			var err error
+			if t.Priority, err = attributes.NormalizePriority(t.Priority); err != nil {
+				return fmt.Errorf("task %q: %v", t.Title, err)
+			}
+			t.Contexts = attributes.NormalizeContexts(t.Contexts)
+			if err := walk(t.SubTasks); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(d.Tasks); err != nil {
+		return err
+	}
+	for id := range graph {
+		if models.DependencyCycle(graph, id) {
+			return fmt.Errorf("task dependencies form a cycle")
+		}
+	}
+	return nil
+}
+
+func containsID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// references names tasks by outline number and recurring series by a
+// number in order of appearance, since exports leave IDs out
+type references struct {
+	tasks  map[primitive.ObjectID]string
+	series map[primitive.ObjectID]string
+}
+
+func newReferences(tasks []models.Task) references {
+	r := references{tasks: map[primitive.ObjectID]string{}, series: map[primitive.ObjectID]string{}}
+	var walk func(tasks []models.Task, prefix string)
+	walk = func(tasks []models.Task, prefix string) {
+		for i, t := range tasks {
+			ref := prefix + strconv.Itoa(i+1)
+			r.tasks[t.ID] = ref
+			if _, ok := r.series[t.SeriesID]; !ok && !t.SeriesID.IsZero() {
+				r.series[t.SeriesID] = strconv.Itoa(len(r.series) + 1)
+			}
+			walk(t.SubTasks, ref+".")
+		}
+	}
+	walk(tasks, "")
+	return r
+}
+
+// dependsOn lists the outline numbers of t's dependencies
+func (r references) dependsOn(t models.Task) []string {
+	var out []string
+	for _, id := range t.DependsOn {
+		if ref, ok := r.tasks[id]; ok {
+			out = append(out, ref)
+		}
+	}
+	return out
+}
+
+func formatRule(r *models.Recurrence) string {
+	if r == nil {
+		return ""
+	}
+	return recurrence.Format(r)
+}
+
+func parseRule(s string) (*models.Recurrence, error) {
+	if s = strings.TrimSpace(s); s == "" {
+		return nil, nil
+	}
+	return recurrence.Parse(s)
+}
+
+// fieldKeys lists the custom fields used anywhere in a task tree, sorted
+func fieldKeys(tasks []models.Task) []string {
+	seen := map[string]bool{}
+	var walk func(tasks []models.Task)
+	walk = func(tasks []models.Task) {
+		for _, t := range tasks {
+			for k := range t.Fields {
+				seen[k] = true
+			}
+			walk(t.SubTasks)
+		}
+	}
+	walk(tasks)
+	return slices.Sorted(maps.Keys(seen))
+}
+
+// formatFieldValue writes a custom field value as text; imports hand it to
+// the field definition to convert back
+func formatFieldValue(v interface{}) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// threadsByTask groups comment threads by task ID, "" for the plan itself
+func threadsByTask(comments []commentModels.Comment) map[string][]commentModels.Comment {
+	out := map[string][]commentModels.Comment{}
+	for _, c := range commentModels.Threads(comments) {
+		out[c.TaskID] = append(out[c.TaskID], c)
+	}
+	return out
+}
+
+// commentByline names the author and time of a comment and whether it was
+// changed since
+func commentByline(c commentModels.Comment) string {
+	author := c.AuthorName
+	if author == "" {
+		author = c.AuthorID
+	}
+	line := author + ", " + c.CreatedAt.UTC().Format(commentLayout)
+	switch {
+	case c.Deleted():
+		line += " (deleted)"
+	case c.EditedAt != nil:
+		line += " (edited)"
+	}
+	return line
+}
+
+// ContentType is the MIME type served for an export.
+func ContentType(format string) string {
+	switch format {
+	case FormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	}
+	return "application/json; charset=utf-8"
+}
+
+// Extension is the file extension used in the download name.
+func Extension(format string) string {
+	if format == FormatMarkdown {
+		return "md"
+	}
+	return format
+}
+
+// formatDeadline writes midnight-UTC deadlines as plain dates and anything
+// else as RFC 3339, so both survive a round trip.
+func formatDeadline(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	u := t.UTC()
+	if u.Hour() == 0 && u.Minute() == 0 && u.Second() == 0 && u.Nanosecond() == 0 {
+		return u.Format(dateLayout)
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+func parseDeadline(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(dateLayout, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+func formatTimestamp(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+func parseTimestamp(s string) (*time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
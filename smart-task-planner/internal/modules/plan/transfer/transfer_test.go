@@ -0,0 +1,181 @@
+package transfer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	commentModels "smart-task-planner/internal/modules/comments/models"
+	"smart-task-planner/internal/modules/plan/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func samplePlan() *models.Plan {
+	done := time.Date(2025, 10, 17, 14, 2, 0, 0, time.UTC)
+	shoes := primitive.NewObjectID()
+	series := primitive.NewObjectID()
+	return &models.Plan{
+		ID:   primitive.NewObjectID(),
+		Goal: "Run a marathon",
+		Tasks: []models.Task{
+			{
+				ID:             shoes,
+				Title:          "Buy running shoes",
+				Description:    "Get fitted\n\nat a running store",
+				Status:         "Completed",
+				Deadline:       time.Date(2025, 10, 20, 0, 0, 0, 0, time.UTC),
+				CompletedAt:    &done,
+				EstimatedHours: 2.5,
+				Tags:           []string{"gear"},
+				Priority:       "P1",
+				Contexts:       []string{"@errands"},
+				Fields:         map[string]interface{}{"budget": "120", "store": "Run Shop"},
+				AssigneeID:     "u1",
+				SubTasks: []models.Task{
+					{ID: primitive.NewObjectID(), Title: "Compare brands", Description: "=HYPERLINK(\"http://example.com\")", Status: "In Progress", Deadline: time.Date(2025, 10, 18, 9, 30, 0, 0, time.UTC)},
+				},
+			},
+			{
+				ID: series, Title: "Long run", Status: "Completed", SeriesID: series, Occurrence: 1,
+				Deadline: time.Date(2025, 10, 25, 0, 0, 0, 0, time.UTC), DependsOn: []primitive.ObjectID{shoes},
+			},
+			{
+				ID: primitive.NewObjectID(), Title: "Long run", Status: "Pending", SeriesID: series, Occurrence: 2,
+				Deadline:   time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC),
+				Recurrence: &models.Recurrence{Freq: "weekly", ByDay: []string{"SA"}, Count: 10},
+				DependsOn:  []primitive.ObjectID{shoes},
+			},
+		},
+	}
+}
+
+// comparable replaces IDs, which an import makes up fresh, with IDs
+// numbered in tree order, so plans can be compared across a round trip
+func comparable(tasks []models.Task) []models.Task {
+	ids := map[primitive.ObjectID]primitive.ObjectID{}
+	var number func(tasks []models.Task)
+	number = func(tasks []models.Task) {
+		for _, t := range tasks {
+			ids[t.ID] = primitive.ObjectID{11: byte(len(ids) + 1)}
+			number(t.SubTasks)
+		}
+	}
+	number(tasks)
+
+	var renumber func(tasks []models.Task) []models.Task
+	renumber = func(tasks []models.Task) []models.Task {
+		var out []models.Task
+		for _, t := range tasks {
+			t.ID = ids[t.ID]
+			if !t.SeriesID.IsZero() {
+				t.SeriesID = ids[t.SeriesID]
+			}
+			var deps []primitive.ObjectID
+			for _, d := range t.DependsOn {
+				deps = append(deps, ids[d])
+			}
+			t.DependsOn = deps
+			t.SubTasks = renumber(t.SubTasks)
+			out = append(out, t)
+		}
+		return out
+	}
+	return renumber(tasks)
+}
+
+func TestRoundTrip(t *testing.T) {
+	plan := samplePlan()
+	comments := []commentModels.Comment{{ID: primitive.NewObjectID(), PlanID: plan.ID.Hex(), TaskID: plan.Tasks[0].ID.Hex(), AuthorName: "Sam", Body: "Budget: 150"}}
+	for _, format := range []string{FormatJSON, FormatCSV, FormatMarkdown} {
+		data, err := Encode(plan, comments, format)
+		if err != nil {
+			t.Fatalf("%s: encode: %v", format, err)
+		}
+		doc, err := Decode(data, format)
+		if err != nil {
+			t.Fatalf("%s: decode: %v\n%s", format, err, data)
+		}
+		if doc.Goal != plan.Goal {
+			t.Errorf("%s: goal %q", format, doc.Goal)
+		}
+		got, want := comparable(doc.Tasks), comparable(plan.Tasks)
+		for i := range want {
+			if i >= len(got) || !reflect.DeepEqual(got[i], want[i]) {
+				t.Errorf("%s: task %d\n got %+v\nwant %+v\n%s", format, i+1, got, want, data)
+				break
+			}
+		}
+		if doc.Tasks[2].SeriesID != doc.Tasks[1].ID {
+			t.Errorf("%s: series should point at the first occurrence", format)
+		}
+	}
+}
+
+func TestCSVEscapesFormulas(t *testing.T) {
+	data, err := Encode(samplePlan(), nil, FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		for _, cell := range row {
+			if cell != "" && strings.ContainsRune("=+-@", rune(cell[0])) {
+				t.Errorf("cell %q would run as a formula", cell)
+			}
+		}
+	}
+}
+
+func TestDecodeLinkErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		err  string
+	}{
+		{
+			name: "unknown dependency",
+			json: `[{"title": "A", "depends_on": ["7"]}]`,
+			err:  "unknown task",
+		},
+		{
+			name: "cycle",
+			json: `[{"title": "A", "depends_on": ["2"]}, {"title": "B", "depends_on": ["1"]}]`,
+			err:  "cycle",
+		},
+		{
+			name: "bad priority",
+			json: `[{"title": "A", "priority": "urgent!"}]`,
+			err:  "priority",
+		},
+		{
+			name: "bad recurrence",
+			json: `[{"title": "A", "recurrence": "FREQ=HOURLY"}]`,
+			err:  "recurrence",
+		},
+	}
+	for _, tt := range tests {
+		data := `{"schema": "smart-task-planner/plan", "version": 2, "plan": {"goal": "G", "tasks": ` + tt.json + `}}`
+		_, err := Decode([]byte(data), FormatJSON)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: error %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestDecodeVersion1(t *testing.T) {
+	data := `{"schema": "smart-task-planner/plan", "version": 1, "plan": {"goal": "G", "tasks": [{"title": "A", "status": "Pending"}]}}`
+	doc, err := Decode([]byte(data), FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Tasks) != 1 || doc.Tasks[0].ID.IsZero() {
+		t.Errorf("tasks %+v", doc.Tasks)
+	}
+}
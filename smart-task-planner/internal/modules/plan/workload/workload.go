@@ -0,0 +1,302 @@
+package workload
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"smart-task-planner/internal/modules/calendar/busytime"
+	"smart-task-planner/internal/modules/plan/agenda"
+	"smart-task-planner/internal/modules/plan/models"
+	"smart-task-planner/internal/modules/plan/progress"
+)
+
+const (
+	dayLayout = "2006-01-02"
+
+	// DefaultHoursPerDay is a member's capacity until they set their own
+	DefaultHoursPerDay = 8.0
+
+	// DefaultDays and MaxDays bound the window of the report
+	DefaultDays = 14
+	MaxDays     = 60
+
+	// small overruns from spreading hours evenly aren't worth a flag
+	tolerance = 0.05
+)
+
+// Member is someone work can be assigned to. Busy may be nil.
+type Member struct {
+	UserID      string
+	Name        string
+	HoursPerDay float64
+	Busy        *busytime.Calendar
+}
+
+// TaskLoad is the share of one task's remaining hours planned on a day
+type TaskLoad struct {
+	PlanID string  `json:"plan_id"`
+	TaskID string  `json:"task_id"`
+	Title  string  `json:"title"`
+	Hours  float64 `json:"hours"`
+}
+
+type Day struct {
+	Date     string     `json:"date"`
+	Hours    float64    `json:"hours"`
+	Capacity float64    `json:"capacity"` // working hours minus busy time, 0 on weekends and busy days
+	Over     bool       `json:"over"`
+	Tasks    []TaskLoad `json:"tasks,omitempty"`
+}
+
+type MemberLoad struct {
+	UserID           string  `json:"user_id"`
+	Name             string  `json:"name,omitempty"`
+	HoursPerDay      float64 `json:"hours_per_day"`
+	Hours            float64 `json:"hours"`             // planned within the window
+	Capacity         float64 `json:"capacity"`          // available within the window
+	Utilization      float64 `json:"utilization"`       // Hours as a percentage of Capacity
+	UnscheduledHours float64 `json:"unscheduled_hours"` // assigned work without a deadline
+	Days             []Day   `json:"days"`
+}
+
+// Overload is a day on which a member has more work than time
+type Overload struct {
+	UserID   string  `json:"user_id"`
+	Name     string  `json:"name,omitempty"`
+	Date     string  `json:"date"`
+	Hours    float64 `json:"hours"`
+	Capacity float64 `json:"capacity"`
+	Excess   float64 `json:"excess"`
+	Reason   string  `json:"reason"`
+}
+
+type Report struct {
+	WorkspaceID     string       `json:"workspace_id,omitempty"`
+	Workspace       string       `json:"workspace,omitempty"`
+	From            string       `json:"from"`
+	To              string       `json:"to"` // last day shown
+	Members         []MemberLoad `json:"members"`
+	UnassignedHours float64      `json:"unassigned_hours"` // open work no member is assigned to, including work of people who left
+	Overloaded      []Overload   `json:"overloaded"`
+	Message         string       `json:"message"`
+}
+
+// Build spreads the remaining hours (estimate minus tracked time) of every
+// open task over the working days from today to its deadline, per assignee,
+// and compares each day with the assignee's capacity. Overdue work lands on
+// today. Only tasks without open subtasks count, so hours aren't counted
+// twice. now must be in the user's time zone.
+func Build(plans []models.Plan, members []Member, days int, now time.Time) *Report {
+	if days <= 0 {
+		days = DefaultDays
+	}
+	days = min(days, MaxDays)
+
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	last := today.AddDate(0, 0, days-1)
+	r := &Report{From: today.Format(dayLayout), To: last.Format(dayLayout), Members: []MemberLoad{}, Overloaded: []Overload{}}
+
+	type load struct {
+		member      Member
+		hours       map[string]float64
+		tasks       map[string][]TaskLoad
+		unscheduled float64
+	}
+	loads := map[string]*load{}
+	for _, mem := range members {
+		if mem.HoursPerDay <= 0 {
+			mem.HoursPerDay = DefaultHoursPerDay
+		}
+		loads[mem.UserID] = &load{member: mem, hours: map[string]float64{}, tasks: map[string][]TaskLoad{}}
+	}
+
+	for _, plan := range plans {
+		var walk func(tasks []models.Task, assignee string)
+		walk = func(tasks []models.Task, assignee string) {
+			for _, t := range tasks {
+				if !open(t) {
+					continue
+				}
+				a := agenda.Assignee(t, assignee)
+				if hasOpen(t.SubTasks) {
+					walk(t.SubTasks, a)
+					continue
+				}
+				hours := math.Max(t.EstimatedHours-t.ActualHours, 0)
+				if hours == 0 {
+					continue
+				}
+				l, ok := loads[a]
+				if !ok {
+					r.UnassignedHours += hours
+					continue
+				}
+				if t.Deadline.IsZero() {
+					l.unscheduled += hours
+					continue
+				}
+
+				spread, total := workingDays(l.member, today, last, t.Deadline.In(now.Location()))
+				share := hours / float64(total)
+				for _, day := range spread {
+					key := day.Format(dayLayout)
+					l.hours[key] += share
+					l.tasks[key] = append(l.tasks[key], TaskLoad{PlanID: plan.ID.Hex(), TaskID: t.ID.Hex(), Title: t.Title, Hours: round(share)})
+				}
+			}
+		}
+		walk(plan.Tasks, "")
+	}
+
+	for _, mem := range members {
+		l := loads[mem.UserID]
+		ml := MemberLoad{UserID: mem.UserID, Name: mem.Name, HoursPerDay: l.member.HoursPerDay, UnscheduledHours: round(l.unscheduled)}
+		for day := today; !day.After(last); day = day.AddDate(0, 0, 1) {
+			key := day.Format(dayLayout)
+			capacity := capacityOn(l.member, day)
+			hours := l.hours[key]
+			tasks := l.tasks[key]
+			sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].Hours > tasks[j].Hours })
+
+			over := hours > capacity+tolerance
+			ml.Days = append(ml.Days, Day{Date: key, Hours: round(hours), Capacity: round(capacity), Over: over, Tasks: tasks})
+			ml.Hours += hours
+			ml.Capacity += capacity
+			if over {
+				r.Overloaded = append(r.Overloaded, Overload{
+					UserID:   mem.UserID,
+					Name:     mem.Name,
+					Date:     key,
+					Hours:    round(hours),
+					Capacity: round(capacity),
+					Excess:   round(hours - capacity),
+					Reason:   reason(l.member, day, hours, capacity),
+				})
+			}
+		}
+		if ml.Capacity > 0 {
+			ml.Utilization = math.Round(ml.Hours / ml.Capacity * 100)
+		}
+		ml.Hours, ml.Capacity = round(ml.Hours), round(ml.Capacity)
+		r.Members = append(r.Members, ml)
+	}
+	r.UnassignedHours = round(r.UnassignedHours)
+
+	// the worst day first, as analyze_risks puts the closest deadline first
+	sort.SliceStable(r.Overloaded, func(i, j int) bool {
+		if r.Overloaded[i].Excess != r.Overloaded[j].Excess {
+			return r.Overloaded[i].Excess > r.Overloaded[j].Excess
+		}
+		return r.Overloaded[i].Date < r.Overloaded[j].Date
+	})
+	r.Message = summary(r)
+	return r
+}
+
+// workingDays are the days from today up to and including the deadline on
+// which the member has time, cut off after last, and how many there are in
+// all. Days past last are counted as weekdays, since busy time is only
+// known for the window. Work due earlier, or with no time left before its
+// deadline, all lands on one day.
+func workingDays(m Member, today, last, deadline time.Time) ([]time.Time, int) {
+	y, mo, d := deadline.Date()
+	end := time.Date(y, mo, d, 0, 0, 0, 0, today.Location())
+	if end.Before(today) {
+		return []time.Time{today}, 1
+	}
+	var out []time.Time
+	for day := today; !day.After(end) && !day.After(last); day = day.AddDate(0, 0, 1) {
+		if capacityOn(m, day) > 0 {
+			out = append(out, day)
+		}
+	}
+	total := len(out)
+	if end.After(last) {
+		total += weekdaysBetween(last, end)
+	}
+	if total == 0 {
+		return []time.Time{end}, 1
+	}
+	return out, total
+}
+
+// weekdaysBetween counts Monday to Friday after from up to and including to
+func weekdaysBetween(from, to time.Time) int {
+	days := int(math.Round(to.Sub(from).Hours() / 24))
+	count := days / 7 * 5
+	for i, wd := 0, from.Weekday(); i < days%7; i++ {
+		if wd = (wd + 1) % 7; wd != time.Saturday && wd != time.Sunday {
+			count++
+		}
+	}
+	return count
+}
+
+// capacityOn is the member's working hours on a weekday less the time
+// blocked in their calendar
+func capacityOn(m Member, day time.Time) float64 {
+	switch day.Weekday() {
+	case time.Saturday, time.Sunday:
+		return 0
+	}
+	if m.Busy == nil {
+		return m.HoursPerDay
+	}
+	if m.Busy.IsBusy(day) {
+		return 0
+	}
+	return math.Max(m.HoursPerDay-m.Busy.Hours(day), 0)
+}
+
+func reason(m Member, day time.Time, hours, capacity float64) string {
+	msg := fmt.Sprintf("%.1f h planned, %.1f h available", hours, capacity)
+	switch {
+	case day.Weekday() == time.Saturday || day.Weekday() == time.Sunday:
+		msg += " (weekend)"
+	case m.Busy != nil && m.Busy.IsBusy(day):
+		msg += " (busy in their calendar)"
+	case m.Busy != nil && m.Busy.Hours(day) > 0:
+		msg += fmt.Sprintf(" (%.1f h of meetings)", m.Busy.Hours(day))
+	}
+	return msg
+}
+
+func summary(r *Report) string {
+	if len(r.Overloaded) == 0 {
+		return fmt.Sprintf("Nobody is over capacity between %s and %s.", r.From, r.To)
+	}
+	people := map[string]bool{}
+	var names []string
+	for _, o := range r.Overloaded {
+		if !people[o.UserID] {
+			people[o.UserID] = true
+			name := o.Name
+			if name == "" {
+				name = o.UserID
+			}
+			names = append(names, name)
+		}
+	}
+	return fmt.Sprintf("%d over-allocated day(s) between %s and %s: %s.", len(r.Overloaded), r.From, r.To, strings.Join(names, ", "))
+}
+
+func open(t models.Task) bool {
+	return !progress.IsCompleted(t) && !strings.EqualFold(t.Status, "Cancelled")
+}
+
+func hasOpen(tasks []models.Task) bool {
+	for _, t := range tasks {
+		if open(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func round(v float64) float64 {
+	return math.Round(v*10) / 10
+}
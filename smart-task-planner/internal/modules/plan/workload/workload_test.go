@@ -0,0 +1,96 @@
+package workload
+
+import (
+	"testing"
+	"time"
+
+	"smart-task-planner/internal/modules/plan/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Monday 3 March 2025
+var monday = time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+
+func task(title, assignee string, hours float64, deadline time.Time) models.Task {
+	return models.Task{ID: primitive.NewObjectID(), Title: title, Status: "Pending", AssigneeID: assignee, EstimatedHours: hours, Deadline: deadline}
+}
+
+func TestBuild(t *testing.T) {
+	members := []Member{{UserID: "ann", Name: "Ann"}, {UserID: "bob", Name: "Bob", HoursPerDay: 4}}
+	parent := task("Launch", "bob", 0, time.Time{})
+	parent.SubTasks = []models.Task{task("Slides", "", 6, monday.AddDate(0, 0, 1))}
+	done := task("Done already", "ann", 5, monday)
+	done.Status = "Completed"
+	plans := []models.Plan{{
+		ID: primitive.NewObjectID(),
+		Tasks: []models.Task{
+			task("Report", "ann", 16, monday.AddDate(0, 0, 1)),         // 8 h on Monday and Tuesday
+			task("Overdue", "ann", 2, monday.AddDate(0, 0, -3)),        // all on today
+			task("Far away", "ann", 100, monday.AddDate(100, 0, 0)),    // a sliver a day
+			task("Someday", "ann", 3, time.Time{}),                     // unscheduled
+			task("Left the team", "carol", 7, monday.AddDate(0, 0, 2)), // no longer a member
+			task("Nobody", "", 1, monday),                              // unassigned
+			parent,                                                     // the subtask is Bob's
+			done,
+		},
+	}}
+
+	r := Build(plans, members, 7, monday)
+	if r.From != "2025-03-03" || r.To != "2025-03-09" {
+		t.Errorf("window %s to %s", r.From, r.To)
+	}
+	if r.UnassignedHours != 8 {
+		t.Errorf("unassigned %v, want 8 (including the departed member's 7)", r.UnassignedHours)
+	}
+
+	ann, bob := r.Members[0], r.Members[1]
+	if ann.UnscheduledHours != 3 {
+		t.Errorf("ann unscheduled %v, want 3", ann.UnscheduledHours)
+	}
+	if got := ann.Days[0].Hours; got < 10 || got > 10.1 {
+		t.Errorf("ann monday %v, want 8 + 2 overdue + a sliver", got)
+	}
+	if got := ann.Days[2].Hours; got > 0.1 {
+		t.Errorf("ann wednesday %v, want only the far-away sliver", got)
+	}
+	if ann.Days[5].Capacity != 0 || ann.Days[5].Hours != 0 {
+		t.Errorf("saturday %+v, want no capacity and no work", ann.Days[5])
+	}
+	if bob.Days[0].Hours != 3 || bob.Days[1].Hours != 3 {
+		t.Errorf("bob %v / %v, want the subtask split 3 + 3", bob.Days[0].Hours, bob.Days[1].Hours)
+	}
+
+	if len(r.Overloaded) != 1 || r.Overloaded[0].UserID != "ann" || r.Overloaded[0].Date != "2025-03-03" {
+		t.Errorf("overloaded %+v, want ann on monday", r.Overloaded)
+	}
+}
+
+func TestWorkingDays(t *testing.T) {
+	m := Member{HoursPerDay: 8}
+	today := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+	last := today.AddDate(0, 0, 6)
+	tests := []struct {
+		name     string
+		deadline time.Time
+		days     int
+		total    int
+	}{
+		{"today", today, 1, 1},
+		{"overdue", today.AddDate(0, 0, -10), 1, 1},
+		{"friday", today.AddDate(0, 0, 4), 5, 5},
+		{"past the window", today.AddDate(0, 0, 13), 5, 10},
+		{"a century out", today.AddDate(100, 0, 0), 5, 26090},
+	}
+	for _, tt := range tests {
+		days, total := workingDays(m, today, last, tt.deadline)
+		if len(days) != tt.days || total != tt.total {
+			t.Errorf("%s: %d days of %d, want %d of %d", tt.name, len(days), total, tt.days, tt.total)
+		}
+		for _, d := range days {
+			if d.After(last) {
+				t.Errorf("%s: %v is past the window", tt.name, d)
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"smart-task-planner/internal/modules/realtime/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	writeWait  = 10 * time.Second
+	pongWait   = 60 * time.Second
+	pingPeriod = pongWait * 9 / 10
+	maxMessage = 4096
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	// the JWT is sent explicitly (header or query), never by the browser on
+	// its own, so a cross-origin page can't open a session in the user's name
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+type LiveHandler struct {
+	hub *service.Hub
+}
+
+func NewLiveHandler(hub *service.Hub) *LiveHandler {
+	return &LiveHandler{hub: hub}
+}
+
+// Connect handles GET /api/live (WebSocket). ?plan_id= limits the stream to
+// one plan. Every message is an event as JSON; nothing is read from the
+// client except control frames.
+func (h *LiveHandler) Connect(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade has already answered with an HTTP error
+		return
+	}
+
+	client := service.NewClient(userID, c.Query("plan_id"))
+	h.hub.Register(client)
+
+	hello, _ := json.Marshal(gin.H{"type": "connected", "user_id": userID, "plan_id": client.PlanID})
+	client.Send <- hello
+
+	go writePump(conn, client)
+	readPump(conn)
+	h.hub.Unregister(client)
+}
+
+// readPump keeps the read deadline moving with pongs and returns when the
+// client goes away
+func readPump(conn *websocket.Conn) {
+	conn.SetReadLimit(maxMessage)
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// writePump is the only writer on the connection
+func writePump(conn *websocket.Conn, client *service.Client) {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		conn.Close()
+	}()
+
+	for {
+		select {
+		case msg, ok := <-client.Send:
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Status handles GET /api/live/status
+func (h *LiveHandler) Status(c *gin.Context) {
+	userID := c.GetString("user_id")
+	c.JSON(http.StatusOK, gin.H{"connections": h.hub.Connections(userID)})
+}
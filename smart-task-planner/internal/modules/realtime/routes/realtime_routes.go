@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"smart-task-planner/internal/middleware"
+	"smart-task-planner/internal/modules/realtime/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRealtimeRoutes(router *gin.Engine, handler *handlers.LiveHandler) {
+	api := router.Group("/api/live")
+	api.Use(middleware.WebSocketAuth())
+	{
+		// WebSocket stream of plan and task events (?token=<jwt>&plan_id=)
+		api.GET("", handler.Connect)
+
+		api.GET("/status", handler.Status)
+	}
+}
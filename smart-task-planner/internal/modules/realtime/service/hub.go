@@ -0,0 +1,114 @@
+package service
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+
+	"smart-task-planner/internal/events"
+)
+
+const sendBuffer = 64
+
+// Client is one open live connection. Messages are queued on Send and
+// written by the connection's own goroutine.
+type Client struct {
+	UserID string
+	PlanID string // only this plan's events when set
+	Send   chan []byte
+
+	closeOnce sync.Once
+}
+
+func NewClient(userID, planID string) *Client {
+	return &Client{UserID: userID, PlanID: planID, Send: make(chan []byte, sendBuffer)}
+}
+
+// Close ends the client's send queue; the writer then closes the connection
+func (c *Client) Close() {
+	c.closeOnce.Do(func() { close(c.Send) })
+}
+
+// Hub fans bus events out to every open connection of the users an event
+// concerns. Audience decides who that is; by default the event's own
+// audience or else the plan's owner.
+type Hub struct {
+	mu       sync.RWMutex
+	clients  map[string]map[*Client]bool
+	Audience func(e events.Event) []string
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		clients: map[string]map[*Client]bool{},
+		Audience: func(e events.Event) []string {
+			if len(e.Audience) > 0 {
+				return e.Audience
+			}
+			return []string{e.UserID}
+		},
+	}
+}
+
+// Start subscribes the hub to local and, when enabled, cross-instance events
+func (h *Hub) Start() {
+	events.SubscribeAll(h.Broadcast)
+}
+
+func (h *Hub) Register(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.clients[c.UserID] == nil {
+		h.clients[c.UserID] = map[*Client]bool{}
+	}
+	h.clients[c.UserID][c] = true
+}
+
+func (h *Hub) Unregister(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if set, ok := h.clients[c.UserID]; ok {
+		delete(set, c)
+		if len(set) == 0 {
+			delete(h.clients, c.UserID)
+		}
+	}
+	c.Close()
+}
+
+// Broadcast pushes an event to its audience. A client whose queue is full is
+// too slow to keep up and gets disconnected rather than holding up others.
+func (h *Hub) Broadcast(e events.Event) {
+	msg, err := json.Marshal(e)
+	if err != nil {
+		log.Printf("❌ Failed to encode live event %s: %v", e.ID, err)
+		return
+	}
+
+	var slow []*Client
+	h.mu.RLock()
+	for _, userID := range h.Audience(e) {
+		for c := range h.clients[userID] {
+			if c.PlanID != "" && c.PlanID != e.PlanID {
+				continue
+			}
+			select {
+			case c.Send <- msg:
+			default:
+				slow = append(slow, c)
+			}
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, c := range slow {
+		h.Unregister(c)
+	}
+}
+
+// Connections counts the user's open connections
+func (h *Hub) Connections(userID string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients[userID])
+}
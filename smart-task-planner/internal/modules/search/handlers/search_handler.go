@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"smart-task-planner/internal/modules/search/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SearchHandler struct {
+	service *service.SearchService
+}
+
+func NewSearchHandler(svc *service.SearchService) *SearchHandler {
+	return &SearchHandler{service: svc}
+}
+
+// Search handles GET /api/search?q=...&status=...&tags=...&priority=...&context=...&field.<key>=...
+// &overdue=...&from=...&to=...&plan_id=...&limit=...
+func (h *SearchHandler) Search(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	params := map[string]interface{}{
+		"query":         c.Query("q"),
+		"status":        c.Query("status"),
+		"tags":          c.Query("tags"),
+		"priority":      c.Query("priority"),
+		"contexts":      c.Query("context"),
+		"plan_id":       c.Query("plan_id"),
+		"deadline_from": c.Query("from"),
+		"deadline_to":   c.Query("to"),
+		"overdue":       c.Query("overdue"),
+		"limit":         c.Query("limit"),
+	}
+	fields := map[string]string{}
+	for name, values := range c.Request.URL.Query() {
+		if key, ok := strings.CutPrefix(name, "field."); ok && key != "" && len(values) > 0 {
+			fields[key] = values[0]
+		}
+	}
+	if len(fields) > 0 {
+		params["fields"] = fields
+	}
+
+	result, err := h.service.Search(userID, params)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"smart-task-planner/internal/middleware"
+	"smart-task-planner/internal/modules/search/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterSearchRoutes(router *gin.Engine, handler *handlers.SearchHandler) {
+	api := router.Group("/api/search")
+	api.Use(middleware.JWTAuth())
+	{
+		api.GET("/", handler.Search)
+	}
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"fmt"
+
+	"smart-task-planner/internal/mcp"
+	"smart-task-planner/internal/modules/plan/repository"
+	"smart-task-planner/internal/modules/plan/search"
+)
+
+type SearchService struct {
+	Repo *repository.PlanRepository
+}
+
+func NewSearchService(repo *repository.PlanRepository) *SearchService {
+	return &SearchService{Repo: repo}
+}
+
+// Search runs the search_tasks MCP tool with the given filters
+func (s *SearchService) Search(userID string, params map[string]interface{}) (*search.Result, error) {
+	params["user_id"] = userID
+
+	result, err := mcp.RunTool("search_tasks", params, s.Repo)
+	if err != nil {
+		return nil, err
+	}
+
+	res, ok := result.(*search.Result)
+	if !ok {
+		return nil, fmt.Errorf("invalid data from MCP search_tasks")
+	}
+	return res, nil
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"smart-task-planner/internal/modules/stats/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StatsHandler struct {
+	service *service.StatsService
+}
+
+func NewStatsHandler(svc *service.StatsService) *StatsHandler {
+	return &StatsHandler{service: svc}
+}
+
+// GetBurndown returns chart-ready burndown series for one plan
+func (h *StatsHandler) GetBurndown(c *gin.Context) {
+	userID := c.GetString("user_id")
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "30"))
+
+	series, err := h.service.Burndown(userID, c.Param("id"), days)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
+
+// GetHistory returns the user's daily progress totals across all plans
+func (h *StatsHandler) GetHistory(c *gin.Context) {
+	userID := c.GetString("user_id")
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "30"))
+
+	series, err := h.service.History(userID, days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch progress history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ProgressSnapshot is one day's progress counters for a plan
+type ProgressSnapshot struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID         string             `bson:"user_id" json:"user_id"`
+	PlanID         string             `bson:"plan_id" json:"plan_id"`
+	Goal           string             `bson:"goal" json:"goal"`
+	Date           time.Time          `bson:"date" json:"date"`
+	Total          int                `bson:"total" json:"total"`
+	Completed      int                `bson:"completed" json:"completed"`
+	Remaining      int                `bson:"remaining" json:"remaining"`
+	Overdue        int                `bson:"overdue" json:"overdue"`
+	EstimateHours  float64            `bson:"estimate_hours" json:"estimate_hours"`
+	RemainingHours float64            `bson:"remaining_hours" json:"remaining_hours"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// Series is a chart-ready set of aligned data series keyed by name
+type Series struct {
+	Labels []string             `json:"labels"`
+	Series map[string][]float64 `json:"series"`
+}
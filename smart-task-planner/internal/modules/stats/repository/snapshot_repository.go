@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"smart-task-planner/internal/modules/stats/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SnapshotRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewSnapshotRepository(db *mongo.Database) *SnapshotRepository {
+	return &SnapshotRepository{
+		Collection: db.Collection("progress_snapshots"),
+	}
+}
+
+// EnsureIndexes creates the unique per-plan-per-day index and the user history index
+func (r *SnapshotRepository) EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.Collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "plan_id", Value: 1}, {Key: "date", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "date", Value: 1}},
+		},
+	})
+	return err
+}
+
+// Upsert stores the snapshot, replacing any earlier one for the same plan and day
+func (r *SnapshotRepository) Upsert(snap *models.ProgressSnapshot) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.Collection.UpdateOne(
+		ctx,
+		bson.M{"plan_id": snap.PlanID, "date": snap.Date},
+		bson.M{"$set": bson.M{
+			"user_id":         snap.UserID,
+			"goal":            snap.Goal,
+			"total":           snap.Total,
+			"completed":       snap.Completed,
+			"remaining":       snap.Remaining,
+			"overdue":         snap.Overdue,
+			"estimate_hours":  snap.EstimateHours,
+			"remaining_hours": snap.RemainingHours,
+			"created_at":      snap.CreatedAt,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// FindByPlan returns a plan's snapshots between from and to, oldest first.
+// Callers check access to the plan first: snapshots are stored under its owner.
+func (r *SnapshotRepository) FindByPlan(planID string, from, to time.Time) ([]models.ProgressSnapshot, error) {
+	return r.find(bson.M{
+		"plan_id": planID,
+		"date":    bson.M{"$gte": from, "$lte": to},
+	})
+}
+
+// FindByPlans returns the snapshots of several plans between from and to, oldest first
+func (r *SnapshotRepository) FindByPlans(planIDs []string, from, to time.Time) ([]models.ProgressSnapshot, error) {
+	if len(planIDs) == 0 {
+		return nil, nil
+	}
+	return r.find(bson.M{
+		"plan_id": bson.M{"$in": planIDs},
+		"date":    bson.M{"$gte": from, "$lte": to},
+	})
+}
+
+func (r *SnapshotRepository) find(filter bson.M) ([]models.ProgressSnapshot, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.Collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "date", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var snaps []models.ProgressSnapshot
+	if err := cursor.All(ctx, &snaps); err != nil {
+		return nil, err
+	}
+	return snaps, nil
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"smart-task-planner/internal/middleware"
+	"smart-task-planner/internal/modules/stats/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterStatsRoutes(router *gin.Engine, handler *handlers.StatsHandler) {
+	api := router.Group("/api")
+	api.Use(middleware.JWTAuth())
+	{
+		// Burndown series for a single plan
+		api.GET("/plan/:id/burndown", handler.GetBurndown)
+
+		// Daily totals across all plans
+		api.GET("/stats/history", handler.GetHistory)
+	}
+}
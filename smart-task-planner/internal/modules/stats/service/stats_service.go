@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	planModels "smart-task-planner/internal/modules/plan/models"
+	"smart-task-planner/internal/modules/plan/progress"
+	planRepository "smart-task-planner/internal/modules/plan/repository"
+	"smart-task-planner/internal/modules/stats/models"
+	"smart-task-planner/internal/modules/stats/repository"
+)
+
+const dateLayout = "2006-01-02"
+
+type StatsService struct {
+	Repo     *repository.SnapshotRepository
+	PlanRepo *planRepository.PlanRepository
+}
+
+func NewStatsService(repo *repository.SnapshotRepository, planRepo *planRepository.PlanRepository) *StatsService {
+	return &StatsService{Repo: repo, PlanRepo: planRepo}
+}
+
+// StartDailySnapshots records a snapshot right away and then shortly after
+// every UTC midnight until ctx is cancelled.
+func (s *StatsService) StartDailySnapshots(ctx context.Context) {
+	go func() {
+		for {
+			if n, err := s.TakeSnapshots(time.Now()); err != nil {
+				log.Println("❌ Progress snapshot failed:", err)
+			} else {
+				log.Printf("📸 Recorded %d progress snapshots", n)
+			}
+
+			next := dayStart(time.Now()).AddDate(0, 0, 1).Add(5 * time.Minute)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Until(next)):
+			}
+		}
+	}()
+}
+
+// TakeSnapshots stores today's progress for every plan and returns how many were written
+func (s *StatsService) TakeSnapshots(now time.Time) (int, error) {
+	count := 0
+	err := s.PlanRepo.ForEach(func(plan *planModels.Plan) error {
+		if err := s.Repo.Upsert(snapshotOf(plan, now)); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// Burndown returns the daily series for one plan plus an ideal line down to the last deadline
+func (s *StatsService) Burndown(userID, planID string, days int) (*models.Series, error) {
+	plan, err := s.PlanRepo.GetByIDForUser(planID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	from, to := window(now, days)
+	snaps, err := s.Repo.FindByPlan(plan.ID.Hex(), from, to)
+	if err != nil {
+		return nil, err
+	}
+	// Always end on a live point so the chart reflects changes made today
+	snaps = withLive(snaps, snapshotOf(plan, now))
+
+	series := toSeries(snaps)
+	series.Series["ideal"] = idealLine(snaps, progress.LastDeadline(plan.Tasks))
+	return series, nil
+}
+
+// History returns the totals across every plan the user can see, one point per day
+func (s *StatsService) History(userID string, days int) (*models.Series, error) {
+	plans, err := s.PlanRepo.GetAllByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(plans))
+	for i := range plans {
+		ids[i] = plans[i].ID.Hex()
+	}
+
+	now := time.Now()
+	from, to := window(now, days)
+	snaps, err := s.Repo.FindByPlans(ids, from, to)
+	if err != nil {
+		return nil, err
+	}
+	today := dayStart(now)
+	// Today's stored points are replaced by live ones
+	var points []models.ProgressSnapshot
+	for _, snap := range snaps {
+		if !snap.Date.Equal(today) {
+			points = append(points, snap)
+		}
+	}
+	for i := range plans {
+		points = append(points, *snapshotOf(&plans[i], now))
+	}
+
+	// Sum every plan's snapshot into a single point per day
+	byDay := map[string]*models.ProgressSnapshot{}
+	var order []string
+	for _, snap := range points {
+		key := snap.Date.Format(dateLayout)
+		agg, ok := byDay[key]
+		if !ok {
+			agg = &models.ProgressSnapshot{UserID: userID, Date: snap.Date}
+			byDay[key] = agg
+			order = append(order, key)
+		}
+		agg.Total += snap.Total
+		agg.Completed += snap.Completed
+		agg.Remaining += snap.Remaining
+		agg.Overdue += snap.Overdue
+		agg.EstimateHours += snap.EstimateHours
+		agg.RemainingHours += snap.RemainingHours
+	}
+
+	// ISO dates sort lexically
+	sort.Strings(order)
+	var merged []models.ProgressSnapshot
+	for _, key := range order {
+		merged = append(merged, *byDay[key])
+	}
+	return toSeries(merged), nil
+}
+
+func snapshotOf(plan *planModels.Plan, now time.Time) *models.ProgressSnapshot {
+	summary := progress.Calculate(plan.Tasks, now)
+	return &models.ProgressSnapshot{
+		UserID:         plan.UserID,
+		PlanID:         plan.ID.Hex(),
+		Goal:           plan.Goal,
+		Date:           dayStart(now),
+		Total:          summary.Total,
+		Completed:      summary.Completed,
+		Remaining:      summary.Remaining,
+		Overdue:        summary.Overdue,
+		EstimateHours:  summary.EstimateHours,
+		RemainingHours: summary.RemainingHours,
+		CreatedAt:      now,
+	}
+}
+
+func withLive(snaps []models.ProgressSnapshot, live *models.ProgressSnapshot) []models.ProgressSnapshot {
+	if n := len(snaps); n > 0 && snaps[n-1].Date.Equal(live.Date) {
+		snaps[n-1] = *live
+		return snaps
+	}
+	return append(snaps, *live)
+}
+
+func toSeries(snaps []models.ProgressSnapshot) *models.Series {
+	out := &models.Series{
+		Labels: []string{},
+		Series: map[string][]float64{
+			"total":           {},
+			"completed":       {},
+			"remaining":       {},
+			"overdue":         {},
+			"estimate_hours":  {},
+			"remaining_hours": {},
+		},
+	}
+	for _, snap := range snaps {
+		out.Labels = append(out.Labels, snap.Date.Format(dateLayout))
+		out.Series["total"] = append(out.Series["total"], float64(snap.Total))
+		out.Series["completed"] = append(out.Series["completed"], float64(snap.Completed))
+		out.Series["remaining"] = append(out.Series["remaining"], float64(snap.Remaining))
+		out.Series["overdue"] = append(out.Series["overdue"], float64(snap.Overdue))
+		out.Series["estimate_hours"] = append(out.Series["estimate_hours"], snap.EstimateHours)
+		out.Series["remaining_hours"] = append(out.Series["remaining_hours"], snap.RemainingHours)
+	}
+	return out
+}
+
+// idealLine falls linearly from the first point's remaining count to zero on the deadline
+func idealLine(snaps []models.ProgressSnapshot, deadline time.Time) []float64 {
+	ideal := make([]float64, len(snaps))
+	if len(snaps) == 0 {
+		return ideal
+	}
+	start := snaps[0].Date
+	startRemaining := float64(snaps[0].Remaining)
+	span := dayStart(deadline).Sub(start).Hours() / 24
+
+	for i, snap := range snaps {
+		if deadline.IsZero() || span <= 0 {
+			ideal[i] = 0
+			continue
+		}
+		elapsed := snap.Date.Sub(start).Hours() / 24
+		v := startRemaining * (1 - elapsed/span)
+		ideal[i] = math.Max(0, math.Round(v*100)/100)
+	}
+	return ideal
+}
+
+func window(now time.Time, days int) (time.Time, time.Time) {
+	if days <= 0 {
+		days = 30
+	}
+	if days > 365 {
+		days = 365
+	}
+	today := dayStart(now)
+	return today.AddDate(0, 0, -(days - 1)), today
+}
+
+func dayStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
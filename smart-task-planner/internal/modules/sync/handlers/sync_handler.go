@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"smart-task-planner/internal/modules/sync/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SyncHandler struct {
+	service *service.SyncService
+}
+
+func NewSyncHandler(svc *service.SyncService) *SyncHandler {
+	return &SyncHandler{service: svc}
+}
+
+// Pull handles GET /api/sync?since=<cursor>&limit=200
+func (h *SyncHandler) Pull(c *gin.Context) {
+	userID := c.GetString("user_id")
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	res, err := h.service.Pull(userID, c.Query("since"), limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// Push handles POST /api/sync with a batch of offline changes
+func (h *SyncHandler) Push(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req struct {
+		Changes []service.Change `json:"changes" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.service.Push(userID, req.Changes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
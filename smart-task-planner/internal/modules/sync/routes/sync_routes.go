@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"smart-task-planner/internal/middleware"
+	"smart-task-planner/internal/modules/sync/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterSyncRoutes(router *gin.Engine, handler *handlers.SyncHandler) {
+	api := router.Group("/api/sync")
+	api.Use(middleware.JWTAuth())
+	{
+		// Changes since a cursor (no cursor = full download)
+		api.GET("", handler.Pull)
+
+		// Batched offline changes with per-field conflict resolution
+		api.POST("", handler.Push)
+	}
+}
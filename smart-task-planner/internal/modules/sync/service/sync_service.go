@@ -0,0 +1,758 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"smart-task-planner/internal/events"
+	fieldsRepository "smart-task-planner/internal/modules/fields/repository"
+	"smart-task-planner/internal/modules/plan/attributes"
+	"smart-task-planner/internal/modules/plan/models"
+	"smart-task-planner/internal/modules/plan/people"
+	"smart-task-planner/internal/modules/plan/recurrence"
+	"smart-task-planner/internal/modules/plan/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultPullLimit = 200
+	maxPullLimit     = 1000
+	maxPushChanges   = 500
+
+	// a version is only handed out as a cursor once every write that could
+	// have taken a lower version has finished (writes time out after 5s)
+	settleWindow = 5 * time.Second
+)
+
+type SyncService struct {
+	Repo *repository.PlanRepository
+}
+
+func NewSyncService(repo *repository.PlanRepository) *SyncService {
+	return &SyncService{Repo: repo}
+}
+
+// TaskChange is a created or updated task, flattened out of its plan
+type TaskChange struct {
+	PlanID   string       `json:"plan_id"`
+	ParentID string       `json:"parent_id,omitempty"`
+	Task     *models.Task `json:"task"`
+}
+
+type PlanChanges struct {
+	Created []models.Plan      `json:"created"`
+	Updated []models.Plan      `json:"updated"`
+	Deleted []models.Tombstone `json:"deleted"`
+}
+
+type TaskChanges struct {
+	Created []TaskChange       `json:"created"`
+	Updated []TaskChange       `json:"updated"`
+	Deleted []models.Tombstone `json:"deleted"`
+}
+
+// PullResult is everything that changed after a cursor. Feed Cursor back as
+// since to continue; HasMore means another call will return more.
+type PullResult struct {
+	Cursor  string      `json:"cursor"`
+	HasMore bool        `json:"has_more"`
+	Full    bool        `json:"full"`
+	Plans   PlanChanges `json:"plans"`
+	Tasks   TaskChanges `json:"tasks"`
+}
+
+// Pull returns changes after since; an empty since means a full download.
+// Changes younger than the settle window are returned but the cursor stops
+// before them, so the next pull repeats them instead of risking a gap.
+func (s *SyncService) Pull(userID, since string, limit int) (*PullResult, error) {
+	from, err := ParseCursor(since)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultPullLimit
+	}
+	if limit > maxPullLimit {
+		limit = maxPullLimit
+	}
+
+	plans, err := s.Repo.ChangedSince(userID, from, int64(limit)+1)
+	if err != nil {
+		return nil, err
+	}
+	var tombstones []models.Tombstone
+	if from > 0 {
+		if tombstones, err = s.Repo.TombstonesSince(userID, from, int64(limit)+1); err != nil {
+			return nil, err
+		}
+	}
+	return collect(userID, from, limit, plans, tombstones, time.Now()), nil
+}
+
+// collect turns the plans and tombstones after from into a PullResult.
+// Plans the user was only just granted count as created, so a client that
+// dropped them when access was lost gets them back whole.
+func collect(userID string, from int64, limit int, plans []models.Plan, tombstones []models.Tombstone, now time.Time) *PullResult {
+	// merge both streams in version order and cut at limit
+	type change struct {
+		version int64
+		at      time.Time
+		plan    *models.Plan
+		tomb    *models.Tombstone
+	}
+	var merged []change
+	pi, ti := 0, 0
+	for len(merged) < limit && (pi < len(plans) || ti < len(tombstones)) {
+		if ti >= len(tombstones) || (pi < len(plans) && plans[pi].Version <= tombstones[ti].Version) {
+			merged = append(merged, change{plans[pi].Version, plans[pi].UpdatedAt, &plans[pi], nil})
+			pi++
+		} else {
+			merged = append(merged, change{tombstones[ti].Version, tombstones[ti].DeletedAt, nil, &tombstones[ti]})
+			ti++
+		}
+	}
+
+	res := &PullResult{
+		HasMore: pi < len(plans) || ti < len(tombstones),
+		Full:    from == 0,
+		Plans:   PlanChanges{Created: []models.Plan{}, Updated: []models.Plan{}, Deleted: []models.Tombstone{}},
+		Tasks:   TaskChanges{Created: []TaskChange{}, Updated: []TaskChange{}, Deleted: []models.Tombstone{}},
+	}
+
+	cursor, settled := from, true
+	cutoff := now.Add(-settleWindow)
+	for _, c := range merged {
+		if settled && c.at.After(cutoff) {
+			settled = false
+		}
+		if settled {
+			cursor = c.version
+		}
+
+		if c.tomb != nil {
+			if c.tomb.Kind == repository.TombstonePlan {
+				res.Plans.Deleted = append(res.Plans.Deleted, *c.tomb)
+			} else {
+				res.Tasks.Deleted = append(res.Tasks.Deleted, *c.tomb)
+			}
+			continue
+		}
+
+		p := c.plan
+		if from == 0 || p.CreatedVersion > from || p.FieldVersions[repository.GrantKey(userID)] > from {
+			res.Plans.Created = append(res.Plans.Created, *p)
+			continue
+		}
+		res.Plans.Updated = append(res.Plans.Updated, *p)
+		created, updated := changedTasks(p, from)
+		res.Tasks.Created = append(res.Tasks.Created, created...)
+		res.Tasks.Updated = append(res.Tasks.Updated, updated...)
+	}
+	res.Cursor = FormatCursor(cursor)
+	return res
+}
+
+// changedTasks lists tasks of an updated plan that are new or changed after
+// version. Subtasks are reported on their own, so SubTasks is left out.
+func changedTasks(p *models.Plan, version int64) ([]TaskChange, []TaskChange) {
+	var created, updated []TaskChange
+	for id, ft := range repository.Flatten(p.Tasks) {
+		t := *ft.Task
+		t.SubTasks = nil
+		change := TaskChange{PlanID: p.ID.Hex(), ParentID: ft.ParentID, Task: &t}
+
+		if p.FieldVersions[repository.FieldKey(id, "")] > version {
+			created = append(created, change)
+			continue
+		}
+		for _, f := range repository.TaskFields {
+			if p.FieldVersions[repository.FieldKey(id, f)] > version {
+				updated = append(updated, change)
+				break
+			}
+		}
+	}
+	return created, updated
+}
+
+// Change is one offline edit pushed by a client. Plans and tasks created in
+// the same batch can be referred to by their client_id in later changes.
+type Change struct {
+	Op          string                     `json:"op"`
+	ClientID    string                     `json:"client_id,omitempty"`
+	PlanID      string                     `json:"plan_id,omitempty"`
+	TaskID      string                     `json:"task_id,omitempty"`
+	ParentID    string                     `json:"parent_id,omitempty"`
+	BaseVersion int64                      `json:"base_version"`
+	Goal        string                     `json:"goal,omitempty"`
+	Task        *models.Task               `json:"task,omitempty"`
+	Fields      map[string]json.RawMessage `json:"fields,omitempty"`
+}
+
+// FieldConflict is a field the client changed that was also changed on the
+// server after base_version. The server value is kept.
+type FieldConflict struct {
+	Field       string          `json:"field"`
+	ServerValue interface{}     `json:"server_value"`
+	ClientValue json.RawMessage `json:"client_value,omitempty"`
+}
+
+const (
+	ResultApplied  = "applied"
+	ResultPartial  = "partial"
+	ResultConflict = "conflict"
+	ResultError    = "error"
+)
+
+type ChangeResult struct {
+	Index     int             `json:"index"`
+	Op        string          `json:"op"`
+	Status    string          `json:"status"`
+	ClientID  string          `json:"client_id,omitempty"`
+	PlanID    string          `json:"plan_id,omitempty"`
+	TaskID    string          `json:"task_id,omitempty"`
+	Version   int64           `json:"version,omitempty"`
+	Applied   []string        `json:"applied,omitempty"`
+	Conflicts []FieldConflict `json:"conflicts,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// PushResult reports each change and the resulting state of touched plans
+type PushResult struct {
+	Results []ChangeResult `json:"results"`
+	Plans   []models.Plan  `json:"plans"`
+	Deleted []string       `json:"deleted_plans"`
+}
+
+// Push applies a batch of offline changes in order. Each change stands on
+// its own: a conflict or error in one doesn't stop the rest.
+func (s *SyncService) Push(userID string, changes []Change) (*PushResult, error) {
+	if len(changes) == 0 {
+		return nil, fmt.Errorf("changes required")
+	}
+	if len(changes) > maxPushChanges {
+		return nil, fmt.Errorf("at most %d changes per push", maxPushChanges)
+	}
+
+	ids := map[string]string{} // client_id -> server ID
+	resolve := func(id string) string {
+		if v, ok := ids[id]; ok {
+			return v
+		}
+		return id
+	}
+
+	touched := map[string]*models.Plan{}
+	var order []string
+	keep := func(p *models.Plan) {
+		if _, ok := touched[p.ID.Hex()]; !ok {
+			order = append(order, p.ID.Hex())
+		}
+		touched[p.ID.Hex()] = p
+	}
+
+	res := &PushResult{Results: []ChangeResult{}, Plans: []models.Plan{}, Deleted: []string{}}
+	for i, ch := range changes {
+		ch.PlanID, ch.TaskID, ch.ParentID = resolve(ch.PlanID), resolve(ch.TaskID), resolve(ch.ParentID)
+		r := ChangeResult{Index: i, Op: ch.Op, ClientID: ch.ClientID, PlanID: ch.PlanID, TaskID: ch.TaskID}
+
+		plan, err := s.apply(userID, ch, &r)
+		if err != nil {
+			r.Status, r.Error = ResultError, err.Error()
+		}
+		if plan != nil {
+			r.Version = plan.Version
+			keep(plan)
+		}
+		if ch.ClientID != "" && r.Status != ResultError {
+			switch ch.Op {
+			case "create_plan":
+				ids[ch.ClientID] = r.PlanID
+			case "create_task":
+				ids[ch.ClientID] = r.TaskID
+			}
+		}
+		if ch.Op == "delete_plan" && r.Status == ResultApplied {
+			delete(touched, ch.PlanID)
+			res.Deleted = append(res.Deleted, ch.PlanID)
+		}
+		res.Results = append(res.Results, r)
+	}
+
+	for _, id := range order {
+		if p, ok := touched[id]; ok {
+			res.Plans = append(res.Plans, *p)
+		}
+	}
+	return res, nil
+}
+
+func (s *SyncService) apply(userID string, ch Change, r *ChangeResult) (*models.Plan, error) {
+	switch ch.Op {
+	case "create_plan":
+		return s.createPlan(userID, ch, r)
+	case "update_plan":
+		return s.updatePlan(userID, ch, r)
+	case "delete_plan":
+		return nil, s.deletePlan(userID, ch, r)
+	case "create_task":
+		return s.createTask(userID, ch, r)
+	case "update_task":
+		return s.updateTask(userID, ch, r)
+	case "delete_task":
+		return s.deleteTask(userID, ch, r)
+	}
+	return nil, fmt.Errorf("unknown op %q", ch.Op)
+}
+
+func (s *SyncService) createPlan(userID string, ch Change, r *ChangeResult) (*models.Plan, error) {
+	if strings.TrimSpace(ch.Goal) == "" {
+		return nil, fmt.Errorf("goal required")
+	}
+	plan := &models.Plan{UserID: userID, Goal: ch.Goal, Tasks: []models.Task{}}
+	if err := s.Repo.Create(plan); err != nil {
+		return nil, err
+	}
+	r.Status, r.PlanID = ResultApplied, plan.ID.Hex()
+	return plan, nil
+}
+
+func (s *SyncService) updatePlan(userID string, ch Change, r *ChangeResult) (*models.Plan, error) {
+	filter, err := s.planFilter(userID, ch.PlanID)
+	if err != nil {
+		return nil, err
+	}
+	for field := range ch.Fields {
+		if field != "goal" {
+			return nil, fmt.Errorf("unknown plan field %q (goal)", field)
+		}
+	}
+
+	plan, err := s.Repo.Mutate(filter, func(plan *models.Plan) error {
+		r.Applied, r.Conflicts = nil, nil
+		raw, ok := ch.Fields["goal"]
+		if !ok {
+			return nil
+		}
+		var goal string
+		if err := json.Unmarshal(raw, &goal); err != nil || strings.TrimSpace(goal) == "" {
+			return fmt.Errorf("goal must be a non-empty string")
+		}
+		if repository.FieldVersion(plan, "goal") > ch.BaseVersion && plan.Goal != goal {
+			r.Conflicts = append(r.Conflicts, FieldConflict{Field: "goal", ServerValue: plan.Goal, ClientValue: raw})
+			return nil
+		}
+		plan.Goal = goal
+		r.Applied = append(r.Applied, "goal")
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.Status = outcome(r)
+	return plan, nil
+}
+
+func (s *SyncService) deletePlan(userID string, ch Change, r *ChangeResult) error {
+	plan, err := s.Repo.GetByIDForUser(ch.PlanID, userID)
+	if err != nil {
+		// deleting twice is fine
+		if t, _ := s.Repo.FindTombstone(userID, ch.PlanID, ""); t != nil {
+			r.Status = ResultApplied
+			return nil
+		}
+		return err
+	}
+	if plan.Version > ch.BaseVersion {
+		r.Status = ResultConflict
+		r.Version = plan.Version
+		r.Conflicts = []FieldConflict{{Field: "plan", ServerValue: fmt.Sprintf("changed at version %d", plan.Version)}}
+		return nil
+	}
+	if err := s.Repo.Delete(userID, ch.PlanID); err != nil {
+		return err
+	}
+	r.Status = ResultApplied
+	return nil
+}
+
+func (s *SyncService) createTask(userID string, ch Change, r *ChangeResult) (*models.Plan, error) {
+	filter, err := s.planFilter(userID, ch.PlanID)
+	if err != nil {
+		return nil, err
+	}
+	if ch.Task == nil || strings.TrimSpace(ch.Task.Title) == "" {
+		return nil, fmt.Errorf("task with a title required")
+	}
+
+	task := *ch.Task
+	task.ID = primitive.NewObjectID()
+	task.SubTasks, task.DependsOn, task.Embedding = nil, nil, nil
+	task.SeriesID, task.Occurrence = primitive.NilObjectID, 0
+	// tracked time and calibration are the server's to fill in
+	task.ActualHours, task.Estimate = 0, nil
+	if task.EstimatedHours < 0 {
+		return nil, fmt.Errorf("estimated_hours must be a non-negative number")
+	}
+	if task.Recurrence != nil {
+		if err := recurrence.Validate(task.Recurrence); err != nil {
+			return nil, err
+		}
+	}
+	if task.Priority, err = attributes.NormalizePriority(task.Priority); err != nil {
+		return nil, err
+	}
+	task.Tags = attributes.NormalizeTags(task.Tags)
+	task.Contexts = attributes.NormalizeContexts(task.Contexts)
+	if values := task.Fields; len(values) > 0 {
+		defs, err := fieldsRepository.NewFieldRepository(s.Repo.Collection.Database()).ByKey(userID)
+		if err != nil {
+			return nil, err
+		}
+		task.Fields = nil
+		if err := attributes.SetFields(&task, values, defs); err != nil {
+			return nil, err
+		}
+	}
+	if task.Status == "" {
+		task.Status = "Pending"
+	} else if task.Status, err = attributes.NormalizeStatus(task.Status); err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(task.Status, "Completed") && task.CompletedAt == nil {
+		now := time.Now()
+		task.CompletedAt = &now
+	}
+
+	plan, err := s.Repo.Mutate(filter, func(plan *models.Plan) error {
+		if err := s.checkAssignee(plan, task.AssigneeID); err != nil {
+			return err
+		}
+		if ch.ParentID == "" {
+			plan.Tasks = append(plan.Tasks, task)
+			return nil
+		}
+		parent, ok := repository.Flatten(plan.Tasks)[ch.ParentID]
+		if !ok {
+			return fmt.Errorf("parent task not found in plan")
+		}
+		parent.Task.SubTasks = append(parent.Task.SubTasks, task)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.Status, r.TaskID = ResultApplied, task.ID.Hex()
+	return plan, nil
+}
+
+func (s *SyncService) updateTask(userID string, ch Change, r *ChangeResult) (*models.Plan, error) {
+	filter, err := s.planFilter(userID, ch.PlanID)
+	if err != nil {
+		return nil, err
+	}
+	if len(ch.Fields) == 0 {
+		return nil, fmt.Errorf("fields required")
+	}
+
+	var oldStatus, newStatus, title string
+	plan, err := s.Repo.Mutate(filter, func(plan *models.Plan) error {
+		r.Applied, r.Conflicts = nil, nil
+		ft, ok := repository.Flatten(plan.Tasks)[ch.TaskID]
+		if !ok {
+			return fmt.Errorf("task not found in plan")
+		}
+		t := ft.Task
+		oldStatus, title = t.Status, t.Title
+
+		for _, field := range fieldNames(ch.Fields) {
+			raw := ch.Fields[field]
+			value, err := decodeTaskField(field, raw)
+			if err != nil {
+				return err
+			}
+			if field == "assignee_id" {
+				if err := s.checkAssignee(plan, value.(string)); err != nil {
+					return err
+				}
+			}
+			current := taskFieldValue(t, field)
+			// the server changed it too: keep the server value unless both agree
+			if repository.FieldVersion(plan, repository.FieldKey(ch.TaskID, field)) > ch.BaseVersion && !equalJSON(current, value) {
+				r.Conflicts = append(r.Conflicts, FieldConflict{Field: field, ServerValue: current, ClientValue: raw})
+				continue
+			}
+			setTaskField(t, field, value)
+			r.Applied = append(r.Applied, field)
+		}
+		newStatus = t.Status
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.Status = outcome(r)
+
+	if oldStatus != newStatus {
+		events.Publish(events.Event{
+			Type:   events.TaskStatusChanged,
+			UserID: userID,
+			PlanID: plan.ID.Hex(),
+			Data: map[string]interface{}{
+				"plan_id":    plan.ID.Hex(),
+				"goal":       plan.Goal,
+				"task_id":    ch.TaskID,
+				"title":      title,
+				"old_status": oldStatus,
+				"new_status": newStatus,
+			},
+		})
+	}
+	return plan, nil
+}
+
+func (s *SyncService) deleteTask(userID string, ch Change, r *ChangeResult) (*models.Plan, error) {
+	filter, err := s.planFilter(userID, ch.PlanID)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := s.Repo.Mutate(filter, func(plan *models.Plan) error {
+		r.Conflicts = nil
+		if _, ok := repository.Flatten(plan.Tasks)[ch.TaskID]; !ok {
+			return nil
+		}
+		for _, f := range append([]string{""}, repository.TaskFields...) {
+			if v := repository.FieldVersion(plan, repository.FieldKey(ch.TaskID, f)); v > ch.BaseVersion {
+				name := f
+				if name == "" {
+					name = "task"
+				}
+				r.Conflicts = append(r.Conflicts, FieldConflict{Field: name, ServerValue: fmt.Sprintf("changed at version %d", v)})
+			}
+		}
+		if len(r.Conflicts) > 0 {
+			return nil
+		}
+		plan.Tasks = removeTask(plan.Tasks, ch.TaskID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(r.Conflicts) > 0 {
+		r.Status = ResultConflict
+	} else {
+		// a task that's already gone counts as deleted
+		r.Status = ResultApplied
+	}
+	return plan, nil
+}
+
+// checkAssignee makes sure a task is only assigned to someone who can edit
+// its plan
+func (s *SyncService) checkAssignee(plan *models.Plan, assigneeID string) error {
+	if assigneeID == "" {
+		return nil
+	}
+	assignable, err := people.Assignable(plan, s.Repo)
+	if err != nil {
+		return err
+	}
+	for _, p := range assignable {
+		if p.UserID == assigneeID {
+			return nil
+		}
+	}
+	return fmt.Errorf("the assignee must be able to edit the plan")
+}
+
+// decodeTaskField validates a pushed value for one of the editable task fields
+func decodeTaskField(field string, raw json.RawMessage) (interface{}, error) {
+	switch field {
+	case "title", "description", "status", "assignee_id":
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, fmt.Errorf("%s must be a string", field)
+		}
+		if field == "title" && strings.TrimSpace(v) == "" {
+			return nil, fmt.Errorf("title can't be empty")
+		}
+		if field == "status" {
+			return attributes.NormalizeStatus(v)
+		}
+		return v, nil
+	case "deadline":
+		var v time.Time
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, fmt.Errorf("deadline must be an RFC 3339 time")
+		}
+		return v, nil
+	case "estimated_hours":
+		var v float64
+		if err := json.Unmarshal(raw, &v); err != nil || v < 0 {
+			return nil, fmt.Errorf("estimated_hours must be a non-negative number")
+		}
+		return v, nil
+	case "tags":
+		var v []string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, fmt.Errorf("tags must be a list of strings")
+		}
+		return attributes.NormalizeTags(v), nil
+	case "priority":
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, fmt.Errorf("priority must be a string")
+		}
+		return attributes.NormalizePriority(v)
+	case "contexts":
+		var v []string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, fmt.Errorf("contexts must be a list of strings")
+		}
+		return attributes.NormalizeContexts(v), nil
+	case "recurrence":
+		// an RRULE string; null or "" ends the series
+		var v *string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, fmt.Errorf("recurrence must be an RRULE string")
+		}
+		if v == nil || strings.TrimSpace(*v) == "" {
+			return (*models.Recurrence)(nil), nil
+		}
+		return recurrence.Parse(*v)
+	}
+	return nil, fmt.Errorf("unknown task field %q (title, description, status, deadline, estimated_hours, tags, priority, contexts, recurrence, assignee_id)", field)
+}
+
+func taskFieldValue(t *models.Task, field string) interface{} {
+	switch field {
+	case "title":
+		return t.Title
+	case "description":
+		return t.Description
+	case "status":
+		return t.Status
+	case "deadline":
+		return t.Deadline
+	case "estimated_hours":
+		return t.EstimatedHours
+	case "tags":
+		return t.Tags
+	case "priority":
+		return t.Priority
+	case "contexts":
+		return t.Contexts
+	case "recurrence":
+		return t.Recurrence
+	case "assignee_id":
+		return t.AssigneeID
+	}
+	return nil
+}
+
+// setTaskField stores a value from decodeTaskField
+func setTaskField(t *models.Task, field string, value interface{}) {
+	switch field {
+	case "title":
+		t.Title = value.(string)
+	case "description":
+		t.Description = value.(string)
+	case "status":
+		t.Status = value.(string)
+		if !strings.EqualFold(t.Status, "Completed") {
+			t.CompletedAt = nil
+		} else if t.CompletedAt == nil {
+			now := time.Now()
+			t.CompletedAt = &now
+		}
+	case "deadline":
+		t.Deadline = value.(time.Time)
+	case "estimated_hours":
+		t.EstimatedHours = value.(float64)
+	case "tags":
+		t.Tags = value.([]string)
+	case "priority":
+		t.Priority = value.(string)
+	case "contexts":
+		t.Contexts = value.([]string)
+	case "recurrence":
+		t.Recurrence = value.(*models.Recurrence)
+	case "assignee_id":
+		t.AssigneeID = value.(string)
+	}
+}
+
+func removeTask(tasks []models.Task, id string) []models.Task {
+	out := tasks[:0]
+	for _, t := range tasks {
+		if t.ID.Hex() == id {
+			continue
+		}
+		t.SubTasks = removeTask(t.SubTasks, id)
+		out = append(out, t)
+	}
+	return out
+}
+
+func outcome(r *ChangeResult) string {
+	switch {
+	case len(r.Conflicts) == 0:
+		return ResultApplied
+	case len(r.Applied) == 0:
+		return ResultConflict
+	}
+	return ResultPartial
+}
+
+func fieldNames(fields map[string]json.RawMessage) []string {
+	names := make([]string, 0, len(fields))
+	for f := range fields {
+		names = append(names, f)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func equalJSON(a, b interface{}) bool {
+	if x, ok := a.(time.Time); ok {
+		if y, ok := b.(time.Time); ok {
+			return x.Equal(y)
+		}
+	}
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return string(x) == string(y)
+}
+
+// planFilter selects a plan the user may change; viewers get ErrReadOnly
+func (s *SyncService) planFilter(userID, planID string) (bson.M, error) {
+	plan, err := s.Repo.GetByIDForRole(planID, userID, models.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	return bson.M{"_id": plan.ID}, nil
+}
+
+// ParseCursor reads a cursor returned by Pull; "" starts from scratch
+func ParseCursor(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid cursor %q", s)
+	}
+	return v, nil
+}
+
+func FormatCursor(v int64) string {
+	return strconv.FormatInt(v, 10)
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"smart-task-planner/internal/modules/plan/models"
+	"smart-task-planner/internal/modules/plan/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCollectWorkspaceMembership(t *testing.T) {
+	now := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	task := models.Task{ID: primitive.NewObjectID(), Title: "Draft", Status: "Pending"}
+	plan := models.Plan{
+		ID: primitive.NewObjectID(), Goal: "Launch", WorkspaceID: "ws", Tasks: []models.Task{task},
+		Version: 3, CreatedVersion: 3, UpdatedAt: now.Add(-time.Hour),
+		FieldVersions: map[string]int64{},
+	}
+	// grant stands in for PlanRepository.GrantWorkspace
+	grant := func(userID string, version int64) {
+		plan.Version = version
+		plan.FieldVersions[repository.GrantKey(userID)] = version
+	}
+	pull := func(userID string, from int64, plans []models.Plan, tombstones []models.Tombstone) *PullResult {
+		return collect(userID, from, defaultPullLimit, plans, tombstones, now)
+	}
+
+	// ann has synced up to 4 before joining
+	grant("ann", 5)
+	res := pull("ann", 4, []models.Plan{plan}, nil)
+	if len(res.Plans.Created) != 1 || len(res.Plans.Updated) != 0 || res.Cursor != "5" {
+		t.Fatalf("join: %+v", res)
+	}
+	// bob was already a member, so the grant is only an update to him
+	res = pull("bob", 4, []models.Plan{plan}, nil)
+	if len(res.Plans.Updated) != 1 || len(res.Tasks.Updated) != 0 {
+		t.Errorf("other member: %+v", res)
+	}
+
+	// leaving leaves a tombstone and the plan is no longer visible
+	left := models.Tombstone{UserID: "ann", Kind: repository.TombstonePlan, PlanID: plan.ID.Hex(), Version: 6, DeletedAt: now.Add(-time.Hour)}
+	res = pull("ann", 5, nil, []models.Tombstone{left})
+	if len(res.Plans.Deleted) != 1 || res.Cursor != "6" {
+		t.Fatalf("leave: %+v", res)
+	}
+
+	// an edit she missed, then the re-join: the plan must come back whole
+	plan.Tasks[0].Title = "Draft the post"
+	plan.FieldVersions[repository.FieldKey(task.ID.Hex(), "title")] = 7
+	grant("ann", 8)
+	res = pull("ann", 6, []models.Plan{plan}, nil)
+	if len(res.Plans.Created) != 1 || len(res.Plans.Updated) != 0 || res.Plans.Created[0].Tasks[0].Title != "Draft the post" {
+		t.Fatalf("re-join: %+v", res)
+	}
+
+	// later changes are plain updates again
+	plan.Version = 9
+	plan.Tasks[0].Status = "Completed"
+	plan.FieldVersions[repository.FieldKey(task.ID.Hex(), "status")] = 9
+	res = pull("ann", 8, []models.Plan{plan}, nil)
+	if len(res.Plans.Updated) != 1 || len(res.Tasks.Updated) != 1 {
+		t.Errorf("after re-join: %+v", res)
+	}
+}
+
+func TestCollectSettleWindow(t *testing.T) {
+	now := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	plans := []models.Plan{
+		{ID: primitive.NewObjectID(), Version: 4, CreatedVersion: 4, UpdatedAt: now.Add(-time.Minute)},
+		{ID: primitive.NewObjectID(), Version: 5, CreatedVersion: 5, UpdatedAt: now.Add(-time.Second)},
+	}
+	res := collect("ann", 0, defaultPullLimit, plans, nil, now)
+	if len(res.Plans.Created) != 2 || res.Cursor != "4" {
+		t.Errorf("cursor %s with %d plans, want 4 and 2", res.Cursor, len(res.Plans.Created))
+	}
+}
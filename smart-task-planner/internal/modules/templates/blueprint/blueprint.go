@@ -0,0 +1,310 @@
+package blueprint
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"smart-task-planner/internal/modules/calendar/busytime"
+	"smart-task-planner/internal/modules/plan/attributes"
+	planModels "smart-task-planner/internal/modules/plan/models"
+	"smart-task-planner/internal/modules/templates/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// VarStartDate is filled in from the start date of every instantiation
+const VarStartDate = "start_date"
+
+// MaxOffsetDays is the latest deadline a template task can have, about five
+// years of working days after the start
+const MaxOffsetDays = 1300
+
+const dayLayout = "2006-01-02"
+
+var (
+	placeholderRe = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+	variableRe    = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// ValidVariable reports whether name can be used as a {{placeholder}}
+func ValidVariable(name string) bool {
+	return variableRe.MatchString(name)
+}
+
+// FromPlan turns a plan into a goal and template tasks. Deadlines become
+// working days after the day the plan was created, and every value in
+// values is replaced by its {{key}} placeholder ({"name": "Alice"} turns
+// "Welcome Alice" into "Welcome {{name}}"). The plan's start date becomes
+// {{start_date}} unless values says otherwise.
+func FromPlan(plan planModels.Plan, values map[string]string, cal *busytime.Calendar) (string, []models.TemplateTask) {
+	created := plan.CreatedAt
+	if created.IsZero() {
+		created = plan.ID.Timestamp()
+	}
+	start := cal.AddWorkdays(created, 0)
+
+	values = copyValues(values)
+	if _, ok := values[VarStartDate]; !ok {
+		values[VarStartDate] = start.Format(dayLayout)
+	}
+	parametrize := placeholders(values)
+
+	refs := map[primitive.ObjectID]string{}
+	var assign func([]planModels.Task, string)
+	assign = func(tasks []planModels.Task, prefix string) {
+		for i, t := range tasks {
+			key := prefix + strconv.Itoa(i+1)
+			refs[t.ID] = key
+			assign(t.SubTasks, key+".")
+		}
+	}
+	assign(plan.Tasks, "")
+
+	var convert func([]planModels.Task) []models.TemplateTask
+	convert = func(tasks []planModels.Task) []models.TemplateTask {
+		out := make([]models.TemplateTask, 0, len(tasks))
+		for _, t := range tasks {
+			tt := models.TemplateTask{
+				Key:            refs[t.ID],
+				Title:          parametrize(t.Title),
+				Description:    parametrize(t.Description),
+				EstimatedHours: t.EstimatedHours,
+				Priority:       t.Priority,
+				Tags:           t.Tags,
+				Contexts:       t.Contexts,
+				Fields:         t.Fields,
+				SubTasks:       convert(t.SubTasks),
+			}
+			if !t.Deadline.IsZero() {
+				offset := MaxOffsetDays
+				if t.Deadline.Before(start.AddDate(0, 0, MaxOffsetDays*2)) {
+					offset = min(max(0, cal.WorkdaysBetween(start, t.Deadline)), MaxOffsetDays)
+				}
+				tt.OffsetDays = &offset
+			}
+			for _, dep := range t.DependsOn {
+				if key, ok := refs[dep]; ok {
+					tt.DependsOn = append(tt.DependsOn, key)
+				}
+			}
+			if len(tt.SubTasks) == 0 {
+				tt.SubTasks = nil
+			}
+			out = append(out, tt)
+		}
+		return out
+	}
+	return parametrize(plan.Goal), convert(plan.Tasks)
+}
+
+// placeholders returns a function that replaces every value with its
+// {{key}} in a single pass, so a placeholder is never rewritten by a later
+// value. Longer values come first: "Alice Smith" wins over "Alice".
+func placeholders(values map[string]string) func(string) string {
+	var keys []string
+	for k, v := range values {
+		if len(strings.TrimSpace(v)) >= 2 {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return func(text string) string { return text }
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if a, b := len(values[keys[i]]), len(values[keys[j]]); a != b {
+			return a > b
+		}
+		return keys[i] < keys[j]
+	})
+	byValue := map[string]string{}
+	alts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if _, ok := byValue[values[k]]; !ok {
+			byValue[values[k]] = k
+			alts = append(alts, regexp.QuoteMeta(values[k]))
+		}
+	}
+	re := regexp.MustCompile(strings.Join(alts, "|"))
+	return func(text string) string {
+		return re.ReplaceAllStringFunc(text, func(m string) string {
+			return "{{" + byValue[m] + "}}"
+		})
+	}
+}
+
+// Normalize checks template tasks written by hand and cleans up their
+// labels. Tasks without a key get one from their position.
+func Normalize(tasks []models.TemplateTask) error {
+	if len(tasks) == 0 {
+		return fmt.Errorf("a template needs at least one task")
+	}
+	keys := map[string]bool{}
+	var walk func([]models.TemplateTask, string) error
+	walk = func(tasks []models.TemplateTask, prefix string) error {
+		for i := range tasks {
+			t := &tasks[i]
+			if strings.TrimSpace(t.Title) == "" {
+				return fmt.Errorf("every task needs a title")
+			}
+			if t.Key = strings.TrimSpace(t.Key); t.Key == "" {
+				t.Key = prefix + strconv.Itoa(i+1)
+			}
+			if keys[t.Key] {
+				return fmt.Errorf("task key %q is used twice", t.Key)
+			}
+			keys[t.Key] = true
+			if t.OffsetDays != nil && *t.OffsetDays < 0 {
+				return fmt.Errorf("offset_days of %q can't be negative", t.Title)
+			}
+			if t.OffsetDays != nil && *t.OffsetDays > MaxOffsetDays {
+				return fmt.Errorf("offset_days of %q can't be more than %d", t.Title, MaxOffsetDays)
+			}
+			if t.EstimatedHours < 0 {
+				return fmt.Errorf("estimated_hours of %q can't be negative", t.Title)
+			}
+			var err error
+			if t.Priority, err = attributes.NormalizePriority(t.Priority); err != nil {
+				return err
+			}
+			t.Tags = attributes.NormalizeTags(t.Tags)
+			t.Contexts = attributes.NormalizeContexts(t.Contexts)
+			if err := walk(t.SubTasks, t.Key+"."); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(tasks, ""); err != nil {
+		return err
+	}
+
+	graph := map[string][]string{}
+	var order []string
+	var deps func([]models.TemplateTask) error
+	deps = func(tasks []models.TemplateTask) error {
+		for _, t := range tasks {
+			for _, d := range t.DependsOn {
+				if !keys[d] || d == t.Key {
+					return fmt.Errorf("task %q depends on unknown task %q", t.Key, d)
+				}
+			}
+			graph[t.Key] = t.DependsOn
+			order = append(order, t.Key)
+			if err := deps(t.SubTasks); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := deps(tasks); err != nil {
+		return err
+	}
+	for _, key := range order {
+		if planModels.DependencyCycle(graph, key) {
+			return fmt.Errorf("dependencies of task %q form a cycle", key)
+		}
+	}
+	return nil
+}
+
+// Variables lists the placeholders of a goal and its tasks in the order
+// they first appear
+func Variables(goal string, tasks []models.TemplateTask) []string {
+	vars := []string{}
+	seen := map[string]bool{}
+	collect := func(text string) {
+		for _, m := range placeholderRe.FindAllStringSubmatch(text, -1) {
+			if !seen[m[1]] {
+				seen[m[1]] = true
+				vars = append(vars, m[1])
+			}
+		}
+	}
+	collect(goal)
+	var walk func([]models.TemplateTask)
+	walk = func(tasks []models.TemplateTask) {
+		for _, t := range tasks {
+			collect(t.Title)
+			collect(t.Description)
+			walk(t.SubTasks)
+		}
+	}
+	walk(tasks)
+	return vars
+}
+
+// Instantiate fills in a template: placeholders from values, and deadlines
+// counted in working days from the first working day on or after start,
+// which is also {{start_date}}. Every placeholder needs a value.
+func Instantiate(t models.Template, start time.Time, values map[string]string, cal *busytime.Calendar) (string, []planModels.Task, error) {
+	start = cal.AddWorkdays(start, 0)
+	values = copyValues(values)
+	values[VarStartDate] = start.Format(dayLayout)
+
+	var missing []string
+	for _, v := range Variables(t.Goal, t.Tasks) {
+		if strings.TrimSpace(values[v]) == "" {
+			missing = append(missing, v)
+		}
+	}
+	if len(missing) > 0 {
+		return "", nil, fmt.Errorf("missing values for %s", strings.Join(missing, ", "))
+	}
+	render := func(text string) string {
+		return placeholderRe.ReplaceAllStringFunc(text, func(m string) string {
+			return values[placeholderRe.FindStringSubmatch(m)[1]]
+		})
+	}
+
+	ids := map[string]primitive.ObjectID{}
+	var assign func([]models.TemplateTask)
+	assign = func(tasks []models.TemplateTask) {
+		for _, tt := range tasks {
+			ids[tt.Key] = primitive.NewObjectID()
+			assign(tt.SubTasks)
+		}
+	}
+	assign(t.Tasks)
+
+	var build func([]models.TemplateTask) []planModels.Task
+	build = func(tasks []models.TemplateTask) []planModels.Task {
+		out := make([]planModels.Task, 0, len(tasks))
+		for _, tt := range tasks {
+			task := planModels.Task{
+				ID:             ids[tt.Key],
+				Title:          render(tt.Title),
+				Description:    render(tt.Description),
+				Status:         "Pending",
+				EstimatedHours: tt.EstimatedHours,
+				Priority:       tt.Priority,
+				Tags:           tt.Tags,
+				Contexts:       tt.Contexts,
+				Fields:         tt.Fields,
+				SubTasks:       build(tt.SubTasks),
+			}
+			if tt.OffsetDays != nil {
+				task.Deadline = cal.AddWorkdays(start, min(*tt.OffsetDays, MaxOffsetDays))
+			}
+			for _, key := range tt.DependsOn {
+				if id, ok := ids[key]; ok {
+					task.DependsOn = append(task.DependsOn, id)
+				}
+			}
+			out = append(out, task)
+		}
+		return out
+	}
+	return render(t.Goal), build(t.Tasks), nil
+}
+
+func copyValues(values map[string]string) map[string]string {
+	out := make(map[string]string, len(values)+1)
+	for k, v := range values {
+		out[k] = v
+	}
+	return out
+}
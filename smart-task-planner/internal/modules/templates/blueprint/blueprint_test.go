@@ -0,0 +1,145 @@
+package blueprint
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"smart-task-planner/internal/modules/calendar/busytime"
+	planModels "smart-task-planner/internal/modules/plan/models"
+	"smart-task-planner/internal/modules/templates/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func offset(n int) *int { return &n }
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		tasks []models.TemplateTask
+		err   string // substring of the expected error, "" for none
+	}{
+		{
+			name: "valid with dependencies",
+			tasks: []models.TemplateTask{
+				{Key: "spec", Title: "Spec", OffsetDays: offset(0)},
+				{Title: "Build", DependsOn: []string{"spec"}, SubTasks: []models.TemplateTask{{Title: "Test", DependsOn: []string{"spec"}}}},
+			},
+		},
+		{name: "no tasks", err: "at least one task"},
+		{name: "missing title", tasks: []models.TemplateTask{{Key: "a"}}, err: "needs a title"},
+		{
+			name:  "duplicate key",
+			tasks: []models.TemplateTask{{Key: "a", Title: "A"}, {Key: "a", Title: "B"}},
+			err:   "used twice",
+		},
+		{name: "negative offset", tasks: []models.TemplateTask{{Title: "A", OffsetDays: offset(-1)}}, err: "negative"},
+		{name: "offset too far", tasks: []models.TemplateTask{{Title: "A", OffsetDays: offset(MaxOffsetDays + 1)}}, err: "more than"},
+		{name: "unknown dependency", tasks: []models.TemplateTask{{Title: "A", DependsOn: []string{"x"}}}, err: "unknown task"},
+		{name: "self dependency", tasks: []models.TemplateTask{{Key: "a", Title: "A", DependsOn: []string{"a"}}}, err: "unknown task"},
+		{
+			name: "cycle",
+			tasks: []models.TemplateTask{
+				{Key: "a", Title: "A", DependsOn: []string{"c"}},
+				{Key: "b", Title: "B", DependsOn: []string{"a"}},
+				{Key: "c", Title: "C", DependsOn: []string{"b"}},
+			},
+			err: "cycle",
+		},
+		{
+			name: "cycle through a subtask",
+			tasks: []models.TemplateTask{
+				{Key: "a", Title: "A", DependsOn: []string{"a.1"}, SubTasks: []models.TemplateTask{{Title: "A1", DependsOn: []string{"a"}}}},
+			},
+			err: "cycle",
+		},
+	}
+	for _, tt := range tests {
+		err := Normalize(tt.tasks)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%s: error %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestNormalizeAssignsKeys(t *testing.T) {
+	tasks := []models.TemplateTask{{Title: "A", SubTasks: []models.TemplateTask{{Title: "A1"}}}, {Title: "B"}}
+	if err := Normalize(tasks); err != nil {
+		t.Fatal(err)
+	}
+	if tasks[0].Key != "1" || tasks[0].SubTasks[0].Key != "1.1" || tasks[1].Key != "2" {
+		t.Errorf("keys %q %q %q", tasks[0].Key, tasks[0].SubTasks[0].Key, tasks[1].Key)
+	}
+}
+
+func TestPlaceholders(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]string
+		in     string
+		want   string
+	}{
+		{"longest first", map[string]string{"name": "Alice", "full": "Alice Smith"}, "Alice Smith and Alice", "{{full}} and {{name}}"},
+		{"placeholders are not rewritten", map[string]string{"a": "team", "b": "{{a}}"}, "team", "{{a}}"},
+		{"value inside another key's name", map[string]string{"team": "Ops", "who": "team"}, "Ops team", "{{team}} {{who}}"},
+		{"special characters", map[string]string{"v": "v1.2 (beta)"}, "Ship v1.2 (beta)", "Ship {{v}}"},
+		{"short values ignored", map[string]string{"x": "a"}, "a cat", "a cat"},
+	}
+	for _, tt := range tests {
+		if got := placeholders(tt.values)(tt.in); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFromPlanAndInstantiate(t *testing.T) {
+	cal := busytime.New(nil, time.UTC)
+	monday := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	spec := planModels.Task{ID: primitive.NewObjectID(), Title: "Spec for Alice", Deadline: monday.AddDate(0, 0, 7)}
+	plan := planModels.Plan{
+		ID:        primitive.NewObjectID(),
+		Goal:      "Onboard Alice",
+		CreatedAt: monday,
+		Tasks: []planModels.Task{
+			spec,
+			{ID: primitive.NewObjectID(), Title: "Far away", Deadline: monday.AddDate(40, 0, 0), DependsOn: []primitive.ObjectID{spec.ID}},
+		},
+	}
+
+	goal, tasks := FromPlan(plan, map[string]string{"name": "Alice"}, cal)
+	if goal != "Onboard {{name}}" || tasks[0].Title != "Spec for {{name}}" {
+		t.Errorf("got %q / %q", goal, tasks[0].Title)
+	}
+	if *tasks[0].OffsetDays != 5 {
+		t.Errorf("offset %d, want 5", *tasks[0].OffsetDays)
+	}
+	if *tasks[1].OffsetDays != MaxOffsetDays {
+		t.Errorf("offset %d, want the cap %d", *tasks[1].OffsetDays, MaxOffsetDays)
+	}
+	if len(tasks[1].DependsOn) != 1 || tasks[1].DependsOn[0] != tasks[0].Key {
+		t.Errorf("depends on %v", tasks[1].DependsOn)
+	}
+
+	tmpl := models.Template{Goal: goal, Tasks: tasks}
+	if _, _, err := Instantiate(tmpl, monday, nil, cal); err == nil || !strings.Contains(err.Error(), "name") {
+		t.Errorf("missing value: %v", err)
+	}
+	goal, out, err := Instantiate(tmpl, monday.AddDate(0, 0, -2), map[string]string{"name": "Bob"}, cal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if goal != "Onboard Bob" || out[0].Title != "Spec for Bob" {
+		t.Errorf("got %q / %q", goal, out[0].Title)
+	}
+	// Saturday starts on Monday; five working days later is the next Monday
+	if want := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC); !out[0].Deadline.Equal(want) {
+		t.Errorf("deadline %v, want %v", out[0].Deadline, want)
+	}
+	if len(out[1].DependsOn) != 1 || out[1].DependsOn[0] != out[0].ID {
+		t.Errorf("depends on %v, want %v", out[1].DependsOn, out[0].ID)
+	}
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	planRepository "smart-task-planner/internal/modules/plan/repository"
+	"smart-task-planner/internal/modules/templates/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TemplateHandler struct {
+	service *service.TemplateService
+}
+
+func NewTemplateHandler(svc *service.TemplateService) *TemplateHandler {
+	return &TemplateHandler{service: svc}
+}
+
+// GetTemplates handles GET /api/templates?scope=mine|shared|all&q=
+func (h *TemplateHandler) GetTemplates(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	templates, err := h.service.List(userID, c.Query("scope"), c.Query("q"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"templates": templates})
+}
+
+// GetTemplate handles GET /api/templates/:id
+func (h *TemplateHandler) GetTemplate(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	t, err := h.service.Get(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, t)
+}
+
+// CreateTemplate handles POST /api/templates
+func (h *TemplateHandler) CreateTemplate(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req service.TemplateInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	t, err := h.service.Create(userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, t)
+}
+
+// CreateFromPlan handles POST /api/templates/from-plan/:plan_id
+func (h *TemplateHandler) CreateFromPlan(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req service.FromPlanInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	t, err := h.service.CreateFromPlan(userID, c.Param("plan_id"), req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, planRepository.ErrNotOwner) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, t)
+}
+
+// UpdateTemplate handles PUT /api/templates/:id
+func (h *TemplateHandler) UpdateTemplate(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req service.TemplateInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	t, err := h.service.Update(userID, c.Param("id"), req)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch err.Error() {
+		case "template not found":
+			status = http.StatusNotFound
+		case "only the owner can change a template", planRepository.ErrNotOwner.Error():
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, t)
+}
+
+// DeleteTemplate handles DELETE /api/templates/:id
+func (h *TemplateHandler) DeleteTemplate(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	if err := h.service.Delete(userID, c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Template deleted"})
+}
+
+// Instantiate handles POST /api/templates/:id/instantiate
+// ({"start_date": "2025-11-03", "variables": {"name": "Bob"}})
+func (h *TemplateHandler) Instantiate(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req struct {
+		StartDate string            `json:"start_date"`
+		Variables map[string]string `json:"variables"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	draft, err := h.service.Instantiate(userID, c.Param("id"), req.StartDate, req.Variables)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "template not found" {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, draft)
+}
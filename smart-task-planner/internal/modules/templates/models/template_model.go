@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Template is a reusable plan. Deadlines are stored as working-day offsets
+// from a start date, and the goal and task texts may hold {{placeholders}}
+// that are filled in when the template is instantiated.
+type Template struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID       string             `bson:"user_id" json:"user_id"`
+	Name         string             `bson:"name" json:"name"`
+	Description  string             `bson:"description,omitempty" json:"description,omitempty"`
+	Goal         string             `bson:"goal" json:"goal"`
+	Tasks        []TemplateTask     `bson:"tasks" json:"tasks"`
+	Variables    []string           `bson:"variables" json:"variables"` // placeholders used, in order of appearance
+	Shared       bool               `bson:"shared" json:"shared"`
+	SourcePlanID string             `bson:"source_plan_id,omitempty" json:"source_plan_id,omitempty"`
+	Uses         int                `bson:"uses" json:"uses"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// TemplateTask is a task without dates or progress. Key names it within the
+// template so DependsOn can point at other tasks.
+type TemplateTask struct {
+	Key            string                 `bson:"key" json:"key"`
+	Title          string                 `bson:"title" json:"title"`
+	Description    string                 `bson:"description,omitempty" json:"description,omitempty"`
+	OffsetDays     *int                   `bson:"offset_days,omitempty" json:"offset_days,omitempty"` // working days after the start; nil for no deadline
+	EstimatedHours float64                `bson:"estimated_hours,omitempty" json:"estimated_hours,omitempty"`
+	Priority       string                 `bson:"priority,omitempty" json:"priority,omitempty"`
+	Tags           []string               `bson:"tags,omitempty" json:"tags,omitempty"`
+	Contexts       []string               `bson:"contexts,omitempty" json:"contexts,omitempty"`
+	Fields         map[string]interface{} `bson:"fields,omitempty" json:"fields,omitempty"`
+	DependsOn      []string               `bson:"depends_on,omitempty" json:"depends_on,omitempty"` // keys
+	SubTasks       []TemplateTask         `bson:"sub_tasks,omitempty" json:"sub_tasks,omitempty"`
+}
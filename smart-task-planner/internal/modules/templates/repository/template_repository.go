@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"smart-task-planner/internal/modules/templates/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Catalogs for List
+const (
+	ScopeAll    = "all"
+	ScopeMine   = "mine"
+	ScopeShared = "shared"
+)
+
+// TemplateRepository stores plan templates
+type TemplateRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewTemplateRepository(db *mongo.Database) *TemplateRepository {
+	return &TemplateRepository{Collection: db.Collection("plan_templates")}
+}
+
+func (r *TemplateRepository) EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.Collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "updated_at", Value: -1}}},
+		{Keys: bson.D{{Key: "shared", Value: 1}, {Key: "uses", Value: -1}}},
+	})
+	return err
+}
+
+// List returns the user's own templates (mine), everyone's shared ones
+// (shared) or both (all), most used first. query filters by name.
+func (r *TemplateRepository) List(userID, scope, query string) ([]models.Template, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var filter bson.M
+	switch scope {
+	case ScopeMine:
+		filter = bson.M{"user_id": userID}
+	case ScopeShared:
+		filter = bson.M{"shared": true}
+	case ScopeAll, "":
+		filter = bson.M{"$or": bson.A{bson.M{"user_id": userID}, bson.M{"shared": true}}}
+	default:
+		return nil, fmt.Errorf("unknown scope %q (use mine, shared or all)", scope)
+	}
+	if q := strings.TrimSpace(query); q != "" {
+		filter = bson.M{"$and": bson.A{filter, bson.M{"name": primitive.Regex{Pattern: regexp.QuoteMeta(q), Options: "i"}}}}
+	}
+
+	cursor, err := r.Collection.Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "uses", Value: -1}, {Key: "updated_at", Value: -1}}).
+		SetLimit(200))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	templates := []models.Template{}
+	if err := cursor.All(ctx, &templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// Get loads a template the user owns or that is shared
+func (r *TemplateRepository) Get(userID, templateID string) (*models.Template, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(templateID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template ID: %v", err)
+	}
+
+	var t models.Template
+	filter := bson.M{"_id": objID, "$or": bson.A{bson.M{"user_id": userID}, bson.M{"shared": true}}}
+	if err := r.Collection.FindOne(ctx, filter).Decode(&t); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("template not found")
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *TemplateRepository) Insert(t *models.Template) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.ID = primitive.NewObjectID()
+	_, err := r.Collection.InsertOne(ctx, t)
+	return err
+}
+
+// Save replaces a template. Only its owner can.
+func (r *TemplateRepository) Save(t *models.Template) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := r.Collection.ReplaceOne(ctx, bson.M{"_id": t.ID, "user_id": t.UserID}, t)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("template not found")
+	}
+	return nil
+}
+
+func (r *TemplateRepository) Delete(userID, templateID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(templateID)
+	if err != nil {
+		return fmt.Errorf("invalid template ID: %v", err)
+	}
+
+	res, err := r.Collection.DeleteOne(ctx, bson.M{"_id": objID, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return fmt.Errorf("template not found")
+	}
+	return nil
+}
+
+// CountUse records that a template was instantiated, for catalog ranking
+func (r *TemplateRepository) CountUse(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.Collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$inc": bson.M{"uses": 1}})
+	return err
+}
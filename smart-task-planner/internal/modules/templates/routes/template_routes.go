@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"smart-task-planner/internal/middleware"
+	"smart-task-planner/internal/modules/templates/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterTemplateRoutes(router *gin.Engine, handler *handlers.TemplateHandler) {
+	api := router.Group("/api/templates")
+	api.Use(middleware.JWTAuth())
+	{
+		// personal and shared catalogs
+		api.GET("/", handler.GetTemplates)
+		api.POST("/", handler.CreateTemplate)
+		api.POST("/from-plan/:plan_id", handler.CreateFromPlan)
+		api.GET("/:id", handler.GetTemplate)
+		api.PUT("/:id", handler.UpdateTemplate)
+		api.DELETE("/:id", handler.DeleteTemplate)
+
+		// draft plan with concrete dates, confirm it with POST /api/plan/confirm
+		api.POST("/:id/instantiate", handler.Instantiate)
+	}
+}
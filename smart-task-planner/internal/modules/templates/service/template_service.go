@@ -0,0 +1,225 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"smart-task-planner/internal/modules/calendar/busytime"
+	calendarRepository "smart-task-planner/internal/modules/calendar/repository"
+	fieldsRepository "smart-task-planner/internal/modules/fields/repository"
+	"smart-task-planner/internal/modules/plan/attributes"
+	planModels "smart-task-planner/internal/modules/plan/models"
+	planRepository "smart-task-planner/internal/modules/plan/repository"
+	"smart-task-planner/internal/modules/templates/blueprint"
+	"smart-task-planner/internal/modules/templates/models"
+	"smart-task-planner/internal/modules/templates/repository"
+)
+
+const (
+	maxNameLength = 100
+
+	// busy time is loaded this far past the start date when dating tasks
+	calendarHorizon = 2 * 365 * 24 * time.Hour
+)
+
+type TemplateService struct {
+	Repo      *repository.TemplateRepository
+	PlanRepo  *planRepository.PlanRepository
+	BusyRepo  *calendarRepository.BusyRepository
+	FieldRepo *fieldsRepository.FieldRepository
+}
+
+func NewTemplateService(repo *repository.TemplateRepository, planRepo *planRepository.PlanRepository,
+	busyRepo *calendarRepository.BusyRepository, fieldRepo *fieldsRepository.FieldRepository) *TemplateService {
+	return &TemplateService{Repo: repo, PlanRepo: planRepo, BusyRepo: busyRepo, FieldRepo: fieldRepo}
+}
+
+// TemplateInput is what a user writes when creating or editing a template
+type TemplateInput struct {
+	Name        string                `json:"name"`
+	Description string                `json:"description"`
+	Goal        string                `json:"goal"`
+	Shared      bool                  `json:"shared"`
+	Tasks       []models.TemplateTask `json:"tasks"`
+}
+
+// FromPlanInput saves an existing plan as a template. Values are replaced
+// by their placeholders: {"name": "Alice"} turns "Alice" into {{name}}.
+type FromPlanInput struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Shared      bool              `json:"shared"`
+	Values      map[string]string `json:"variables"`
+}
+
+// Draft is an instantiated template, ready for POST /api/plan/confirm.
+// StartDate is the first working day on or after the requested start.
+type Draft struct {
+	TemplateID string            `json:"template_id"`
+	Goal       string            `json:"goal"`
+	StartDate  string            `json:"start_date"`
+	Tasks      []planModels.Task `json:"tasks"`
+}
+
+func (s *TemplateService) List(userID, scope, query string) ([]models.Template, error) {
+	return s.Repo.List(userID, strings.ToLower(scope), query)
+}
+
+func (s *TemplateService) Get(userID, templateID string) (*models.Template, error) {
+	return s.Repo.Get(userID, templateID)
+}
+
+func (s *TemplateService) Create(userID string, in TemplateInput) (*models.Template, error) {
+	now := time.Now()
+	t := &models.Template{UserID: userID, CreatedAt: now}
+	if err := apply(t, in); err != nil {
+		return nil, err
+	}
+	t.UpdatedAt = now
+	if err := s.Repo.Insert(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// CreateFromPlan saves a plan the user can see as a template. Only an
+// owner of the plan can share the template with everyone.
+func (s *TemplateService) CreateFromPlan(userID, planID string, in FromPlanInput) (*models.Template, error) {
+	plan, err := s.PlanRepo.GetByIDForUser(planID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if in.Shared && plan.Role != planModels.RoleOwner {
+		return nil, planRepository.ErrNotOwner
+	}
+	if len(plan.Tasks) == 0 {
+		return nil, fmt.Errorf("plan has no tasks")
+	}
+	for k := range in.Values {
+		if !blueprint.ValidVariable(k) {
+			return nil, fmt.Errorf("invalid variable name %q (letters, digits and _)", k)
+		}
+	}
+
+	// offsets count weekdays only; the busy days of the original plan
+	// don't repeat
+	goal, tasks := blueprint.FromPlan(*plan, in.Values, busytime.New(nil, time.Local))
+	name := in.Name
+	if strings.TrimSpace(name) == "" {
+		name = plan.Goal
+	}
+
+	now := time.Now()
+	t := &models.Template{UserID: userID, SourcePlanID: planID, CreatedAt: now}
+	if err := apply(t, TemplateInput{Name: name, Description: in.Description, Goal: goal, Shared: in.Shared, Tasks: tasks}); err != nil {
+		return nil, err
+	}
+	t.UpdatedAt = now
+	if err := s.Repo.Insert(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Update replaces the owner's template
+func (s *TemplateService) Update(userID, templateID string, in TemplateInput) (*models.Template, error) {
+	t, err := s.Repo.Get(userID, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if t.UserID != userID {
+		return nil, fmt.Errorf("only the owner can change a template")
+	}
+	if in.Shared && !t.Shared && t.SourcePlanID != "" {
+		// the same rule as CreateFromPlan, for templates made private first
+		if _, err := s.PlanRepo.GetByIDForRole(t.SourcePlanID, userID, planModels.RoleOwner); err != nil {
+			return nil, planRepository.ErrNotOwner
+		}
+	}
+	if err := apply(t, in); err != nil {
+		return nil, err
+	}
+	t.UpdatedAt = time.Now()
+	if err := s.Repo.Save(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (s *TemplateService) Delete(userID, templateID string) error {
+	return s.Repo.Delete(userID, templateID)
+}
+
+// Instantiate turns a template into a draft plan starting on startDate
+// (YYYY-MM-DD, default today). Deadlines skip weekends and the user's busy
+// days. Nothing is saved until the draft is confirmed.
+func (s *TemplateService) Instantiate(userID, templateID, startDate string, values map[string]string) (*Draft, error) {
+	t, err := s.Repo.Get(userID, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if startDate != "" {
+		if start, err = time.ParseInLocation("2006-01-02", startDate, time.Local); err != nil {
+			return nil, fmt.Errorf("start_date must be YYYY-MM-DD")
+		}
+	}
+
+	blocks, err := s.BusyRepo.FindBetween(userID, start, start.Add(calendarHorizon))
+	if err != nil {
+		blocks = nil
+	}
+	cal := busytime.New(blocks, time.Local)
+	goal, tasks, err := blueprint.Instantiate(*t, start, values, cal)
+	if err != nil {
+		return nil, err
+	}
+
+	// shared templates may carry fields this user hasn't defined; those are dropped
+	defs, err := s.FieldRepo.ByKey(userID)
+	if err != nil {
+		return nil, err
+	}
+	var keepFields func([]planModels.Task)
+	keepFields = func(tasks []planModels.Task) {
+		for i := range tasks {
+			values := tasks[i].Fields
+			tasks[i].Fields = nil
+			for key, value := range values {
+				_ = attributes.SetFields(&tasks[i], map[string]interface{}{key: value}, defs)
+			}
+			keepFields(tasks[i].SubTasks)
+		}
+	}
+	keepFields(tasks)
+
+	if err := s.Repo.CountUse(t.ID); err != nil {
+		log.Println("Error counting template use:", err)
+	}
+	return &Draft{TemplateID: t.ID.Hex(), Goal: goal, StartDate: cal.AddWorkdays(start, 0).Format("2006-01-02"), Tasks: tasks}, nil
+}
+
+// apply validates in and copies it onto t
+func apply(t *models.Template, in TemplateInput) error {
+	in.Name = strings.TrimSpace(in.Name)
+	if in.Name == "" {
+		return fmt.Errorf("name required")
+	}
+	if len(in.Name) > maxNameLength {
+		return fmt.Errorf("name is longer than %d characters", maxNameLength)
+	}
+	if strings.TrimSpace(in.Goal) == "" {
+		return fmt.Errorf("goal required")
+	}
+	if err := blueprint.Normalize(in.Tasks); err != nil {
+		return err
+	}
+
+	t.Name, t.Description, t.Goal, t.Shared, t.Tasks = in.Name, in.Description, in.Goal, in.Shared, in.Tasks
+	t.Variables = blueprint.Variables(t.Goal, t.Tasks)
+	return nil
+}
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	planRepository "smart-task-planner/internal/modules/plan/repository"
+	"smart-task-planner/internal/modules/timetracking/repository"
+	"smart-task-planner/internal/modules/timetracking/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TimeHandler struct {
+	service *service.TimeService
+}
+
+func NewTimeHandler(svc *service.TimeService) *TimeHandler {
+	return &TimeHandler{service: svc}
+}
+
+// StartTimer handles POST /api/time/timer/start {"plan_id", "task_id", "note"}
+func (h *TimeHandler) StartTimer(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req struct {
+		PlanID string `json:"plan_id" binding:"required"`
+		TaskID string `json:"task_id" binding:"required"`
+		Note   string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.service.StartTimer(userID, req.PlanID, req.TaskID, req.Note)
+	if err != nil {
+		c.JSON(errStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+func (h *TimeHandler) StopTimer(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	entry, err := h.service.StopTimer(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// CurrentTimer handles GET /api/time/timer; "timer" is null when none runs
+func (h *TimeHandler) CurrentTimer(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	entry, err := h.service.CurrentTimer(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch timer"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"timer": entry})
+}
+
+// ListEntries handles GET /api/time/entries?from=&to=&plan_id=&task_id=&tz=
+func (h *TimeHandler) ListEntries(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	f, _, err := filterFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entries, err := h.service.Entries(userID, f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch time entries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entries": entries, "count": len(entries)})
+}
+
+// ExportEntries handles GET /api/time/entries.csv with the same filters
+func (h *TimeHandler) ExportEntries(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	f, loc, err := filterFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := h.service.ExportCSV(userID, f, loc)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export time entries"})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="time-entries.csv"`)
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", data)
+}
+
+func (h *TimeHandler) AddEntry(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req service.ManualEntry
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := h.service.AddEntry(userID, req)
+	if err != nil {
+		c.JSON(errStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+func (h *TimeHandler) UpdateEntry(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req service.EntryUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := h.service.UpdateEntry(userID, c.Param("id"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+func (h *TimeHandler) DeleteEntry(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	if err := h.service.DeleteEntry(userID, c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Time entry deleted"})
+}
+
+// Report handles GET /api/time/report?from=&to=&plan_id=&tz=
+func (h *TimeHandler) Report(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	f, loc, err := filterFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.service.Report(userID, f, loc)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build time report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// filterFromQuery reads from/to (YYYY-MM-DD in tz, or RFC 3339), plan_id,
+// task_id and tz (default UTC). A plain to date includes that day.
+// errStatus is 403 for plans the user can only view, 409 for a timer that
+// is already running and 400 otherwise
+func errStatus(err error) int {
+	switch {
+	case errors.Is(err, planRepository.ErrReadOnly):
+		return http.StatusForbidden
+	case err == repository.ErrTimerRunning:
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
+
+func filterFromQuery(c *gin.Context) (repository.Filter, *time.Location, error) {
+	f := repository.Filter{PlanID: c.Query("plan_id"), TaskID: c.Query("task_id")}
+
+	loc := time.UTC
+	if tz := c.Query("tz"); tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return f, nil, fmt.Errorf("unknown time zone %q", tz)
+		}
+	}
+
+	parse := func(name string, end bool) (*time.Time, error) {
+		v := c.Query(name)
+		if v == "" {
+			return nil, nil
+		}
+		if d, err := time.ParseInLocation("2006-01-02", v, loc); err == nil {
+			if end {
+				d = d.AddDate(0, 0, 1)
+			}
+			return &d, nil
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: use YYYY-MM-DD or RFC 3339", name)
+		}
+		return &t, nil
+	}
+
+	var err error
+	if f.From, err = parse("from", false); err != nil {
+		return f, nil, err
+	}
+	if f.To, err = parse("to", true); err != nil {
+		return f, nil, err
+	}
+	return f, loc, nil
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	SourceTimer  = "timer"
+	SourceManual = "manual"
+)
+
+// TimeEntry is time spent on one task or subtask. A running timer has no
+// End yet; Hours is filled in when it stops.
+type TimeEntry struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    string             `bson:"user_id" json:"-"`
+	PlanID    string             `bson:"plan_id" json:"plan_id"`
+	TaskID    string             `bson:"task_id" json:"task_id"`
+	Goal      string             `bson:"goal" json:"goal"`             // copied for reports and exports
+	TaskTitle string             `bson:"task_title" json:"task_title"` // copied for reports and exports
+	Start     time.Time          `bson:"start" json:"start"`
+	End       *time.Time         `bson:"end,omitempty" json:"end,omitempty"`
+	Hours     float64            `bson:"hours" json:"hours"`
+	Running   bool               `bson:"running,omitempty" json:"running"`
+	Source    string             `bson:"source" json:"source"`
+	Note      string             `bson:"note,omitempty" json:"note,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// Stop closes the entry at end
+func (e *TimeEntry) Stop(end time.Time) {
+	e.End = &end
+	e.Hours = end.Sub(e.Start).Hours()
+	e.Running = false
+}
@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"smart-task-planner/internal/modules/timetracking/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrTimerRunning is returned when a user already has a running timer
+var ErrTimerRunning = fmt.Errorf("a timer is already running")
+
+type TimeEntryRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewTimeEntryRepository(db *mongo.Database) *TimeEntryRepository {
+	return &TimeEntryRepository{Collection: db.Collection("time_entries")}
+}
+
+func (r *TimeEntryRepository) EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.Collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "start", Value: -1}}},
+		{Keys: bson.D{{Key: "plan_id", Value: 1}, {Key: "task_id", Value: 1}}},
+		{
+			// at most one running timer per user
+			Keys:    bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"running": true}),
+		},
+	})
+	return err
+}
+
+// Insert stores a new entry. Starting a second timer fails with ErrTimerRunning.
+func (r *TimeEntryRepository) Insert(e *models.TimeEntry) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	e.ID = primitive.NewObjectID()
+	if _, err := r.Collection.InsertOne(ctx, e); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrTimerRunning
+		}
+		return err
+	}
+	return nil
+}
+
+// Running returns the user's running timer, nil when there is none
+func (r *TimeEntryRepository) Running(userID string) (*models.TimeEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var e models.TimeEntry
+	err := r.Collection.FindOne(ctx, bson.M{"user_id": userID, "running": true}).Decode(&e)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *TimeEntryRepository) Get(userID, id string) (*models.TimeEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("time entry not found")
+	}
+	var e models.TimeEntry
+	if err := r.Collection.FindOne(ctx, bson.M{"_id": objID, "user_id": userID}).Decode(&e); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("time entry not found")
+		}
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Save replaces a stored entry
+func (r *TimeEntryRepository) Save(e *models.TimeEntry) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := r.Collection.ReplaceOne(ctx, bson.M{"_id": e.ID, "user_id": e.UserID}, e)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("time entry not found")
+	}
+	return nil
+}
+
+func (r *TimeEntryRepository) Delete(userID, id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("time entry not found")
+	}
+	res, err := r.Collection.DeleteOne(ctx, bson.M{"_id": objID, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return fmt.Errorf("time entry not found")
+	}
+	return nil
+}
+
+// Filter narrows List. Entries overlapping [From, To) are returned.
+type Filter struct {
+	From   *time.Time
+	To     *time.Time
+	PlanID string
+	TaskID string
+}
+
+// List returns the user's entries, most recent first
+func (r *TimeEntryRepository) List(userID string, f Filter) ([]models.TimeEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID}
+	if f.PlanID != "" {
+		filter["plan_id"] = f.PlanID
+	}
+	if f.TaskID != "" {
+		filter["task_id"] = f.TaskID
+	}
+	if f.To != nil {
+		filter["start"] = bson.M{"$lt": *f.To}
+	}
+	if f.From != nil {
+		filter["$or"] = bson.A{
+			bson.M{"end": bson.M{"$gt": *f.From}},
+			bson.M{"running": true},
+		}
+	}
+
+	cursor, err := r.Collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "start", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []models.TimeEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// HoursByTask sums the user's own finished entries per task ID
+func (r *TimeEntryRepository) HoursByTask(userID string) (map[string]float64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.Collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID, "running": bson.M{"$ne": true}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$task_id", "hours": bson.M{"$sum": "$hours"}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		TaskID string  `bson:"_id"`
+		Hours  float64 `bson:"hours"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	hours := make(map[string]float64, len(rows))
+	for _, row := range rows {
+		hours[row.TaskID] = row.Hours
+	}
+	return hours, nil
+}
+
+// TaskHours sums the finished entries of a task, across all users
+func (r *TimeEntryRepository) TaskHours(planID, taskID string) (float64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := r.Collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"plan_id": planID, "task_id": taskID, "running": bson.M{"$ne": true}}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "hours": bson.M{"$sum": "$hours"}}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Hours float64 `bson:"hours"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil || len(rows) == 0 {
+		return 0, err
+	}
+	return rows[0].Hours, nil
+}
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"smart-task-planner/internal/middleware"
+	"smart-task-planner/internal/modules/timetracking/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterTimeRoutes(router *gin.Engine, handler *handlers.TimeHandler) {
+	api := router.Group("/api/time")
+	api.Use(middleware.JWTAuth())
+	{
+		// One running timer per user; starting another stops it
+		api.GET("/timer", handler.CurrentTimer)
+		api.POST("/timer/start", handler.StartTimer)
+		api.POST("/timer/stop", handler.StopTimer)
+
+		// Time entries (manual or from timers)
+		api.GET("/entries", handler.ListEntries)
+		api.GET("/entries.csv", handler.ExportEntries)
+		api.POST("/entries", handler.AddEntry)
+		api.PATCH("/entries/:id", handler.UpdateEntry)
+		api.DELETE("/entries/:id", handler.DeleteEntry)
+
+		// Actual vs estimated hours per plan, day and task
+		api.GET("/report", handler.Report)
+	}
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	planModels "smart-task-planner/internal/modules/plan/models"
+	planRepository "smart-task-planner/internal/modules/plan/repository"
+	"smart-task-planner/internal/modules/timetracking/models"
+	"smart-task-planner/internal/modules/timetracking/repository"
+)
+
+type Report struct {
+	From        *time.Time  `json:"from,omitempty"`
+	To          *time.Time  `json:"to,omitempty"`
+	TimeZone    string      `json:"time_zone"`
+	ActualHours float64     `json:"actual_hours"`
+	Plans       []PlanHours `json:"plans"`
+	Days        []DayHours  `json:"days"`
+	Tasks       []TaskHours `json:"tasks"`
+}
+
+type PlanHours struct {
+	PlanID         string  `json:"plan_id"`
+	Goal           string  `json:"goal"`
+	ActualHours    float64 `json:"actual_hours"`
+	EstimatedHours float64 `json:"estimated_hours"` // estimates of the tasks with tracked time
+}
+
+type DayHours struct {
+	Date  string  `json:"date"`
+	Hours float64 `json:"hours"`
+}
+
+// TaskHours compares a task's tracked time in the report window with its
+// estimate. Variance is actual minus estimate; Ratio is actual / estimate.
+type TaskHours struct {
+	PlanID         string   `json:"plan_id"`
+	TaskID         string   `json:"task_id"`
+	Title          string   `json:"title"`
+	Status         string   `json:"status"`
+	ActualHours    float64  `json:"actual_hours"`
+	EstimatedHours float64  `json:"estimated_hours"`
+	VarianceHours  *float64 `json:"variance_hours,omitempty"`
+	Ratio          *float64 `json:"ratio,omitempty"`
+}
+
+// BuildReport spreads every entry over the local days it covers, clipped to
+// the filter's window. Titles and estimates come from the current plans;
+// deleted tasks keep the title stored with their entries.
+func BuildReport(entries []models.TimeEntry, plans []planModels.Plan, f repository.Filter, loc *time.Location, now time.Time) *Report {
+	r := &Report{From: f.From, To: f.To, TimeZone: loc.String(), Plans: []PlanHours{}, Days: []DayHours{}, Tasks: []TaskHours{}}
+
+	tasks := map[string]map[string]planRepository.FlatTask{}
+	goals := map[string]string{}
+	for _, p := range plans {
+		tasks[p.ID.Hex()] = planRepository.Flatten(p.Tasks)
+		goals[p.ID.Hex()] = p.Goal
+	}
+
+	planIdx := map[string]int{}
+	taskIdx := map[string]int{}
+	days := map[string]float64{}
+	for _, e := range entries {
+		start, end := e.Start, now
+		if e.End != nil {
+			end = *e.End
+		}
+		if f.From != nil && start.Before(*f.From) {
+			start = *f.From
+		}
+		if f.To != nil && end.After(*f.To) {
+			end = *f.To
+		}
+		if !end.After(start) {
+			continue
+		}
+		hours := end.Sub(start).Hours()
+		r.ActualHours += hours
+
+		// split at local midnights
+		for cur := start.In(loc); cur.Before(end); {
+			y, m, d := cur.Date()
+			next := time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+			if next.After(end) {
+				next = end
+			}
+			days[cur.Format("2006-01-02")] += next.Sub(cur).Hours()
+			cur = next
+		}
+
+		ft, known := tasks[e.PlanID][e.TaskID]
+		key := e.PlanID + "/" + e.TaskID
+		i, ok := taskIdx[key]
+		if !ok {
+			th := TaskHours{PlanID: e.PlanID, TaskID: e.TaskID, Title: e.TaskTitle}
+			if known {
+				th.Title, th.Status, th.EstimatedHours = ft.Task.Title, ft.Task.Status, ft.Task.EstimatedHours
+			}
+			i = len(r.Tasks)
+			taskIdx[key] = i
+			r.Tasks = append(r.Tasks, th)
+		}
+		r.Tasks[i].ActualHours += hours
+
+		j, ok := planIdx[e.PlanID]
+		if !ok {
+			goal, found := goals[e.PlanID]
+			if !found {
+				goal = e.Goal
+			}
+			j = len(r.Plans)
+			planIdx[e.PlanID] = j
+			r.Plans = append(r.Plans, PlanHours{PlanID: e.PlanID, Goal: goal})
+		}
+		r.Plans[j].ActualHours += hours
+	}
+
+	for i := range r.Tasks {
+		t := &r.Tasks[i]
+		r.Plans[planIdx[t.PlanID]].EstimatedHours += t.EstimatedHours
+		t.ActualHours = round2(t.ActualHours)
+		if t.EstimatedHours > 0 {
+			variance := round2(t.ActualHours - t.EstimatedHours)
+			ratio := round2(t.ActualHours / t.EstimatedHours)
+			t.VarianceHours, t.Ratio = &variance, &ratio
+		}
+	}
+	for i := range r.Plans {
+		r.Plans[i].ActualHours = round2(r.Plans[i].ActualHours)
+	}
+	for date, hours := range days {
+		r.Days = append(r.Days, DayHours{Date: date, Hours: round2(hours)})
+	}
+	r.ActualHours = round2(r.ActualHours)
+
+	sort.Slice(r.Days, func(i, j int) bool { return r.Days[i].Date < r.Days[j].Date })
+	sort.SliceStable(r.Plans, func(i, j int) bool { return r.Plans[i].ActualHours > r.Plans[j].ActualHours })
+	sort.SliceStable(r.Tasks, func(i, j int) bool { return r.Tasks[i].ActualHours > r.Tasks[j].ActualHours })
+	return r
+}
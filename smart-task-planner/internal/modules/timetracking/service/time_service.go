@@ -0,0 +1,323 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	planModels "smart-task-planner/internal/modules/plan/models"
+	planRepository "smart-task-planner/internal/modules/plan/repository"
+	"smart-task-planner/internal/modules/timetracking/models"
+	"smart-task-planner/internal/modules/timetracking/repository"
+	"smart-task-planner/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// manual entries may not end further in the future than this (clock skew)
+const futureSlack = 5 * time.Minute
+
+type TimeService struct {
+	Repo     *repository.TimeEntryRepository
+	PlanRepo *planRepository.PlanRepository
+}
+
+func NewTimeService(repo *repository.TimeEntryRepository, planRepo *planRepository.PlanRepository) *TimeService {
+	return &TimeService{Repo: repo, PlanRepo: planRepo}
+}
+
+// StartResult is the new timer and the one it replaced, if any
+type StartResult struct {
+	Entry   *models.TimeEntry `json:"entry"`
+	Stopped *models.TimeEntry `json:"stopped,omitempty"`
+}
+
+// StartTimer starts timing a task. A timer that is already running is
+// stopped first, so a user only ever has one.
+func (s *TimeService) StartTimer(userID, planID, taskID, note string) (*StartResult, error) {
+	plan, task, err := s.findTask(userID, planID, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &StartResult{}
+	if res.Stopped, err = s.stopRunning(userID, time.Now()); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	entry := &models.TimeEntry{
+		UserID:    userID,
+		PlanID:    planID,
+		TaskID:    taskID,
+		Goal:      plan.Goal,
+		TaskTitle: task.Title,
+		Start:     now,
+		Running:   true,
+		Source:    models.SourceTimer,
+		Note:      note,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.Repo.Insert(entry); err != nil {
+		return nil, err
+	}
+	res.Entry = entry
+	return res, nil
+}
+
+// StopTimer stops the user's running timer
+func (s *TimeService) StopTimer(userID string) (*models.TimeEntry, error) {
+	entry, err := s.stopRunning(userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, fmt.Errorf("no timer is running")
+	}
+	return entry, nil
+}
+
+// CurrentTimer returns the running timer, nil when none is running
+func (s *TimeService) CurrentTimer(userID string) (*models.TimeEntry, error) {
+	return s.Repo.Running(userID)
+}
+
+func (s *TimeService) stopRunning(userID string, at time.Time) (*models.TimeEntry, error) {
+	entry, err := s.Repo.Running(userID)
+	if err != nil || entry == nil {
+		return nil, err
+	}
+	entry.Stop(at)
+	entry.UpdatedAt = at
+	if err := s.Repo.Save(entry); err != nil {
+		return nil, err
+	}
+	s.refreshActualHours(userID, entry.PlanID, entry.TaskID)
+	return entry, nil
+}
+
+// ManualEntry logs time after the fact. Either End or Minutes is required.
+type ManualEntry struct {
+	PlanID  string     `json:"plan_id" binding:"required"`
+	TaskID  string     `json:"task_id" binding:"required"`
+	Start   time.Time  `json:"start" binding:"required"`
+	End     *time.Time `json:"end"`
+	Minutes float64    `json:"minutes"`
+	Note    string     `json:"note"`
+}
+
+func (s *TimeService) AddEntry(userID string, req ManualEntry) (*models.TimeEntry, error) {
+	plan, task, err := s.findTask(userID, req.PlanID, req.TaskID)
+	if err != nil {
+		return nil, err
+	}
+
+	end := req.End
+	if end == nil {
+		if req.Minutes <= 0 {
+			return nil, fmt.Errorf("end or minutes is required")
+		}
+		e := req.Start.Add(time.Duration(req.Minutes * float64(time.Minute)))
+		end = &e
+	}
+
+	now := time.Now()
+	entry := &models.TimeEntry{
+		UserID:    userID,
+		PlanID:    req.PlanID,
+		TaskID:    req.TaskID,
+		Goal:      plan.Goal,
+		TaskTitle: task.Title,
+		Start:     req.Start,
+		Source:    models.SourceManual,
+		Note:      req.Note,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	entry.Stop(*end)
+	if err := validateSpan(entry, now); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.Insert(entry); err != nil {
+		return nil, err
+	}
+	s.refreshActualHours(userID, entry.PlanID, entry.TaskID)
+	return entry, nil
+}
+
+// EntryUpdate changes the given fields of an entry
+type EntryUpdate struct {
+	Start *time.Time `json:"start"`
+	End   *time.Time `json:"end"`
+	Note  *string    `json:"note"`
+}
+
+func (s *TimeService) UpdateEntry(userID, id string, req EntryUpdate) (*models.TimeEntry, error) {
+	entry, err := s.Repo.Get(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Note != nil {
+		entry.Note = *req.Note
+	}
+	if req.Start != nil {
+		entry.Start = *req.Start
+	}
+	if req.End != nil {
+		if entry.Running {
+			return nil, fmt.Errorf("stop the timer before setting its end")
+		}
+		entry.End = req.End
+	}
+	now := time.Now()
+	if !entry.Running {
+		entry.Stop(*entry.End)
+		if err := validateSpan(entry, now); err != nil {
+			return nil, err
+		}
+	} else if entry.Start.After(now) {
+		return nil, fmt.Errorf("start can't be in the future")
+	}
+
+	entry.UpdatedAt = now
+	if err := s.Repo.Save(entry); err != nil {
+		return nil, err
+	}
+	s.refreshActualHours(userID, entry.PlanID, entry.TaskID)
+	return entry, nil
+}
+
+func (s *TimeService) DeleteEntry(userID, id string) error {
+	entry, err := s.Repo.Get(userID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.Repo.Delete(userID, id); err != nil {
+		return err
+	}
+	s.refreshActualHours(userID, entry.PlanID, entry.TaskID)
+	return nil
+}
+
+func (s *TimeService) Entries(userID string, f repository.Filter) ([]models.TimeEntry, error) {
+	return s.Repo.List(userID, f)
+}
+
+// ExportCSV writes the entries in f as CSV, oldest first, times in loc.
+// Text that a spreadsheet would run as a formula gets a leading "'".
+func (s *TimeService) ExportCSV(userID string, f repository.Filter, loc *time.Location) ([]byte, error) {
+	entries, err := s.Repo.List(userID, f)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"id", "date", "start", "end", "hours", "goal", "task", "plan_id", "task_id", "source", "note"})
+	now := time.Now()
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		end, hours := "", e.Hours
+		if e.End != nil {
+			end = e.End.In(loc).Format(time.RFC3339)
+		} else {
+			hours = now.Sub(e.Start).Hours()
+		}
+		w.Write([]string{
+			e.ID.Hex(),
+			e.Start.In(loc).Format("2006-01-02"),
+			e.Start.In(loc).Format(time.RFC3339),
+			end,
+			strconv.FormatFloat(round2(hours), 'f', 2, 64),
+			utils.EscapeCSVCell(e.Goal),
+			utils.EscapeCSVCell(e.TaskTitle),
+			e.PlanID,
+			e.TaskID,
+			e.Source,
+			utils.EscapeCSVCell(e.Note),
+		})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// Report sums the entries in f per plan, per day (in loc) and per task and
+// compares them with the task estimates. A running timer counts up to now.
+func (s *TimeService) Report(userID string, f repository.Filter, loc *time.Location) (*Report, error) {
+	entries, err := s.Repo.List(userID, f)
+	if err != nil {
+		return nil, err
+	}
+	plans, err := s.PlanRepo.GetAllByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	return BuildReport(entries, plans, f, loc, time.Now()), nil
+}
+
+func (s *TimeService) findTask(userID, planID, taskID string) (*planModels.Plan, *planModels.Task, error) {
+	plan, err := s.PlanRepo.GetByIDForRole(planID, userID, planModels.RoleEditor)
+	if err != nil {
+		return nil, nil, err
+	}
+	ft, ok := planRepository.Flatten(plan.Tasks)[taskID]
+	if !ok {
+		return nil, nil, fmt.Errorf("task not found in plan")
+	}
+	return plan, ft.Task, nil
+}
+
+// refreshActualHours copies a task's tracked total onto the task, where
+// progress, the Gantt chart and next-task suggestions pick it up, as long as
+// userID can still edit the plan. Failures are logged; the entries stay the
+// source of truth.
+func (s *TimeService) refreshActualHours(userID, planID, taskID string) {
+	hours, err := s.Repo.TaskHours(planID, taskID)
+	if err != nil {
+		log.Println("Error summing tracked time:", err)
+		return
+	}
+	objID, err := primitive.ObjectIDFromHex(planID)
+	if err != nil {
+		return
+	}
+
+	filter, err := s.PlanRepo.FilterFor(userID, planModels.RoleEditor)
+	if err != nil {
+		log.Println("Error updating tracked hours:", err)
+		return
+	}
+	filter["_id"] = objID
+
+	hours = round2(hours)
+	_, err = s.PlanRepo.Mutate(filter, func(plan *planModels.Plan) error {
+		if ft, ok := planRepository.Flatten(plan.Tasks)[taskID]; ok {
+			ft.Task.ActualHours = hours
+		}
+		return nil
+	})
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		log.Println("Error updating tracked hours:", err)
+	}
+}
+
+func validateSpan(e *models.TimeEntry, now time.Time) error {
+	if !e.End.After(e.Start) {
+		return fmt.Errorf("end must be after start")
+	}
+	if e.End.After(now.Add(futureSlack)) {
+		return fmt.Errorf("time entries can't end in the future")
+	}
+	return nil
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"smart-task-planner/internal/modules/webhooks/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	service *service.WebhookService
+}
+
+func NewWebhookHandler(svc *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: svc}
+}
+
+// CreateEndpoint handles POST /api/webhooks. The response carries the
+// signing secret, which is not shown again.
+func (h *WebhookHandler) CreateEndpoint(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req struct {
+		URL         string   `json:"url" binding:"required"`
+		Events      []string `json:"events"`
+		Description string   `json:"description"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := h.service.CreateEndpoint(userID, req.URL, req.Events, req.Description)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *WebhookHandler) ListEndpoints(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	endpoints, err := h.service.ListEndpoints(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhooks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, endpoints)
+}
+
+func (h *WebhookHandler) GetEndpoint(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	ep, err := h.service.GetEndpoint(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ep)
+}
+
+func (h *WebhookHandler) DeleteEndpoint(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	if err := h.service.DeleteEndpoint(userID, c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted"})
+}
+
+// Ping handles POST /api/webhooks/:id/ping and waits for the endpoint's answer
+func (h *WebhookHandler) Ping(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	res, err := h.service.Ping(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// Deliveries handles GET /api/webhooks/:id/deliveries?status=failed&limit=20
+func (h *WebhookHandler) Deliveries(c *gin.Context) {
+	userID := c.GetString("user_id")
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	deliveries, err := h.service.Deliveries(userID, c.Param("id"), c.Query("status"), limit)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries, "count": len(deliveries)})
+}
+
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	d, err := h.service.Redeliver(userID, c.Param("delivery_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, d)
+}
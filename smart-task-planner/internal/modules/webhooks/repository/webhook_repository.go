@@ -0,0 +1,284 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"smart-task-planner/internal/modules/webhooks/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WebhookRepository stores endpoints and the delivery outbox
+type WebhookRepository struct {
+	Endpoints  *mongo.Collection
+	Deliveries *mongo.Collection
+}
+
+func NewWebhookRepository(db *mongo.Database) *WebhookRepository {
+	return &WebhookRepository{
+		Endpoints:  db.Collection("webhook_endpoints"),
+		Deliveries: db.Collection("webhook_deliveries"),
+	}
+}
+
+func (r *WebhookRepository) EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := r.Endpoints.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "events", Value: 1}},
+	}); err != nil {
+		return err
+	}
+	_, err := r.Deliveries.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "endpoint_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{
+			// one delivery per endpoint for events that describe the same fact
+			Keys: bson.D{{Key: "endpoint_id", Value: 1}, {Key: "dedupe_key", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"dedupe_key": bson.M{"$type": "string"}}),
+		},
+	})
+	return err
+}
+
+func (r *WebhookRepository) CreateEndpoint(ep *models.Endpoint) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ep.ID = primitive.NewObjectID()
+	_, err := r.Endpoints.InsertOne(ctx, ep)
+	return err
+}
+
+func (r *WebhookRepository) ListEndpoints(userID string) ([]models.Endpoint, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := r.Endpoints.Find(ctx, bson.M{"user_id": userID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	endpoints := []models.Endpoint{}
+	if err := cursor.All(ctx, &endpoints); err != nil {
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+func (r *WebhookRepository) GetEndpoint(userID, endpointID string) (*models.Endpoint, error) {
+	objID, err := primitive.ObjectIDFromHex(endpointID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint ID: %v", err)
+	}
+	return r.endpointByID(bson.M{"_id": objID, "user_id": userID})
+}
+
+// EndpointByID loads an endpoint for the delivery worker, whoever owns it
+func (r *WebhookRepository) EndpointByID(id primitive.ObjectID) (*models.Endpoint, error) {
+	return r.endpointByID(bson.M{"_id": id})
+}
+
+func (r *WebhookRepository) endpointByID(filter bson.M) (*models.Endpoint, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var ep models.Endpoint
+	if err := r.Endpoints.FindOne(ctx, filter).Decode(&ep); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("endpoint not found")
+		}
+		return nil, err
+	}
+	return &ep, nil
+}
+
+// DeleteEndpoint removes an endpoint together with its delivery log
+func (r *WebhookRepository) DeleteEndpoint(userID, endpointID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(endpointID)
+	if err != nil {
+		return fmt.Errorf("invalid endpoint ID: %v", err)
+	}
+
+	res, err := r.Endpoints.DeleteOne(ctx, bson.M{"_id": objID, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return fmt.Errorf("endpoint not found")
+	}
+	_, err = r.Deliveries.DeleteMany(ctx, bson.M{"endpoint_id": objID})
+	return err
+}
+
+// FindSubscribed returns the user's active endpoints listening for eventType
+func (r *WebhookRepository) FindSubscribed(userID, eventType string) ([]models.Endpoint, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := r.Endpoints.Find(ctx, bson.M{"user_id": userID, "events": eventType, "active": true})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var endpoints []models.Endpoint
+	if err := cursor.All(ctx, &endpoints); err != nil {
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+// UsersSubscribedTo lists users with at least one active endpoint for eventType
+func (r *WebhookRepository) UsersSubscribedTo(eventType string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	values, err := r.Endpoints.Distinct(ctx, "user_id", bson.M{"events": eventType, "active": true})
+	if err != nil {
+		return nil, err
+	}
+	users := make([]string, 0, len(values))
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			users = append(users, s)
+		}
+	}
+	return users, nil
+}
+
+// InsertDelivery queues a delivery. It reports false without an error when
+// the endpoint already has a delivery with the same dedupe key.
+func (r *WebhookRepository) InsertDelivery(d *models.Delivery) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	d.ID = primitive.NewObjectID()
+	if _, err := r.Deliveries.InsertOne(ctx, d); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// ClaimDue locks the oldest due pending delivery for lease so concurrent
+// workers don't send it twice. It returns nil when nothing is due.
+func (r *WebhookRepository) ClaimDue(now time.Time, lease time.Duration) (*models.Delivery, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"status":          models.DeliveryPending,
+		"next_attempt_at": bson.M{"$lte": now},
+		"$or": []bson.M{
+			{"locked_until": bson.M{"$exists": false}},
+			{"locked_until": bson.M{"$lt": now}},
+		},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var d models.Delivery
+	err := r.Deliveries.FindOneAndUpdate(ctx, filter,
+		bson.M{"$set": bson.M{"locked_until": now.Add(lease)}}, opts).Decode(&d)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// RecordAttempt appends an attempt to the log and moves the delivery to its
+// next state, releasing the worker's lock.
+func (r *WebhookRepository) RecordAttempt(d *models.Delivery, attempt models.Attempt) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	set := bson.M{
+		"status":           d.Status,
+		"attempts":         d.Attempts,
+		"next_attempt_at":  d.NextAttemptAt,
+		"last_status_code": d.LastStatusCode,
+		"last_error":       d.LastError,
+	}
+	if d.DeliveredAt != nil {
+		set["delivered_at"] = d.DeliveredAt
+	}
+	_, err := r.Deliveries.UpdateOne(ctx, bson.M{"_id": d.ID}, bson.M{
+		"$set":   set,
+		"$push":  bson.M{"log": attempt},
+		"$unset": bson.M{"locked_until": ""},
+	})
+	return err
+}
+
+func (r *WebhookRepository) ListDeliveries(endpointID primitive.ObjectID, status string, limit int64) ([]models.Delivery, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"endpoint_id": endpointID}
+	if status != "" {
+		filter["status"] = status
+	}
+	cursor, err := r.Deliveries.Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	deliveries := []models.Delivery{}
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *WebhookRepository) GetDelivery(userID, deliveryID string) (*models.Delivery, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid delivery ID: %v", err)
+	}
+
+	var d models.Delivery
+	if err := r.Deliveries.FindOne(ctx, bson.M{"_id": objID, "user_id": userID}).Decode(&d); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("delivery not found")
+		}
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Requeue puts a delivery back in the outbox with a fresh retry budget.
+// Earlier attempts stay in the log.
+func (r *WebhookRepository) Requeue(id primitive.ObjectID, at time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.Deliveries.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set":   bson.M{"status": models.DeliveryPending, "attempts": 0, "next_attempt_at": at},
+		"$unset": bson.M{"locked_until": ""},
+	})
+	return err
+}
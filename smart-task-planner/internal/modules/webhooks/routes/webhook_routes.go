@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"smart-task-planner/internal/middleware"
+	"smart-task-planner/internal/modules/webhooks/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterWebhookRoutes(router *gin.Engine, handler *handlers.WebhookHandler) {
+	api := router.Group("/api/webhooks")
+	api.Use(middleware.JWTAuth())
+	{
+		api.POST("/", handler.CreateEndpoint)
+		api.GET("/", handler.ListEndpoints)
+		api.GET("/:id", handler.GetEndpoint)
+		api.DELETE("/:id", handler.DeleteEndpoint)
+
+		// Send a signed test event and wait for the response
+		api.POST("/:id/ping", handler.Ping)
+
+		// Delivery log and manual retries
+		api.GET("/:id/deliveries", handler.Deliveries)
+		api.POST("/deliveries/:delivery_id/redeliver", handler.Redeliver)
+	}
+}
@@ -0,0 +1,427 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"smart-task-planner/internal/events"
+	"smart-task-planner/internal/mcp"
+	planRepository "smart-task-planner/internal/modules/plan/repository"
+	"smart-task-planner/internal/modules/webhooks/models"
+	"smart-task-planner/internal/modules/webhooks/repository"
+	"smart-task-planner/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// PingEvent is sent by the test endpoint; it can't be subscribed to
+	PingEvent = "ping"
+
+	sendTimeout  = 10 * time.Second
+	pollInterval = 5 * time.Second
+	claimLease   = time.Minute
+	riskInterval = time.Hour
+
+	// retries wait 30s, 1m, 2m, ... capped at 6h; after maxAttempts the delivery fails
+	baseBackoff = 30 * time.Second
+	maxBackoff  = 6 * time.Hour
+	maxAttempts = 8
+)
+
+type WebhookService struct {
+	Repo     *repository.WebhookRepository
+	PlanRepo *planRepository.PlanRepository
+	client   *http.Client
+	nudge    chan struct{}
+}
+
+func NewWebhookService(repo *repository.WebhookRepository, planRepo *planRepository.PlanRepository) *WebhookService {
+	return &WebhookService{
+		Repo:     repo,
+		PlanRepo: planRepo,
+		client:   utils.NewOutboundClient(sendTimeout),
+		nudge:    make(chan struct{}, 1),
+	}
+}
+
+// CreatedEndpoint is returned once when an endpoint is registered; the
+// signing secret cannot be fetched later.
+type CreatedEndpoint struct {
+	Secret   string           `json:"secret"`
+	Endpoint *models.Endpoint `json:"endpoint"`
+}
+
+// PingResult is the outcome of a synchronous test delivery
+type PingResult struct {
+	Delivered bool             `json:"delivered"`
+	Delivery  *models.Delivery `json:"delivery"`
+}
+
+func (s *WebhookService) CreateEndpoint(userID, rawURL string, eventTypes []string, description string) (*CreatedEndpoint, error) {
+	target, err := validateURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	types, err := validateEvents(eventTypes)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	ep := &models.Endpoint{
+		UserID:      userID,
+		URL:         target,
+		Events:      types,
+		Secret:      secret,
+		Description: description,
+		Active:      true,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.Repo.CreateEndpoint(ep); err != nil {
+		return nil, err
+	}
+	return &CreatedEndpoint{Secret: secret, Endpoint: ep}, nil
+}
+
+func (s *WebhookService) ListEndpoints(userID string) ([]models.Endpoint, error) {
+	return s.Repo.ListEndpoints(userID)
+}
+
+func (s *WebhookService) GetEndpoint(userID, endpointID string) (*models.Endpoint, error) {
+	return s.Repo.GetEndpoint(userID, endpointID)
+}
+
+func (s *WebhookService) DeleteEndpoint(userID, endpointID string) error {
+	return s.Repo.DeleteEndpoint(userID, endpointID)
+}
+
+// Deliveries returns the newest deliveries of an endpoint, optionally by status
+func (s *WebhookService) Deliveries(userID, endpointID, status string, limit int) ([]models.Delivery, error) {
+	ep, err := s.Repo.GetEndpoint(userID, endpointID)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	return s.Repo.ListDeliveries(ep.ID, status, int64(limit))
+}
+
+// Redeliver sends a delivery again, whatever its current state, with a
+// fresh set of retries.
+func (s *WebhookService) Redeliver(userID, deliveryID string) (*models.Delivery, error) {
+	d, err := s.Repo.GetDelivery(userID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if d.Status == models.DeliveryPending && d.LockedUntil != nil && d.LockedUntil.After(time.Now()) {
+		return nil, fmt.Errorf("delivery is being sent right now")
+	}
+
+	now := time.Now()
+	if err := s.Repo.Requeue(d.ID, now); err != nil {
+		return nil, err
+	}
+	d.Status, d.Attempts, d.NextAttemptAt = models.DeliveryPending, 0, now
+	s.wake()
+	return d, nil
+}
+
+// Ping sends a signed test event straight away and reports the result.
+// Pings are logged like any delivery but never retried.
+func (s *WebhookService) Ping(userID, endpointID string) (*PingResult, error) {
+	ep, err := s.Repo.GetEndpoint(userID, endpointID)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := s.enqueue(ep, events.Event{
+		ID:         "evt_" + primitive.NewObjectID().Hex(),
+		Type:       PingEvent,
+		UserID:     userID,
+		OccurredAt: time.Now().UTC(),
+		Data:       map[string]interface{}{"endpoint_id": ep.ID.Hex(), "message": "Webhook test from Smart Task Planner"},
+	}, time.Now().Add(maxBackoff))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.deliver(ep, d, false); err != nil {
+		return nil, err
+	}
+	return &PingResult{Delivered: d.Status == models.DeliveryDelivered, Delivery: d}, nil
+}
+
+// Start subscribes to planner events, drains the outbox in the background
+// and scans for new risks every hour, until ctx is cancelled.
+func (s *WebhookService) Start(ctx context.Context) {
+	events.Subscribe(s.handle)
+
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-s.nudge:
+			}
+			s.drain(ctx)
+		}
+	}()
+
+	go func() {
+		ticker := time.NewTicker(riskInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.scanRisks()
+			}
+		}
+	}()
+}
+
+// handle queues an event for every endpoint that listens for it, of
+// everyone who can see the event's plan
+func (s *WebhookService) handle(e events.Event) {
+	if e.UserID == "" && len(e.Audience) == 0 {
+		return
+	}
+	queued := false
+	for _, userID := range s.PlanRepo.EventAudience(e) {
+		endpoints, err := s.Repo.FindSubscribed(userID, e.Type)
+		if err != nil {
+			log.Printf("❌ Failed to look up webhooks for %s: %v", e.Type, err)
+			return
+		}
+		for i := range endpoints {
+			if _, err := s.enqueue(&endpoints[i], e, time.Now()); err != nil {
+				log.Printf("❌ Failed to queue webhook %s for endpoint %s: %v", e.Type, endpoints[i].ID.Hex(), err)
+			}
+		}
+		queued = queued || len(endpoints) > 0
+	}
+	if queued {
+		s.wake()
+	}
+}
+
+// enqueue stores the event as a pending delivery. A duplicate of an
+// already queued fact is dropped and returns a nil delivery.
+func (s *WebhookService) enqueue(ep *models.Endpoint, e events.Event, next time.Time) (*models.Delivery, error) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	d := &models.Delivery{
+		EndpointID:    ep.ID,
+		UserID:        ep.UserID,
+		EventID:       e.ID,
+		EventType:     e.Type,
+		Payload:       string(payload),
+		Status:        models.DeliveryPending,
+		NextAttemptAt: next,
+		DedupeKey:     e.Key,
+		CreatedAt:     time.Now(),
+		Log:           []models.Attempt{},
+	}
+	inserted, err := s.Repo.InsertDelivery(d)
+	if err != nil || !inserted {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (s *WebhookService) wake() {
+	select {
+	case s.nudge <- struct{}{}:
+	default:
+	}
+}
+
+// drain sends due deliveries one by one until the outbox has nothing due
+func (s *WebhookService) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		d, err := s.Repo.ClaimDue(time.Now(), claimLease)
+		if err != nil {
+			log.Println("❌ Failed to claim webhook delivery:", err)
+			return
+		}
+		if d == nil {
+			return
+		}
+
+		ep, err := s.Repo.EndpointByID(d.EndpointID)
+		if err != nil {
+			// endpoint deleted while the delivery was queued
+			d.Status, d.LastError = models.DeliveryFailed, err.Error()
+			s.Repo.RecordAttempt(d, models.Attempt{At: time.Now(), Error: err.Error()})
+			continue
+		}
+		if err := s.deliver(ep, d, true); err != nil {
+			log.Printf("❌ Failed to record webhook delivery %s: %v", d.ID.Hex(), err)
+		}
+	}
+}
+
+// deliver makes one attempt and stores its outcome on d. Failed attempts are
+// rescheduled with exponential backoff when retry is set.
+func (s *WebhookService) deliver(ep *models.Endpoint, d *models.Delivery, retry bool) error {
+	started := time.Now()
+	code, sendErr := s.send(ep, d)
+	attempt := models.Attempt{
+		At:         started,
+		StatusCode: code,
+		DurationMs: time.Since(started).Milliseconds(),
+	}
+
+	d.Attempts++
+	d.LastStatusCode = code
+	d.LastError = ""
+	switch {
+	case sendErr == nil:
+		now := time.Now()
+		d.Status, d.DeliveredAt = models.DeliveryDelivered, &now
+	case retry && d.Attempts < maxAttempts:
+		attempt.Error, d.LastError = sendErr.Error(), sendErr.Error()
+		d.NextAttemptAt = time.Now().Add(Backoff(d.Attempts))
+	default:
+		attempt.Error, d.LastError = sendErr.Error(), sendErr.Error()
+		d.Status = models.DeliveryFailed
+	}
+	d.Log = append(d.Log, attempt)
+	return s.Repo.RecordAttempt(d, attempt)
+}
+
+// send POSTs the payload; any 2xx response counts as delivered
+func (s *WebhookService) send(ep *models.Endpoint, d *models.Delivery) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, ep.URL, strings.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "SmartTaskPlanner-Webhooks/1.0")
+	req.Header.Set("X-Webhook-Event", d.EventType)
+	req.Header.Set("X-Webhook-Delivery", d.ID.Hex())
+	req.Header.Set("X-Webhook-Signature", Sign(ep.Secret, time.Now(), []byte(d.Payload)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// the body stays out of the error: it's shown to the endpoint's owner
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint answered HTTP %d", resp.StatusCode)
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	return resp.StatusCode, nil
+}
+
+// scanRisks runs risk analysis for users with a risk.detected endpoint, so
+// risks are reported even when nobody asks for them. Each risk is only
+// delivered once per endpoint thanks to the event key.
+func (s *WebhookService) scanRisks() {
+	users, err := s.Repo.UsersSubscribedTo(events.RiskDetected)
+	if err != nil {
+		log.Println("❌ Risk scan failed:", err)
+		return
+	}
+	for _, userID := range users {
+		if _, err := mcp.RunTool("analyze_risks", map[string]interface{}{"user_id": userID}, s.PlanRepo); err != nil {
+			log.Printf("⚠️  Risk scan failed for user %s: %v", userID, err)
+		}
+	}
+}
+
+// Sign builds the X-Webhook-Signature value: the unix time and an
+// HMAC-SHA256 of "<time>.<body>" keyed with the endpoint secret.
+func Sign(secret string, at time.Time, body []byte) string {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff is the wait before the next attempt after n failed ones
+func Backoff(n int) time.Duration {
+	d := baseBackoff
+	for i := 1; i < n; i++ {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return d
+}
+
+// validateURL rejects URLs that aren't http(s) or that point at loopback,
+// private or link-local addresses
+func validateURL(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("url must be an absolute http(s) URL")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := utils.CheckOutboundURL(ctx, u); err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+func validateEvents(types []string) ([]string, error) {
+	if len(types) == 0 {
+		return nil, fmt.Errorf("at least one event type required (%s)", strings.Join(events.Types, ", "))
+	}
+	seen := map[string]bool{}
+	var out []string
+	for _, t := range types {
+		t = strings.TrimSpace(t)
+		if t == "*" {
+			return append([]string{}, events.Types...), nil
+		}
+		known := false
+		for _, k := range events.Types {
+			known = known || k == t
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown event type %q (%s)", t, strings.Join(events.Types, ", "))
+		}
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
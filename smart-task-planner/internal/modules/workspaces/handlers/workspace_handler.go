@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"smart-task-planner/internal/modules/workspaces/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WorkspaceHandler struct {
+	service *service.WorkspaceService
+}
+
+func NewWorkspaceHandler(svc *service.WorkspaceService) *WorkspaceHandler {
+	return &WorkspaceHandler{service: svc}
+}
+
+// GetWorkspaces handles GET /api/workspaces
+func (h *WorkspaceHandler) GetWorkspaces(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	workspaces, err := h.service.List(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"workspaces": workspaces})
+}
+
+// GetWorkspace handles GET /api/workspaces/:id
+func (h *WorkspaceHandler) GetWorkspace(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	w, err := h.service.Get(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(statusFor(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, w)
+}
+
+// CreateWorkspace handles POST /api/workspaces ({"name": "Marketing"})
+func (h *WorkspaceHandler) CreateWorkspace(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	w, err := h.service.Create(userID, req.Name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, w)
+}
+
+// RenameWorkspace handles PATCH /api/workspaces/:id ({"name": "Growth"})
+func (h *WorkspaceHandler) RenameWorkspace(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	w, err := h.service.Rename(userID, c.Param("id"), req.Name)
+	if err != nil {
+		c.JSON(statusFor(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, w)
+}
+
+// DeleteWorkspace handles DELETE /api/workspaces/:id
+func (h *WorkspaceHandler) DeleteWorkspace(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	if err := h.service.Delete(userID, c.Param("id")); err != nil {
+		c.JSON(statusFor(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Workspace deleted"})
+}
+
+// UpdateMember handles PUT /api/workspaces/:id/members/:user_id
+// ({"role": "viewer", "hours_per_day": 6})
+func (h *WorkspaceHandler) UpdateMember(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req service.MemberInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	w, err := h.service.UpdateMember(userID, c.Param("id"), c.Param("user_id"), req)
+	if err != nil {
+		c.JSON(statusFor(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, w)
+}
+
+// GetWorkload handles GET /api/workspaces/:id/workload?days=14
+func (h *WorkspaceHandler) GetWorkload(c *gin.Context) {
+	userID := c.GetString("user_id")
+	days, _ := strconv.Atoi(c.Query("days"))
+
+	report, err := h.service.Workload(userID, c.Param("id"), days)
+	if err != nil {
+		c.JSON(statusFor(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// RemoveMember handles DELETE /api/workspaces/:id/members/:user_id; members
+// leave with their own ID
+func (h *WorkspaceHandler) RemoveMember(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	if err := h.service.RemoveMember(userID, c.Param("id"), c.Param("user_id")); err != nil {
+		c.JSON(statusFor(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed"})
+}
+
+// Invite handles POST /api/workspaces/:id/invitations ({"email": "bob@example.com", "role": "editor"})
+func (h *WorkspaceHandler) Invite(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req struct {
+		Email string `json:"email" binding:"required"`
+		Role  string `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := h.service.Invite(userID, c.Param("id"), req.Email, req.Role)
+	if err != nil {
+		c.JSON(statusFor(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// ListInvitations handles GET /api/workspaces/:id/invitations
+func (h *WorkspaceHandler) ListInvitations(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	invitations, err := h.service.ListInvitations(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(statusFor(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invitations": invitations})
+}
+
+// RevokeInvitation handles DELETE /api/workspaces/:id/invitations/:invitation_id
+func (h *WorkspaceHandler) RevokeInvitation(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	if err := h.service.RevokeInvitation(userID, c.Param("id"), c.Param("invitation_id")); err != nil {
+		c.JSON(statusFor(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation revoked"})
+}
+
+// AcceptInvitation handles POST /api/invitations/accept ({"token": "..."}).
+// The token stays out of the URL so it doesn't end up in access logs.
+func (h *WorkspaceHandler) AcceptInvitation(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	w, err := h.service.AcceptInvitation(userID, req.Token)
+	if err != nil {
+		c.JSON(statusFor(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, w)
+}
+
+// MovePlan handles PUT /api/plan/:id/workspace ({"workspace_id": "..."}, "" for personal)
+func (h *WorkspaceHandler) MovePlan(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req struct {
+		WorkspaceID string `json:"workspace_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, err := h.service.MovePlan(userID, c.Param("id"), req.WorkspaceID)
+	if err != nil {
+		c.JSON(statusFor(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// SharePlan handles POST /api/plan/:id/shares ({"email": "bob@example.com", "role": "viewer"})
+func (h *WorkspaceHandler) SharePlan(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req service.ShareInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, err := h.service.SharePlan(userID, c.Param("id"), req)
+	if err != nil {
+		c.JSON(statusFor(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// UnsharePlan handles DELETE /api/plan/:id/shares/:user_id
+func (h *WorkspaceHandler) UnsharePlan(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	plan, err := h.service.UnsharePlan(userID, c.Param("id"), c.Param("user_id"))
+	if err != nil {
+		c.JSON(statusFor(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+func statusFor(err error) int {
+	switch err.Error() {
+	case "workspace not found", "invitation not found", "member not found", "plan not found", "user not found":
+		return http.StatusNotFound
+	case service.ErrNotWorkspaceOwner.Error(), service.ErrWrongInvitee.Error(),
+		"only an owner of this plan can do that", "you can only view this plan", "you can't add plans to this workspace":
+		return http.StatusForbidden
+	case service.ErrLastOwner.Error(), "already a member of this workspace":
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
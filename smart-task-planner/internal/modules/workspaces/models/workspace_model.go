@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Workspace is a team whose members share its plans. A member's role
+// (owner, editor or viewer, as for plans) applies to every plan in it.
+type Workspace struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name      string             `bson:"name" json:"name"`
+	Members   []Member           `bson:"members" json:"members"`
+	CreatedBy string             `bson:"created_by" json:"created_by"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+type Member struct {
+	UserID   string    `bson:"user_id" json:"user_id"`
+	Name     string    `bson:"name,omitempty" json:"name,omitempty"`
+	Email    string    `bson:"email,omitempty" json:"email,omitempty"`
+	Role     string    `bson:"role" json:"role"`
+	JoinedAt time.Time `bson:"joined_at" json:"joined_at"`
+
+	// HoursPerDay is how much task work fits in one of the member's working
+	// days, for the workload view. Unset means 8.
+	HoursPerDay float64 `bson:"hours_per_day,omitempty" json:"hours_per_day,omitempty"`
+}
+
+// Member returns the user's membership, nil if they aren't a member
+func (w *Workspace) Member(userID string) *Member {
+	for i := range w.Members {
+		if w.Members[i].UserID == userID {
+			return &w.Members[i]
+		}
+	}
+	return nil
+}
+
+// Invitation lets whoever holds the token and signs in with Email join a
+// workspace. Only a hash of the token is stored.
+type Invitation struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	WorkspaceID primitive.ObjectID `bson:"workspace_id" json:"workspace_id"`
+	Email       string             `bson:"email" json:"email"`
+	Role        string             `bson:"role" json:"role"`
+	TokenHash   string             `bson:"token_hash" json:"-"`
+	InvitedBy   string             `bson:"invited_by" json:"invited_by"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	ExpiresAt   time.Time          `bson:"expires_at" json:"expires_at"`
+	AcceptedAt  *time.Time         `bson:"accepted_at,omitempty" json:"accepted_at,omitempty"`
+	AcceptedBy  string             `bson:"accepted_by,omitempty" json:"accepted_by,omitempty"`
+}
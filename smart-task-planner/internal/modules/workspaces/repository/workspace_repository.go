@@ -0,0 +1,270 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"smart-task-planner/internal/modules/workspaces/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type WorkspaceRepository struct {
+	Collection  *mongo.Collection
+	Invitations *mongo.Collection
+}
+
+func NewWorkspaceRepository(db *mongo.Database) *WorkspaceRepository {
+	return &WorkspaceRepository{
+		Collection:  db.Collection("workspaces"),
+		Invitations: db.Collection("workspace_invitations"),
+	}
+}
+
+// EnsureIndexes covers membership lookups, which every plan query makes,
+// and makes invitation token hashes unique
+func (r *WorkspaceRepository) EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := r.Collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "members.user_id", Value: 1}},
+	}); err != nil {
+		return err
+	}
+	_, err := r.Invitations.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "workspace_id", Value: 1}}},
+	})
+	return err
+}
+
+// ListForUser returns the workspaces the user is a member of, by name
+func (r *WorkspaceRepository) ListForUser(userID string) ([]models.Workspace, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := r.Collection.Find(ctx, bson.M{"members.user_id": userID},
+		options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	workspaces := []models.Workspace{}
+	if err := cursor.All(ctx, &workspaces); err != nil {
+		return nil, err
+	}
+	return workspaces, nil
+}
+
+// Get returns a workspace the user is a member of
+func (r *WorkspaceRepository) Get(userID, workspaceID string) (*models.Workspace, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workspace ID: %v", err)
+	}
+
+	var w models.Workspace
+	if err := r.Collection.FindOne(ctx, bson.M{"_id": objID, "members.user_id": userID}).Decode(&w); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("workspace not found")
+		}
+		return nil, err
+	}
+	return &w, nil
+}
+
+func (r *WorkspaceRepository) Create(w *models.Workspace) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	w.ID = primitive.NewObjectID()
+	_, err := r.Collection.InsertOne(ctx, w)
+	return err
+}
+
+func (r *WorkspaceRepository) Rename(id primitive.ObjectID, name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.Collection.UpdateOne(ctx, bson.M{"_id": id},
+		bson.M{"$set": bson.M{"name": name, "updated_at": time.Now()}})
+	return err
+}
+
+// AddMember adds m unless the user already belongs to the workspace
+func (r *WorkspaceRepository) AddMember(id primitive.ObjectID, m models.Member) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := r.Collection.UpdateOne(ctx,
+		bson.M{"_id": id, "members.user_id": bson.M{"$ne": m.UserID}},
+		bson.M{"$push": bson.M{"members": m}, "$set": bson.M{"updated_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("already a member of this workspace")
+	}
+	return nil
+}
+
+func (r *WorkspaceRepository) SetRole(id primitive.ObjectID, userID, role string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := r.Collection.UpdateOne(ctx,
+		bson.M{"_id": id, "members.user_id": userID},
+		bson.M{"$set": bson.M{"members.$.role": role, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("member not found")
+	}
+	return nil
+}
+
+// SetHoursPerDay stores a member's daily capacity; 0 resets it to the default
+func (r *WorkspaceRepository) SetHoursPerDay(id primitive.ObjectID, userID string, hours float64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"members.$.hours_per_day": hours, "updated_at": time.Now()}}
+	if hours == 0 {
+		update = bson.M{"$unset": bson.M{"members.$.hours_per_day": ""}, "$set": bson.M{"updated_at": time.Now()}}
+	}
+	res, err := r.Collection.UpdateOne(ctx, bson.M{"_id": id, "members.user_id": userID}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("member not found")
+	}
+	return nil
+}
+
+func (r *WorkspaceRepository) RemoveMember(id primitive.ObjectID, userID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := r.Collection.UpdateOne(ctx,
+		bson.M{"_id": id, "members.user_id": userID},
+		bson.M{"$pull": bson.M{"members": bson.M{"user_id": userID}}, "$set": bson.M{"updated_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("member not found")
+	}
+	return nil
+}
+
+// Delete removes a workspace together with its invitations
+func (r *WorkspaceRepository) Delete(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := r.Collection.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+		return err
+	}
+	_, err := r.Invitations.DeleteMany(ctx, bson.M{"workspace_id": id})
+	return err
+}
+
+func (r *WorkspaceRepository) CreateInvitation(inv *models.Invitation) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	inv.ID = primitive.NewObjectID()
+	_, err := r.Invitations.InsertOne(ctx, inv)
+	return err
+}
+
+// ListInvitations returns the pending invitations of a workspace, newest first
+func (r *WorkspaceRepository) ListInvitations(workspaceID primitive.ObjectID) ([]models.Invitation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := r.Invitations.Find(ctx,
+		bson.M{"workspace_id": workspaceID, "accepted_at": bson.M{"$exists": false}},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	invitations := []models.Invitation{}
+	if err := cursor.All(ctx, &invitations); err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// FindPendingByHash returns the unaccepted, unexpired invitation with the given hash
+func (r *WorkspaceRepository) FindPendingByHash(hash string) (*models.Invitation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var inv models.Invitation
+	err := r.Invitations.FindOne(ctx, bson.M{
+		"token_hash":  hash,
+		"accepted_at": bson.M{"$exists": false},
+		"expires_at":  bson.M{"$gt": time.Now()},
+	}).Decode(&inv)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("invitation not found")
+		}
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// MarkAccepted records who accepted an invitation so its token can't be reused
+func (r *WorkspaceRepository) MarkAccepted(id primitive.ObjectID, userID string, at time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := r.Invitations.UpdateOne(ctx,
+		bson.M{"_id": id, "accepted_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"accepted_at": at, "accepted_by": userID}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("invitation not found")
+	}
+	return nil
+}
+
+func (r *WorkspaceRepository) DeleteInvitation(workspaceID primitive.ObjectID, invitationID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(invitationID)
+	if err != nil {
+		return fmt.Errorf("invalid invitation ID: %v", err)
+	}
+
+	res, err := r.Invitations.DeleteOne(ctx, bson.M{"_id": objID, "workspace_id": workspaceID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return fmt.Errorf("invitation not found")
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"smart-task-planner/internal/middleware"
+	"smart-task-planner/internal/modules/workspaces/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterWorkspaceRoutes(router *gin.Engine, handler *handlers.WorkspaceHandler) {
+	api := router.Group("/api")
+	api.Use(middleware.JWTAuth())
+	{
+		// Workspaces and their members
+		api.GET("/workspaces", handler.GetWorkspaces)
+		api.POST("/workspaces", handler.CreateWorkspace)
+		api.GET("/workspaces/:id", handler.GetWorkspace)
+		api.PATCH("/workspaces/:id", handler.RenameWorkspace)
+		api.DELETE("/workspaces/:id", handler.DeleteWorkspace)
+		api.PUT("/workspaces/:id/members/:user_id", handler.UpdateMember)
+		api.DELETE("/workspaces/:id/members/:user_id", handler.RemoveMember)
+
+		// Planned hours per member and day against their capacity
+		api.GET("/workspaces/:id/workload", handler.GetWorkload)
+
+		// Email invitations, accepted by the invited user while signed in
+		api.POST("/workspaces/:id/invitations", handler.Invite)
+		api.GET("/workspaces/:id/invitations", handler.ListInvitations)
+		api.DELETE("/workspaces/:id/invitations/:invitation_id", handler.RevokeInvitation)
+		api.POST("/invitations/accept", handler.AcceptInvitation)
+
+		// Moving a plan into a workspace and sharing it with single users
+		api.PUT("/plan/:id/workspace", handler.MovePlan)
+		api.POST("/plan/:id/shares", handler.SharePlan)
+		api.DELETE("/plan/:id/shares/:user_id", handler.UnsharePlan)
+	}
+}
@@ -0,0 +1,524 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"smart-task-planner/config"
+	"smart-task-planner/internal/events"
+	"smart-task-planner/internal/mcp"
+	authRepository "smart-task-planner/internal/modules/auth/repository"
+	planModels "smart-task-planner/internal/modules/plan/models"
+	planRepository "smart-task-planner/internal/modules/plan/repository"
+	"smart-task-planner/internal/modules/plan/workload"
+	"smart-task-planner/internal/modules/workspaces/models"
+	"smart-task-planner/internal/modules/workspaces/repository"
+)
+
+const (
+	maxNameLength = 100
+
+	invitationTTL = 7 * 24 * time.Hour
+)
+
+var (
+	ErrNotWorkspaceOwner = fmt.Errorf("only workspace owners can do that")
+	ErrLastOwner         = fmt.Errorf("a workspace needs at least one owner")
+	ErrWrongInvitee      = fmt.Errorf("this invitation is for another email address")
+)
+
+// Mailer delivers invitation emails; the SMTP notification channel is one
+type Mailer interface {
+	SendMessage(to, subject, body string) error
+}
+
+type WorkspaceService struct {
+	Repo     *repository.WorkspaceRepository
+	PlanRepo *planRepository.PlanRepository
+	Mailer   Mailer // nil when SMTP isn't configured; tokens are still returned
+}
+
+func NewWorkspaceService(repo *repository.WorkspaceRepository, planRepo *planRepository.PlanRepository, mailer Mailer) *WorkspaceService {
+	return &WorkspaceService{Repo: repo, PlanRepo: planRepo, Mailer: mailer}
+}
+
+// CreatedInvitation is returned once when an invitation is created; the
+// token cannot be recovered later.
+type CreatedInvitation struct {
+	Token      string             `json:"token"`
+	AcceptURL  string             `json:"accept_url"`
+	Emailed    bool               `json:"emailed"`
+	Invitation *models.Invitation `json:"invitation"`
+}
+
+// ShareInput names the user to share a plan with, by ID or email
+type ShareInput struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+}
+
+func (s *WorkspaceService) List(userID string) ([]models.Workspace, error) {
+	return s.Repo.ListForUser(userID)
+}
+
+func (s *WorkspaceService) Get(userID, workspaceID string) (*models.Workspace, error) {
+	return s.Repo.Get(userID, workspaceID)
+}
+
+// Create starts a workspace with the creator as its only owner
+func (s *WorkspaceService) Create(userID, name string) (*models.Workspace, error) {
+	name, err := cleanName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	w := &models.Workspace{
+		Name:      name,
+		Members:   []models.Member{member(userID, planModels.RoleOwner, now)},
+		CreatedBy: userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.Repo.Create(w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (s *WorkspaceService) Rename(userID, workspaceID, name string) (*models.Workspace, error) {
+	w, err := s.owned(userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if w.Name, err = cleanName(name); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.Rename(w.ID, w.Name); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Delete removes an empty workspace; its plans have to be moved or deleted first
+func (s *WorkspaceService) Delete(userID, workspaceID string) error {
+	w, err := s.owned(userID, workspaceID)
+	if err != nil {
+		return err
+	}
+	count, err := s.PlanRepo.CountInWorkspace(w.ID.Hex())
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("workspace still has %d plan(s), move or delete them first", count)
+	}
+	return s.Repo.Delete(w.ID)
+}
+
+// Invite emails a one-time link that lets the person signed in with email
+// join the workspace with role
+func (s *WorkspaceService) Invite(userID, workspaceID, email, role string) (*CreatedInvitation, error) {
+	w, err := s.owned(userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	email = strings.TrimSpace(email)
+	if !strings.Contains(email, "@") {
+		return nil, fmt.Errorf("a valid email is required")
+	}
+	if role == "" {
+		role = planModels.RoleEditor
+	}
+	if !planModels.ValidRole(role) {
+		return nil, fmt.Errorf("role must be owner, editor or viewer")
+	}
+	for _, m := range w.Members {
+		if strings.EqualFold(m.Email, email) {
+			return nil, fmt.Errorf("already a member of this workspace")
+		}
+	}
+
+	token, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	inv := &models.Invitation{
+		WorkspaceID: w.ID,
+		Email:       email,
+		Role:        role,
+		TokenHash:   hashToken(token),
+		InvitedBy:   userID,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(invitationTTL),
+	}
+	if err := s.Repo.CreateInvitation(inv); err != nil {
+		return nil, err
+	}
+
+	created := &CreatedInvitation{
+		Token:      token,
+		AcceptURL:  config.AppConfig.BaseURL + "/api/invitations/accept",
+		Invitation: inv,
+	}
+	if s.Mailer != nil {
+		if err := s.Mailer.SendMessage(email, "You're invited to "+w.Name, invitationBody(w, inv, inviterName(w, userID), created.AcceptURL, token)); err != nil {
+			log.Println("Error sending invitation email:", err)
+		} else {
+			created.Emailed = true
+		}
+	}
+	return created, nil
+}
+
+func (s *WorkspaceService) ListInvitations(userID, workspaceID string) ([]models.Invitation, error) {
+	w, err := s.owned(userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return s.Repo.ListInvitations(w.ID)
+}
+
+func (s *WorkspaceService) RevokeInvitation(userID, workspaceID, invitationID string) error {
+	w, err := s.owned(userID, workspaceID)
+	if err != nil {
+		return err
+	}
+	return s.Repo.DeleteInvitation(w.ID, invitationID)
+}
+
+// AcceptInvitation adds the user to the invitation's workspace. The token
+// alone isn't enough: the user must be signed in with the invited email.
+// Their sync clients then download the workspace's plans.
+func (s *WorkspaceService) AcceptInvitation(userID, token string) (*models.Workspace, error) {
+	inv, err := s.Repo.FindPendingByHash(hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	user, err := authRepository.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(strings.TrimSpace(user.Email), inv.Email) {
+		return nil, ErrWrongInvitee
+	}
+
+	// join first: if that fails the invitation stays usable for another try
+	now := time.Now()
+	m := models.Member{UserID: userID, Name: user.Name, Email: user.Email, Role: inv.Role, JoinedAt: now}
+	if err := s.Repo.AddMember(inv.WorkspaceID, m); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.MarkAccepted(inv.ID, userID, now); err != nil {
+		return nil, err
+	}
+	if err := s.PlanRepo.GrantWorkspace(inv.WorkspaceID.Hex(), userID); err != nil {
+		log.Println("Error granting workspace plans to new member:", err)
+	}
+	return s.Repo.Get(userID, inv.WorkspaceID.Hex())
+}
+
+// MemberInput changes a member. Omitted fields are left alone.
+type MemberInput struct {
+	Role        string   `json:"role"`
+	HoursPerDay *float64 `json:"hours_per_day"` // 0 resets to the default of 8
+}
+
+// UpdateMember changes a member's role and daily capacity. Owners change
+// anyone; members can only set their own capacity. The last owner can't be
+// demoted.
+func (s *WorkspaceService) UpdateMember(userID, workspaceID, memberID string, in MemberInput) (*models.Workspace, error) {
+	w, err := s.Repo.Get(userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	isOwner := w.Member(userID).Role == planModels.RoleOwner
+	m := w.Member(memberID)
+	if m == nil {
+		return nil, fmt.Errorf("member not found")
+	}
+	if in.Role == "" && in.HoursPerDay == nil {
+		return nil, fmt.Errorf("nothing to change: give a role or hours_per_day")
+	}
+
+	if in.Role != "" && in.Role != m.Role {
+		if !isOwner {
+			return nil, ErrNotWorkspaceOwner
+		}
+		if !planModels.ValidRole(in.Role) {
+			return nil, fmt.Errorf("role must be owner, editor or viewer")
+		}
+		if m.Role == planModels.RoleOwner && owners(w) == 1 {
+			return nil, ErrLastOwner
+		}
+	}
+	if in.HoursPerDay != nil {
+		if !isOwner && memberID != userID {
+			return nil, ErrNotWorkspaceOwner
+		}
+		if *in.HoursPerDay < 0 || *in.HoursPerDay > 24 {
+			return nil, fmt.Errorf("hours_per_day must be between 0 and 24")
+		}
+	}
+
+	if in.Role != "" && in.Role != m.Role {
+		if err := s.Repo.SetRole(w.ID, memberID, in.Role); err != nil {
+			return nil, err
+		}
+		m.Role = in.Role
+	}
+	if in.HoursPerDay != nil {
+		if err := s.Repo.SetHoursPerDay(w.ID, memberID, *in.HoursPerDay); err != nil {
+			return nil, err
+		}
+		m.HoursPerDay = *in.HoursPerDay
+	}
+	return w, nil
+}
+
+// RemoveMember takes a member out of the workspace. Owners remove anyone,
+// everyone else can only leave. Their sync clients drop the workspace's plans.
+func (s *WorkspaceService) RemoveMember(userID, workspaceID, memberID string) error {
+	w, err := s.Repo.Get(userID, workspaceID)
+	if err != nil {
+		return err
+	}
+	if memberID != userID && w.Member(userID).Role != planModels.RoleOwner {
+		return ErrNotWorkspaceOwner
+	}
+	m := w.Member(memberID)
+	if m == nil {
+		return fmt.Errorf("member not found")
+	}
+	if m.Role == planModels.RoleOwner && owners(w) == 1 {
+		return ErrLastOwner
+	}
+
+	if err := s.Repo.RemoveMember(w.ID, memberID); err != nil {
+		return err
+	}
+	if err := s.PlanRepo.ForgetWorkspace(w.ID.Hex(), memberID); err != nil {
+		log.Println("Error writing tombstones for removed member:", err)
+	}
+	return nil
+}
+
+// Workload runs the get_workload MCP tool for one workspace
+func (s *WorkspaceService) Workload(userID, workspaceID string, days int) (*workload.Report, error) {
+	result, err := mcp.RunTool("get_workload", map[string]interface{}{
+		"user_id":      userID,
+		"workspace_id": workspaceID,
+		"days":         days,
+	}, s.PlanRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	report, ok := result.(*workload.Report)
+	if !ok {
+		return nil, fmt.Errorf("invalid data from MCP get_workload")
+	}
+	return report, nil
+}
+
+// MovePlan puts a plan into a workspace, or back into the caller's personal
+// plans when workspaceID is "". The caller must own the plan and be at
+// least an editor of the target workspace.
+func (s *WorkspaceService) MovePlan(userID, planID, workspaceID string) (*planModels.Plan, error) {
+	plan, err := s.PlanRepo.GetByIDForRole(planID, userID, planModels.RoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	if workspaceID != "" {
+		w, err := s.Repo.Get(userID, workspaceID)
+		if err != nil {
+			return nil, err
+		}
+		if !planModels.RoleAtLeast(w.Member(userID).Role, planModels.RoleEditor) {
+			return nil, fmt.Errorf("you can't add plans to this workspace")
+		}
+		workspaceID = w.ID.Hex()
+	}
+	if workspaceID == plan.WorkspaceID {
+		return plan, nil
+	}
+
+	updated, err := s.PlanRepo.SetAccess(plan.ID, userID, workspaceID, plan.SharedWith)
+	if err != nil {
+		return nil, err
+	}
+	updated.Role = planModels.RoleOwner
+	if workspaceID != "" {
+		updated.Role = s.roleIn(userID, updated)
+	}
+	return updated, nil
+}
+
+// SharePlan gives one user editor or viewer rights on a plan, replacing
+// any earlier share with them
+func (s *WorkspaceService) SharePlan(userID, planID string, in ShareInput) (*planModels.Plan, error) {
+	plan, err := s.PlanRepo.GetByIDForRole(planID, userID, planModels.RoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	if in.Role == "" {
+		in.Role = planModels.RoleViewer
+	}
+	if in.Role != planModels.RoleEditor && in.Role != planModels.RoleViewer {
+		return nil, fmt.Errorf("plans can be shared as editor or viewer")
+	}
+
+	var target string
+	switch {
+	case in.UserID != "":
+		user, err := authRepository.GetUserByID(in.UserID)
+		if err != nil {
+			return nil, err
+		}
+		target = user.ID.Hex()
+	case in.Email != "":
+		user, err := authRepository.GetUserByEmail(strings.TrimSpace(in.Email))
+		if err != nil {
+			return nil, err
+		}
+		target = user.ID.Hex()
+	default:
+		return nil, fmt.Errorf("user_id or email is required")
+	}
+	if target == userID {
+		return nil, fmt.Errorf("you can't share a plan with yourself")
+	}
+
+	shares := []planModels.Share{{UserID: target, Role: in.Role}}
+	for _, sh := range plan.SharedWith {
+		if sh.UserID != target {
+			shares = append(shares, sh)
+		}
+	}
+	return s.setShares(userID, plan, shares)
+}
+
+// UnsharePlan stops sharing a plan with a user. Owners unshare anyone, a
+// user a plan was shared with can remove themselves.
+func (s *WorkspaceService) UnsharePlan(userID, planID, targetID string) (*planModels.Plan, error) {
+	role := planModels.RoleOwner
+	if targetID == userID {
+		role = planModels.RoleViewer
+	}
+	plan, err := s.PlanRepo.GetByIDForRole(planID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	var shares []planModels.Share
+	for _, sh := range plan.SharedWith {
+		if sh.UserID != targetID {
+			shares = append(shares, sh)
+		}
+	}
+	if len(shares) == len(plan.SharedWith) {
+		return nil, fmt.Errorf("plan is not shared with that user")
+	}
+	return s.setShares(userID, plan, shares)
+}
+
+// Audience sends live events about a plan to everyone who can see it
+func (s *WorkspaceService) Audience(e events.Event) []string {
+	return s.PlanRepo.EventAudience(e)
+}
+
+func (s *WorkspaceService) setShares(userID string, plan *planModels.Plan, shares []planModels.Share) (*planModels.Plan, error) {
+	updated, err := s.PlanRepo.SetAccess(plan.ID, plan.UserID, plan.WorkspaceID, shares)
+	if err != nil {
+		return nil, err
+	}
+	updated.Role = s.roleIn(userID, updated)
+	return updated, nil
+}
+
+// roleIn is userID's role on plan after a change of access
+func (s *WorkspaceService) roleIn(userID string, plan *planModels.Plan) string {
+	a, err := s.PlanRepo.Access(userID)
+	if err != nil {
+		return ""
+	}
+	return a.Role(plan)
+}
+
+// owned loads a workspace the user owns
+func (s *WorkspaceService) owned(userID, workspaceID string) (*models.Workspace, error) {
+	w, err := s.Repo.Get(userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if w.Member(userID).Role != planModels.RoleOwner {
+		return nil, ErrNotWorkspaceOwner
+	}
+	return w, nil
+}
+
+func member(userID, role string, at time.Time) models.Member {
+	m := models.Member{UserID: userID, Role: role, JoinedAt: at}
+	if user, err := authRepository.GetUserByID(userID); err == nil {
+		m.Name, m.Email = user.Name, user.Email
+	}
+	return m
+}
+
+func owners(w *models.Workspace) int {
+	n := 0
+	for _, m := range w.Members {
+		if m.Role == planModels.RoleOwner {
+			n++
+		}
+	}
+	return n
+}
+
+func cleanName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("name is required")
+	}
+	if len(name) > maxNameLength {
+		return "", fmt.Errorf("name must be at most %d characters", maxNameLength)
+	}
+	return name, nil
+}
+
+func inviterName(w *models.Workspace, userID string) string {
+	if m := w.Member(userID); m != nil && m.Name != "" {
+		return m.Name
+	}
+	return "A teammate"
+}
+
+func invitationBody(w *models.Workspace, inv *models.Invitation, inviter, acceptURL, token string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s invited you to join %q on Smart Task Planner as %s.\n\n", inviter, w.Name, inv.Role)
+	fmt.Fprintf(&b, "Sign in with %s and accept the invitation:\n  POST %s\n  {\"token\": %q}\n\n", inv.Email, acceptURL, token)
+	fmt.Fprintf(&b, "The invitation expires on %s.\n", inv.ExpiresAt.Format("Jan 2, 2006"))
+	b.WriteString("-- \nSmart Task Planner\n")
+	return b.String()
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,23 @@
+package utils
+
+import "strings"
+
+// formulaPrefixes start a cell that spreadsheets would run as a formula
+const formulaPrefixes = "=+-@\t\r"
+
+// EscapeCSVCell makes user text safe to open in a spreadsheet by putting a
+// ' in front of a cell that would otherwise be read as a formula
+func EscapeCSVCell(s string) string {
+	if s != "" && strings.ContainsRune(formulaPrefixes, rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// UnescapeCSVCell reverses EscapeCSVCell
+func UnescapeCSVCell(s string) string {
+	if len(s) > 1 && s[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(s[1])) {
+		return s[1:]
+	}
+	return s
+}
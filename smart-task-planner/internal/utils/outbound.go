@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"time"
+)
+
+const maxRedirects = 5
+
+// ErrPrivateAddress is returned for user-supplied URLs that point into the
+// server's own network
+var ErrPrivateAddress = errors.New("URL must point to a public address")
+
+// blockedPrefixes are ranges that aren't reachable on the public internet
+// and that IsPrivate/IsLoopback don't already cover
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"), // NAT64 can reach IPv4 private ranges
+}
+
+// allowPrivate lets local development point webhooks and calendars at
+// localhost
+func allowPrivate() bool {
+	return os.Getenv("OUTBOUND_ALLOW_PRIVATE") == "true"
+}
+
+// PublicAddr reports whether ip may be contacted on behalf of a user
+func PublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsValid() || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, p := range blockedPrefixes {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckOutboundURL rejects URLs that aren't http(s) or whose host resolves
+// only to non-public addresses. The client from NewOutboundClient checks
+// again on every connection, so a DNS change later can't get around it.
+func CheckOutboundURL(ctx context.Context, u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return fmt.Errorf("url must be an absolute http(s) URL")
+	}
+	if allowPrivate() {
+		return nil
+	}
+	_, err := resolvePublic(ctx, u.Hostname())
+	return err
+}
+
+// NewOutboundClient is an HTTP client for URLs users give us (webhooks,
+// calendar subscriptions). It resolves every host itself and refuses to
+// connect to loopback, private, link-local and similar addresses, on the
+// first request and on each redirect. Environment proxies aren't used.
+func NewOutboundClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, KeepAlive: 30 * time.Second}
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			if allowPrivate() {
+				return dialer.DialContext(ctx, network, addr)
+			}
+			host, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			ips, err := resolvePublic(ctx, host)
+			if err != nil {
+				return nil, err
+			}
+			var lastErr error
+			for _, ip := range ips {
+				conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+				if err == nil {
+					return conn, nil
+				}
+				lastErr = err
+			}
+			return nil, lastErr
+		},
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          20,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return CheckOutboundURL(req.Context(), req.URL)
+		},
+	}
+}
+
+// resolvePublic looks host up and fails if any of its addresses is not
+// public, so a name can't mix a public and an internal address
+func resolvePublic(ctx context.Context, host string) ([]netip.Addr, error) {
+	if ip, err := netip.ParseAddr(host); err == nil {
+		if !PublicAddr(ip) {
+			return nil, ErrPrivateAddress
+		}
+		return []netip.Addr{ip}, nil
+	}
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve %s", host)
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("cannot resolve %s", host)
+	}
+	for _, ip := range ips {
+		if !PublicAddr(ip) {
+			return nil, ErrPrivateAddress
+		}
+	}
+	return ips, nil
+}